@@ -2,33 +2,80 @@ package client
 
 import (
 	"context"
+	crand "crypto/rand"
 	"fmt"
+	"io"
+	"math/rand"
 	"time"
 
 	"kvstore/proto"
+	"kvstore/tracing"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-// KVClient is a gRPC client for the KVStore service
+const (
+	// putStreamChunkSize is the size of the chunks PutStream sends (64KB)
+	putStreamChunkSize = 64 * 1024
+
+	// streamTimeout bounds a whole GetStream or PutStream transfer
+	streamTimeout = 5 * time.Minute
+)
+
+// retryServiceConfig retries calls that hit a connection the server has just
+// closed (e.g. during a restart) instead of surfacing Unavailable to callers
+const retryServiceConfig = `{
+	"methodConfig": [{
+		"name": [{"service": "kvstore.KVStore"}],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// KVClient is a gRPC client for the KVStore service.
+// The store itself accepts arbitrary byte-string keys, but keys travel as
+// proto3 string fields, which must be valid UTF-8 on the wire.
 type KVClient struct {
 	conn   *grpc.ClientConn
 	client proto.KVStoreClient
 }
 
-// NewKVClient creates a new KV client
+// NewKVClient creates a new KV client.
+// The connection is established lazily and re-established with backoff if
+// the server restarts; calls wait for it (within their timeout) instead of
+// failing fast. Use WaitForReady to block until the server is reachable.
 func NewKVClient(serverAddr string) (*KVClient, error) {
-	// Set up connection with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	conn, err := grpc.DialContext(ctx, serverAddr,
+	opts := append([]grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-	)
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff: backoff.Config{
+				BaseDelay:  100 * time.Millisecond,
+				Multiplier: 1.6,
+				Jitter:     0.2,
+				MaxDelay:   5 * time.Second,
+			},
+			MinConnectTimeout: 5 * time.Second,
+		}),
+		grpc.WithDefaultCallOptions(
+			grpc.WaitForReady(true),
+			grpc.MaxCallRecvMsgSize(proto.DefaultMaxMessageSize),
+			grpc.MaxCallSendMsgSize(proto.DefaultMaxMessageSize),
+		),
+		grpc.WithDefaultServiceConfig(retryServiceConfig),
+	}, tracing.DialOptions()...)
+	conn, err := grpc.NewClient(serverAddr, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to server: %w", err)
+		return nil, fmt.Errorf("failed to create client for %s: %w", serverAddr, err)
 	}
 
 	return &KVClient{
@@ -37,14 +84,59 @@ func NewKVClient(serverAddr string) (*KVClient, error) {
 	}, nil
 }
 
+// NewKVClientUnix creates a KV client for a server on the same host
+// listening on the Unix domain socket at path (the server's -socket flag).
+// It behaves like NewKVClient otherwise.
+func NewKVClientUnix(path string) (*KVClient, error) {
+	return NewKVClient("unix:" + path)
+}
+
+// WaitForReady blocks until the connection to the server is ready or ctx is done
+func (c *KVClient) WaitForReady(ctx context.Context) error {
+	for {
+		state := c.conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.Idle:
+			c.conn.Connect()
+		case connectivity.Shutdown:
+			return fmt.Errorf("client is closed")
+		}
+
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("server not ready (last state %s): %w", state, ctx.Err())
+		}
+	}
+}
+
+// newIdempotencyKey returns a random (version 4) UUID. Each write gets one,
+// and gRPC retries resend the same request message, so a retry of a write
+// the server already applied is recognised and not applied twice.
+func newIdempotencyKey() string {
+	var b [16]byte
+	crand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
 // Put stores a key-value pair
 func (c *KVClient) Put(key string, value []byte) error {
+	return c.PutNS("", key, value)
+}
+
+// PutNS stores a key-value pair in a namespace ("" is the default one).
+// Namespaces isolate keyspaces: the same key in two namespaces is two keys.
+func (c *KVClient) PutNS(ns, key string, value []byte) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	resp, err := c.client.Put(ctx, &proto.PutRequest{
-		Key:   key,
-		Value: value,
+		Key:            key,
+		Value:          value,
+		IdempotencyKey: newIdempotencyKey(),
+		Ns:             ns,
 	})
 	if err != nil {
 		return fmt.Errorf("Put RPC failed: %w", err)
@@ -57,13 +149,42 @@ func (c *KVClient) Put(key string, value []byte) error {
 	return nil
 }
 
+// PutIfAbsent stores a key-value pair only if the key does not exist yet,
+// and reports whether it was written. Of several clients racing to create
+// the same key, exactly one gets true.
+func (c *KVClient) PutIfAbsent(key string, value []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.PutIfAbsent(ctx, &proto.PutIfAbsentRequest{
+		Key:            key,
+		Value:          value,
+		IdempotencyKey: newIdempotencyKey(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("PutIfAbsent RPC failed: %w", err)
+	}
+
+	if resp.Error != "" {
+		return resp.Written, fmt.Errorf("PutIfAbsent failed: %s", resp.Error)
+	}
+
+	return resp.Written, nil
+}
+
 // Get retrieves a value by key
 func (c *KVClient) Get(key string) ([]byte, error) {
+	return c.GetNS("", key)
+}
+
+// GetNS retrieves a value by key from a namespace
+func (c *KVClient) GetNS(ns, key string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	resp, err := c.client.Get(ctx, &proto.GetRequest{
 		Key: key,
+		Ns:  ns,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("Get RPC failed: %w", err)
@@ -76,16 +197,149 @@ func (c *KVClient) Get(key string) ([]byte, error) {
 		return nil, fmt.Errorf("key not found")
 	}
 
+	// An empty value arrives as nil; Found tells it apart from a missing key
+	if resp.Value == nil {
+		return []byte{}, nil
+	}
 	return resp.Value, nil
 }
 
+// GetRange retrieves length bytes of a value starting at offset. A range
+// outside the value fails with a codes.OutOfRange status error.
+func (c *KVClient) GetRange(key string, offset, length int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.GetRange(ctx, &proto.GetRangeRequest{
+		Key:    key,
+		Offset: offset,
+		Length: length,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("GetRange RPC failed: %w", err)
+	}
+
+	if !resp.Found {
+		if resp.Error != "" {
+			return nil, fmt.Errorf("GetRange failed: %s", resp.Error)
+		}
+		return nil, fmt.Errorf("key not found")
+	}
+
+	return resp.Value, nil
+}
+
+// Exists reports whether a key has a value, without transferring the value
+func (c *KVClient) Exists(key string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Exists(ctx, &proto.ExistsRequest{
+		Key: key,
+	})
+	if err != nil {
+		return false, fmt.Errorf("Exists RPC failed: %w", err)
+	}
+
+	if resp.Error != "" {
+		return false, fmt.Errorf("Exists failed: %s", resp.Error)
+	}
+
+	return resp.Exists, nil
+}
+
+// GetStream retrieves a value by key and writes it to w chunk by chunk,
+// so the client never holds the whole value in memory. If w fails part way
+// through, the data already written is not rolled back.
+func (c *KVClient) GetStream(key string, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), streamTimeout)
+	defer cancel()
+
+	stream, err := c.client.GetStream(ctx, &proto.GetRequest{Key: key})
+	if err != nil {
+		return fmt.Errorf("GetStream RPC failed: %w", err)
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				return fmt.Errorf("key not found")
+			}
+			return fmt.Errorf("GetStream RPC failed: %w", err)
+		}
+
+		if _, err := w.Write(chunk.Data); err != nil {
+			return fmt.Errorf("GetStream failed to write value: %w", err)
+		}
+	}
+}
+
+// PutStream stores the contents of r under key, sending it in chunks.
+// Values are limited to storage.MaxValueSize (32MB) per key; the server
+// rejects larger uploads.
+func (c *KVClient) PutStream(key string, r io.Reader) error {
+	ctx, cancel := context.WithTimeout(context.Background(), streamTimeout)
+	defer cancel()
+
+	stream, err := c.client.PutStream(ctx)
+	if err != nil {
+		return fmt.Errorf("PutStream RPC failed: %w", err)
+	}
+
+	buf := make([]byte, putStreamChunkSize)
+	first := true
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 || first {
+			req := &proto.PutStreamRequest{Chunk: buf[:n]}
+			if first {
+				req.Key = key
+				req.IdempotencyKey = newIdempotencyKey()
+				first = false
+			}
+			if err := stream.Send(req); err != nil {
+				// The server has ended the stream; its reason comes from CloseAndRecv
+				break
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("PutStream failed to read value: %w", readErr)
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("PutStream RPC failed: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("PutStream failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
 // Delete removes a key-value pair
 func (c *KVClient) Delete(key string) error {
+	return c.DeleteNS("", key)
+}
+
+// DeleteNS removes a key-value pair from a namespace
+func (c *KVClient) DeleteNS(ns, key string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	resp, err := c.client.Delete(ctx, &proto.DeleteRequest{
-		Key: key,
+		Key:            key,
+		IdempotencyKey: newIdempotencyKey(),
+		Ns:             ns,
 	})
 	if err != nil {
 		return fmt.Errorf("Delete RPC failed: %w", err)
@@ -98,6 +352,26 @@ func (c *KVClient) Delete(key string) error {
 	return nil
 }
 
+// WriteBatch applies several puts and deletes atomically
+func (c *KVClient) WriteBatch(ops []*proto.BatchOperation) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.WriteBatch(ctx, &proto.WriteBatchRequest{
+		Operations:     ops,
+		IdempotencyKey: newIdempotencyKey(),
+	})
+	if err != nil {
+		return fmt.Errorf("WriteBatch RPC failed: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("WriteBatch failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
 // Stats returns storage statistics
 func (c *KVClient) Stats() (*proto.StatsResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -128,6 +402,235 @@ func (c *KVClient) Compact() error {
 	return nil
 }
 
+// Truncate deletes every key on the server. Servers refuse it with
+// codes.PermissionDenied unless started with -allow-truncate.
+func (c *KVClient) Truncate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Truncate(ctx, &proto.TruncateRequest{})
+	if err != nil {
+		return fmt.Errorf("Truncate RPC failed: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("Truncate failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// Sync asks the server to fsync its WAL. Once it returns, every write this
+// and any other client had acknowledged before the call survives a crash of
+// the server machine, not just of the server process.
+func (c *KVClient) Sync() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Sync(ctx, &proto.SyncRequest{})
+	if err != nil {
+		return fmt.Errorf("Sync RPC failed: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("Sync failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// Flush asks the server to write its MemTable to an SSTable now, rather
+// than when it reaches the size threshold
+func (c *KVClient) Flush() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Flush(ctx, &proto.FlushRequest{})
+	if err != nil {
+		return fmt.Errorf("Flush RPC failed: %w", err)
+	}
+
+	if !resp.Success {
+		return fmt.Errorf("Flush failed: %s", resp.Error)
+	}
+
+	return nil
+}
+
+// RingInfo returns the server's view of the hash ring, simulating
+// simulatedKeys keys for the distribution (0 uses the server default)
+func (c *KVClient) RingInfo(simulatedKeys int) (*proto.RingInfoResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.RingInfo(ctx, &proto.RingInfoRequest{SimulatedKeys: int32(simulatedKeys)})
+	if err != nil {
+		return nil, fmt.Errorf("RingInfo RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// GetQuorum returns the replication factor and W/R quorums the server's
+// cluster client uses
+func (c *KVClient) GetQuorum() (*proto.QuorumResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.GetQuorum(ctx, &proto.GetQuorumRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("GetQuorum RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// SetQuorum changes the W and R quorums of the server's cluster client and
+// returns the quorums now in effect. Servers refuse values outside 1..N or
+// with W+R <= N with codes.InvalidArgument.
+func (c *KVClient) SetQuorum(w, r int) (*proto.QuorumResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := c.client.SetQuorum(ctx, &proto.SetQuorumRequest{W: int32(w), R: int32(r)})
+	if err != nil {
+		return nil, fmt.Errorf("SetQuorum RPC failed: %w", err)
+	}
+	return resp, nil
+}
+
+// Ping measures the round-trip time to the server
+func (c *KVClient) Ping() (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nonce := rand.Uint64()
+	start := time.Now()
+
+	resp, err := c.client.Ping(ctx, &proto.PingRequest{Nonce: nonce})
+	if err != nil {
+		return 0, fmt.Errorf("Ping RPC failed: %w", err)
+	}
+	rtt := time.Since(start)
+
+	if resp.Nonce != nonce {
+		return 0, fmt.Errorf("Ping failed: nonce mismatch (sent %d, got %d)", nonce, resp.Nonce)
+	}
+
+	return rtt, nil
+}
+
+// Scan returns the key-value pairs in [start, end).
+// An empty start or end leaves that side of the range open.
+func (c *KVClient) Scan(start, end string) ([]*proto.KeyValue, error) {
+	return c.scan("", start, end, false)
+}
+
+// ScanNS returns every key-value pair in a namespace, with keys relative
+// to it
+func (c *KVClient) ScanNS(ns string) ([]*proto.KeyValue, error) {
+	return c.scan(ns, "", "", false)
+}
+
+// ScanKeys returns the keys in [start, end) without fetching their values
+func (c *KVClient) ScanKeys(start, end string) ([]string, error) {
+	entries, err := c.scan("", start, end, true)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = entry.Key
+	}
+	return keys, nil
+}
+
+func (c *KVClient) scan(ns, start, end string, keysOnly bool) ([]*proto.KeyValue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := c.client.Scan(ctx, &proto.ScanRequest{
+		StartKey: start,
+		EndKey:   end,
+		KeysOnly: keysOnly,
+		Ns:       ns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Scan RPC failed: %w", err)
+	}
+
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Scan failed: %s", resp.Error)
+	}
+
+	return resp.Entries, nil
+}
+
+// Event is a mutation delivered by Watch
+type Event struct {
+	Key       string
+	Value     []byte // nil for deletes
+	Delete    bool
+	Timestamp int64 // UnixNano time of the write
+
+	// Lagged marks that the watch fell behind and events were dropped
+	// before this one; the other fields are empty. Callers that keep
+	// derived state (caches, indexes) should rebuild it from a Scan.
+	Lagged bool
+}
+
+// Watch calls fn, from a single goroutine and in order, for every Put and
+// Delete on the server to keys starting with prefix (every key if prefix is
+// empty). It returns once the server has registered the watch, so every
+// later write is reported.
+//
+// The watch runs until stop is called or the stream fails; stop ends it,
+// waits for fn to return and reports the error the stream failed with, if
+// any. fn must not call stop.
+func (c *KVClient) Watch(prefix string, fn func(Event)) (stop func() error, err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := c.client.Watch(ctx, &proto.WatchRequest{Prefix: prefix})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("Watch RPC failed: %w", err)
+	}
+
+	// The server sends headers once the watch is in place
+	if _, err := stream.Header(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("Watch RPC failed: %w", err)
+	}
+
+	done := make(chan struct{})
+	var streamErr error
+	go func() {
+		defer close(done)
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() == nil {
+					streamErr = fmt.Errorf("Watch stream failed: %w", err)
+				}
+				return
+			}
+
+			fn(Event{
+				Key:       event.Key,
+				Value:     event.Value,
+				Delete:    event.Delete,
+				Timestamp: event.Timestamp,
+				Lagged:    event.Lagged,
+			})
+		}
+	}()
+
+	return func() error {
+		cancel()
+		<-done
+		return streamErr
+	}, nil
+}
+
 // Close closes the connection
 func (c *KVClient) Close() error {
 	if c.conn != nil {
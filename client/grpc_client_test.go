@@ -0,0 +1,409 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/server"
+	"kvstore/storage"
+
+	"google.golang.org/grpc"
+)
+
+// startTestServer serves a fresh store from dataDir on addr
+func startTestServer(t *testing.T, addr, dataDir string) (*grpc.Server, *storage.LSMStore) {
+	t.Helper()
+
+	var lis net.Listener
+	var err error
+	// The port may take a moment to become free again after a restart
+	for i := 0; i < 50; i++ {
+		if lis, err = net.Listen("tcp", addr); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", addr, err)
+	}
+
+	return serveTestStore(t, lis, dataDir)
+}
+
+// serveTestStore serves a fresh store from dataDir on lis
+func serveTestStore(t *testing.T, lis net.Listener, dataDir string) (*grpc.Server, *storage.LSMStore) {
+	t.Helper()
+
+	store, err := storage.NewLSMStore(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.MaxRecvMsgSize(proto.DefaultMaxMessageSize),
+		grpc.MaxSendMsgSize(proto.DefaultMaxMessageSize),
+	)
+	proto.RegisterKVStoreServer(grpcServer, server.NewGRPCServer(store))
+	go grpcServer.Serve(lis)
+
+	return grpcServer, store
+}
+
+func TestKVClient_ReconnectAfterServerRestart(t *testing.T) {
+	dataDir := t.TempDir()
+
+	// Reserve a port so the server can come back on the same address
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, dataDir)
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := kvClient.WaitForReady(ctx); err != nil {
+		t.Fatalf("WaitForReady failed: %v", err)
+	}
+
+	if err := kvClient.Put("key", []byte("before")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// Restart the server
+	grpcServer.Stop()
+	store.Close()
+
+	grpcServer, store = startTestServer(t, addr, dataDir)
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	// The same client resumes without being recreated
+	if err := kvClient.WaitForReady(ctx); err != nil {
+		t.Fatalf("WaitForReady after restart failed: %v", err)
+	}
+
+	value, err := kvClient.Get("key")
+	if err != nil {
+		t.Fatalf("Get after restart failed: %v", err)
+	}
+	if string(value) != "before" {
+		t.Errorf("Expected 'before', got '%s'", value)
+	}
+
+	if err := kvClient.Put("key", []byte("after")); err != nil {
+		t.Errorf("Put after restart failed: %v", err)
+	}
+}
+
+func TestKVClient_StreamLargeValue(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, t.TempDir())
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	// Larger than the default 4MB gRPC message limit
+	value := make([]byte, 10*1024*1024+123)
+	for i := range value {
+		value[i] = byte(i % 251)
+	}
+
+	if err := kvClient.PutStream("blob", bytes.NewReader(value)); err != nil {
+		t.Fatalf("PutStream failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := kvClient.GetStream("blob", &buf); err != nil {
+		t.Fatalf("GetStream failed: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), value) {
+		t.Errorf("Streamed value mismatch: got %d bytes, want %d", buf.Len(), len(value))
+	}
+
+	// Empty values round-trip too
+	if err := kvClient.PutStream("empty", bytes.NewReader(nil)); err != nil {
+		t.Fatalf("PutStream of empty value failed: %v", err)
+	}
+	buf.Reset()
+	if err := kvClient.GetStream("empty", &buf); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected empty value, got %d bytes (err: %v)", buf.Len(), err)
+	}
+
+	if err := kvClient.GetStream("missing", &buf); err == nil {
+		t.Error("Expected error streaming a missing key")
+	}
+
+	// Values above the store limit are rejected
+	tooLarge := bytes.NewReader(make([]byte, storage.MaxValueSize+1))
+	if err := kvClient.PutStream("huge", tooLarge); err == nil {
+		t.Error("Expected PutStream to reject a value above MaxValueSize")
+	}
+}
+
+func TestKVClient_WaitForReadyTimeout(t *testing.T) {
+	// Nothing listens on this address
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := kvClient.WaitForReady(ctx); err == nil {
+		t.Error("Expected WaitForReady to fail with no server")
+	}
+}
+
+func TestKVClient_Watch(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, t.TempDir())
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	events := make(chan Event, 10)
+	stop, err := kvClient.Watch("user:", func(event Event) { events <- event })
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	// Watch has returned, so these writes are all reported
+	if err := kvClient.Put("user:1", []byte("alice")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := kvClient.Put("order:1", []byte("ignored")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := kvClient.Delete("user:1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	expected := []Event{
+		{Key: "user:1", Value: []byte("alice")},
+		{Key: "user:1", Delete: true},
+	}
+	for i, want := range expected {
+		select {
+		case got := <-events:
+			if got.Key != want.Key || got.Delete != want.Delete || string(got.Value) != string(want.Value) || got.Timestamp == 0 {
+				t.Errorf("Event %d: expected %+v, got %+v", i, want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+
+	if err := stop(); err != nil {
+		t.Errorf("stop returned %v", err)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected event %+v", event)
+	default:
+	}
+}
+
+func TestKVClient_PutGetAboveDefaultMessageLimit(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, t.TempDir())
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	// Larger than gRPC's 4MB default, sent as a single unary message
+	value := make([]byte, 6*1024*1024)
+	for i := range value {
+		value[i] = byte(i % 251)
+	}
+
+	if err := kvClient.Put("big", value); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	got, err := kvClient.Get("big")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Errorf("Value mismatch: got %d bytes, want %d", len(got), len(value))
+	}
+
+	// Above the raised limit, unary calls still fail; PutStream is the way
+	if err := kvClient.Put("huge", make([]byte, proto.DefaultMaxMessageSize+1)); err == nil {
+		t.Error("Expected Put above DefaultMaxMessageSize to fail")
+	}
+}
+
+func TestKVClient_Flush(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, t.TempDir())
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := kvClient.Put(fmt.Sprintf("key%d", i), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := kvClient.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	stats := store.Stats()
+	if n := stats["num_sstables"].(int); n != 1 {
+		t.Errorf("Expected 1 SSTable after Flush, got %d", n)
+	}
+	if entries := stats["memtable_entries"].(int64); entries != 0 {
+		t.Errorf("Expected an empty MemTable after Flush, got %d entries", entries)
+	}
+}
+
+func TestKVClient_EmptyValue(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	grpcServer, store := startTestServer(t, addr, t.TempDir())
+	defer func() {
+		grpcServer.Stop()
+		store.Close()
+	}()
+
+	kvClient, err := NewKVClient(addr)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	// Stored empty: found, with an empty value
+	if err := kvClient.Put("empty", nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	value, err := kvClient.Get("empty")
+	if err != nil {
+		t.Fatalf("Expected the empty value found, got %v", err)
+	}
+	if value == nil || len(value) != 0 {
+		t.Errorf("Expected a non-nil empty value, got %q", value)
+	}
+
+	// Deleted: not found
+	if err := kvClient.Delete("empty"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := kvClient.Get("empty"); err == nil {
+		t.Error("Expected Get of a deleted key to fail")
+	}
+}
+
+func TestKVClient_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.sock")
+	lis, err := server.ListenUnix(path)
+	if err != nil {
+		t.Fatalf("ListenUnix failed: %v", err)
+	}
+	grpcServer, store := serveTestStore(t, lis, t.TempDir())
+	defer store.Close()
+
+	kvClient, err := NewKVClientUnix(path)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer kvClient.Close()
+
+	if err := kvClient.Put("sidecar", []byte("over a socket")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	value, err := kvClient.Get("sidecar")
+	if err != nil || string(value) != "over a socket" {
+		t.Fatalf("Get = %q, %v", value, err)
+	}
+
+	// Stopping the server removes the socket file
+	grpcServer.GracefulStop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the socket file removed on shutdown, got %v", err)
+	}
+}
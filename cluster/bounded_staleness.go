@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"kvstore/proto"
+)
+
+// GetBoundedStaleness reads key from a single replica, the nearest one, and
+// returns its value if it was written no more than maxAge ago. If the value
+// is older, or the replica doesn't have the key or can't be reached, it
+// falls back to a quorum read like Get. Reads of keys that change more
+// often than maxAge take one replica call; values may be up to maxAge behind
+// the latest write.
+//
+// The nearest replica is this node's own when SetLocalStore is in use, else
+// the nearest by SetNodeDistances, else the one with the lowest recent
+// median read latency. Replicas with no recent reads are tried first, so
+// every replica gets measured.
+func (cc *ClusterClient) GetBoundedStaleness(ctx context.Context, key string, maxAge time.Duration) ([]byte, error) {
+	preferenceList, _, _, err := cc.preferenceListFor("get", key, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID := cc.nearestReplica(preferenceList)
+	replicaCtx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+	start := time.Now()
+	resp, err := cc.replicaGet(replicaCtx, nodeID, &proto.ReplicaGetRequest{Key: key})
+	cc.latency.recordRead(nodeID, start)
+	cancel()
+
+	switch {
+	case err != nil:
+		log.Printf("⚠️  GET %s from %s failed, escalating to quorum read: %v", key, nodeID, err)
+	case !resp.Found:
+		log.Printf("⏫ GET %s: not found on %s, escalating to quorum read", key, nodeID)
+	default:
+		age := time.Since(time.Unix(0, resp.Timestamp))
+		if age <= maxAge {
+			return resp.Value, nil
+		}
+		log.Printf("⏫ GET %s: value on %s is %v old (max %v), escalating to quorum read",
+			key, nodeID, age.Round(time.Millisecond), maxAge)
+	}
+
+	return cc.Get(ctx, key)
+}
+
+// nearestReplica picks the replica to serve a single-replica read: the
+// local one, else the nearest by distance, else the first without recent
+// reads, else the fastest
+func (cc *ClusterClient) nearestReplica(preferenceList []string) string {
+	for _, nodeID := range preferenceList {
+		if _, local := cc.localStoreFor(nodeID); local {
+			return nodeID
+		}
+	}
+	if ordered, nearest := cc.byDistance(preferenceList); nearest {
+		return ordered[0]
+	}
+
+	nearest := preferenceList[0]
+	var nearestP50 time.Duration
+	measured := false
+	for _, nodeID := range preferenceList {
+		p50, ok := cc.latency.readP50(nodeID)
+		if !ok {
+			return nodeID
+		}
+		if !measured || p50 < nearestP50 {
+			nearest, nearestP50, measured = nodeID, p50, true
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kvstore/proto"
+)
+
+func TestClusterClient_GetBoundedStaleness(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	// node3 answers fastest, so it is the replica a bounded read goes to
+	for i := 0; i < 100; i++ {
+		cc.latency.node("node1").reads.record(10 * time.Millisecond)
+		cc.latency.node("node2").reads.record(10 * time.Millisecond)
+		cc.latency.node("node3").reads.record(time.Microsecond)
+	}
+
+	// node3 missed the last write an hour ago
+	fresh := time.Now().UnixNano()
+	stale := time.Now().Add(-time.Hour).UnixNano()
+	nodes["node1"].data["dash:1"] = &proto.ReplicaPutRequest{Key: "dash:1", Value: []byte("new"), Timestamp: fresh, Version: fresh}
+	nodes["node2"].data["dash:1"] = &proto.ReplicaPutRequest{Key: "dash:1", Value: []byte("new"), Timestamp: fresh, Version: fresh}
+	nodes["node3"].data["dash:1"] = &proto.ReplicaPutRequest{Key: "dash:1", Value: []byte("old"), Timestamp: stale, Version: stale}
+
+	value, err := cc.GetBoundedStaleness(context.Background(), "dash:1", time.Minute)
+	if err != nil {
+		t.Fatalf("GetBoundedStaleness failed: %v", err)
+	}
+	if string(value) != "new" {
+		t.Errorf("Expected the quorum's 'new' over the stale replica's value, got %q", value)
+	}
+	if nodes["node1"].getCount() == 0 && nodes["node2"].getCount() == 0 {
+		t.Error("Expected a stale replica to escalate to a quorum read")
+	}
+	cc.background.Wait()
+
+	// The quorum read repaired node3, whose answer is now used on its own
+	before := map[string]int{}
+	for nodeID, node := range nodes {
+		before[nodeID] = node.getCount()
+	}
+	value, err = cc.GetBoundedStaleness(context.Background(), "dash:1", time.Minute)
+	if err != nil {
+		t.Fatalf("GetBoundedStaleness failed: %v", err)
+	}
+	if string(value) != "new" {
+		t.Errorf("Expected 'new' from the repaired replica, got %q", value)
+	}
+	if got := nodes["node3"].getCount() - before["node3"]; got != 1 {
+		t.Errorf("Expected 1 read from the nearest replica, got %d", got)
+	}
+	for _, nodeID := range []string{"node1", "node2"} {
+		if got := nodes[nodeID].getCount() - before[nodeID]; got != 0 {
+			t.Errorf("%s: expected no reads within maxAge, got %d", nodeID, got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"kvstore/proto"
+)
+
+// ErrCircuitOpen is returned for a replica call to a node whose circuit
+// breaker is open; the call was not sent
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// CircuitState is the state of one node's circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed  CircuitState = iota // Calls go through
+	CircuitOpen                        // Calls fail straight away until the cooldown ends
+	CircuitProbing                     // The cooldown ended and a Ping is checking the node; calls still fail
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitProbing:
+		return "probing"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitStatus describes one node's circuit breaker
+type CircuitStatus struct {
+	State    CircuitState
+	Failures int       // Consecutive failed calls
+	OpenedAt time.Time // When the circuit last opened (zero if it never did)
+}
+
+// circuitBreakers holds a breaker per node. The zero value is disabled.
+type circuitBreakers struct {
+	mu        sync.Mutex
+	threshold int           // Consecutive failures that open a circuit (0 = off)
+	cooldown  time.Duration // How long a circuit stays open before a probe
+	nodes     map[string]*CircuitStatus
+}
+
+// SetCircuitBreaker stops sending replica calls to a node that keeps
+// failing, so operations don't wait on its timeouts during an outage. After
+// failures consecutive failed replica calls to a node its circuit opens:
+// calls to it fail with ErrCircuitOpen without being sent, so writes store
+// a hint for it straight away and reads go to the other replicas. Once
+// cooldown has passed, the next call sends the node a Ping; if it answers
+// the circuit closes, otherwise it stays open for another cooldown.
+//
+// Calls the caller cancels don't count as failures, and calls to this
+// node's local store never go through a breaker. failures <= 0 turns the
+// breakers off (the default) and closes every circuit.
+func (cc *ClusterClient) SetCircuitBreaker(failures int, cooldown time.Duration) {
+	b := &cc.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = max(failures, 0)
+	b.cooldown = cooldown
+	b.nodes = nil
+}
+
+// CircuitBreakers returns the state of every node's circuit breaker that
+// has seen a failure; nodes missing from the map are closed
+func (cc *ClusterClient) CircuitBreakers() map[string]CircuitStatus {
+	b := &cc.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	states := make(map[string]CircuitStatus, len(b.nodes))
+	for nodeID, status := range b.nodes {
+		states[nodeID] = *status
+	}
+	return states
+}
+
+// allowCall returns ErrCircuitOpen if calls to nodeID must not be sent,
+// starting a probe if the node's cooldown is over
+func (cc *ClusterClient) allowCall(nodeID string) error {
+	b := &cc.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := b.nodes[nodeID]
+	if b.threshold == 0 || status == nil || status.State == CircuitClosed {
+		return nil
+	}
+	if status.State == CircuitOpen && time.Since(status.OpenedAt) >= b.cooldown {
+		status.State = CircuitProbing
+		cc.background.Add(1)
+		go cc.probeNode(nodeID)
+	}
+	return fmt.Errorf("%w for node %s (%d consecutive failures)", ErrCircuitOpen, nodeID, status.Failures)
+}
+
+// probeNode pings a node whose circuit is open and closes it if it answers
+func (cc *ClusterClient) probeNode(nodeID string) {
+	defer cc.background.Done()
+
+	_, err := cc.PingNode(nodeID)
+
+	b := &cc.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := b.nodes[nodeID]
+	if status == nil || status.State != CircuitProbing {
+		return // Reset by SetCircuitBreaker
+	}
+	if err != nil {
+		log.Printf("🔌 Circuit for %s stays open, probe failed: %v", nodeID, err)
+		status.State, status.OpenedAt = CircuitOpen, time.Now()
+		return
+	}
+	log.Printf("🔌 Circuit for %s closed, probe succeeded", nodeID)
+	delete(b.nodes, nodeID)
+}
+
+// recordCall counts a replica call's outcome against nodeID's breaker
+func (cc *ClusterClient) recordCall(ctx context.Context, nodeID string, err error) {
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return // The caller gave up; says nothing about the node
+	}
+
+	b := &cc.breakers
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.threshold == 0 {
+		return
+	}
+
+	if err == nil {
+		if status := b.nodes[nodeID]; status != nil && status.State == CircuitClosed {
+			delete(b.nodes, nodeID)
+		}
+		return
+	}
+
+	if b.nodes == nil {
+		b.nodes = make(map[string]*CircuitStatus)
+	}
+	status := b.nodes[nodeID]
+	if status == nil {
+		status = &CircuitStatus{}
+		b.nodes[nodeID] = status
+	}
+	status.Failures++
+	if status.State == CircuitClosed && status.Failures >= b.threshold {
+		log.Printf("🔌 Circuit for %s opened after %d consecutive failures: %v", nodeID, status.Failures, err)
+		status.State, status.OpenedAt = CircuitOpen, time.Now()
+	}
+}
+
+// callNode runs call with nodeID's gRPC client, through its circuit breaker
+func (cc *ClusterClient) callNode(ctx context.Context, nodeID string, call func(client proto.KVStoreClient) error) error {
+	client, exists := cc.getClient(nodeID)
+	if !exists {
+		return fmt.Errorf("no client for node")
+	}
+	if err := cc.allowCall(nodeID); err != nil {
+		return err
+	}
+	err := call(client)
+	cc.recordCall(ctx, nodeID, err)
+	return err
+}
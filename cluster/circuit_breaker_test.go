@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestClusterClient_CircuitBreaker(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	cc.SetCircuitBreaker(3, time.Hour)
+	ctx := context.Background()
+
+	// Every key lives on all three nodes; node3 fails each write
+	nodes["node3"].setFailed(true)
+	for i := 0; i < 3; i++ {
+		if err := cc.Put(ctx, "key", []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	cc.background.Wait()
+
+	status := cc.CircuitBreakers()["node3"]
+	if status.State != CircuitOpen || status.Failures != 3 {
+		t.Fatalf("Expected node3's circuit open after 3 failures, got %+v", status)
+	}
+	if _, ok := cc.CircuitBreakers()["node1"]; ok {
+		t.Error("Expected node1's circuit untouched")
+	}
+
+	// Later calls short-circuit: node3 is not contacted, and still gets a hint
+	sent := nodes["node3"].putCount()
+	result, err := cc.PutWithResult(ctx, "key", []byte("value"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	cc.background.Wait()
+	if n := nodes["node3"].putCount(); n != sent {
+		t.Errorf("Expected no calls to node3 while its circuit is open, got %d more", n-sent)
+	}
+	if _, err := cc.replicaGet(ctx, "node3", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if len(result.HintedNodes) != 1 || result.HintedNodes[0] != "node3" {
+		t.Errorf("Expected a hint for node3, got %v", result.HintedNodes)
+	}
+
+	stats, _ := cc.GetAllStats()
+	if stats.Circuits["node3"].State != CircuitOpen {
+		t.Errorf("Expected the open circuit in the cluster stats, got %+v", stats.Circuits)
+	}
+
+	// After the cooldown a probe closes the circuit once node3 is back
+	nodes["node3"].setFailed(false)
+	cc.breakers.mu.Lock()
+	cc.breakers.cooldown = 0
+	cc.breakers.mu.Unlock()
+	if err := cc.Put(ctx, "key", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	cc.background.Wait()
+	if _, open := cc.CircuitBreakers()["node3"]; open {
+		t.Fatalf("Expected node3's circuit closed after a successful probe, got %+v", cc.CircuitBreakers()["node3"])
+	}
+
+	sent = nodes["node3"].putCount()
+	if err := cc.Put(ctx, "key", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	cc.background.Wait()
+	if n := nodes["node3"].putCount(); n != sent+1 {
+		t.Errorf("Expected node3 to be called again, got %d calls", n-sent)
+	}
+}
@@ -2,49 +2,97 @@ package cluster
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"kvstore/proto"
 	"kvstore/replication"
+	"kvstore/storage"
+	"kvstore/tracing"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
+const (
+	// ReadRepairMaxJitter is the longest a read repair waits before writing,
+	// so repairs triggered by many readers at once don't land together
+	ReadRepairMaxJitter = 50 * time.Millisecond
+
+	// ReplicaTimeout is the longest a single replica RPC may take. Put, Get
+	// and Delete shorten it to the caller's context deadline.
+	ReplicaTimeout = 5 * time.Second
+)
+
 // ClusterClient is a client that can communicate with multiple nodes
 type ClusterClient struct {
 	registry          *NodeRegistry
 	connections       map[string]*grpc.ClientConn    // nodeID -> connection
 	clients           map[string]proto.KVStoreClient // nodeID -> gRPC client
+	mu                sync.RWMutex                   // Guards connections, clients and the local store
+	selfID            string                         // This node, when it has a local store
+	localStore        *storage.LSMStore              // Serves selfID's replicas without RPCs (nil = off)
 	hintedHandoff     *replication.HintedHandoff
 	replicationFactor int
-	writeQuorum       int
-	readQuorum        int
+	quorum            atomic.Pointer[Quorum]      // W and R, adjustable with SetQuorum
+	relaxedQuorum     atomic.Bool                 // shrink W/R to the nodes available instead of failing
+	syncReadRepair    atomic.Bool                 // Get waits for read repairs instead of running them in the background
+	repairsInFlight   sync.Map                    // key -> struct{}, read repairs currently running
+	background        sync.WaitGroup              // Read repairs and late replica answers still being handled
+	replicaCalls      atomic.Pointer[replicaPool] // Caps concurrent replica calls (nil = no cap)
+	latency           latencyTracker
+	drains            map[string]*DrainProgress               // nodeID -> progress of DrainNode
+	drainMu           sync.Mutex                              // Guards drains
+	resolver          replication.ConflictResolver            // Resolves divergent replicas (nil = last-write-wins)
+	prefixResolvers   map[string]replication.ConflictResolver // key prefix -> resolver overriding resolver
+	resolverMu        sync.RWMutex                            // Guards resolver and prefixResolvers
+	breakers          circuitBreakers                         // Per-node circuit breakers (see SetCircuitBreaker)
+	distances         atomic.Pointer[map[string]int]          // nodeID -> distance for nearest reads (nil = off, see SetNodeDistances)
 }
 
-// NewClusterClient creates a new cluster client
+// NewClusterClient creates a new cluster client connected to every node in
+// nodeAddresses (node ID -> address). Each node needs its own ID and its
+// own address: a node listed twice under different IDs would hold two
+// replicas of the same keys. If any node can't be reached, every
+// connection already opened is closed before the error is returned.
 func NewClusterClient(nodeAddresses map[string]string) (*ClusterClient, error) {
+	if err := validateNodeAddresses(nodeAddresses); err != nil {
+		return nil, err
+	}
+
 	registry := NewNodeRegistry(DefaultVirtualNodes)
 	connections := make(map[string]*grpc.ClientConn)
 	clients := make(map[string]proto.KVStoreClient)
 
+	// Clean up existing connections when the client can't be built
+	closeAll := func() {
+		for _, c := range connections {
+			c.Close()
+		}
+	}
+
 	// Connect to all nodes
 	for nodeID, address := range nodeAddresses {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		conn, err := grpc.DialContext(ctx, address,
+		opts := append([]grpc.DialOption{
 			grpc.WithTransportCredentials(insecure.NewCredentials()),
 			grpc.WithBlock(),
-		)
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallRecvMsgSize(proto.DefaultMaxMessageSize),
+				grpc.MaxCallSendMsgSize(proto.DefaultMaxMessageSize),
+			),
+		}, tracing.DialOptions()...)
+		conn, err := grpc.DialContext(ctx, address, opts...)
 		if err != nil {
-			// Clean up existing connections
-			for _, c := range connections {
-				c.Close()
-			}
+			closeAll()
 			return nil, fmt.Errorf("failed to connect to node %s at %s: %w", nodeID, address, err)
 		}
 
@@ -53,6 +101,7 @@ func NewClusterClient(nodeAddresses map[string]string) (*ClusterClient, error) {
 
 		// Register node
 		if err := registry.RegisterNode(nodeID, address); err != nil {
+			closeAll()
 			return nil, err
 		}
 	}
@@ -60,32 +109,194 @@ func NewClusterClient(nodeAddresses map[string]string) (*ClusterClient, error) {
 	// Initialize hinted handoff
 	hintedHandoff, err := replication.NewHintedHandoff("./hints")
 	if err != nil {
+		closeAll()
 		return nil, fmt.Errorf("failed to create hinted handoff: %w", err)
 	}
 
 	// Start cleanup task for old hints
 	hintedHandoff.StartCleanupTask(1 * time.Hour)
 
-	return &ClusterClient{
+	cc := &ClusterClient{
 		registry:          registry,
 		connections:       connections,
 		clients:           clients,
 		hintedHandoff:     hintedHandoff,
 		replicationFactor: replication.ReplicationFactor,
-		writeQuorum:       replication.WriteQuorum,
-		readQuorum:        replication.ReadQuorum,
-	}, nil
+		drains:            make(map[string]*DrainProgress),
+	}
+	cc.quorum.Store(&Quorum{
+		N: replication.ReplicationFactor,
+		W: replication.WriteQuorum,
+		R: replication.ReadQuorum,
+	})
+	return cc, nil
 }
 
-// Put stores a key-value pair with replication
-func (cc *ClusterClient) Put(key string, value []byte) error {
-	// Get preference list (N nodes for replication)
-	preferenceList, err := cc.registry.hashRing.GetPreferenceList(key, cc.replicationFactor)
+// validateNodeAddresses rejects empty node IDs or addresses and addresses
+// listed under more than one ID
+func validateNodeAddresses(nodeAddresses map[string]string) error {
+	nodeIDs := make([]string, 0, len(nodeAddresses))
+	for nodeID := range nodeAddresses {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs) // Report the same pair every time
+
+	byAddress := make(map[string]string, len(nodeAddresses))
+	for _, nodeID := range nodeIDs {
+		address := nodeAddresses[nodeID]
+		if nodeID == "" {
+			return fmt.Errorf("%w: empty node ID for address %q", ErrInvalidNode, address)
+		}
+		if address == "" {
+			return fmt.Errorf("%w: node %s has no address", ErrInvalidNode, nodeID)
+		}
+		if other, dup := byAddress[address]; dup {
+			return fmt.Errorf("%w: nodes %s and %s both have address %s", ErrInvalidNode, other, nodeID, address)
+		}
+		byAddress[address] = nodeID
+	}
+	return nil
+}
+
+// ErrInvalidNode is returned by NewClusterClient for a node list it can't
+// build a ring from
+var ErrInvalidNode = errors.New("invalid cluster node")
+
+// ErrTopologyChanged is returned when cluster membership changed while a
+// write was in flight. The write was sent to a stale preference list and is
+// not acknowledged; it is safe to retry against the new topology.
+var ErrTopologyChanged = errors.New("cluster membership changed during operation")
+
+// ErrQuorumNotReached is returned when a write reaches fewer than W replicas.
+// The write may still have been applied on the replicas that succeeded, and
+// hints are stored for the ones that failed, so callers can decide whether to
+// retry or accept the partial write.
+type ErrQuorumNotReached struct {
+	Key         string
+	Successes   int      // Replicas that acknowledged the write
+	Attempted   int      // Replicas in the preference list
+	Required    int      // Write quorum (W)
+	HintedNodes []string // Failed replicas that have a hint stored
+	Cause       error    // The caller's context error, if it ended before the quorum
+}
+
+func (e *ErrQuorumNotReached) Error() string {
+	msg := fmt.Sprintf("write quorum not reached: %d/%d successful (need %d), hints stored for %v",
+		e.Successes, e.Attempted, e.Required, e.HintedNodes)
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the context error, so errors.Is(err, context.DeadlineExceeded)
+// tells a caller's expired budget from failing replicas
+func (e *ErrQuorumNotReached) Unwrap() error {
+	return e.Cause
+}
+
+// ErrInsufficientReplicas is returned before any replica is contacted when
+// the cluster has fewer nodes than the quorum an operation needs, so the
+// quorum could never be reached. See SetRelaxedQuorum.
+type ErrInsufficientReplicas struct {
+	Op        string // "put", "get" or "delete"
+	Key       string
+	Available int // Nodes in the cluster
+	Required  int // Quorum for the operation
+}
+
+func (e *ErrInsufficientReplicas) Error() string {
+	return fmt.Sprintf("insufficient replicas for %s %s: %d node(s) available, need %d (short by %d)",
+		e.Op, e.Key, e.Available, e.Required, e.Required-e.Available)
+}
+
+// SetRelaxedQuorum controls what happens when the cluster has fewer nodes
+// than W or R. By default operations fail with *ErrInsufficientReplicas; in
+// relaxed mode the quorum shrinks to the number of nodes available, trading
+// consistency for availability in small or degraded clusters. An empty
+// cluster always fails.
+func (cc *ClusterClient) SetRelaxedQuorum(enabled bool) {
+	cc.relaxedQuorum.Store(enabled)
+}
+
+// SetSyncReadRepair controls when Get repairs stale replicas. By default the
+// repair runs in the background after Get returns, so another reader may
+// still see the stale value for a moment. In sync mode Get waits for every
+// replica to answer and for the repair ReplicaPuts to finish, so once it
+// returns every reachable replica has the value returned, at the cost of
+// the slowest replica's latency.
+func (cc *ClusterClient) SetSyncReadRepair(enabled bool) {
+	cc.syncReadRepair.Store(enabled)
+}
+
+// preferenceListFor returns the preference list for key, the ring generation
+// it was computed at, and the quorum to use for op given the nodes available
+func (cc *ClusterClient) preferenceListFor(op, key string, quorum int) ([]string, uint64, int, error) {
+	preferenceList, generation, err := cc.registry.hashRing.GetPreferenceListWithGeneration(key, cc.replicationFactor)
+	if errors.Is(err, ErrEmptyRing) {
+		return nil, generation, 0, &ErrInsufficientReplicas{Op: op, Key: key, Available: 0, Required: quorum}
+	}
+	if err != nil {
+		return nil, generation, 0, fmt.Errorf("failed to get preference list: %w", err)
+	}
+
+	if len(preferenceList) >= quorum {
+		return preferenceList, generation, quorum, nil
+	}
+
+	if !cc.relaxedQuorum.Load() {
+		return nil, generation, 0, &ErrInsufficientReplicas{Op: op, Key: key, Available: len(preferenceList), Required: quorum}
+	}
+
+	log.Printf("⚠️  %s %s: only %d node(s) available, relaxing quorum %d → %d",
+		op, key, len(preferenceList), quorum, len(preferenceList))
+	return preferenceList, generation, len(preferenceList), nil
+}
+
+// WriteResult describes the outcome of a replicated write
+type WriteResult struct {
+	Key            string
+	PreferenceList []string
+	SucceededNodes []string // Replicas that acknowledged the write
+	FailedNodes    []string // Replicas that failed or rejected the write
+	HintedNodes    []string // Failed replicas that have a hint stored
+	PendingNodes   []string // Replicas that had not answered when the call returned
+	Timestamp      int64
+	Version        int64
+	QuorumReached  bool
+}
+
+// Put stores a key-value pair with replication (see PutWithResult)
+func (cc *ClusterClient) Put(ctx context.Context, key string, value []byte) error {
+	_, err := cc.PutWithResult(ctx, key, value)
+	return err
+}
+
+// PutWithResult stores a key-value pair with replication and reports which
+// replicas took the write. Each replica RPC gets a deadline of ReplicaTimeout
+// or ctx's, whichever is sooner, and the call returns as soon as W replicas
+// have acknowledged the write or ctx ends. Replicas still answering at that
+// point are listed in PendingNodes; any of them that then fails gets a hint.
+// If the write quorum is not reached, the returned error is an
+// *ErrQuorumNotReached and the result is still populated.
+func (cc *ClusterClient) PutWithResult(ctx context.Context, key string, value []byte) (*WriteResult, error) {
+	ctx, span := tracing.Start(ctx, "ClusterClient.Put")
+	span.SetAttribute("key", key)
+	writeResult, err := cc.putWithResult(ctx, key, value)
+	span.SetError(err)
+	span.End()
+	return writeResult, err
+}
+
+// putWithResult does the work of PutWithResult inside its span
+func (cc *ClusterClient) putWithResult(ctx context.Context, key string, value []byte) (*WriteResult, error) {
+	// Get preference list (N nodes for replication) and the ring generation it belongs to
+	preferenceList, generation, writeQuorum, err := cc.preferenceListFor("put", key, cc.Quorum().W)
 	if err != nil {
-		return fmt.Errorf("failed to get preference list: %w", err)
+		return nil, err
 	}
 
-	log.Printf("🎯 PUT %s → replicas: %v (W=%d)", key, preferenceList, cc.writeQuorum)
+	log.Printf("🎯 PUT %s → replicas: %v (W=%d)", key, preferenceList, writeQuorum)
 
 	// Generate version and timestamp
 	timestamp := replication.GenerateTimestamp()
@@ -99,29 +310,22 @@ func (cc *ClusterClient) Put(key string, value []byte) error {
 	}
 
 	resultChan := make(chan result, len(preferenceList))
-	var wg sync.WaitGroup
 
 	for _, nodeID := range preferenceList {
-		wg.Add(1)
-		go func(nID string) {
-			defer wg.Done()
-
-			client, exists := cc.clients[nID]
-			if !exists {
-				resultChan <- result{nodeID: nID, success: false, err: fmt.Errorf("no client for node")}
-				return
-			}
-
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		nID := nodeID
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
 			defer cancel()
 
 			// Use ReplicaPut for internal replication
-			resp, err := client.ReplicaPut(ctx, &proto.ReplicaPutRequest{
+			start := time.Now()
+			resp, err := cc.replicaPut(ctx, nID, &proto.ReplicaPutRequest{
 				Key:       key,
 				Value:     value,
 				Timestamp: timestamp,
 				Version:   version,
 			})
+			cc.latency.recordWrite(nID, start)
 
 			if err != nil {
 				resultChan <- result{nodeID: nID, success: false, err: err}
@@ -129,131 +333,214 @@ func (cc *ClusterClient) Put(key string, value []byte) error {
 			}
 
 			resultChan <- result{nodeID: nID, success: resp.Success, err: nil}
-		}(nodeID)
+		})
 	}
 
-	// Wait for all writes to complete
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
+	writeResult := &WriteResult{
+		Key:            key,
+		PreferenceList: preferenceList,
+		Timestamp:      timestamp,
+		Version:        version,
+	}
+
+	// storeHint records a write for a replica that failed it
+	storeHint := func(nodeID string) bool {
+		if err := cc.hintedHandoff.StoreHint(nodeID, key, value, timestamp, version); err != nil {
+			log.Printf("⚠️  Failed to store hint for %s: %v", nodeID, err)
+			return false
+		}
+		return true
+	}
 
-	// Collect results
+	// Collect results until the quorum is reached or the caller gives up
 	var responses []replication.ReplicaResponse
-	for res := range resultChan {
+	answered := make(map[string]bool)
+collect:
+	for len(answered) < len(preferenceList) && len(writeResult.SucceededNodes) < writeQuorum {
+		var res result
+		select {
+		case res = <-resultChan:
+		case <-ctx.Done():
+			break collect
+		}
+
+		answered[res.nodeID] = true
 		responses = append(responses, replication.ReplicaResponse{
 			NodeID:  res.nodeID,
 			Success: res.success,
 			Error:   res.err,
 		})
 
-		if !res.success && res.err != nil {
-			log.Printf("⚠️  Failed to write to %s: %v", res.nodeID, res.err)
-			// Store hint for failed node
-			cc.hintedHandoff.StoreHint(res.nodeID, key, value, timestamp, version)
+		if res.success {
+			writeResult.SucceededNodes = append(writeResult.SucceededNodes, res.nodeID)
+			continue
+		}
+
+		log.Printf("⚠️  Failed to write to %s: %v", res.nodeID, res.err)
+		writeResult.FailedNodes = append(writeResult.FailedNodes, res.nodeID)
+
+		// Store hint for failed node
+		if storeHint(res.nodeID) {
+			writeResult.HintedNodes = append(writeResult.HintedNodes, res.nodeID)
 		}
 	}
 
-	// Check if write quorum is satisfied
-	if !replication.QuorumReached(responses, cc.writeQuorum) {
-		successCount := 0
-		for _, r := range responses {
-			if r.Success {
-				successCount++
+	for _, nodeID := range preferenceList {
+		if !answered[nodeID] {
+			writeResult.PendingNodes = append(writeResult.PendingNodes, nodeID)
+		}
+	}
+	if pending := len(writeResult.PendingNodes); pending > 0 {
+		// The slower replicas may still fail; they need a hint all the same
+		cc.background.Add(1)
+		go func() {
+			defer cc.background.Done()
+			for i := 0; i < pending; i++ {
+				if res := <-resultChan; !res.success {
+					log.Printf("⚠️  Failed to write to %s after PUT returned: %v", res.nodeID, res.err)
+					storeHint(res.nodeID)
+				}
 			}
+		}()
+	}
+
+	// Don't acknowledge a write made against a stale topology
+	if current := cc.registry.hashRing.Generation(); current != generation {
+		log.Printf("⚠️  PUT %s: membership changed during write (generation %d → %d)", key, generation, current)
+		return writeResult, fmt.Errorf("%w: put %s", ErrTopologyChanged, key)
+	}
+
+	// Check if write quorum is satisfied
+	writeResult.QuorumReached = replication.QuorumReached(responses, writeQuorum)
+	if !writeResult.QuorumReached {
+		return writeResult, &ErrQuorumNotReached{
+			Key:         key,
+			Successes:   len(writeResult.SucceededNodes),
+			Attempted:   len(preferenceList),
+			Required:    writeQuorum,
+			HintedNodes: writeResult.HintedNodes,
+			Cause:       ctx.Err(),
 		}
-		return fmt.Errorf("write quorum not reached: %d/%d successful (need %d)",
-			successCount, len(responses), cc.writeQuorum)
 	}
 
 	log.Printf("✅ PUT successful: %d/%d replicas (quorum: %d)",
-		len(responses), cc.replicationFactor, cc.writeQuorum)
+		len(writeResult.SucceededNodes), cc.replicationFactor, writeQuorum)
 
-	return nil
+	return writeResult, nil
 }
 
-// Get retrieves a value by key with quorum reads
-func (cc *ClusterClient) Get(key string) ([]byte, error) {
+// Get retrieves a value by key with quorum reads. Like PutWithResult, it
+// returns as soon as R replicas have answered with the key or ctx ends;
+// the slower replicas' answers are still used to decide on read repair.
+// With SetSyncReadRepair, it also waits for them and for the repair. With
+// SetNodeDistances, only the nearest replicas are asked.
+func (cc *ClusterClient) Get(ctx context.Context, key string) ([]byte, error) {
+	ctx, span := tracing.Start(ctx, "ClusterClient.Get")
+	span.SetAttribute("key", key)
+	value, err := cc.get(ctx, key)
+	span.SetError(err)
+	span.End()
+	return value, err
+}
+
+// get does the work of Get inside its span
+func (cc *ClusterClient) get(ctx context.Context, key string) ([]byte, error) {
 	// Get preference list (N nodes for replication)
-	preferenceList, err := cc.registry.hashRing.GetPreferenceList(key, cc.replicationFactor)
+	preferenceList, _, readQuorum, err := cc.preferenceListFor("get", key, cc.Quorum().R)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get preference list: %w", err)
+		return nil, err
+	}
+
+	// Nearest first; with node distances set, only R are asked up front
+	preferenceList, nearest := cc.byDistance(preferenceList)
+	initial := len(preferenceList)
+	if nearest {
+		initial = min(readQuorum, initial)
 	}
 
-	log.Printf("🎯 GET %s → replicas: %v (R=%d)", key, preferenceList, cc.readQuorum)
+	log.Printf("🎯 GET %s → replicas: %v (R=%d)", key, preferenceList[:initial], readQuorum)
 
 	// Read from replicas in parallel
 	type result struct {
-		nodeID    string
-		value     []byte
-		found     bool
-		version   int64
-		timestamp int64
-		err       error
+		response replication.ReplicaResponse
+		found    bool
 	}
 
 	resultChan := make(chan result, len(preferenceList))
-	var wg sync.WaitGroup
-
-	for _, nodeID := range preferenceList {
-		wg.Add(1)
-		go func(nID string) {
-			defer wg.Done()
-
-			client, exists := cc.clients[nID]
-			if !exists {
-				resultChan <- result{nodeID: nID, found: false, err: fmt.Errorf("no client for node")}
-				return
-			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	sent := 0
+	send := func() {
+		nID := preferenceList[sent]
+		sent++
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
 			defer cancel()
 
 			// Use ReplicaGet for quorum reads
-			resp, err := client.ReplicaGet(ctx, &proto.ReplicaGetRequest{
+			start := time.Now()
+			resp, err := cc.replicaGet(ctx, nID, &proto.ReplicaGetRequest{
 				Key: key,
 			})
+			cc.latency.recordRead(nID, start)
 
 			if err != nil {
-				resultChan <- result{nodeID: nID, found: false, err: err}
+				resultChan <- result{response: replication.ReplicaResponse{NodeID: nID, Error: err}}
 				return
 			}
 
 			resultChan <- result{
-				nodeID:    nID,
-				value:     resp.Value,
-				found:     resp.Found,
-				version:   resp.Version,
-				timestamp: resp.Timestamp,
-				err:       nil,
+				response: replication.ReplicaResponse{
+					NodeID:    nID,
+					Success:   true,
+					Value:     resp.Value,
+					Version:   resp.Version,
+					Timestamp: resp.Timestamp,
+				},
+				found: resp.Found,
 			}
-		}(nodeID)
+		})
+	}
+	for sent < initial {
+		send()
 	}
 
-	// Wait for all reads to complete
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	// Collect results
+	// Collect results until R replicas have the key or the caller gives up,
+	// asking the next nearest replica for each one that doesn't have it
 	var responses []replication.ReplicaResponse
-	for res := range resultChan {
-		if res.found {
-			responses = append(responses, replication.ReplicaResponse{
-				NodeID:    res.nodeID,
-				Success:   true,
-				Value:     res.value,
-				Version:   res.version,
-				Timestamp: res.timestamp,
-			})
+	answered := 0
+collect:
+	for answered < sent && len(responses) < readQuorum {
+		select {
+		case res := <-resultChan:
+			answered++
+			if res.found {
+				responses = append(responses, res.response)
+			} else if sent < len(preferenceList) {
+				send()
+			}
+		case <-ctx.Done():
+			break collect
 		}
 	}
 
 	// Check if read quorum is satisfied
-	if len(responses) < cc.readQuorum {
+	if len(responses) < readQuorum {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("read quorum not reached: %d/%d successful (need %d): %w",
+				len(responses), cc.replicationFactor, readQuorum, err)
+		}
 		return nil, fmt.Errorf("read quorum not reached: %d/%d successful (need %d)",
-			len(responses), cc.replicationFactor, cc.readQuorum)
+			len(responses), cc.replicationFactor, readQuorum)
+	}
+
+	// In sync mode every replica's answer counts before resolving and repairing
+	syncRepair := cc.syncReadRepair.Load()
+	if syncRepair {
+		for ; answered < sent; answered++ {
+			if res := <-resultChan; res.found {
+				responses = append(responses, res.response)
+			}
+		}
 	}
 
 	// No responses means key not found
@@ -261,8 +548,8 @@ func (cc *ClusterClient) Get(key string) ([]byte, error) {
 		return nil, fmt.Errorf("key not found")
 	}
 
-	// Resolve conflicts (Last-Write-Wins)
-	latest := replication.ResolveConflict(responses)
+	// Resolve conflicts (Last-Write-Wins unless a resolver is set)
+	latest := cc.resolve(key, responses)
 	if latest == nil {
 		return nil, fmt.Errorf("failed to resolve conflict")
 	}
@@ -270,60 +557,102 @@ func (cc *ClusterClient) Get(key string) ([]byte, error) {
 	log.Printf("✅ GET successful: found on %d/%d replicas, version=%d",
 		len(responses), cc.replicationFactor, latest.Version)
 
-	// Check if read repair is needed
-	if replication.NeedsReadRepair(responses) {
-		log.Printf("🔧 Read repair needed for key %s", key)
-		outdated := replication.GetOutdatedReplicas(responses, latest)
-		cc.performReadRepair(key, latest, outdated)
+	// Check if read repair is needed, counting the replicas still answering
+	if syncRepair {
+		if replication.NeedsReadRepair(responses) {
+			log.Printf("🔧 Read repair needed for key %s, repairing before returning", key)
+			cc.writeReadRepair(ctx, key, latest, replication.GetOutdatedReplicas(responses, latest))
+		}
+	} else if pending := sent - answered; pending == 0 {
+		cc.checkReadRepair(key, responses)
+	} else {
+		all := append([]replication.ReplicaResponse(nil), responses...)
+		cc.background.Add(1)
+		go func() {
+			defer cc.background.Done()
+			for i := 0; i < pending; i++ {
+				if res := <-resultChan; res.found {
+					all = append(all, res.response)
+				}
+			}
+			cc.checkReadRepair(key, all)
+		}()
 	}
 
 	return latest.Value, nil
 }
 
-// performReadRepair updates outdated replicas with the latest value
+// checkReadRepair repairs the replicas among responses that are behind the
+// latest version
+func (cc *ClusterClient) checkReadRepair(key string, responses []replication.ReplicaResponse) {
+	if !replication.NeedsReadRepair(responses) {
+		return
+	}
+
+	log.Printf("🔧 Read repair needed for key %s", key)
+	latest := cc.resolve(key, responses)
+	outdated := replication.GetOutdatedReplicas(responses, latest)
+	cc.performReadRepair(key, latest, outdated)
+}
+
+// performReadRepair updates outdated replicas with the latest value.
+// Only one repair per key runs at a time: readers that find the same
+// inconsistency while a repair is in flight drop theirs instead of sending
+// duplicate ReplicaPuts to the stale nodes.
 func (cc *ClusterClient) performReadRepair(key string, latest *replication.ReplicaResponse, outdatedNodes []string) {
+	if _, inFlight := cc.repairsInFlight.LoadOrStore(key, struct{}{}); inFlight {
+		log.Printf("⏭️  Read repair already in flight for key %s, skipping", key)
+		return
+	}
+
 	// Perform read repair asynchronously
+	cc.background.Add(1)
 	go func() {
-		for _, nodeID := range outdatedNodes {
-			client, exists := cc.clients[nodeID]
-			if !exists {
-				continue
-			}
+		defer cc.background.Done()
+		defer cc.repairsInFlight.Delete(key)
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
+		time.Sleep(time.Duration(rand.Int63n(int64(ReadRepairMaxJitter))))
 
-			_, err := client.ReplicaPut(ctx, &proto.ReplicaPutRequest{
-				Key:       key,
-				Value:     latest.Value,
-				Timestamp: latest.Timestamp,
-				Version:   latest.Version,
-			})
+		cc.writeReadRepair(context.Background(), key, latest, outdatedNodes)
+	}()
+}
 
-			if err != nil {
-				log.Printf("⚠️  Read repair failed for node %s: %v", nodeID, err)
-			} else {
-				log.Printf("✅ Read repair completed for node %s", nodeID)
-			}
+// writeReadRepair sends the latest value to each outdated replica in turn
+// and returns once they have all answered or failed
+func (cc *ClusterClient) writeReadRepair(ctx context.Context, key string, latest *replication.ReplicaResponse, outdatedNodes []string) {
+	for _, nodeID := range outdatedNodes {
+		putCtx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+		_, err := cc.replicaPut(putCtx, nodeID, &proto.ReplicaPutRequest{
+			Key:       key,
+			Value:     latest.Value,
+			Timestamp: latest.Timestamp,
+			Version:   latest.Version,
+		})
+		cancel()
+
+		if err != nil {
+			log.Printf("⚠️  Read repair failed for node %s: %v", nodeID, err)
+		} else {
+			log.Printf("✅ Read repair completed for node %s", nodeID)
 		}
-	}()
+	}
 }
 
-// Delete removes a key-value pair with replication
-func (cc *ClusterClient) Delete(key string) error {
-	// Get preference list
+// RepairKey reads a key from all N replicas (not just R), resolves the latest
+// version and pushes it to every stale or missing replica. It returns how many
+// replicas were repaired. Unreachable replicas are skipped.
+func (cc *ClusterClient) RepairKey(key string) (int, error) {
 	preferenceList, err := cc.registry.hashRing.GetPreferenceList(key, cc.replicationFactor)
 	if err != nil {
-		return fmt.Errorf("failed to get preference list: %w", err)
+		return 0, fmt.Errorf("failed to get preference list: %w", err)
 	}
 
-	log.Printf("🎯 DELETE %s → replicas: %v", key, preferenceList)
+	log.Printf("🔧 REPAIR %s → replicas: %v", key, preferenceList)
 
-	// Delete from replicas in parallel
 	type result struct {
-		nodeID  string
-		success bool
-		err     error
+		response replication.ReplicaResponse
+		found    bool
+		err      error
 	}
 
 	resultChan := make(chan result, len(preferenceList))
@@ -331,21 +660,120 @@ func (cc *ClusterClient) Delete(key string) error {
 
 	for _, nodeID := range preferenceList {
 		wg.Add(1)
-		go func(nID string) {
+		nID := nodeID
+		cc.goReplica(func() {
 			defer wg.Done()
 
-			client, exists := cc.clients[nID]
-			if !exists {
-				resultChan <- result{nodeID: nID, success: false, err: fmt.Errorf("no client for node")}
+			ctx, cancel := context.WithTimeout(context.Background(), ReplicaTimeout)
+			defer cancel()
+
+			resp, err := cc.replicaGet(ctx, nID, &proto.ReplicaGetRequest{Key: key})
+			if err != nil {
+				resultChan <- result{response: replication.ReplicaResponse{NodeID: nID}, err: err}
 				return
 			}
 
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			// A replica without the key counts as version 0, so it is repaired too
+			resultChan <- result{
+				response: replication.ReplicaResponse{
+					NodeID:    nID,
+					Success:   true,
+					Value:     resp.Value,
+					Version:   resp.Version,
+					Timestamp: resp.Timestamp,
+				},
+				found: resp.Found,
+			}
+		})
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	var reachable, withKey []replication.ReplicaResponse
+	for res := range resultChan {
+		if res.err != nil {
+			log.Printf("⚠️  Repair read from %s failed: %v", res.response.NodeID, res.err)
+			continue
+		}
+		reachable = append(reachable, res.response)
+		if res.found {
+			withKey = append(withKey, res.response)
+		}
+	}
+
+	if len(reachable) == 0 {
+		return 0, fmt.Errorf("no replicas reachable for key %s", key)
+	}
+	if len(withKey) == 0 {
+		return 0, fmt.Errorf("key not found")
+	}
+
+	latest := cc.resolve(key, withKey)
+	outdated := replication.GetOutdatedReplicas(reachable, latest)
+
+	repaired := 0
+	var failed []string
+	for _, nodeID := range outdated {
+		ctx, cancel := context.WithTimeout(context.Background(), ReplicaTimeout)
+		resp, err := cc.replicaPut(ctx, nodeID, &proto.ReplicaPutRequest{
+			Key:       key,
+			Value:     latest.Value,
+			Timestamp: latest.Timestamp,
+			Version:   latest.Version,
+		})
+		cancel()
+
+		if err != nil || !resp.Success {
+			log.Printf("⚠️  Repair of %s on %s failed: %v", key, nodeID, err)
+			failed = append(failed, nodeID)
+			continue
+		}
+		repaired++
+	}
+
+	if len(failed) > 0 {
+		return repaired, fmt.Errorf("failed to repair key %s on %v", key, failed)
+	}
+
+	log.Printf("✅ REPAIR %s: %d/%d replicas repaired, version=%d",
+		key, repaired, len(preferenceList), latest.Version)
+	return repaired, nil
+}
+
+// Delete removes a key-value pair with replication. Like PutWithResult, it
+// returns as soon as W replicas have acknowledged the delete or ctx ends.
+func (cc *ClusterClient) Delete(ctx context.Context, key string) error {
+	// Get preference list and the ring generation it belongs to
+	preferenceList, generation, writeQuorum, err := cc.preferenceListFor("delete", key, cc.Quorum().W)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎯 DELETE %s → replicas: %v", key, preferenceList)
+
+	// Delete from replicas in parallel
+	type result struct {
+		nodeID  string
+		success bool
+		err     error
+	}
+
+	resultChan := make(chan result, len(preferenceList))
+
+	for _, nodeID := range preferenceList {
+		nID := nodeID
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
 			defer cancel()
 
-			resp, err := client.Delete(ctx, &proto.DeleteRequest{
+			start := time.Now()
+			resp, err := cc.replicaDelete(ctx, nID, &proto.DeleteRequest{
 				Key: key,
 			})
+			cc.latency.recordWrite(nID, start)
 
 			if err != nil {
 				resultChan <- result{nodeID: nID, success: false, err: err}
@@ -353,51 +781,94 @@ func (cc *ClusterClient) Delete(key string) error {
 			}
 
 			resultChan <- result{nodeID: nID, success: resp.Success, err: nil}
-		}(nodeID)
+		})
 	}
 
-	// Wait for all deletes
-	go func() {
-		wg.Wait()
-		close(resultChan)
-	}()
-
-	// Collect results
+	// Collect results until the quorum is reached or the caller gives up
 	var responses []replication.ReplicaResponse
-	for res := range resultChan {
-		responses = append(responses, replication.ReplicaResponse{
-			NodeID:  res.nodeID,
-			Success: res.success,
-			Error:   res.err,
-		})
+	successes := 0
+collect:
+	for len(responses) < len(preferenceList) && successes < writeQuorum {
+		select {
+		case res := <-resultChan:
+			responses = append(responses, replication.ReplicaResponse{
+				NodeID:  res.nodeID,
+				Success: res.success,
+				Error:   res.err,
+			})
+			if res.success {
+				successes++
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	// Don't acknowledge a delete made against a stale topology
+	if current := cc.registry.hashRing.Generation(); current != generation {
+		log.Printf("⚠️  DELETE %s: membership changed during delete (generation %d → %d)", key, generation, current)
+		return fmt.Errorf("%w: delete %s", ErrTopologyChanged, key)
 	}
 
 	// Check if write quorum is satisfied
-	if !replication.QuorumReached(responses, cc.writeQuorum) {
+	if !replication.QuorumReached(responses, writeQuorum) {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("delete quorum not reached: %w", err)
+		}
 		return fmt.Errorf("delete quorum not reached")
 	}
 
-	log.Printf("✅ DELETE successful: %d/%d replicas", len(responses), cc.replicationFactor)
+	log.Printf("✅ DELETE successful: %d/%d replicas", successes, cc.replicationFactor)
 	return nil
 }
 
-// GetAllStats returns stats from all nodes
-func (cc *ClusterClient) GetAllStats() (map[string]*proto.StatsResponse, error) {
-	allStats := make(map[string]*proto.StatsResponse)
+// ClusterStats is the outcome of GetAllStats: every node appears in
+// exactly one of Nodes and Errors
+type ClusterStats struct {
+	Nodes    map[string]*proto.StatsResponse // Stats of the nodes that answered
+	Errors   map[string]error                // Why each other node did not
+	Circuits map[string]CircuitStatus        // Nodes whose circuit breaker has seen failures (see CircuitBreakers)
+}
 
-	for nodeID, client := range cc.clients {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+// GetAllStats returns stats from all nodes. The nodes are queried in
+// parallel and a node that fails does not hide the others: its error is
+// recorded in Errors. The returned error is only set when nodes were queried
+// and none of them answered; the result is populated either way.
+func (cc *ClusterClient) GetAllStats() (*ClusterStats, error) {
+	clients := cc.snapshotClients()
+	result := &ClusterStats{
+		Nodes:    make(map[string]*proto.StatsResponse),
+		Errors:   make(map[string]error),
+		Circuits: cc.CircuitBreakers(),
+	}
 
-		resp, err := client.Stats(ctx, &proto.StatsRequest{})
-		if err != nil {
-			return nil, fmt.Errorf("Stats RPC to node %s failed: %w", nodeID, err)
-		}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for nodeID, client := range clients {
+		wg.Add(1)
+		go func(nodeID string, client proto.KVStoreClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), ReplicaTimeout)
+			defer cancel()
+
+			resp, err := client.Stats(ctx, &proto.StatsRequest{})
 
-		allStats[nodeID] = resp
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors[nodeID] = fmt.Errorf("Stats RPC to node %s failed: %w", nodeID, err)
+				return
+			}
+			result.Nodes[nodeID] = resp
+		}(nodeID, client)
 	}
+	wg.Wait()
 
-	return allStats, nil
+	if len(clients) > 0 && len(result.Nodes) == 0 {
+		return result, fmt.Errorf("Stats failed on all %d nodes", len(clients))
+	}
+	return result, nil
 }
 
 // GetRegistry returns the node registry
@@ -405,8 +876,93 @@ func (cc *ClusterClient) GetRegistry() *NodeRegistry {
 	return cc.registry
 }
 
-// Close closes all connections
+// RemoveNode takes a node out of the cluster and closes its connection.
+// Writes that were in flight against the old membership fail with
+// ErrTopologyChanged.
+func (cc *ClusterClient) RemoveNode(nodeID string) error {
+	if err := cc.registry.UnregisterNode(nodeID); err != nil {
+		return err
+	}
+
+	cc.mu.Lock()
+	conn := cc.connections[nodeID]
+	delete(cc.connections, nodeID)
+	delete(cc.clients, nodeID)
+	cc.mu.Unlock()
+
+	if conn != nil {
+		if err := conn.Close(); err != nil {
+			log.Printf("⚠️  Failed to close connection to %s: %v", nodeID, err)
+		}
+	}
+
+	log.Printf("➖ Removed node %s from cluster", nodeID)
+	return nil
+}
+
+// getClient returns the gRPC client for a node
+func (cc *ClusterClient) getClient(nodeID string) (proto.KVStoreClient, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	client, exists := cc.clients[nodeID]
+	return client, exists
+}
+
+// snapshotClients returns a copy of the nodeID -> client map
+func (cc *ClusterClient) snapshotClients() map[string]proto.KVStoreClient {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+
+	clients := make(map[string]proto.KVStoreClient, len(cc.clients))
+	for nodeID, client := range cc.clients {
+		clients[nodeID] = client
+	}
+	return clients
+}
+
+// Shutdown stops the client cleanly. It waits for the work still running in
+// the background (read repairs, and replicas that a Put or Get returned
+// without) so late write failures still get their hints, then stops the hint
+// cleanup task, fsyncs the hints and closes the connections. If ctx ends
+// first, Shutdown stops waiting and closes everything anyway, returning the
+// context's error; the remaining work then fails on the closed connections.
+func (cc *ClusterClient) Shutdown(ctx context.Context) error {
+	log.Printf("🛑 Shutting down cluster client")
+
+	waited := make(chan struct{})
+	go func() {
+		cc.background.Wait()
+		close(waited)
+	}()
+
+	var err error
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		err = fmt.Errorf("gave up waiting for background work: %w", ctx.Err())
+	}
+
+	if hintErr := cc.hintedHandoff.Close(); hintErr != nil && err == nil {
+		err = fmt.Errorf("failed to close hinted handoff: %w", hintErr)
+	}
+	if closeErr := cc.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Close closes all connections. It does not wait for background work or
+// stop the hint cleanup task; see Shutdown.
 func (cc *ClusterClient) Close() error {
+	// Queued replica calls still run, and fail on the closed connections
+	if pool := cc.replicaCalls.Swap(nil); pool != nil {
+		pool.close()
+	}
+
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
 	for _, conn := range cc.connections {
 		if err := conn.Close(); err != nil {
 			return err
@@ -424,9 +980,17 @@ func (cc *ClusterClient) GetNodeForKey(key string) (string, string, error) {
 	return node.ID, node.Address, nil
 }
 
+// LatencyStats returns recent read and write latency percentiles for every
+// node this client has sent replica calls to. Failed and timed-out calls are
+// included, so a degraded node shows up before it fails outright.
+func (cc *ClusterClient) LatencyStats() map[string]NodeLatency {
+	return cc.latency.snapshot()
+}
+
 // GetHintStats returns statistics about hinted handoff
 func (cc *ClusterClient) GetHintStats() map[string]interface{} {
 	return map[string]interface{}{
-		"total_hints": cc.hintedHandoff.GetHintCount(),
+		"total_hints":      cc.hintedHandoff.GetHintCount(),
+		"total_hint_bytes": cc.hintedHandoff.GetHintBytes(),
 	}
 }
@@ -0,0 +1,813 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/replication"
+
+	"google.golang.org/grpc"
+)
+
+// fakeNode is an in-process replica used to exercise ClusterClient
+type fakeNode struct {
+	proto.UnimplementedKVStoreServer
+	mu       sync.Mutex
+	data     map[string]*proto.ReplicaPutRequest
+	failed   bool
+	putDelay time.Duration     // Simulates a slow replica
+	lostPuts int               // ReplicaPut answers still to drop after applying the write
+	counted  map[string][]byte // Counter increments applied, by idempotency key
+	puts     int               // ReplicaPut calls received
+	gets     int               // ReplicaGet calls received
+	batches  int               // ReplicaBatchGet calls received
+	compacts int               // Compact calls received
+	gauge    *concurrencyGauge // Shared across nodes to count ReplicaPuts and Compacts in flight (nil = off)
+}
+
+func (f *fakeNode) putCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.puts
+}
+
+func (f *fakeNode) getCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.gets
+}
+
+func (f *fakeNode) setFailed(failed bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failed = failed
+}
+
+func (f *fakeNode) setPutDelay(delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.putDelay = delay
+}
+
+func (f *fakeNode) ReplicaPut(ctx context.Context, req *proto.ReplicaPutRequest) (*proto.ReplicaPutResponse, error) {
+	f.mu.Lock()
+	f.puts++
+	delay := f.putDelay
+	f.mu.Unlock()
+	if f.gauge != nil {
+		f.gauge.enter()
+		defer f.gauge.leave()
+	}
+	time.Sleep(delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	if req.Crdt == proto.ReplicaPutRequest_CRDT_GCOUNTER {
+		// Like the server, an increment retried with its idempotency key
+		// gets its first answer
+		if counter, ok := f.counted[req.IdempotencyKey]; ok && req.IdempotencyKey != "" {
+			return &proto.ReplicaPutResponse{Success: true, Value: counter}, nil
+		}
+		var current []byte
+		stored, found := f.data[req.Key]
+		if found {
+			current = stored.Value
+		}
+		counter, err := replication.GCounterUpdate(req.Value, req.CounterNode, req.Increment)(current, found)
+		if err != nil {
+			return &proto.ReplicaPutResponse{Success: false, Error: err.Error()}, nil
+		}
+		f.data[req.Key] = &proto.ReplicaPutRequest{Key: req.Key, Value: counter, Timestamp: time.Now().UnixNano()}
+		if req.IdempotencyKey != "" {
+			if f.counted == nil {
+				f.counted = make(map[string][]byte)
+			}
+			f.counted[req.IdempotencyKey] = counter
+		}
+		if f.lostPuts > 0 {
+			f.lostPuts--
+			return nil, fmt.Errorf("answer lost")
+		}
+		return &proto.ReplicaPutResponse{Success: true, Value: counter}, nil
+	}
+	f.data[req.Key] = req
+	return &proto.ReplicaPutResponse{Success: true}, nil
+}
+
+func (f *fakeNode) ReplicaGet(ctx context.Context, req *proto.ReplicaGetRequest) (*proto.ReplicaGetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.gets++
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	return f.lookupLocked(req.Key), nil
+}
+
+func (f *fakeNode) ReplicaBatchGet(ctx context.Context, req *proto.ReplicaBatchGetRequest) (*proto.ReplicaBatchGetResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches++
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	resp := &proto.ReplicaBatchGetResponse{}
+	for _, key := range req.Keys {
+		resp.Results = append(resp.Results, f.lookupLocked(key))
+	}
+	return resp, nil
+}
+
+// lookupLocked answers a replica read of key (must be called with mu held)
+func (f *fakeNode) lookupLocked(key string) *proto.ReplicaGetResponse {
+	stored, ok := f.data[key]
+	if !ok {
+		return &proto.ReplicaGetResponse{Found: false}
+	}
+	return &proto.ReplicaGetResponse{
+		Value:     stored.Value,
+		Found:     true,
+		Timestamp: stored.Timestamp,
+		Version:   stored.Version,
+	}
+}
+
+func (f *fakeNode) Delete(ctx context.Context, req *proto.DeleteRequest) (*proto.DeleteResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	delete(f.data, req.Key)
+	return &proto.DeleteResponse{Success: true}, nil
+}
+
+func (f *fakeNode) Scan(ctx context.Context, req *proto.ScanRequest) (*proto.ScanResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+
+	resp := &proto.ScanResponse{}
+	for key, stored := range f.data {
+		if key < req.StartKey || (req.EndKey != "" && key >= req.EndKey) {
+			continue
+		}
+		entry := &proto.KeyValue{Key: key}
+		if !req.KeysOnly {
+			entry.Value = stored.Value
+		}
+		resp.Entries = append(resp.Entries, entry)
+	}
+	sort.Slice(resp.Entries, func(i, j int) bool {
+		return resp.Entries[i].Key < resp.Entries[j].Key
+	})
+	if req.Limit > 0 && len(resp.Entries) > int(req.Limit) {
+		resp.Entries = resp.Entries[:req.Limit]
+	}
+	return resp, nil
+}
+
+func (f *fakeNode) Ping(ctx context.Context, req *proto.PingRequest) (*proto.PingResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	return &proto.PingResponse{Nonce: req.Nonce}, nil
+}
+
+// Compact takes 20ms, so overlapping compactions show up on the gauge
+func (f *fakeNode) Compact(ctx context.Context, req *proto.CompactRequest) (*proto.CompactResponse, error) {
+	f.mu.Lock()
+	f.compacts++
+	failed, gauge := f.failed, f.gauge
+	f.mu.Unlock()
+
+	if failed {
+		return &proto.CompactResponse{Success: false, Error: "disk full"}, nil
+	}
+	if gauge != nil {
+		gauge.enter()
+		defer gauge.leave()
+	}
+	time.Sleep(20 * time.Millisecond)
+	return &proto.CompactResponse{Success: true}, nil
+}
+
+func (f *fakeNode) Stats(ctx context.Context, req *proto.StatsRequest) (*proto.StatsResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failed {
+		return nil, fmt.Errorf("node unavailable")
+	}
+	return &proto.StatsResponse{MemtableSize: int64(len(f.data))}, nil
+}
+
+// startFakeCluster starts n fake nodes and returns a ClusterClient connected to them
+func startFakeCluster(t testing.TB, n int) (*ClusterClient, map[string]*fakeNode) {
+	t.Helper()
+
+	// Hints are written relative to the working directory
+	t.Chdir(t.TempDir())
+
+	nodes := make(map[string]*fakeNode)
+	addresses := make(map[string]string)
+
+	for i := 1; i <= n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+
+		node := &fakeNode{data: make(map[string]*proto.ReplicaPutRequest)}
+		server := grpc.NewServer()
+		proto.RegisterKVStoreServer(server, node)
+		go server.Serve(lis)
+		t.Cleanup(server.Stop)
+
+		nodeID := fmt.Sprintf("node%d", i)
+		nodes[nodeID] = node
+		addresses[nodeID] = lis.Addr().String()
+	}
+
+	cc, err := NewClusterClient(addresses)
+	if err != nil {
+		t.Fatalf("Failed to create cluster client: %v", err)
+	}
+	t.Cleanup(func() { cc.Close() })
+
+	return cc, nodes
+}
+
+// countingListener tracks the connections it accepted that are still open
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int64
+	open     atomic.Int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.accepted.Add(1)
+	l.open.Add(1)
+	return &countedConn{Conn: conn, l: l}, nil
+}
+
+type countedConn struct {
+	net.Conn
+	l    *countingListener
+	once sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.once.Do(func() { c.l.open.Add(-1) })
+	return c.Conn.Close()
+}
+
+// startCountingNodes serves n fake nodes behind counting listeners
+func startCountingNodes(t *testing.T, n int) (map[string]string, []*countingListener) {
+	t.Helper()
+	addresses := make(map[string]string)
+	var listeners []*countingListener
+	for i := 1; i <= n; i++ {
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		counting := &countingListener{Listener: lis}
+		server := grpc.NewServer()
+		proto.RegisterKVStoreServer(server, &fakeNode{data: make(map[string]*proto.ReplicaPutRequest)})
+		go server.Serve(counting)
+		t.Cleanup(server.Stop)
+
+		addresses[fmt.Sprintf("node%d", i)] = lis.Addr().String()
+		listeners = append(listeners, counting)
+	}
+	return addresses, listeners
+}
+
+// Test: a node listed twice is rejected before anything is dialed, and a
+// failure after dialing closes every connection opened
+func TestNewClusterClient_NoConnectionLeakOnError(t *testing.T) {
+	t.Chdir(t.TempDir())
+	addresses, listeners := startCountingNodes(t, 2)
+
+	// node3 is node1 again under another ID
+	duplicated := map[string]string{"node1": addresses["node1"], "node2": addresses["node2"], "node3": addresses["node1"]}
+	if _, err := NewClusterClient(duplicated); !errors.Is(err, ErrInvalidNode) {
+		t.Fatalf("Expected ErrInvalidNode for a duplicate address, got %v", err)
+	}
+	if _, err := NewClusterClient(map[string]string{"": addresses["node1"]}); !errors.Is(err, ErrInvalidNode) {
+		t.Fatalf("Expected ErrInvalidNode for an empty node ID, got %v", err)
+	}
+	for i, lis := range listeners {
+		if n := lis.accepted.Load(); n != 0 {
+			t.Fatalf("node%d: invalid input still dialed %d connection(s)", i+1, n)
+		}
+	}
+
+	// A file where the hints directory goes fails the constructor after
+	// every node is connected
+	if err := os.WriteFile("hints", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClusterClient(addresses); err == nil {
+		t.Fatal("Expected NewClusterClient to fail without a hints directory")
+	}
+	for i, lis := range listeners {
+		if lis.accepted.Load() == 0 {
+			t.Fatalf("node%d was never dialed", i+1)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for lis.open.Load() > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if n := lis.open.Load(); n != 0 {
+			t.Errorf("node%d: %d connection(s) leaked", i+1, n)
+		}
+	}
+}
+
+func TestClusterClient_PutWithResult(t *testing.T) {
+	cc, _ := startFakeCluster(t, 3)
+
+	result, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice"))
+	if err != nil {
+		t.Fatalf("PutWithResult failed: %v", err)
+	}
+
+	if !result.QuorumReached {
+		t.Error("Expected quorum to be reached")
+	}
+	// The call returns once W=2 replicas acknowledge; the third may still be writing
+	if len(result.SucceededNodes) < 2 || len(result.SucceededNodes)+len(result.PendingNodes) != 3 {
+		t.Errorf("Expected at least 2 successful replicas and the rest pending, got %v (pending %v)",
+			result.SucceededNodes, result.PendingNodes)
+	}
+	if len(result.HintedNodes) != 0 {
+		t.Errorf("Expected no hints, got %v", result.HintedNodes)
+	}
+}
+
+func TestClusterClient_GetAllStatsPartialFailure(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	nodes["node2"].setFailed(true)
+
+	result, err := cc.GetAllStats()
+	if err != nil {
+		t.Fatalf("Expected stats from the healthy nodes, got error: %v", err)
+	}
+
+	if len(result.Nodes) != 2 || result.Nodes["node1"] == nil || result.Nodes["node3"] == nil {
+		t.Errorf("Expected stats from node1 and node3, got %v", result.Nodes)
+	}
+	if len(result.Errors) != 1 || result.Errors["node2"] == nil {
+		t.Errorf("Expected an error for node2 only, got %v", result.Errors)
+	}
+
+	// With every node down the call fails, but still says why per node
+	nodes["node1"].setFailed(true)
+	nodes["node3"].setFailed(true)
+	result, err = cc.GetAllStats()
+	if err == nil {
+		t.Fatal("Expected an error when no node answers")
+	}
+	if len(result.Errors) != 3 {
+		t.Errorf("Expected 3 per-node errors, got %v", result.Errors)
+	}
+}
+
+func TestClusterClient_PutQuorumNotReached(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	nodes["node1"].setFailed(true)
+	nodes["node2"].setFailed(true)
+
+	result, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice"))
+	if err == nil {
+		t.Fatal("Expected quorum error with 2/3 replicas down")
+	}
+
+	var quorumErr *ErrQuorumNotReached
+	if !errors.As(err, &quorumErr) {
+		t.Fatalf("Expected *ErrQuorumNotReached, got %T: %v", err, err)
+	}
+
+	if quorumErr.Successes != 1 || quorumErr.Required != 2 {
+		t.Errorf("Expected 1 success (need 2), got %d (need %d)", quorumErr.Successes, quorumErr.Required)
+	}
+	if len(quorumErr.HintedNodes) != 2 {
+		t.Errorf("Expected hints for 2 nodes, got %v", quorumErr.HintedNodes)
+	}
+
+	// The partial write is still reported
+	if result == nil || result.QuorumReached {
+		t.Fatalf("Expected a populated result without quorum, got %+v", result)
+	}
+	if len(result.SucceededNodes) != 1 || result.SucceededNodes[0] != "node3" {
+		t.Errorf("Expected node3 to have the write, got %v", result.SucceededNodes)
+	}
+
+	// Put returns the same typed error
+	if err := cc.Put(context.Background(), "user:2", []byte("bob")); !errors.As(err, &quorumErr) {
+		t.Errorf("Expected Put to return *ErrQuorumNotReached, got %v", err)
+	}
+}
+
+func TestClusterClient_PutHonorsContext(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	// One slow replica doesn't hold up a write that has its quorum
+	nodes["node3"].setPutDelay(2 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := cc.PutWithResult(ctx, "user:1", []byte("alice"))
+	if err != nil {
+		t.Fatalf("PutWithResult failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected PutWithResult to return without waiting for the slow replica, took %v", elapsed)
+	}
+	if len(result.PendingNodes) != 1 || result.PendingNodes[0] != "node3" {
+		t.Errorf("Expected node3 to be pending, got %v", result.PendingNodes)
+	}
+
+	// With two slow replicas the quorum can't be reached within the deadline
+	nodes["node2"].setPutDelay(2 * time.Second)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start = time.Now()
+	err = cc.Put(ctx, "user:2", []byte("bob"))
+
+	var quorumErr *ErrQuorumNotReached
+	if !errors.As(err, &quorumErr) {
+		t.Fatalf("Expected *ErrQuorumNotReached, got %T: %v", err, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if quorumErr.Successes != 1 {
+		t.Errorf("Expected 1 success before the deadline, got %d", quorumErr.Successes)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected Put to return at the deadline, took %v", elapsed)
+	}
+}
+
+func TestClusterClient_InsufficientReplicas(t *testing.T) {
+	// N=3, W=R=2 throughout
+	tests := []struct {
+		nodes     int
+		strictOK  bool // operations succeed without relaxed quorum
+		relaxedOK bool // operations succeed with relaxed quorum
+	}{
+		{nodes: 0, strictOK: false, relaxedOK: false},
+		{nodes: 1, strictOK: false, relaxedOK: true},
+		{nodes: 2, strictOK: true, relaxedOK: true},
+	}
+
+	for _, tt := range tests {
+		for _, relaxed := range []bool{false, true} {
+			t.Run(fmt.Sprintf("nodes=%d/relaxed=%v", tt.nodes, relaxed), func(t *testing.T) {
+				cc, _ := startFakeCluster(t, tt.nodes)
+				cc.SetRelaxedQuorum(relaxed)
+
+				wantOK := tt.strictOK
+				if relaxed {
+					wantOK = tt.relaxedOK
+				}
+
+				putErr := cc.Put(context.Background(), "user:1", []byte("alice"))
+				_, getErr := cc.Get(context.Background(), "user:1")
+				deleteErr := cc.Delete(context.Background(), "user:1")
+
+				for op, err := range map[string]error{"put": putErr, "get": getErr, "delete": deleteErr} {
+					if wantOK {
+						if err != nil {
+							t.Errorf("%s: unexpected error: %v", op, err)
+						}
+						continue
+					}
+
+					var insufficient *ErrInsufficientReplicas
+					if !errors.As(err, &insufficient) {
+						t.Errorf("%s: expected *ErrInsufficientReplicas, got %T: %v", op, err, err)
+						continue
+					}
+					if insufficient.Op != op || insufficient.Available != tt.nodes || insufficient.Required != 2 {
+						t.Errorf("%s: expected %d available (need 2), got %+v", op, tt.nodes, insufficient)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestClusterClient_PutRejectedOnTopologyChange(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 4)
+
+	for _, node := range nodes {
+		node.setPutDelay(200 * time.Millisecond)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice"))
+		errCh <- err
+	}()
+
+	// Remove a node while the replicas are still processing the write
+	time.Sleep(50 * time.Millisecond)
+	if err := cc.RemoveNode("node4"); err != nil {
+		t.Fatalf("RemoveNode failed: %v", err)
+	}
+
+	err := <-errCh
+	if !errors.Is(err, ErrTopologyChanged) {
+		t.Fatalf("Expected ErrTopologyChanged, got %v", err)
+	}
+
+	// A retry against the new topology succeeds
+	for _, node := range nodes {
+		node.setPutDelay(0)
+	}
+	result, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice"))
+	if err != nil {
+		t.Fatalf("Retry failed: %v", err)
+	}
+	for _, nodeID := range result.PreferenceList {
+		if nodeID == "node4" {
+			t.Error("Removed node should not be in the preference list")
+		}
+	}
+}
+
+func TestClusterClient_ReadRepairCoalesced(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	// node3 is stale; its slow writes keep the repair in flight while the reads run
+	nodes["node1"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("new"), Timestamp: 200, Version: 200}
+	nodes["node2"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("new"), Timestamp: 200, Version: 200}
+	nodes["node3"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("old"), Timestamp: 100, Version: 100}
+	nodes["node3"].setPutDelay(500 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cc.Get(context.Background(), "user:1"); err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Wait for the repair to land
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		nodes["node3"].mu.Lock()
+		version := nodes["node3"].data["user:1"].Version
+		nodes["node3"].mu.Unlock()
+		if version == 200 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Read repair never reached the stale replica")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if puts := nodes["node3"].putCount(); puts != 1 {
+		t.Errorf("Expected 1 repair RPC to the stale node, got %d", puts)
+	}
+	for _, nodeID := range []string{"node1", "node2"} {
+		if puts := nodes[nodeID].putCount(); puts != 0 {
+			t.Errorf("%s: expected no repair RPCs, got %d", nodeID, puts)
+		}
+	}
+}
+
+func TestClusterClient_SyncReadRepair(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	cc.SetSyncReadRepair(true)
+
+	// node2 and node3 are stale, and node3 is slow to take the repair
+	nodes["node1"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("new"), Timestamp: 200, Version: 200}
+	nodes["node2"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("old"), Timestamp: 100, Version: 100}
+	nodes["node3"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("old"), Timestamp: 100, Version: 100}
+	nodes["node3"].setPutDelay(200 * time.Millisecond)
+
+	value, err := cc.Get(context.Background(), "user:1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(value) != "new" {
+		t.Errorf("Expected 'new', got %q", value)
+	}
+
+	// No waiting: the replicas were repaired before Get returned
+	for nodeID, node := range nodes {
+		node.mu.Lock()
+		stored := node.data["user:1"]
+		node.mu.Unlock()
+		if string(stored.Value) != "new" || stored.Version != 200 {
+			t.Errorf("%s: expected latest version right after Get, got %+v", nodeID, stored)
+		}
+	}
+}
+
+// sumResolver merges divergent counters by adding up the replicas' values
+var sumResolver = replication.ConflictResolverFunc(func(responses []replication.ReplicaResponse) *replication.ReplicaResponse {
+	merged := responses[0]
+	total := 0
+	for _, resp := range responses {
+		n, _ := strconv.Atoi(string(resp.Value))
+		total += n
+		if resp.Version > merged.Version {
+			merged.Version, merged.Timestamp = resp.Version, resp.Timestamp
+		}
+	}
+	merged.NodeID = ""
+	merged.Value = []byte(strconv.Itoa(total))
+	return &merged
+})
+
+func TestClusterClient_ConflictResolverForPrefix(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	cc.SetSyncReadRepair(true) // Resolve over all three replicas
+	cc.SetConflictResolverForPrefix("counter:", sumResolver)
+
+	// Each replica took a different concurrent increment at the same version
+	for _, key := range []string{"counter:hits", "user:1"} {
+		for n, nodeID := range []string{"node1", "node2", "node3"} {
+			nodes[nodeID].data[key] = &proto.ReplicaPutRequest{Key: key, Value: []byte(strconv.Itoa(n + 1)), Timestamp: 100, Version: 100}
+		}
+	}
+
+	value, err := cc.Get(context.Background(), "counter:hits")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(value) != "6" {
+		t.Errorf("Expected the replicas' counts summed to 6, got %q", value)
+	}
+	for nodeID, node := range nodes {
+		if puts := node.putCount(); puts != 0 {
+			t.Errorf("%s: expected no repair of a merged value at the same version, got %d puts", nodeID, puts)
+		}
+	}
+
+	// Other keys still resolve to one replica's value
+	value, err = cc.Get(context.Background(), "user:1")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if n, _ := strconv.Atoi(string(value)); n < 1 || n > 3 {
+		t.Errorf("Expected one replica's value for a non-counter key, got %q", value)
+	}
+
+	// Removing the prefix resolver falls back to last-write-wins
+	cc.SetConflictResolverForPrefix("counter:", nil)
+	if value, _ := cc.Get(context.Background(), "counter:hits"); string(value) == "6" {
+		t.Error("Expected last-write-wins once the prefix resolver is removed")
+	}
+}
+
+// clientGoroutines returns the stacks of running goroutines started by a
+// ClusterClient or its HintedHandoff, keyed by their "goroutine N" header
+func clientGoroutines() map[string]string {
+	buf := make([]byte, 1<<20)
+	stacks := string(buf[:runtime.Stack(buf, true)])
+
+	goroutines := make(map[string]string)
+	for _, goroutine := range strings.Split(stacks, "\n\n") {
+		if strings.Contains(goroutine, "kvstore/cluster.(*ClusterClient)") ||
+			strings.Contains(goroutine, "kvstore/replication.(*HintedHandoff)") {
+			header, _, _ := strings.Cut(goroutine, " [")
+			goroutines[header] = goroutine
+		}
+	}
+	return goroutines
+}
+
+func TestClusterClient_ShutdownLeavesNoGoroutines(t *testing.T) {
+	// Clients of other tests are never shut down; only look for new leaks
+	before := clientGoroutines()
+
+	cc, nodes := startFakeCluster(t, 3)
+
+	// A Put that returns before the slow replica answers, and a Get that
+	// finds a stale replica, both leave work running in the background
+	nodes["node3"].setPutDelay(300 * time.Millisecond)
+	if _, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice")); err != nil {
+		t.Fatalf("PutWithResult failed: %v", err)
+	}
+	nodes["node1"].mu.Lock()
+	nodes["node1"].data["user:2"] = &proto.ReplicaPutRequest{Key: "user:2", Value: []byte("new"), Timestamp: 200, Version: 200}
+	nodes["node1"].mu.Unlock()
+	nodes["node2"].mu.Lock()
+	nodes["node2"].data["user:2"] = &proto.ReplicaPutRequest{Key: "user:2", Value: []byte("old"), Timestamp: 100, Version: 100}
+	nodes["node2"].mu.Unlock()
+	if _, err := cc.Get(context.Background(), "user:2"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := cc.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// The background work finished before Shutdown returned
+	nodes["node2"].mu.Lock()
+	repaired := nodes["node2"].data["user:2"].Version == 200
+	nodes["node2"].mu.Unlock()
+	if !repaired {
+		t.Error("Expected the read repair to complete before Shutdown returned")
+	}
+
+	// Give goroutines that were told to stop a moment to exit
+	var leaked []string
+	deadline := time.Now().Add(time.Second)
+	for {
+		leaked = leaked[:0]
+		for header, goroutine := range clientGoroutines() {
+			if _, ok := before[header]; !ok {
+				leaked = append(leaked, goroutine)
+			}
+		}
+		if len(leaked) == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, goroutine := range leaked {
+		t.Errorf("Goroutine still running after Shutdown:\n%s", goroutine)
+	}
+}
+
+func TestClusterClient_RepairKey(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	// node1 has the latest version, node2 a stale one, node3 nothing
+	nodes["node1"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("new"), Timestamp: 200, Version: 200}
+	nodes["node2"].data["user:1"] = &proto.ReplicaPutRequest{Key: "user:1", Value: []byte("old"), Timestamp: 100, Version: 100}
+
+	repaired, err := cc.RepairKey("user:1")
+	if err != nil {
+		t.Fatalf("RepairKey failed: %v", err)
+	}
+	if repaired != 2 {
+		t.Errorf("Expected 2 replicas repaired, got %d", repaired)
+	}
+
+	for nodeID, node := range nodes {
+		stored, ok := node.data["user:1"]
+		if !ok || string(stored.Value) != "new" || stored.Version != 200 {
+			t.Errorf("%s: expected latest version after repair, got %+v", nodeID, stored)
+		}
+	}
+
+	// A consistent key needs no repair
+	repaired, err = cc.RepairKey("user:1")
+	if err != nil || repaired != 0 {
+		t.Errorf("Expected nothing to repair, got %d (err: %v)", repaired, err)
+	}
+
+	if _, err := cc.RepairKey("missing"); err == nil {
+		t.Error("Expected error repairing a key no replica has")
+	}
+}
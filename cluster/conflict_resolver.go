@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"strings"
+
+	"kvstore/replication"
+)
+
+// SetConflictResolver sets how Get and RepairKey pick a key's value when its
+// replicas disagree, for keys without a prefix resolver. nil restores the
+// default, replication.LastWriteWins.
+func (cc *ClusterClient) SetConflictResolver(resolver replication.ConflictResolver) {
+	cc.resolverMu.Lock()
+	defer cc.resolverMu.Unlock()
+	cc.resolver = resolver
+}
+
+// SetConflictResolverForPrefix sets the resolver for keys starting with
+// prefix, e.g. a summing resolver for "counter:" keys. When several prefixes
+// match a key the longest wins. nil removes the prefix's resolver.
+func (cc *ClusterClient) SetConflictResolverForPrefix(prefix string, resolver replication.ConflictResolver) {
+	cc.resolverMu.Lock()
+	defer cc.resolverMu.Unlock()
+
+	if resolver == nil {
+		delete(cc.prefixResolvers, prefix)
+		return
+	}
+	if cc.prefixResolvers == nil {
+		cc.prefixResolvers = make(map[string]replication.ConflictResolver)
+	}
+	cc.prefixResolvers[prefix] = resolver
+}
+
+// resolverFor returns the resolver for key: that of its longest matching
+// prefix, else the client's, else last-write-wins
+func (cc *ClusterClient) resolverFor(key string) replication.ConflictResolver {
+	cc.resolverMu.RLock()
+	defer cc.resolverMu.RUnlock()
+
+	var resolver replication.ConflictResolver
+	longest := -1
+	for prefix, r := range cc.prefixResolvers {
+		if len(prefix) > longest && strings.HasPrefix(key, prefix) {
+			resolver, longest = r, len(prefix)
+		}
+	}
+	if resolver == nil {
+		resolver = cc.resolver
+	}
+	if resolver == nil {
+		resolver = replication.LastWriteWins{}
+	}
+	return resolver
+}
+
+// resolve picks the value of key among the replicas' responses
+func (cc *ClusterClient) resolve(key string, responses []replication.ReplicaResponse) *replication.ReplicaResponse {
+	if len(responses) == 0 {
+		return nil
+	}
+	return cc.resolverFor(key).Resolve(responses)
+}
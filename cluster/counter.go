@@ -0,0 +1,270 @@
+package cluster
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/replication"
+	"kvstore/tracing"
+)
+
+// Increment adds delta to the distributed counter at key and returns the
+// counter's value as its owning replica saw it after the increment.
+//
+// Counters are G-Counters (replication.GCounter), not last-write-wins
+// values, so concurrent increments are never lost. The first reachable
+// replica in the key's preference list applies delta to its own count,
+// atomically, and the counter it ends up with is merged into the other
+// replicas, which keep the higher count per node. Like PutWithResult, it
+// fails with *ErrQuorumNotReached if fewer than W replicas took the write.
+// No hints are stored: a replica that missed the merge catches up when
+// GetCounter next reads the key.
+//
+// Every attempt carries the same idempotency key, and an owner that fails
+// to answer is asked once more before the next replica is tried: if it had
+// applied delta and only its answer was lost, it returns that answer instead
+// of counting delta again. Only an owner that stays unreachable after
+// applying delta can still see it counted by the next replica too. Read
+// counters with GetCounter; Get returns their encoded form.
+func (cc *ClusterClient) Increment(ctx context.Context, key string, delta uint64) (uint64, error) {
+	ctx, span := tracing.Start(ctx, "ClusterClient.Increment")
+	span.SetAttribute("key", key)
+	value, err := cc.increment(ctx, key, delta)
+	span.SetError(err)
+	span.End()
+	return value, err
+}
+
+// increment does the work of Increment inside its span
+func (cc *ClusterClient) increment(ctx context.Context, key string, delta uint64) (uint64, error) {
+	preferenceList, _, writeQuorum, err := cc.preferenceListFor("increment", key, cc.Quorum().W)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("🎯 INCREMENT %s by %d → replicas: %v (W=%d)", key, delta, preferenceList, writeQuorum)
+
+	// The owner counts the increment under its own node ID
+	idempotencyKey := newIncrementKey()
+	var owner string
+	var counter []byte
+	for _, nodeID := range preferenceList {
+		resp, err := cc.incrementOn(ctx, nodeID, &proto.ReplicaPutRequest{
+			Key:            key,
+			Crdt:           proto.ReplicaPutRequest_CRDT_GCOUNTER,
+			Increment:      delta,
+			CounterNode:    nodeID,
+			IdempotencyKey: idempotencyKey,
+		})
+		if err != nil {
+			if errors.Is(err, replication.ErrNotGCounter) {
+				return 0, err
+			}
+			log.Printf("⚠️  INCREMENT %s on %s failed, trying the next replica: %v", key, nodeID, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if !resp.Success {
+			// The replica is up but refused, e.g. the key isn't a counter
+			return 0, fmt.Errorf("increment %s on %s failed: %s", key, nodeID, resp.Error)
+		}
+		owner, counter = nodeID, resp.Value
+		break
+	}
+	if owner == "" {
+		return 0, &ErrQuorumNotReached{
+			Key:       key,
+			Attempted: len(preferenceList),
+			Required:  writeQuorum,
+			Cause:     ctx.Err(),
+		}
+	}
+
+	state, err := replication.DecodeGCounter(counter)
+	if err != nil {
+		return 0, fmt.Errorf("replica %s returned a bad counter: %w", owner, err)
+	}
+
+	// Merge the owner's counter into the other replicas
+	others := make([]string, 0, len(preferenceList)-1)
+	for _, nodeID := range preferenceList {
+		if nodeID != owner {
+			others = append(others, nodeID)
+		}
+	}
+	succeeded := 1 + cc.mergeCounter(ctx, key, counter, others)
+
+	if succeeded < writeQuorum {
+		return state.Value(), &ErrQuorumNotReached{
+			Key:       key,
+			Successes: succeeded,
+			Attempted: len(preferenceList),
+			Required:  writeQuorum,
+			Cause:     ctx.Err(),
+		}
+	}
+
+	log.Printf("✅ INCREMENT successful: %d/%d replicas (quorum: %d), value=%d",
+		succeeded, len(preferenceList), writeQuorum, state.Value())
+	return state.Value(), nil
+}
+
+// incrementOn sends an increment to one replica, asking once more with the
+// same request if the first attempt gets no answer
+func (cc *ClusterClient) incrementOn(ctx context.Context, nodeID string, req *proto.ReplicaPutRequest) (*proto.ReplicaPutResponse, error) {
+	var resp *proto.ReplicaPutResponse
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		putCtx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+		resp, err = cc.replicaPut(putCtx, nodeID, req)
+		cancel()
+		if err == nil || errors.Is(err, replication.ErrNotGCounter) || ctx.Err() != nil {
+			break
+		}
+	}
+	return resp, err
+}
+
+// newIncrementKey returns a random idempotency key for one Increment
+func newIncrementKey() string {
+	var b [16]byte
+	crand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// mergeCounter merges an encoded counter into each of nodeIDs in parallel
+// and returns how many took it
+func (cc *ClusterClient) mergeCounter(ctx context.Context, key string, counter []byte, nodeIDs []string) int {
+	resultChan := make(chan bool, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		nID := nodeID
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+			defer cancel()
+
+			start := time.Now()
+			resp, err := cc.replicaPut(ctx, nID, &proto.ReplicaPutRequest{
+				Key:   key,
+				Value: counter,
+				Crdt:  proto.ReplicaPutRequest_CRDT_GCOUNTER,
+			})
+			cc.latency.recordWrite(nID, start)
+
+			if err != nil || !resp.Success {
+				log.Printf("⚠️  Counter merge of %s into %s failed: %v", key, nID, err)
+				resultChan <- false
+				return
+			}
+			resultChan <- true
+		})
+	}
+
+	succeeded := 0
+	for range nodeIDs {
+		if <-resultChan {
+			succeeded++
+		}
+	}
+	return succeeded
+}
+
+// GetCounter returns the value of the distributed counter at key: the
+// replicas' counters are merged, keeping the higher count per node, and
+// summed. A counter no replica has is 0. It waits for every replica to
+// answer or ctx to end, needs R answers, and merges the result back into
+// the replicas that were behind.
+func (cc *ClusterClient) GetCounter(ctx context.Context, key string) (uint64, error) {
+	ctx, span := tracing.Start(ctx, "ClusterClient.GetCounter")
+	span.SetAttribute("key", key)
+	defer span.End()
+
+	preferenceList, _, readQuorum, err := cc.preferenceListFor("get", key, cc.Quorum().R)
+	if err != nil {
+		span.SetError(err)
+		return 0, err
+	}
+
+	type result struct {
+		nodeID  string
+		counter replication.GCounter // nil if the replica doesn't have the key
+		err     error
+	}
+
+	resultChan := make(chan result, len(preferenceList))
+	for _, nodeID := range preferenceList {
+		nID := nodeID
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+			defer cancel()
+
+			start := time.Now()
+			resp, err := cc.replicaGet(ctx, nID, &proto.ReplicaGetRequest{Key: key})
+			cc.latency.recordRead(nID, start)
+
+			res := result{nodeID: nID, err: err}
+			if err == nil && resp.Found {
+				res.counter, res.err = replication.DecodeGCounter(resp.Value)
+			}
+			resultChan <- res
+		})
+	}
+
+	merged := make(replication.GCounter)
+	replicas := make(map[string]replication.GCounter) // nodeID -> counter, for the replicas that answered
+collect:
+	for range preferenceList {
+		select {
+		case res := <-resultChan:
+			if errors.Is(res.err, replication.ErrNotGCounter) {
+				err := fmt.Errorf("key %s on %s: %w", key, res.nodeID, res.err)
+				span.SetError(err)
+				return 0, err
+			}
+			if res.err != nil {
+				log.Printf("⚠️  GET COUNTER from %s failed: %v", res.nodeID, res.err)
+				continue
+			}
+			merged.Merge(res.counter)
+			replicas[res.nodeID] = res.counter
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	if len(replicas) < readQuorum {
+		err := fmt.Errorf("read quorum not reached: %d/%d successful (need %d)",
+			len(replicas), len(preferenceList), readQuorum)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("%w: %w", err, ctxErr)
+		}
+		span.SetError(err)
+		return 0, err
+	}
+
+	// Repair the replicas missing some node's increments
+	var behind []string
+	for nodeID, counter := range replicas {
+		if !counter.Covers(merged) {
+			behind = append(behind, nodeID)
+		}
+	}
+	if len(behind) > 0 && len(merged) > 0 {
+		log.Printf("🔧 Counter repair needed for key %s on %v", key, behind)
+		encoded := merged.Encode()
+		cc.background.Add(1)
+		go func() {
+			defer cc.background.Done()
+			cc.mergeCounter(context.Background(), key, encoded, behind)
+		}()
+	}
+
+	return merged.Value(), nil
+}
@@ -0,0 +1,135 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"kvstore/replication"
+)
+
+// Test: concurrent increments, with the owner replica down for a while so
+// two replicas count increments, add up on every replica
+func TestClusterClient_IncrementConcurrent(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	ctx := context.Background()
+	const key = "page:views"
+
+	preferenceList, err := cc.registry.hashRing.GetPreferenceList(key, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := nodes[preferenceList[0]]
+
+	incrementAll := func(workers, perWorker int) {
+		t.Helper()
+		var wg sync.WaitGroup
+		errs := make(chan error, workers*perWorker)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < perWorker; i++ {
+					if _, err := cc.Increment(ctx, key, 1); err != nil {
+						errs <- err
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			t.Errorf("Increment failed: %v", err)
+		}
+	}
+
+	incrementAll(10, 10)
+
+	// The next replica owns the increments while the first is down
+	owner.setFailed(true)
+	incrementAll(10, 10)
+	owner.setFailed(false)
+
+	total, err := cc.GetCounter(ctx, key)
+	if err != nil {
+		t.Fatalf("GetCounter failed: %v", err)
+	}
+	if total != 200 {
+		t.Errorf("Expected 200, got %d", total)
+	}
+
+	// Reading merged the missed increments back into the first replica
+	cc.background.Wait()
+	for nodeID, node := range nodes {
+		node.mu.Lock()
+		counter, err := replication.DecodeGCounter(node.data[key].Value)
+		node.mu.Unlock()
+		if err != nil {
+			t.Fatalf("%s: %v", nodeID, err)
+		}
+		if counter.Value() != 200 || len(counter) != 2 {
+			t.Errorf("%s: expected 200 counted by 2 owners, got %v", nodeID, counter)
+		}
+	}
+}
+
+// Test: an increment whose answer is lost is retried on its owner with the
+// same idempotency key, and counted once
+func TestClusterClient_IncrementAnswerLost(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	ctx := context.Background()
+	const key = "page:views"
+
+	preferenceList, err := cc.registry.hashRing.GetPreferenceList(key, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner := nodes[preferenceList[0]]
+	owner.mu.Lock()
+	owner.lostPuts = 1
+	owner.mu.Unlock()
+
+	value, err := cc.Increment(ctx, key, 3)
+	if err != nil {
+		t.Fatalf("Increment failed: %v", err)
+	}
+	if value != 3 {
+		t.Errorf("Expected 3, got %d", value)
+	}
+	if total, err := cc.GetCounter(ctx, key); err != nil || total != 3 {
+		t.Errorf("GetCounter = %d, %v, want 3", total, err)
+	}
+
+	// The owner counted it, once; the next replica only merged it
+	cc.background.Wait()
+	owner.mu.Lock()
+	counter, err := replication.DecodeGCounter(owner.data[key].Value)
+	owner.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counter) != 1 || counter[preferenceList[0]] != 3 {
+		t.Errorf("Expected 3 counted by %s alone, got %v", preferenceList[0], counter)
+	}
+}
+
+func TestClusterClient_IncrementNotACounter(t *testing.T) {
+	cc, _ := startFakeCluster(t, 3)
+	ctx := context.Background()
+
+	if err := cc.Put(ctx, "plain", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := cc.Increment(ctx, "plain", 1); err == nil {
+		t.Error("Expected Increment of a plain value to fail")
+	}
+	if _, err := cc.GetCounter(ctx, "plain"); !errors.Is(err, replication.ErrNotGCounter) {
+		t.Errorf("Expected ErrNotGCounter, got %v", err)
+	}
+
+	// A counter nothing has incremented is 0
+	if total, err := cc.GetCounter(ctx, "never-incremented"); err != nil || total != 0 {
+		t.Errorf("GetCounter = %d, %v", total, err)
+	}
+}
@@ -0,0 +1,167 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"kvstore/proto"
+)
+
+const (
+	// drainLogInterval is how many keys DrainNode moves between progress logs
+	drainLogInterval = 1000
+)
+
+// DrainProgress reports how far DrainNode has got with a node
+type DrainProgress struct {
+	NodeID    string
+	KeysMoved int    // Keys re-replicated so far, across retries
+	LastKey   string // Last key fully re-replicated; a retry resumes after it
+	Done      bool   // All keys moved and the node unregistered
+}
+
+// DrainNode moves every key stored on nodeID to the preference list the key
+// will have once the node is gone, then removes the node from the cluster.
+//
+// Keys are copied with their original timestamp and version, so copying a
+// key twice is harmless. If a copy fails, DrainNode stops and returns the
+// error with the progress kept; calling it again resumes after the last key
+// that was fully copied. Writes that reach the node while it drains are
+// also sent to the other replicas in the key's preference list.
+func (cc *ClusterClient) DrainNode(nodeID string) error {
+	progress := cc.drainProgressFor(nodeID)
+	if progress.Done {
+		return nil
+	}
+
+	client, exists := cc.getClient(nodeID)
+	if !exists {
+		return fmt.Errorf("node %s not found", nodeID)
+	}
+
+	futureRing := cc.registry.hashRing.withoutNode(nodeID)
+	if futureRing.GetNodeCount() == 0 {
+		return fmt.Errorf("cannot drain %s: it is the last node in the cluster", nodeID)
+	}
+
+	// Keys come back sorted, so resuming after LastKey skips finished work
+	startKey := ""
+	if progress.LastKey != "" {
+		startKey = progress.LastKey + "\x00"
+		log.Printf("🚚 DRAIN %s: resuming after %q (%d keys moved)", nodeID, progress.LastKey, progress.KeysMoved)
+	} else {
+		log.Printf("🚚 DRAIN %s: starting", nodeID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	scanResp, err := client.Scan(ctx, &proto.ScanRequest{StartKey: startKey, KeysOnly: true})
+	cancel()
+	if err != nil {
+		return fmt.Errorf("drain %s: scan failed: %w", nodeID, err)
+	}
+	if scanResp.Error != "" {
+		return fmt.Errorf("drain %s: scan failed: %s", nodeID, scanResp.Error)
+	}
+
+	for _, entry := range scanResp.Entries {
+		if err := cc.drainKey(client, futureRing, entry.Key); err != nil {
+			current, _ := cc.GetDrainProgress(nodeID)
+			return fmt.Errorf("drain %s: %w (%d keys moved, retry to resume)", nodeID, err, current.KeysMoved)
+		}
+
+		moved := cc.drainKeyMoved(nodeID, entry.Key)
+		if moved%drainLogInterval == 0 {
+			log.Printf("🚚 DRAIN %s: %d keys moved", nodeID, moved)
+		}
+	}
+
+	if err := cc.RemoveNode(nodeID); err != nil {
+		return fmt.Errorf("drain %s: %w", nodeID, err)
+	}
+
+	cc.drainMu.Lock()
+	cc.drains[nodeID].Done = true
+	moved := cc.drains[nodeID].KeysMoved
+	cc.drainMu.Unlock()
+
+	log.Printf("✅ DRAIN %s complete: %d keys moved, node removed", nodeID, moved)
+	return nil
+}
+
+// GetDrainProgress returns the progress of DrainNode for a node, if a drain
+// has been started
+func (cc *ClusterClient) GetDrainProgress(nodeID string) (DrainProgress, bool) {
+	cc.drainMu.Lock()
+	defer cc.drainMu.Unlock()
+
+	progress, exists := cc.drains[nodeID]
+	if !exists {
+		return DrainProgress{}, false
+	}
+	return *progress, true
+}
+
+// drainKey copies one key from the draining node to every node in its
+// future preference list
+func (cc *ClusterClient) drainKey(source proto.KVStoreClient, futureRing *HashRing, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ReplicaTimeout)
+	defer cancel()
+
+	resp, err := source.ReplicaGet(ctx, &proto.ReplicaGetRequest{Key: key})
+	if err != nil {
+		return fmt.Errorf("read %s: %w", key, err)
+	}
+	if !resp.Found {
+		return nil // Deleted since the scan
+	}
+
+	targets, err := futureRing.GetPreferenceList(key, cc.replicationFactor)
+	if err != nil {
+		return fmt.Errorf("preference list for %s: %w", key, err)
+	}
+
+	for _, target := range targets {
+		putResp, err := cc.replicaPut(ctx, target, &proto.ReplicaPutRequest{
+			Key:       key,
+			Value:     resp.Value,
+			Timestamp: resp.Timestamp,
+			Version:   resp.Version,
+		})
+		if err != nil {
+			return fmt.Errorf("copy %s to %s: %w", key, target, err)
+		}
+		if !putResp.Success {
+			return fmt.Errorf("copy %s to %s: %s", key, target, putResp.Error)
+		}
+	}
+
+	return nil
+}
+
+// drainProgressFor returns a copy of the progress record for a node,
+// creating it on the first drain attempt
+func (cc *ClusterClient) drainProgressFor(nodeID string) DrainProgress {
+	cc.drainMu.Lock()
+	defer cc.drainMu.Unlock()
+
+	progress, exists := cc.drains[nodeID]
+	if !exists {
+		progress = &DrainProgress{NodeID: nodeID}
+		cc.drains[nodeID] = progress
+	}
+	return *progress
+}
+
+// drainKeyMoved records key as fully copied and returns the number of keys
+// moved so far
+func (cc *ClusterClient) drainKeyMoved(nodeID, key string) int {
+	cc.drainMu.Lock()
+	defer cc.drainMu.Unlock()
+
+	progress := cc.drains[nodeID]
+	progress.KeysMoved++
+	progress.LastKey = key
+	return progress.KeysMoved
+}
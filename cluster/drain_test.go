@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestClusterClient_DrainNode(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 4)
+
+	for i := 0; i < 50; i++ {
+		if err := cc.Put(context.Background(), fmt.Sprintf("key%02d", i), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	drained := nodes["node4"]
+	drained.mu.Lock()
+	owned := make(map[string]int64)
+	for key, stored := range drained.data {
+		owned[key] = stored.Version
+	}
+	drained.mu.Unlock()
+	if len(owned) == 0 {
+		t.Fatal("Expected node4 to own some keys")
+	}
+
+	// node2 fails mid-drain; the drain stops with its progress kept
+	nodes["node2"].setFailed(true)
+	if err := cc.DrainNode("node4"); err == nil {
+		t.Fatal("Expected drain to fail while node2 is down")
+	}
+	progress, ok := cc.GetDrainProgress("node4")
+	if !ok || progress.Done {
+		t.Fatalf("Expected an unfinished drain, got %+v", progress)
+	}
+	if _, err := cc.registry.GetNode("node4"); err != nil {
+		t.Error("node4 should stay registered until the drain completes")
+	}
+
+	// The retry resumes and finishes
+	nodes["node2"].setFailed(false)
+	if err := cc.DrainNode("node4"); err != nil {
+		t.Fatalf("DrainNode retry failed: %v", err)
+	}
+
+	progress, _ = cc.GetDrainProgress("node4")
+	if !progress.Done || progress.KeysMoved != len(owned) {
+		t.Errorf("Expected %d keys moved in total, got %+v", len(owned), progress)
+	}
+	if _, err := cc.registry.GetNode("node4"); err == nil {
+		t.Error("Expected node4 to be unregistered")
+	}
+
+	// With 3 nodes left and N=3, every remaining node has every drained key
+	for nodeID, node := range nodes {
+		if nodeID == "node4" {
+			continue
+		}
+		node.mu.Lock()
+		for key, version := range owned {
+			stored, ok := node.data[key]
+			if !ok || stored.Version != version {
+				t.Errorf("%s: expected %s at version %d, got %+v", nodeID, key, version, stored)
+			}
+		}
+		node.mu.Unlock()
+	}
+
+	// Draining again is a no-op
+	if err := cc.DrainNode("node4"); err != nil {
+		t.Errorf("Expected repeated drain to succeed, got %v", err)
+	}
+}
+
+func TestClusterClient_DrainLastNode(t *testing.T) {
+	cc, _ := startFakeCluster(t, 1)
+
+	if err := cc.DrainNode("node1"); err == nil {
+		t.Error("Expected error draining the last node")
+	}
+}
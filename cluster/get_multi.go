@@ -0,0 +1,137 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/replication"
+	"kvstore/storage"
+	"kvstore/tracing"
+)
+
+// keyRead collects the replicas' answers for one key of a GetMulti
+type keyRead struct {
+	replicas  int // Replicas in the key's preference list
+	quorum    int // R for the key
+	answered  int // Replicas that answered, with the key or without
+	responses []replication.ReplicaResponse
+}
+
+// GetMulti reads several keys with one ReplicaBatchGet per replica instead
+// of a quorum read per key: the keys are grouped by the nodes in their
+// preference lists, so reading 100 keys from a 5-node cluster takes at most
+// 5 replica calls rather than 300. Each key is then resolved and read
+// repaired on its own, like Get.
+//
+// It waits for every replica to answer or ctx to end, rather than for the
+// first R per key. Keys read from R replicas are in the values map; each
+// other key has its own error: storage.ErrKeyNotFound if at least R
+// replicas answered and none had it, else a read quorum error.
+func (cc *ClusterClient) GetMulti(ctx context.Context, keys []string) (map[string][]byte, map[string]error) {
+	ctx, span := tracing.Start(ctx, "ClusterClient.GetMulti")
+	span.SetAttribute("keys", len(keys))
+	defer span.End()
+
+	values := make(map[string][]byte)
+	errs := make(map[string]error)
+
+	// Batch each key into the calls to every replica it lives on
+	reads := make(map[string]*keyRead)
+	batches := make(map[string][]string) // nodeID -> keys
+	readQuorum := cc.Quorum().R
+	for _, key := range keys {
+		if _, seen := reads[key]; seen {
+			continue
+		}
+		preferenceList, _, quorum, err := cc.preferenceListFor("get", key, readQuorum)
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		reads[key] = &keyRead{replicas: len(preferenceList), quorum: quorum}
+		for _, nodeID := range preferenceList {
+			batches[nodeID] = append(batches[nodeID], key)
+		}
+	}
+
+	log.Printf("🎯 GET MULTI %d keys → %d replicas", len(reads), len(batches))
+
+	type result struct {
+		nodeID string
+		keys   []string
+		resp   *proto.ReplicaBatchGetResponse
+		err    error
+	}
+
+	resultChan := make(chan result, len(batches))
+
+	for nodeID, batch := range batches {
+		nID, batch := nodeID, batch
+		cc.goReplica(func() {
+			ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+			defer cancel()
+
+			start := time.Now()
+			resp, err := cc.replicaBatchGet(ctx, nID, &proto.ReplicaBatchGetRequest{Keys: batch})
+			cc.latency.recordRead(nID, start)
+
+			if err == nil && len(resp.Results) != len(batch) {
+				err = fmt.Errorf("%d results for %d keys", len(resp.Results), len(batch))
+			}
+			resultChan <- result{nodeID: nID, keys: batch, resp: resp, err: err}
+		})
+	}
+
+	// Collect every replica's answers until the caller gives up
+collect:
+	for i := 0; i < len(batches); i++ {
+		select {
+		case res := <-resultChan:
+			if res.err != nil {
+				log.Printf("⚠️  GET MULTI from %s failed: %v", res.nodeID, res.err)
+				continue
+			}
+			for j, key := range res.keys {
+				read := reads[key]
+				read.answered++
+				if r := res.resp.Results[j]; r.Found {
+					read.responses = append(read.responses, replication.ReplicaResponse{
+						NodeID:    res.nodeID,
+						Success:   true,
+						Value:     r.Value,
+						Version:   r.Version,
+						Timestamp: r.Timestamp,
+					})
+				}
+			}
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	// Resolve and repair each key on its own
+	for key, read := range reads {
+		switch {
+		case len(read.responses) >= read.quorum:
+			values[key] = cc.resolve(key, read.responses).Value
+			cc.checkReadRepair(key, read.responses)
+
+		case len(read.responses) == 0 && read.answered >= read.quorum:
+			errs[key] = storage.ErrKeyNotFound
+
+		default:
+			err := fmt.Errorf("read quorum not reached: %d/%d successful (need %d)",
+				len(read.responses), read.replicas, read.quorum)
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				err = fmt.Errorf("%w: %w", err, ctxErr)
+			}
+			errs[key] = err
+		}
+	}
+
+	log.Printf("✅ GET MULTI: %d found, %d failed or missing", len(values), len(errs))
+	return values, errs
+}
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"kvstore/proto"
+	"kvstore/storage"
+)
+
+func TestClusterClient_GetMulti(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 5)
+	ctx := context.Background()
+
+	var keys []string
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		if err := cc.Put(ctx, key, []byte("v-"+key)); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+		keys = append(keys, key)
+	}
+	cc.background.Wait()
+
+	// One replica of user:0 holds an older version
+	owners, _ := cc.registry.hashRing.GetPreferenceList("user:0", cc.replicationFactor)
+	stale := nodes[owners[0]]
+	stale.mu.Lock()
+	stale.data["user:0"] = &proto.ReplicaPutRequest{Key: "user:0", Value: []byte("old"), Timestamp: 1, Version: 1}
+	stale.mu.Unlock()
+
+	values, errs := cc.GetMulti(ctx, append(keys, "missing", "user:1"))
+
+	if len(values) != len(keys) {
+		t.Errorf("Expected %d values, got %d", len(keys), len(values))
+	}
+	for _, key := range keys {
+		if string(values[key]) != "v-"+key {
+			t.Errorf("%s: expected %q, got %q", key, "v-"+key, values[key])
+		}
+	}
+	if len(errs) != 1 || !errors.Is(errs["missing"], storage.ErrKeyNotFound) {
+		t.Errorf("Expected only the missing key to fail with ErrKeyNotFound, got %v", errs)
+	}
+
+	// One batch per node, and no per-key reads
+	for nodeID, node := range nodes {
+		node.mu.Lock()
+		batches, gets := node.batches, node.gets
+		node.mu.Unlock()
+		if batches != 1 || gets != 0 {
+			t.Errorf("%s: expected 1 batch read and no single reads, got %d and %d", nodeID, batches, gets)
+		}
+	}
+
+	// The stale replica is read repaired
+	cc.background.Wait()
+	stale.mu.Lock()
+	repaired := string(stale.data["user:0"].Value)
+	stale.mu.Unlock()
+	if repaired != "v-user:0" {
+		t.Errorf("Expected the stale replica repaired, got %q", repaired)
+	}
+
+	// A replica failing only costs the keys that then miss their quorum
+	for _, nodeID := range owners[:2] {
+		nodes[nodeID].setFailed(true)
+	}
+	values, errs = cc.GetMulti(ctx, keys)
+	if _, ok := errs["user:0"]; !ok {
+		t.Error("Expected user:0 to fail with two of its replicas down")
+	}
+	if errors.Is(errs["user:0"], storage.ErrKeyNotFound) {
+		t.Errorf("Expected a quorum error for user:0, got %v", errs["user:0"])
+	}
+	if len(values)+len(errs) != len(keys) || len(values) == 0 {
+		t.Errorf("Expected the other keys still read, got %d values and %d errors", len(values), len(errs))
+	}
+}
@@ -3,7 +3,9 @@ package cluster
 import (
 	"crypto/md5"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"sync"
 )
@@ -13,27 +15,51 @@ const (
 	DefaultVirtualNodes = 256
 )
 
+// ErrEmptyRing is returned when a lookup is made on a ring with no nodes
+var ErrEmptyRing = errors.New("no nodes in hash ring")
+
 // HashRing implements consistent hashing with virtual nodes
 type HashRing struct {
 	virtualNodes int
 	ring         map[uint32]string // hash -> node ID
 	sortedHashes []uint32          // sorted list of hashes
 	nodes        map[string]bool   // set of physical nodes
+	generation   uint64            // bumped on every membership change
+	prefCache    *preferenceCache  // optional preference list cache
+	hashFn       func(string) uint32
 	mu           sync.RWMutex
 }
 
+// HashRingOption configures a HashRing at construction
+type HashRingOption func(*HashRing)
+
+// WithHashFunc replaces the MD5-based hash used to place both virtual nodes
+// (hashed as "<nodeID>-vnode-<i>") and keys on the ring. Tests use it to put
+// nodes and keys at known positions.
+func WithHashFunc(hashFn func(string) uint32) HashRingOption {
+	return func(hr *HashRing) {
+		hr.hashFn = hashFn
+	}
+}
+
 // NewHashRing creates a new hash ring
-func NewHashRing(virtualNodes int) *HashRing {
+func NewHashRing(virtualNodes int, opts ...HashRingOption) *HashRing {
 	if virtualNodes <= 0 {
 		virtualNodes = DefaultVirtualNodes
 	}
 
-	return &HashRing{
+	hr := &HashRing{
 		virtualNodes: virtualNodes,
 		ring:         make(map[uint32]string),
 		sortedHashes: make([]uint32, 0),
 		nodes:        make(map[string]bool),
+		hashFn:       md5Hash,
 	}
+	for _, opt := range opts {
+		opt(hr)
+	}
+
+	return hr
 }
 
 // AddNode adds a physical node to the ring
@@ -46,6 +72,7 @@ func (hr *HashRing) AddNode(nodeID string) {
 	}
 
 	hr.nodes[nodeID] = true
+	hr.generation++
 
 	// Add virtual nodes
 	for i := 0; i < hr.virtualNodes; i++ {
@@ -61,6 +88,21 @@ func (hr *HashRing) AddNode(nodeID string) {
 	})
 }
 
+// withoutNode returns a copy of the ring with nodeID removed, for computing
+// preference lists as they will be after the node leaves
+func (hr *HashRing) withoutNode(nodeID string) *HashRing {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	copied := NewHashRing(hr.virtualNodes, WithHashFunc(hr.hashFn))
+	for id := range hr.nodes {
+		if id != nodeID {
+			copied.AddNode(id)
+		}
+	}
+	return copied
+}
+
 // RemoveNode removes a physical node from the ring
 func (hr *HashRing) RemoveNode(nodeID string) {
 	hr.mu.Lock()
@@ -71,6 +113,7 @@ func (hr *HashRing) RemoveNode(nodeID string) {
 	}
 
 	delete(hr.nodes, nodeID)
+	hr.generation++
 
 	// Remove virtual nodes
 	newHashes := make([]uint32, 0)
@@ -91,7 +134,7 @@ func (hr *HashRing) GetNode(key string) (string, error) {
 	defer hr.mu.RUnlock()
 
 	if len(hr.sortedHashes) == 0 {
-		return "", fmt.Errorf("no nodes in hash ring")
+		return "", ErrEmptyRing
 	}
 
 	hash := hr.hashKey(key)
@@ -129,8 +172,28 @@ func (hr *HashRing) GetNodeCount() int {
 	return len(hr.nodes)
 }
 
-// hashKey hashes a key to a uint32 using MD5
+// Generation returns a counter that changes whenever nodes are added or removed
+func (hr *HashRing) Generation() uint64 {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+	return hr.generation
+}
+
+// EnablePreferenceCache turns on an LRU cache of preference lists holding up
+// to capacity entries. Cached lists are invalidated on any membership change.
+func (hr *HashRing) EnablePreferenceCache(capacity int) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.prefCache = newPreferenceCache(capacity)
+}
+
+// hashKey hashes a key to its position on the ring
 func (hr *HashRing) hashKey(key string) uint32 {
+	return hr.hashFn(key)
+}
+
+// md5Hash is the default ring hash
+func md5Hash(key string) uint32 {
 	hash := md5.Sum([]byte(key))
 	// Take first 4 bytes and convert to uint32
 	return binary.BigEndian.Uint32(hash[:4])
@@ -166,17 +229,59 @@ func (hr *HashRing) GetKeyDistribution(numKeys int) map[string]int {
 // GetPreferenceList returns N nodes responsible for a key (primary + replicas)
 // Returns nodes in clockwise order starting from the primary node
 func (hr *HashRing) GetPreferenceList(key string, n int) ([]string, error) {
+	nodes, _, err := hr.GetPreferenceListWithGeneration(key, n)
+	return nodes, err
+}
+
+// GetLivePreferenceList is GetPreferenceList for a ring with nodes down;
+// alive holds the nodes currently up. Walking clockwise from the key, live
+// gets the first n live nodes, so the live nodes after the key's replicas
+// stand in for dead ones, and dead gets the dead nodes among the key's first
+// n, which are the ones to store hints for. len(live) < n means fewer than n
+// nodes are up, while a non-empty dead means some of the key's own
+// replicas are down.
+func (hr *HashRing) GetLivePreferenceList(key string, n int, alive map[string]bool) (live, dead []string, err error) {
+	// The whole ring in clockwise order from the key
+	walk, _, err := hr.GetPreferenceListWithGeneration(key, math.MaxInt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, nodeID := range walk {
+		switch {
+		case alive[nodeID]:
+			if len(live) < n {
+				live = append(live, nodeID)
+			}
+		case i < n:
+			dead = append(dead, nodeID)
+		}
+	}
+
+	return live, dead, nil
+}
+
+// GetPreferenceListWithGeneration returns the preference list together with
+// the ring generation it was computed at, so callers can detect membership
+// changes that happen while they use the list
+func (hr *HashRing) GetPreferenceListWithGeneration(key string, n int) ([]string, uint64, error) {
 	hr.mu.RLock()
 	defer hr.mu.RUnlock()
 
 	if len(hr.sortedHashes) == 0 {
-		return nil, fmt.Errorf("no nodes in hash ring")
+		return nil, hr.generation, ErrEmptyRing
 	}
 
 	if n > len(hr.nodes) {
 		n = len(hr.nodes) // Can't have more replicas than nodes
 	}
 
+	if hr.prefCache != nil {
+		if cached, ok := hr.prefCache.get(key, n, hr.generation); ok {
+			return cached, hr.generation, nil
+		}
+	}
+
 	hash := hr.hashKey(key)
 
 	// Binary search to find the first node >= hash
@@ -205,5 +310,9 @@ func (hr *HashRing) GetPreferenceList(key string, n int) ([]string, error) {
 		idx = (idx + 1) % len(hr.sortedHashes)
 	}
 
-	return result, nil
+	if hr.prefCache != nil {
+		hr.prefCache.put(key, n, hr.generation, result)
+	}
+
+	return result, hr.generation, nil
 }
@@ -245,6 +245,52 @@ func TestHashRing_GetPreferenceList(t *testing.T) {
 	}
 }
 
+func TestHashRing_GetLivePreferenceList(t *testing.T) {
+	ring := NewHashRing(256)
+	for i := 1; i <= 5; i++ {
+		ring.AddNode(fmt.Sprintf("node%d", i))
+	}
+
+	key := "test_key"
+	walk, err := ring.GetPreferenceList(key, 5)
+	if err != nil {
+		t.Fatalf("GetPreferenceList failed: %v", err)
+	}
+
+	// The key's primary and fourth node are down
+	alive := map[string]bool{walk[1]: true, walk[2]: true, walk[4]: true}
+
+	live, dead, err := ring.GetLivePreferenceList(key, 3, alive)
+	if err != nil {
+		t.Fatalf("GetLivePreferenceList failed: %v", err)
+	}
+
+	// The live replicas come first, then the next live node stands in
+	if want := []string{walk[1], walk[2], walk[4]}; fmt.Sprint(live) != fmt.Sprint(want) {
+		t.Errorf("Expected live nodes %v, got %v", want, live)
+	}
+	// Only the dead node among the key's own replicas needs a hint
+	if want := []string{walk[0]}; fmt.Sprint(dead) != fmt.Sprint(want) {
+		t.Errorf("Expected dead nodes %v, got %v", want, dead)
+	}
+
+	// With too few nodes up, live comes back short
+	live, dead, err = ring.GetLivePreferenceList(key, 3, map[string]bool{walk[2]: true})
+	if err != nil {
+		t.Fatalf("GetLivePreferenceList failed: %v", err)
+	}
+	if len(live) != 1 || live[0] != walk[2] {
+		t.Errorf("Expected only %s live, got %v", walk[2], live)
+	}
+	if want := []string{walk[0], walk[1]}; fmt.Sprint(dead) != fmt.Sprint(want) {
+		t.Errorf("Expected dead nodes %v, got %v", want, dead)
+	}
+
+	if _, _, err := NewHashRing(256).GetLivePreferenceList(key, 3, alive); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, got %v", err)
+	}
+}
+
 func TestHashRing_GetPreferenceListConsistency(t *testing.T) {
 	ring := NewHashRing(256)
 
@@ -268,3 +314,158 @@ func TestHashRing_GetPreferenceListConsistency(t *testing.T) {
 
 	t.Logf("Consistent preference list: %v", list1)
 }
+
+func TestHashRing_PreferenceCacheInvalidation(t *testing.T) {
+	cached := NewHashRing(64)
+	cached.EnablePreferenceCache(16)
+	uncached := NewHashRing(64)
+
+	for _, ring := range []*HashRing{cached, uncached} {
+		ring.AddNode("node1")
+		ring.AddNode("node2")
+		ring.AddNode("node3")
+	}
+
+	assertSameLists := func(stage string) {
+		t.Helper()
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("key_%d", i)
+			got, _ := cached.GetPreferenceList(key, 3)
+			want, _ := uncached.GetPreferenceList(key, 3)
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Fatalf("%s: stale preference list for %s: got %v, want %v", stage, key, got, want)
+			}
+		}
+	}
+
+	// Warm the cache
+	assertSameLists("initial")
+	assertSameLists("cached")
+
+	// Membership changes must not serve stale lists
+	cached.AddNode("node4")
+	uncached.AddNode("node4")
+	assertSameLists("after AddNode")
+
+	cached.RemoveNode("node2")
+	uncached.RemoveNode("node2")
+	assertSameLists("after RemoveNode")
+}
+
+func TestHashRing_PreferenceCacheEviction(t *testing.T) {
+	ring := NewHashRing(64)
+	ring.EnablePreferenceCache(10)
+	ring.AddNode("node1")
+	ring.AddNode("node2")
+
+	for i := 0; i < 100; i++ {
+		ring.GetPreferenceList(fmt.Sprintf("key_%d", i), 2)
+	}
+
+	if size := ring.prefCache.len(); size != 10 {
+		t.Errorf("Expected cache to hold 10 entries, got %d", size)
+	}
+
+	// Callers must not be able to corrupt cached entries
+	list1, _ := ring.GetPreferenceList("key_99", 2)
+	list1[0] = "corrupted"
+	list2, _ := ring.GetPreferenceList("key_99", 2)
+	if list2[0] == "corrupted" {
+		t.Error("Cached preference list was modified through a returned slice")
+	}
+}
+
+func TestHashRing_CustomHashWrapAround(t *testing.T) {
+	// One virtual node per physical node at known positions: A=100, B=200, C=300
+	positions := map[string]uint32{
+		"nodeA-vnode-0": 100,
+		"nodeB-vnode-0": 200,
+		"nodeC-vnode-0": 300,
+	}
+	hashFn := func(key string) uint32 {
+		if pos, ok := positions[key]; ok {
+			return pos
+		}
+		var pos uint32
+		if _, err := fmt.Sscanf(key, "key@%d", &pos); err != nil {
+			panic(fmt.Sprintf("unexpected key %q", key))
+		}
+		return pos
+	}
+
+	ring := NewHashRing(1, WithHashFunc(hashFn))
+	ring.AddNode("nodeA")
+	ring.AddNode("nodeB")
+	ring.AddNode("nodeC")
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"key@0", "nodeA"},   // Before the first node
+		{"key@100", "nodeA"}, // Exactly on a node
+		{"key@101", "nodeB"},
+		{"key@300", "nodeC"},        // Exactly on the last node
+		{"key@301", "nodeA"},        // Past the last node: wraps to index 0
+		{"key@4294967295", "nodeA"}, // Top of the hash space
+	}
+
+	for _, tt := range tests {
+		node, err := ring.GetNode(tt.key)
+		if err != nil {
+			t.Fatalf("GetNode(%s) failed: %v", tt.key, err)
+		}
+		if node != tt.expected {
+			t.Errorf("GetNode(%s): expected %s, got %s", tt.key, tt.expected, node)
+		}
+	}
+
+	// Preference lists walk clockwise and wrap around the end of the ring
+	prefTests := []struct {
+		key      string
+		expected []string
+	}{
+		{"key@301", []string{"nodeA", "nodeB", "nodeC"}},
+		{"key@250", []string{"nodeC", "nodeA", "nodeB"}},
+		{"key@150", []string{"nodeB", "nodeC", "nodeA"}},
+	}
+
+	for _, tt := range prefTests {
+		list, err := ring.GetPreferenceList(tt.key, 3)
+		if err != nil {
+			t.Fatalf("GetPreferenceList(%s) failed: %v", tt.key, err)
+		}
+		if fmt.Sprint(list) != fmt.Sprint(tt.expected) {
+			t.Errorf("GetPreferenceList(%s): expected %v, got %v", tt.key, tt.expected, list)
+		}
+	}
+}
+
+func benchmarkGetPreferenceList(b *testing.B, cacheSize int) {
+	ring := NewHashRing(256)
+	if cacheSize > 0 {
+		ring.EnablePreferenceCache(cacheSize)
+	}
+	for i := 0; i < 10; i++ {
+		ring.AddNode(fmt.Sprintf("node%d", i))
+	}
+
+	// Hot key set
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("hot_key_%d", i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ring.GetPreferenceList(keys[i%len(keys)], 3)
+	}
+}
+
+func BenchmarkHashRing_GetPreferenceList(b *testing.B) {
+	benchmarkGetPreferenceList(b, 0)
+}
+
+func BenchmarkHashRing_GetPreferenceListCached(b *testing.B) {
+	benchmarkGetPreferenceList(b, DefaultPreferenceCacheSize)
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"kvstore/proto"
+)
+
+const (
+	// HealthCheckTimeout bounds a single Ping during health checks
+	HealthCheckTimeout = 2 * time.Second
+)
+
+// NodeHealth is the result of a health check against one node
+type NodeHealth struct {
+	NodeID  string
+	Healthy bool
+	Latency time.Duration // Round-trip time of the Ping (zero if unhealthy)
+	Err     error
+}
+
+// PingNode sends a Ping to a node and returns the round-trip time.
+// Ping does not touch the node's store, so it is safe to call frequently.
+func (cc *ClusterClient) PingNode(nodeID string) (time.Duration, error) {
+	client, exists := cc.getClient(nodeID)
+	if !exists {
+		return 0, fmt.Errorf("no client for node %s", nodeID)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), HealthCheckTimeout)
+	defer cancel()
+
+	nonce := rand.Uint64()
+	start := time.Now()
+
+	resp, err := client.Ping(ctx, &proto.PingRequest{Nonce: nonce})
+	if err != nil {
+		return 0, fmt.Errorf("Ping RPC to node %s failed: %w", nodeID, err)
+	}
+	rtt := time.Since(start)
+
+	if resp.Nonce != nonce {
+		return 0, fmt.Errorf("Ping to node %s returned wrong nonce", nodeID)
+	}
+
+	return rtt, nil
+}
+
+// CheckHealth pings every node in parallel and reports which ones are up
+func (cc *ClusterClient) CheckHealth() map[string]*NodeHealth {
+	results := make(map[string]*NodeHealth)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for nodeID := range cc.snapshotClients() {
+		wg.Add(1)
+		go func(nodeID string) {
+			defer wg.Done()
+
+			rtt, err := cc.PingNode(nodeID)
+
+			mu.Lock()
+			results[nodeID] = &NodeHealth{
+				NodeID:  nodeID,
+				Healthy: err == nil,
+				Latency: rtt,
+				Err:     err,
+			}
+			mu.Unlock()
+		}(nodeID)
+	}
+
+	wg.Wait()
+	return results
+}
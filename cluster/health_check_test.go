@@ -0,0 +1,28 @@
+package cluster
+
+import "testing"
+
+func TestClusterClient_CheckHealth(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	nodes["node2"].setFailed(true)
+
+	health := cc.CheckHealth()
+	if len(health) != 3 {
+		t.Fatalf("Expected health for 3 nodes, got %d", len(health))
+	}
+
+	for nodeID, h := range health {
+		expectHealthy := nodeID != "node2"
+		if h.Healthy != expectHealthy {
+			t.Errorf("%s: expected healthy=%v, got %v (err: %v)", nodeID, expectHealthy, h.Healthy, h.Err)
+		}
+		if h.Healthy && h.Latency <= 0 {
+			t.Errorf("%s: expected a positive latency, got %v", nodeID, h.Latency)
+		}
+	}
+
+	if _, err := cc.PingNode("unknown"); err == nil {
+		t.Error("Expected error pinging an unknown node")
+	}
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/replication"
+)
+
+// hintID identifies a stored hint, to remove exactly the ones delivered
+type hintID struct {
+	key       string
+	version   int64
+	createdAt time.Time
+}
+
+// ReplayHints delivers the writes hinted for nodeID, typically once it is
+// back up, and returns how many hints were delivered.
+//
+// Membership may have changed while the node was down, so each hinted key's
+// replicas are looked up on the current ring rather than trusted from when
+// the hint was stored. If nodeID still holds the key, the write goes to it;
+// if not, it goes to each of the key's current replicas instead, so it
+// lands where reads will look. A replica that already has the key at the
+// hint's version or newer is left alone, so an old hint never overwrites a
+// later write. Delivered hints are removed; the rest are kept for the next
+// replay and reported in the error.
+func (cc *ClusterClient) ReplayHints(ctx context.Context, nodeID string) (int, error) {
+	hints := cc.hintedHandoff.GetHints(nodeID)
+	if len(hints) == 0 {
+		return 0, nil
+	}
+
+	delivered := make(map[hintID]bool)
+	var firstErr error
+	for _, hint := range hints {
+		targets, err := cc.registry.hashRing.GetPreferenceList(hint.Key, cc.replicationFactor)
+		if err != nil {
+			return 0, err
+		}
+		if slices.Contains(targets, nodeID) {
+			targets = []string{nodeID}
+		} else {
+			log.Printf("🔀 Hint for %s: key=%s now belongs to %v", nodeID, hint.Key, targets)
+		}
+
+		if err := cc.replayHint(ctx, hint, targets); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		delivered[hintID{hint.Key, hint.Version, hint.CreatedAt}] = true
+	}
+
+	removed := cc.hintedHandoff.RemoveHints(nodeID, func(hint replication.Hint) bool {
+		return delivered[hintID{hint.Key, hint.Version, hint.CreatedAt}]
+	})
+	log.Printf("📬 Replayed %d/%d hints for %s", removed, len(hints), nodeID)
+
+	if firstErr != nil {
+		return removed, fmt.Errorf("%d of %d hints for %s not delivered: %w", len(hints)-len(delivered), len(hints), nodeID, firstErr)
+	}
+	return removed, nil
+}
+
+// replayHint writes a hinted value to each target that doesn't already
+// have it at the same or a newer version
+func (cc *ClusterClient) replayHint(ctx context.Context, hint replication.Hint, targets []string) error {
+	for _, target := range targets {
+		ctx, cancel := context.WithTimeout(ctx, ReplicaTimeout)
+		current, err := cc.replicaGet(ctx, target, &proto.ReplicaGetRequest{Key: hint.Key})
+		if err != nil {
+			cancel()
+			return fmt.Errorf("read %s from %s: %w", hint.Key, target, err)
+		}
+		if current.Found && current.Version >= hint.Version {
+			cancel()
+			continue
+		}
+
+		resp, err := cc.replicaPut(ctx, target, &proto.ReplicaPutRequest{
+			Key:       hint.Key,
+			Value:     hint.Value,
+			Timestamp: hint.Timestamp,
+			Version:   hint.Version,
+		})
+		cancel()
+		if err != nil {
+			return fmt.Errorf("replay %s to %s: %w", hint.Key, target, err)
+		}
+		if !resp.Success {
+			return fmt.Errorf("replay %s to %s: %s", hint.Key, target, resp.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"kvstore/proto"
+)
+
+// stored returns the write a fake node holds for key, or nil
+func (f *fakeNode) stored(key string) *proto.ReplicaPutRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data[key]
+}
+
+// Test: a node joins while node1 is down, taking over some of the keys
+// hinted for node1; replay sends those to their current replicas instead
+func TestClusterClient_ReplayHintsFollowsCurrentOwners(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 5)
+	ctx := context.Background()
+
+	// node5 joins later
+	node5Addr := cc.registry.GetNodeAddresses()["node5"]
+	if err := cc.registry.UnregisterNode("node5"); err != nil {
+		t.Fatalf("Failed to unregister node5: %v", err)
+	}
+
+	nodes["node1"].setFailed(true)
+	for i := 0; i < 60; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if err := cc.Put(ctx, key, []byte("v1")); err != nil {
+			t.Fatalf("Put(%s) failed: %v", key, err)
+		}
+	}
+	cc.background.Wait()
+	var hinted []string
+	for _, hint := range cc.hintedHandoff.GetHints("node1") {
+		hinted = append(hinted, hint.Key)
+	}
+	if len(hinted) == 0 {
+		t.Fatal("Expected hints for node1")
+	}
+
+	if err := cc.registry.RegisterNode("node5", node5Addr); err != nil {
+		t.Fatalf("Failed to register node5: %v", err)
+	}
+	nodes["node1"].setFailed(false)
+
+	// A key written again after recovery must not be rolled back by its hint
+	var rewritten string
+	for _, key := range hinted {
+		owners, _ := cc.registry.hashRing.GetPreferenceList(key, cc.replicationFactor)
+		if slices.Contains(owners, "node1") {
+			rewritten = key
+			if err := cc.Put(ctx, key, []byte("v2")); err != nil {
+				t.Fatalf("Put(%s) failed: %v", key, err)
+			}
+			break
+		}
+	}
+	cc.background.Wait()
+
+	replayed, err := cc.ReplayHints(ctx, "node1")
+	if err != nil {
+		t.Fatalf("ReplayHints failed: %v", err)
+	}
+	if replayed != len(hinted) {
+		t.Errorf("Expected %d hints replayed, got %d", len(hinted), replayed)
+	}
+	if n := cc.hintedHandoff.GetHintCountForNode("node1"); n != 0 {
+		t.Errorf("Expected node1's hints removed, %d left", n)
+	}
+
+	rerouted := 0
+	for _, key := range hinted {
+		// Kept keys go to node1 only; rerouted ones to every current owner
+		owners, _ := cc.registry.hashRing.GetPreferenceList(key, cc.replicationFactor)
+		targets := []string{"node1"}
+		if !slices.Contains(owners, "node1") {
+			rerouted++
+			targets = owners
+			if nodes["node1"].stored(key) != nil {
+				t.Errorf("%s no longer belongs to node1 but was replayed to it", key)
+			}
+		}
+		for _, owner := range targets {
+			stored := nodes[owner].stored(key)
+			if stored == nil {
+				t.Errorf("%s missing on current owner %s", key, owner)
+				continue
+			}
+			want := "v1"
+			if key == rewritten {
+				want = "v2"
+			}
+			if string(stored.Value) != want {
+				t.Errorf("%s on %s = %q, expected %q", key, owner, stored.Value, want)
+			}
+		}
+	}
+	if rerouted == 0 || rewritten == "" {
+		t.Fatalf("Expected both rerouted and kept keys (rerouted %d of %d)", rerouted, len(hinted))
+	}
+}
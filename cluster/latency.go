@@ -0,0 +1,203 @@
+package cluster
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// LatencyWindow is how often the per-node latency histograms roll over.
+	// Percentiles cover the current window plus the previous one, so they
+	// reflect the last one to two windows of traffic.
+	LatencyWindow = time.Minute
+
+	// latencyBuckets covers 0µs to ~67s with four buckets per power of two
+	// (each bucket is at most ~25% wide)
+	latencyBuckets = 100
+)
+
+// NodeLatency summarizes recent replica call latencies for one node
+type NodeLatency struct {
+	ReadCount  int64 // ReplicaGet calls in the window
+	ReadP50    time.Duration
+	ReadP99    time.Duration
+	WriteCount int64 // ReplicaPut and Delete calls in the window
+	WriteP50   time.Duration
+	WriteP99   time.Duration
+}
+
+// latencyHistogram counts durations in log-scale buckets using atomics only
+type latencyHistogram struct {
+	buckets [latencyBuckets]atomic.Int64
+}
+
+// latencyBucket returns the bucket index for a duration
+func latencyBucket(d time.Duration) int {
+	us := uint64(d / time.Microsecond)
+	if d < 0 {
+		us = 0
+	}
+	if us < 4 {
+		return int(us)
+	}
+
+	// Octave from the leading bit, sub-bucket from the next two bits
+	exp := bits.Len64(us) - 1
+	sub := int(us>>(exp-2)) & 3
+	idx := (exp-1)*4 + sub
+	if idx >= latencyBuckets {
+		idx = latencyBuckets - 1
+	}
+	return idx
+}
+
+// latencyBucketUpperBound returns the (exclusive) upper bound of a bucket
+func latencyBucketUpperBound(idx int) time.Duration {
+	if idx < 4 {
+		return time.Duration(idx+1) * time.Microsecond
+	}
+	exp := idx/4 + 1
+	sub := idx % 4
+	return time.Duration((4+sub+1)<<(exp-2)) * time.Microsecond
+}
+
+// rollingHistogram keeps a current and a previous window of latencies.
+// Recording is lock-free: one atomic load to find the window and one atomic
+// add; rotation is done by whichever recorder first sees the window expire.
+type rollingHistogram struct {
+	current     atomic.Pointer[latencyHistogram]
+	previous    atomic.Pointer[latencyHistogram]
+	windowStart atomic.Int64 // UnixNano
+}
+
+func newRollingHistogram() *rollingHistogram {
+	r := &rollingHistogram{}
+	r.current.Store(&latencyHistogram{})
+	r.previous.Store(&latencyHistogram{})
+	r.windowStart.Store(time.Now().UnixNano())
+	return r
+}
+
+// record adds one observation
+func (r *rollingHistogram) record(d time.Duration) {
+	r.maybeRotate(time.Now())
+	r.current.Load().buckets[latencyBucket(d)].Add(1)
+}
+
+// maybeRotate starts a new window if the current one has expired.
+// An observation racing with the rotation may land in the retiring window,
+// which only shifts it by one window.
+func (r *rollingHistogram) maybeRotate(now time.Time) {
+	start := r.windowStart.Load()
+	if now.UnixNano()-start < int64(LatencyWindow) {
+		return
+	}
+	if !r.windowStart.CompareAndSwap(start, now.UnixNano()) {
+		return // Another recorder rotated
+	}
+
+	// After two idle windows the previous window is stale too
+	if now.UnixNano()-start >= 2*int64(LatencyWindow) {
+		r.previous.Store(&latencyHistogram{})
+	} else {
+		r.previous.Store(r.current.Load())
+	}
+	r.current.Store(&latencyHistogram{})
+}
+
+// summary returns the observation count and p50/p99 over both windows
+func (r *rollingHistogram) summary() (int64, time.Duration, time.Duration) {
+	r.maybeRotate(time.Now())
+
+	var counts [latencyBuckets]int64
+	var total int64
+	for _, h := range []*latencyHistogram{r.previous.Load(), r.current.Load()} {
+		for i := range counts {
+			n := h.buckets[i].Load()
+			counts[i] += n
+			total += n
+		}
+	}
+
+	return total, percentile(counts[:], total, 0.50), percentile(counts[:], total, 0.99)
+}
+
+// percentile returns the upper bound of the bucket holding quantile q
+func percentile(counts []int64, total int64, q float64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+
+	rank := int64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+
+	var seen int64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank {
+			return latencyBucketUpperBound(i)
+		}
+	}
+	return latencyBucketUpperBound(len(counts) - 1)
+}
+
+// nodeLatency holds the read and write histograms of one node
+type nodeLatency struct {
+	reads  *rollingHistogram
+	writes *rollingHistogram
+}
+
+// latencyTracker records replica call latencies per node
+type latencyTracker struct {
+	nodes sync.Map // nodeID -> *nodeLatency
+}
+
+func (lt *latencyTracker) node(nodeID string) *nodeLatency {
+	if nl, ok := lt.nodes.Load(nodeID); ok {
+		return nl.(*nodeLatency)
+	}
+	nl, _ := lt.nodes.LoadOrStore(nodeID, &nodeLatency{
+		reads:  newRollingHistogram(),
+		writes: newRollingHistogram(),
+	})
+	return nl.(*nodeLatency)
+}
+
+// recordRead records a ReplicaGet that started at start
+func (lt *latencyTracker) recordRead(nodeID string, start time.Time) {
+	lt.node(nodeID).reads.record(time.Since(start))
+}
+
+// recordWrite records a ReplicaPut or Delete that started at start
+func (lt *latencyTracker) recordWrite(nodeID string, start time.Time) {
+	lt.node(nodeID).writes.record(time.Since(start))
+}
+
+// readP50 returns a node's median read latency, or false if it has no
+// recent reads
+func (lt *latencyTracker) readP50(nodeID string) (time.Duration, bool) {
+	nl, ok := lt.nodes.Load(nodeID)
+	if !ok {
+		return 0, false
+	}
+	count, p50, _ := nl.(*nodeLatency).reads.summary()
+	return p50, count > 0
+}
+
+// snapshot summarizes every node that has recorded calls
+func (lt *latencyTracker) snapshot() map[string]NodeLatency {
+	stats := make(map[string]NodeLatency)
+	lt.nodes.Range(func(key, value interface{}) bool {
+		nl := value.(*nodeLatency)
+		var s NodeLatency
+		s.ReadCount, s.ReadP50, s.ReadP99 = nl.reads.summary()
+		s.WriteCount, s.WriteP50, s.WriteP99 = nl.writes.summary()
+		stats[key.(string)] = s
+		return true
+	})
+	return stats
+}
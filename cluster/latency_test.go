@@ -0,0 +1,120 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLatencyBucket_Bounds(t *testing.T) {
+	prev := time.Duration(0)
+	for i := 0; i < latencyBuckets; i++ {
+		upper := latencyBucketUpperBound(i)
+		if upper <= prev {
+			t.Fatalf("Bucket %d upper bound %v not above previous %v", i, upper, prev)
+		}
+		prev = upper
+	}
+
+	for _, d := range []time.Duration{
+		0,
+		3 * time.Microsecond,
+		250 * time.Microsecond,
+		time.Millisecond,
+		37 * time.Millisecond,
+		2 * time.Second,
+	} {
+		idx := latencyBucket(d)
+		if d >= latencyBucketUpperBound(idx) {
+			t.Errorf("%v: above bucket %d upper bound %v", d, idx, latencyBucketUpperBound(idx))
+		}
+		if idx > 0 && d < latencyBucketUpperBound(idx-1) {
+			t.Errorf("%v: below bucket %d lower bound %v", d, idx, latencyBucketUpperBound(idx-1))
+		}
+	}
+
+	// Anything beyond the range lands in the last bucket
+	if idx := latencyBucket(time.Hour); idx != latencyBuckets-1 {
+		t.Errorf("Expected overflow bucket, got %d", idx)
+	}
+}
+
+func TestRollingHistogram_Percentiles(t *testing.T) {
+	r := newRollingHistogram()
+
+	for i := 0; i < 98; i++ {
+		r.record(time.Millisecond)
+	}
+	r.record(100 * time.Millisecond)
+	r.record(100 * time.Millisecond)
+
+	count, p50, p99 := r.summary()
+	if count != 100 {
+		t.Fatalf("Expected 100 observations, got %d", count)
+	}
+
+	// Buckets are at most 25% wide
+	if p50 < time.Millisecond || p50 > 1250*time.Microsecond {
+		t.Errorf("Expected p50 around 1ms, got %v", p50)
+	}
+	if p99 < 100*time.Millisecond || p99 > 125*time.Millisecond {
+		t.Errorf("Expected p99 around 100ms, got %v", p99)
+	}
+}
+
+func TestRollingHistogram_Rotation(t *testing.T) {
+	r := newRollingHistogram()
+	r.record(time.Millisecond)
+
+	// One window later the observation is still in the previous window
+	start := r.windowStart.Load()
+	r.maybeRotate(time.Unix(0, start).Add(LatencyWindow))
+	if count, _, _ := r.summary(); count != 1 {
+		t.Errorf("Expected 1 observation after one rotation, got %d", count)
+	}
+
+	// Another window later it has aged out
+	start = r.windowStart.Load()
+	r.maybeRotate(time.Unix(0, start).Add(LatencyWindow))
+	if count, _, _ := r.summary(); count != 0 {
+		t.Errorf("Expected observations to age out, got %d", count)
+	}
+}
+
+func TestClusterClient_LatencyStats(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	nodes["node3"].setPutDelay(50 * time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		if err := cc.Put(context.Background(), "user:1", []byte("alice")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if _, err := cc.Get(context.Background(), "user:1"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+
+	// Put returns at quorum; wait for the slow replica to finish recording
+	deadline := time.Now().Add(2 * time.Second)
+	for cc.LatencyStats()["node3"].WriteCount < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("Slow replica writes were never recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats := cc.LatencyStats()
+	for _, nodeID := range []string{"node1", "node2", "node3"} {
+		s := stats[nodeID]
+		if s.WriteCount != 5 || s.ReadCount != 5 {
+			t.Errorf("%s: expected 5 reads and 5 writes, got %+v", nodeID, s)
+		}
+	}
+
+	if stats["node3"].WriteP50 < 50*time.Millisecond {
+		t.Errorf("Expected node3 write p50 >= 50ms, got %v", stats["node3"].WriteP50)
+	}
+	if stats["node1"].WriteP50 >= stats["node3"].WriteP50 {
+		t.Errorf("Expected node1 writes (%v) faster than node3 (%v)", stats["node1"].WriteP50, stats["node3"].WriteP50)
+	}
+}
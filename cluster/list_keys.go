@@ -0,0 +1,162 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"kvstore/proto"
+)
+
+const (
+	// DefaultListKeysLimit is the page size ListKeys uses for a limit of 0
+	// or less
+	DefaultListKeysLimit = 1000
+)
+
+// ErrPartialListing is returned by ListKeys with a page that was built
+// without some nodes. Keys stored only on those nodes may be missing from
+// the page; the keys and cursor returned are otherwise valid.
+type ErrPartialListing struct {
+	Errors map[string]error // Why each missing node did not answer
+}
+
+func (e *ErrPartialListing) Error() string {
+	nodes := make([]string, 0, len(e.Errors))
+	for nodeID := range e.Errors {
+		nodes = append(nodes, nodeID)
+	}
+	sort.Strings(nodes)
+	return fmt.Sprintf("partial key listing: no answer from %s", strings.Join(nodes, ", "))
+}
+
+// ListKeys returns up to limit live keys >= startKey from across the
+// cluster in sorted order, and the cursor to pass as startKey for the next
+// page ("" once every key has been listed).
+//
+// Keys are placed by hash, so no node holds a contiguous key range: every
+// node is asked for its first limit keys from startKey with a key-only Scan,
+// and the per-node sorted streams are merged, dropping the duplicates that
+// replication produces. Any key among the cluster's first limit is among the
+// first limit of every node holding it, so the merged page is globally
+// sorted and complete.
+//
+// A node that fails does not fail the listing: the page is built from the
+// others and returned with an *ErrPartialListing. An error with no keys is
+// returned only when nodes were asked and none of them answered.
+func (cc *ClusterClient) ListKeys(startKey string, limit int) ([]string, string, error) {
+	if limit <= 0 {
+		limit = DefaultListKeysLimit
+	}
+
+	clients := cc.snapshotClients()
+	if len(clients) == 0 {
+		return nil, "", nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	streams := make(map[string][]string)
+	failed := make(map[string]error)
+	for nodeID, client := range clients {
+		wg.Add(1)
+		go func(nodeID string, client proto.KVStoreClient) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), ReplicaTimeout)
+			defer cancel()
+
+			resp, err := client.Scan(ctx, &proto.ScanRequest{
+				StartKey: startKey,
+				KeysOnly: true,
+				Limit:    int32(limit),
+			})
+			if err == nil && resp.Error != "" {
+				err = fmt.Errorf("%s", resp.Error)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed[nodeID] = fmt.Errorf("Scan RPC to node %s failed: %w", nodeID, err)
+				return
+			}
+
+			keys := make([]string, len(resp.Entries))
+			for i, entry := range resp.Entries {
+				keys[i] = entry.Key
+			}
+			streams[nodeID] = keys
+		}(nodeID, client)
+	}
+	wg.Wait()
+
+	if len(streams) == 0 {
+		return nil, "", fmt.Errorf("Scan failed on all %d nodes", len(clients))
+	}
+
+	keys, more := mergeKeyStreams(streams, limit)
+
+	// A node that filled its page may hold keys past the last one merged
+	for _, stream := range streams {
+		if len(stream) >= limit {
+			more = true
+		}
+	}
+
+	var nextCursor string
+	if more && len(keys) > 0 {
+		nextCursor = keys[len(keys)-1] + "\x00"
+	}
+
+	log.Printf("📋 LIST from %q: %d keys from %d/%d nodes", startKey, len(keys), len(streams), len(clients))
+
+	if len(failed) > 0 {
+		for nodeID, err := range failed {
+			log.Printf("⚠️  LIST: %s missing from the listing: %v", nodeID, err)
+		}
+		return keys, nextCursor, &ErrPartialListing{Errors: failed}
+	}
+	return keys, nextCursor, nil
+}
+
+// mergeKeyStreams merges sorted key streams into their first limit distinct
+// keys. The boolean reports whether keys were left over.
+func mergeKeyStreams(streams map[string][]string, limit int) ([]string, bool) {
+	heads := make([][]string, 0, len(streams))
+	for _, stream := range streams {
+		if len(stream) > 0 {
+			heads = append(heads, stream)
+		}
+	}
+
+	var keys []string
+	for len(heads) > 0 {
+		smallest := heads[0][0]
+		for _, head := range heads[1:] {
+			if head[0] < smallest {
+				smallest = head[0]
+			}
+		}
+		if len(keys) == limit {
+			return keys, true
+		}
+		keys = append(keys, smallest)
+
+		// Advance every stream holding the key; replicas all do
+		remaining := heads[:0]
+		for _, head := range heads {
+			if head[0] == smallest {
+				head = head[1:]
+			}
+			if len(head) > 0 {
+				remaining = append(remaining, head)
+			}
+		}
+		heads = remaining
+	}
+	return keys, false
+}
@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClusterClient_ListKeys(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 4)
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		if err := cc.Put(context.Background(), key, []byte("v")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		want = append(want, key)
+	}
+
+	// Page through with a page size that doesn't divide the key count
+	var got []string
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("Listing did not terminate")
+		}
+		keys, next, err := cc.ListKeys(cursor, 4)
+		if err != nil {
+			t.Fatalf("ListKeys failed: %v", err)
+		}
+		if len(keys) > 4 {
+			t.Fatalf("Expected at most 4 keys per page, got %v", keys)
+		}
+		got = append(got, keys...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected every key once in order %v, got %v", want, got)
+	}
+
+	// A down node makes the listing partial, not failed
+	nodes["node1"].setFailed(true)
+	keys, _, err := cc.ListKeys("", 100)
+	var partial *ErrPartialListing
+	if !errors.As(err, &partial) {
+		t.Fatalf("Expected *ErrPartialListing, got %T: %v", err, err)
+	}
+	if _, ok := partial.Errors["node1"]; !ok || len(partial.Errors) != 1 {
+		t.Errorf("Expected node1 to be reported missing, got %v", partial.Errors)
+	}
+	// With N=3 every key still has a replica on the other nodes
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("Expected %v from the remaining nodes, got %v", want, keys)
+	}
+
+	for _, node := range nodes {
+		node.setFailed(true)
+	}
+	if keys, _, err := cc.ListKeys("", 100); err == nil || errors.As(err, &partial) || keys != nil {
+		t.Errorf("Expected a plain error with every node down, got %v, %v", keys, err)
+	}
+}
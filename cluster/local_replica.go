@@ -0,0 +1,163 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+
+	"kvstore/proto"
+	"kvstore/replication"
+	"kvstore/storage"
+	"kvstore/tracing"
+)
+
+// SetLocalStore makes replicas that are selfID read and write store
+// directly instead of going through gRPC, for nodes that run a
+// ClusterClient next to their own store. Other replicas still use RPCs.
+//
+// The store keeps a value's write timestamp, which is also its version
+// (replication.GenerateVersion), so local and remote replicas compare
+// equal after the same write. A nil store turns local routing off.
+func (cc *ClusterClient) SetLocalStore(selfID string, store *storage.LSMStore) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.selfID = selfID
+	cc.localStore = store
+}
+
+// localStoreFor returns the local store if nodeID is this node
+func (cc *ClusterClient) localStoreFor(nodeID string) (*storage.LSMStore, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	if cc.localStore == nil || nodeID != cc.selfID {
+		return nil, false
+	}
+	return cc.localStore, true
+}
+
+// startReplicaSpan begins the span of one replica call
+func startReplicaSpan(ctx context.Context, name, nodeID string, local bool) (context.Context, *tracing.Span) {
+	ctx, span := tracing.Start(ctx, name)
+	span.SetAttribute("node", nodeID)
+	span.SetAttribute("local", local)
+	return ctx, span
+}
+
+// replicaPut writes a versioned value to one replica, locally when it is
+// this node
+func (cc *ClusterClient) replicaPut(ctx context.Context, nodeID string, req *proto.ReplicaPutRequest) (resp *proto.ReplicaPutResponse, err error) {
+	store, local := cc.localStoreFor(nodeID)
+	ctx, span := startReplicaSpan(ctx, "replica.Put", nodeID, local)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if local {
+		if req.Crdt == proto.ReplicaPutRequest_CRDT_GCOUNTER {
+			counter, err := store.Update(req.Key, replication.GCounterUpdate(req.Value, req.CounterNode, req.Increment))
+			if err != nil {
+				return nil, err
+			}
+			return &proto.ReplicaPutResponse{Success: true, Value: counter}, nil
+		}
+		if err := store.PutWithTimestamp(req.Key, req.Value, req.Timestamp); err != nil {
+			return nil, err
+		}
+		return &proto.ReplicaPutResponse{Success: true}, nil
+	}
+
+	err = cc.callNode(ctx, nodeID, func(client proto.KVStoreClient) (err error) {
+		resp, err = client.ReplicaPut(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// replicaGet reads a versioned value from one replica, locally when it is
+// this node
+func (cc *ClusterClient) replicaGet(ctx context.Context, nodeID string, req *proto.ReplicaGetRequest) (resp *proto.ReplicaGetResponse, err error) {
+	store, local := cc.localStoreFor(nodeID)
+	ctx, span := startReplicaSpan(ctx, "replica.Get", nodeID, local)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if local {
+		return localReplicaGet(ctx, store, req.Key)
+	}
+
+	err = cc.callNode(ctx, nodeID, func(client proto.KVStoreClient) (err error) {
+		resp, err = client.ReplicaGet(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// replicaBatchGet reads several versioned values from one replica in a
+// single call, locally when it is this node
+func (cc *ClusterClient) replicaBatchGet(ctx context.Context, nodeID string, req *proto.ReplicaBatchGetRequest) (resp *proto.ReplicaBatchGetResponse, err error) {
+	store, local := cc.localStoreFor(nodeID)
+	ctx, span := startReplicaSpan(ctx, "replica.BatchGet", nodeID, local)
+	span.SetAttribute("keys", len(req.Keys))
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if local {
+		resp := &proto.ReplicaBatchGetResponse{Results: make([]*proto.ReplicaGetResponse, len(req.Keys))}
+		for i, key := range req.Keys {
+			if resp.Results[i], err = localReplicaGet(ctx, store, key); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+
+	err = cc.callNode(ctx, nodeID, func(client proto.KVStoreClient) (err error) {
+		resp, err = client.ReplicaBatchGet(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// localReplicaGet reads a versioned value from the local store
+func localReplicaGet(ctx context.Context, store *storage.LSMStore, key string) (*proto.ReplicaGetResponse, error) {
+	value, timestamp, err := store.GetWithTimestampContext(ctx, key)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return &proto.ReplicaGetResponse{Found: false}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ReplicaGetResponse{
+		Value:     value,
+		Found:     true,
+		Timestamp: timestamp,
+		Version:   replication.GenerateVersion(timestamp),
+	}, nil
+}
+
+// replicaDelete deletes a key from one replica, locally when it is this node
+func (cc *ClusterClient) replicaDelete(ctx context.Context, nodeID string, req *proto.DeleteRequest) (resp *proto.DeleteResponse, err error) {
+	store, local := cc.localStoreFor(nodeID)
+	ctx, span := startReplicaSpan(ctx, "replica.Delete", nodeID, local)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if local {
+		if err := store.Delete(req.Key); err != nil {
+			return nil, err
+		}
+		return &proto.DeleteResponse{Success: true}, nil
+	}
+
+	err = cc.callNode(ctx, nodeID, func(client proto.KVStoreClient) (err error) {
+		resp, err = client.Delete(ctx, req)
+		return err
+	})
+	return resp, err
+}
@@ -0,0 +1,66 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kvstore/storage"
+)
+
+func TestClusterClient_LocalReplicaSkipsRPC(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// This client runs on node1; with N=3 node1 holds every key
+	cc.SetLocalStore("node1", store)
+
+	result, err := cc.PutWithResult(context.Background(), "user:1", []byte("alice"))
+	if err != nil {
+		t.Fatalf("PutWithResult failed: %v", err)
+	}
+
+	value, timestamp, err := store.GetWithTimestamp("user:1")
+	if err != nil || string(value) != "alice" {
+		t.Fatalf("Expected the write in the local store, got %q (err: %v)", value, err)
+	}
+	if timestamp != result.Timestamp {
+		t.Errorf("Expected the coordinator's timestamp %d locally, got %d", result.Timestamp, timestamp)
+	}
+
+	value, err = cc.Get(context.Background(), "user:1")
+	if err != nil || string(value) != "alice" {
+		t.Fatalf("Expected 'alice', got %q (err: %v)", value, err)
+	}
+
+	// PutWithResult may return before the slower remote replica has the write
+	deadline := time.Now().Add(time.Second)
+	for nodes["node2"].putCount() == 0 || nodes["node3"].putCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Remote replicas never received the write")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cc.background.Wait() // Any read repair has finished
+
+	if puts, gets := nodes["node1"].putCount(), nodes["node1"].getCount(); puts != 0 || gets != 0 {
+		t.Errorf("Expected no RPCs to the local node, got %d ReplicaPuts and %d ReplicaGets", puts, gets)
+	}
+	for _, nodeID := range []string{"node2", "node3"} {
+		if puts := nodes[nodeID].putCount(); puts != 1 {
+			t.Errorf("%s: expected 1 ReplicaPut (no read repair), got %d", nodeID, puts)
+		}
+	}
+
+	if err := cc.Delete(context.Background(), "user:1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("user:1"); err != storage.ErrKeyNotFound {
+		t.Errorf("Expected the key deleted locally, got %v", err)
+	}
+}
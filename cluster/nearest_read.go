@@ -0,0 +1,58 @@
+package cluster
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+// SetNodeDistances makes Get read from the nearest replicas, e.g. those in
+// the caller's availability zone, instead of all of them. distances maps a
+// node ID to its distance from this client: only the relative order
+// matters, so it can be a zone rank (0 for the same zone, 1 for the same
+// region, ...) or a measured round trip. Nodes missing from the map are the
+// farthest.
+//
+// With distances set, Get sends to the R nearest replicas of the key and
+// only sends to the next nearest when one of them fails or doesn't have the
+// key, so a healthy cluster serves reads without crossing zones. Read repair
+// then only sees the replicas that were asked. GetBoundedStaleness also
+// prefers the nearest replica over the fastest. nil or an empty map turns
+// it off (the default): Get asks every replica at once and uses the first R
+// answers.
+func (cc *ClusterClient) SetNodeDistances(distances map[string]int) {
+	if len(distances) == 0 {
+		cc.distances.Store(nil)
+		log.Printf("📍 Node distances cleared: reads go to every replica")
+		return
+	}
+
+	copied := make(map[string]int, len(distances))
+	for nodeID, distance := range distances {
+		copied[nodeID] = distance
+	}
+	cc.distances.Store(&copied)
+	log.Printf("📍 Node distances set for %d nodes: reads prefer the nearest replicas", len(copied))
+}
+
+// byDistance returns preferenceList ordered nearest first, keeping the ring
+// order among equally distant nodes, and whether node distances are set
+// (if not, preferenceList is returned as it is)
+func (cc *ClusterClient) byDistance(preferenceList []string) ([]string, bool) {
+	distances := cc.distances.Load()
+	if distances == nil {
+		return preferenceList, false
+	}
+
+	distance := func(nodeID string) int {
+		if d, ok := (*distances)[nodeID]; ok {
+			return d
+		}
+		return math.MaxInt
+	}
+	ordered := append([]string(nil), preferenceList...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return distance(ordered[i]) < distance(ordered[j])
+	})
+	return ordered, true
+}
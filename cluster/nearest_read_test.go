@@ -0,0 +1,73 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+)
+
+func TestClusterClient_NearestReads(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	ctx := context.Background()
+
+	if err := cc.Put(ctx, "key", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	cc.background.Wait()
+
+	// node2 is in our zone, node1 in the next, node3 farthest
+	cc.SetNodeDistances(map[string]int{"node2": 0, "node1": 1, "node3": 5})
+
+	gets := func() map[string]int {
+		counts := make(map[string]int)
+		for nodeID, node := range nodes {
+			counts[nodeID] = node.getCount()
+		}
+		return counts
+	}
+	getFrom := func() map[string]int {
+		t.Helper()
+		before := gets()
+		value, err := cc.Get(ctx, "key")
+		if err != nil || string(value) != "value" {
+			t.Fatalf("Expected value, got %q (err: %v)", value, err)
+		}
+		cc.background.Wait()
+		after := gets()
+		for nodeID := range after {
+			after[nodeID] -= before[nodeID]
+		}
+		return after
+	}
+
+	// QUORUM (R=2): the two nearest only
+	if calls := getFrom(); calls["node2"] != 1 || calls["node1"] != 1 || calls["node3"] != 0 {
+		t.Errorf("Expected node2 and node1 to serve the read, got %v", calls)
+	}
+
+	// ONE (R=1): the nearest only
+	if err := cc.SetQuorum(3, 1); err != nil {
+		t.Fatal(err)
+	}
+	if calls := getFrom(); calls["node2"] != 1 || calls["node1"] != 0 || calls["node3"] != 0 {
+		t.Errorf("Expected node2 alone to serve the read, got %v", calls)
+	}
+	if nearest := cc.nearestReplica([]string{"node1", "node2", "node3"}); nearest != "node2" {
+		t.Errorf("Expected node2 as the nearest replica, got %s", nearest)
+	}
+
+	// The far replica is only asked when a nearer one can't answer
+	if err := cc.SetQuorum(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	nodes["node1"].setFailed(true)
+	if calls := getFrom(); calls["node2"] != 1 || calls["node1"] != 1 || calls["node3"] != 1 {
+		t.Errorf("Expected node3 to make up the quorum for node1, got %v", calls)
+	}
+	nodes["node1"].setFailed(false)
+
+	// Without distances every replica is asked
+	cc.SetNodeDistances(nil)
+	if calls := getFrom(); calls["node1"] != 1 || calls["node2"] != 1 || calls["node3"] != 1 {
+		t.Errorf("Expected all three replicas to be asked, got %v", calls)
+	}
+}
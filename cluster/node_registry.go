@@ -2,10 +2,20 @@ package cluster
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+const (
+	// DefaultRingInfoKeys is how many keys RingInfo simulates when asked for 0
+	DefaultRingInfoKeys = 10000
+
+	// MaxRingInfoKeys caps the simulation so a remote caller can't make
+	// RingInfo arbitrarily expensive
+	MaxRingInfoKeys = 1000000
+)
+
 // Node represents a node in the cluster
 type Node struct {
 	ID      string    // Unique node identifier
@@ -126,6 +136,58 @@ func (nr *NodeRegistry) GetNodeAddresses() map[string]string {
 	return addresses
 }
 
+// RingNode describes one physical node's share of the hash ring
+type RingNode struct {
+	ID            string
+	Address       string
+	VirtualNodes  int // Virtual nodes the node owns on the ring
+	SimulatedKeys int // Keys of the simulation the node is primary for
+}
+
+// RingInfo is a snapshot of the hash ring for observability tooling
+type RingInfo struct {
+	Generation    uint64     // Changes on every membership change
+	SimulatedKeys int        // Keys simulated for RingNode.SimulatedKeys
+	Nodes         []RingNode // Sorted by node ID
+}
+
+// RingInfo returns the registered nodes with their virtual-node counts and
+// the primary ownership of simulatedKeys synthetic keys (DefaultRingInfoKeys
+// if 0, at most MaxRingInfoKeys). The simulation is deterministic and nodes
+// are sorted by ID, so repeated calls are comparable and only differ after
+// membership changes.
+func (nr *NodeRegistry) RingInfo(simulatedKeys int) RingInfo {
+	if simulatedKeys <= 0 {
+		simulatedKeys = DefaultRingInfoKeys
+	}
+	simulatedKeys = min(simulatedKeys, MaxRingInfoKeys)
+
+	nr.mu.RLock()
+	defer nr.mu.RUnlock()
+
+	virtualNodes := nr.hashRing.GetDistribution()
+	keys := nr.hashRing.GetKeyDistribution(simulatedKeys)
+
+	info := RingInfo{
+		Generation:    nr.hashRing.Generation(),
+		SimulatedKeys: simulatedKeys,
+		Nodes:         make([]RingNode, 0, len(nr.nodes)),
+	}
+	for id, node := range nr.nodes {
+		info.Nodes = append(info.Nodes, RingNode{
+			ID:            id,
+			Address:       node.Address,
+			VirtualNodes:  virtualNodes[id],
+			SimulatedKeys: keys[id],
+		})
+	}
+	sort.Slice(info.Nodes, func(i, j int) bool {
+		return info.Nodes[i].ID < info.Nodes[j].ID
+	})
+
+	return info
+}
+
 // GetKeyDistribution returns statistics about key distribution
 func (nr *NodeRegistry) GetKeyDistribution(numKeys int) map[string]int {
 	nr.mu.RLock()
@@ -1,6 +1,8 @@
 package cluster
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -157,3 +159,75 @@ func TestNodeRegistry_KeyDistribution(t *testing.T) {
 		}
 	}
 }
+
+func TestNodeRegistry_RingInfo(t *testing.T) {
+	registry := NewNodeRegistry(64)
+
+	registry.RegisterNode("node3", "localhost:50053")
+	registry.RegisterNode("node1", "localhost:50051")
+	registry.RegisterNode("node2", "localhost:50052")
+
+	info := registry.RingInfo(0)
+	if info.SimulatedKeys != DefaultRingInfoKeys {
+		t.Errorf("Expected %d simulated keys, got %d", DefaultRingInfoKeys, info.SimulatedKeys)
+	}
+	if len(info.Nodes) != 3 {
+		t.Fatalf("Expected 3 nodes, got %d", len(info.Nodes))
+	}
+
+	totalKeys := 0
+	for i, node := range info.Nodes {
+		if want := fmt.Sprintf("node%d", i+1); node.ID != want {
+			t.Errorf("Position %d: expected %s, got %s", i, want, node.ID)
+		}
+		if node.VirtualNodes != 64 {
+			t.Errorf("%s: expected 64 virtual nodes, got %d", node.ID, node.VirtualNodes)
+		}
+		totalKeys += node.SimulatedKeys
+	}
+	if totalKeys != DefaultRingInfoKeys {
+		t.Errorf("Expected simulated keys to add up to %d, got %d", DefaultRingInfoKeys, totalKeys)
+	}
+
+	// Repeated calls are identical until membership changes
+	if again := registry.RingInfo(0); !reflect.DeepEqual(info, again) {
+		t.Errorf("RingInfo changed between calls:\n%+v\n%+v", info, again)
+	}
+
+	registry.UnregisterNode("node2")
+	after := registry.RingInfo(0)
+	if after.Generation == info.Generation || len(after.Nodes) != 2 {
+		t.Errorf("Expected a new generation with 2 nodes, got generation %d with %d nodes", after.Generation, len(after.Nodes))
+	}
+
+	if capped := registry.RingInfo(MaxRingInfoKeys + 1); capped.SimulatedKeys != MaxRingInfoKeys {
+		t.Errorf("Expected simulation capped at %d keys, got %d", MaxRingInfoKeys, capped.SimulatedKeys)
+	}
+}
+
+func TestNodeRegistry_MembershipInvalidatesPreferenceCache(t *testing.T) {
+	registry := NewNodeRegistry(64)
+	registry.hashRing.EnablePreferenceCache(DefaultPreferenceCacheSize)
+
+	registry.RegisterNode("node1", "localhost:50051")
+	registry.RegisterNode("node2", "localhost:50052")
+
+	before, _ := registry.hashRing.GetPreferenceList("user:1", 3)
+	if len(before) != 2 {
+		t.Fatalf("Expected 2 nodes before registration, got %v", before)
+	}
+
+	registry.RegisterNode("node3", "localhost:50053")
+	after, _ := registry.hashRing.GetPreferenceList("user:1", 3)
+	if len(after) != 3 {
+		t.Errorf("Expected 3 nodes after registration, got %v", after)
+	}
+
+	registry.UnregisterNode("node3")
+	final, _ := registry.hashRing.GetPreferenceList("user:1", 3)
+	for _, nodeID := range final {
+		if nodeID == "node3" {
+			t.Errorf("Unregistered node still in cached preference list: %v", final)
+		}
+	}
+}
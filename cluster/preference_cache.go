@@ -0,0 +1,114 @@
+package cluster
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	// DefaultPreferenceCacheSize is the default number of cached preference lists
+	DefaultPreferenceCacheSize = 1024
+)
+
+// preferenceCacheKey identifies a cached preference list
+type preferenceCacheKey struct {
+	key string
+	n   int
+}
+
+type preferenceCacheEntry struct {
+	cacheKey preferenceCacheKey
+	nodes    []string
+}
+
+// preferenceCache is a small LRU cache of key -> preference list.
+// Entries are only valid for the ring generation they were computed at;
+// when the ring changes the whole cache is dropped on the next access.
+type preferenceCache struct {
+	capacity   int
+	generation uint64
+	entries    map[preferenceCacheKey]*list.Element
+	order      *list.List // front = most recently used
+	mu         sync.Mutex
+}
+
+// newPreferenceCache creates a new LRU cache holding up to capacity lists
+func newPreferenceCache(capacity int) *preferenceCache {
+	if capacity <= 0 {
+		capacity = DefaultPreferenceCacheSize
+	}
+
+	return &preferenceCache{
+		capacity: capacity,
+		entries:  make(map[preferenceCacheKey]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// get returns a copy of the cached preference list if it was computed at
+// the given ring generation
+func (pc *preferenceCache) get(key string, n int, generation uint64) ([]string, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.syncGenerationLocked(generation)
+
+	elem, exists := pc.entries[preferenceCacheKey{key: key, n: n}]
+	if !exists {
+		return nil, false
+	}
+
+	pc.order.MoveToFront(elem)
+	nodes := elem.Value.(*preferenceCacheEntry).nodes
+	return append([]string(nil), nodes...), true
+}
+
+// put caches a preference list computed at the given ring generation
+func (pc *preferenceCache) put(key string, n int, generation uint64, nodes []string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.syncGenerationLocked(generation)
+	if generation != pc.generation {
+		return // Computed against an older ring
+	}
+
+	cacheKey := preferenceCacheKey{key: key, n: n}
+	if elem, exists := pc.entries[cacheKey]; exists {
+		elem.Value.(*preferenceCacheEntry).nodes = append([]string(nil), nodes...)
+		pc.order.MoveToFront(elem)
+		return
+	}
+
+	elem := pc.order.PushFront(&preferenceCacheEntry{
+		cacheKey: cacheKey,
+		nodes:    append([]string(nil), nodes...),
+	})
+	pc.entries[cacheKey] = elem
+
+	// Evict least recently used entries
+	for pc.order.Len() > pc.capacity {
+		oldest := pc.order.Back()
+		pc.order.Remove(oldest)
+		delete(pc.entries, oldest.Value.(*preferenceCacheEntry).cacheKey)
+	}
+}
+
+// len returns the number of cached lists
+func (pc *preferenceCache) len() int {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	return pc.order.Len()
+}
+
+// syncGenerationLocked drops all entries if the ring has moved to a newer
+// generation (must be called with lock held)
+func (pc *preferenceCache) syncGenerationLocked(generation uint64) {
+	if generation <= pc.generation {
+		return
+	}
+
+	pc.generation = generation
+	pc.entries = make(map[preferenceCacheKey]*list.Element)
+	pc.order.Init()
+}
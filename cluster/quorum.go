@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrInvalidQuorum is returned by SetQuorum for a W and R that would let a
+// read miss the latest write
+var ErrInvalidQuorum = errors.New("invalid quorum")
+
+// Quorum is the replication factor and the quorums a ClusterClient uses
+type Quorum struct {
+	N int // Replicas per key
+	W int // Replicas that must acknowledge a write
+	R int // Replicas that must answer a read
+}
+
+// Quorum returns the N, W and R in effect
+func (cc *ClusterClient) Quorum() Quorum {
+	return *cc.quorum.Load()
+}
+
+// SetQuorum changes W and R without a restart, e.g. raising W for a
+// critical batch of writes. Both must be between 1 and N, and W+R must
+// exceed N so every read quorum overlaps every write quorum; other values
+// fail with ErrInvalidQuorum. Requests already in flight keep the quorum
+// they started with.
+func (cc *ClusterClient) SetQuorum(w, r int) error {
+	n := cc.replicationFactor
+	if w < 1 || w > n || r < 1 || r > n {
+		return fmt.Errorf("%w: W=%d and R=%d must be between 1 and N=%d", ErrInvalidQuorum, w, r, n)
+	}
+	if w+r <= n {
+		return fmt.Errorf("%w: W+R=%d must exceed N=%d", ErrInvalidQuorum, w+r, n)
+	}
+
+	old := cc.quorum.Swap(&Quorum{N: n, W: w, R: r})
+	log.Printf("⚖️  Quorum changed: W %d → %d, R %d → %d (N=%d)", old.W, w, old.R, r, n)
+	return nil
+}
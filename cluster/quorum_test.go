@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestClusterClient_SetQuorum(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+
+	for _, bad := range []struct{ w, r int }{
+		{4, 2}, // W > N
+		{2, 0}, // R < 1
+		{1, 2}, // W+R = N
+	} {
+		if err := cc.SetQuorum(bad.w, bad.r); !errors.Is(err, ErrInvalidQuorum) {
+			t.Errorf("SetQuorum(%d, %d): expected ErrInvalidQuorum, got %v", bad.w, bad.r, err)
+		}
+	}
+	if q := cc.Quorum(); q != (Quorum{N: 3, W: 2, R: 2}) {
+		t.Fatalf("Rejected changes altered the quorum: %+v", q)
+	}
+
+	// With one replica down a W=2 write succeeds
+	nodes["node1"].setFailed(true)
+	if err := cc.Put(context.Background(), "user:1", []byte("alice")); err != nil {
+		t.Fatalf("Put with W=2 failed: %v", err)
+	}
+	cc.background.Wait()
+
+	// The next write needs every replica
+	if err := cc.SetQuorum(3, 1); err != nil {
+		t.Fatalf("SetQuorum(3, 1) failed: %v", err)
+	}
+	if q := cc.Quorum(); q != (Quorum{N: 3, W: 3, R: 1}) {
+		t.Errorf("Expected W=3 R=1, got %+v", q)
+	}
+
+	var quorumErr *ErrQuorumNotReached
+	err := cc.Put(context.Background(), "user:2", []byte("bob"))
+	if !errors.As(err, &quorumErr) || quorumErr.Required != 3 {
+		t.Fatalf("Expected a W=3 write to fail with a replica down, got %v", err)
+	}
+	cc.background.Wait()
+
+	// And a read needs only one
+	nodes["node2"].setFailed(true)
+	if value, err := cc.Get(context.Background(), "user:1"); err != nil || string(value) != "alice" {
+		t.Errorf("Expected an R=1 read to succeed, got %q (err: %v)", value, err)
+	}
+}
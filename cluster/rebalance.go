@@ -0,0 +1,191 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ringSize is the number of positions on the ring (hashes are uint32)
+const ringSize = 1 << 32
+
+// Range is an arc of the hash ring holding the keys whose hash h satisfies
+// Start < h <= End. An arc with Start >= End wraps past the top of the ring
+// through 0; Start == End is the whole ring.
+type Range struct {
+	Start uint32
+	End   uint32
+	From  string // Primary owner before the change ("" if the ring was empty)
+	To    string // Primary owner after the change ("" if the ring becomes empty)
+}
+
+// Fraction returns the share of the keyspace the range covers
+func (r Range) Fraction() float64 {
+	return float64(arcLength(r.Start, r.End)) / ringSize
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("(%d, %d] %s -> %s", r.Start, r.End, r.From, r.To)
+}
+
+// ringPoint is a virtual node position and its owner
+type ringPoint struct {
+	hash   uint32
+	nodeID string
+}
+
+// SimulateAddNode reports, without changing the ring, what adding nodeID
+// would do: the fraction of the keyspace whose primary node changes and the
+// hash ranges that move, in ring order with adjacent ranges merged. Replica
+// placement shifts along with the primaries but is not included. Adding a
+// node that is already in the ring moves nothing.
+func (hr *HashRing) SimulateAddNode(nodeID string) (float64, []Range) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	if hr.nodes[nodeID] {
+		return 0, nil
+	}
+	before := hr.pointsLocked()
+
+	after := append([]ringPoint(nil), before...)
+	for i := 0; i < hr.virtualNodes; i++ {
+		hash := hr.hashKey(fmt.Sprintf("%s-vnode-%d", nodeID, i))
+		after = append(after, ringPoint{hash: hash, nodeID: nodeID})
+	}
+
+	return diffRings(before, sortPoints(after))
+}
+
+// SimulateRemoveNode is SimulateAddNode for removing nodeID. Removing a node
+// that is not in the ring moves nothing.
+func (hr *HashRing) SimulateRemoveNode(nodeID string) (float64, []Range) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	if !hr.nodes[nodeID] {
+		return 0, nil
+	}
+	before := hr.pointsLocked()
+
+	after := make([]ringPoint, 0, len(before))
+	for _, point := range before {
+		if point.nodeID != nodeID {
+			after = append(after, point)
+		}
+	}
+
+	return diffRings(before, after)
+}
+
+// pointsLocked returns the ring's virtual nodes sorted by hash (must be
+// called with lock held)
+func (hr *HashRing) pointsLocked() []ringPoint {
+	points := make([]ringPoint, 0, len(hr.ring))
+	for hash, nodeID := range hr.ring {
+		points = append(points, ringPoint{hash: hash, nodeID: nodeID})
+	}
+	return sortPoints(points)
+}
+
+// sortPoints sorts points by hash. On a hash collision the later point
+// wins, as it does in AddNode.
+func sortPoints(points []ringPoint) []ringPoint {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].hash < points[j].hash
+	})
+
+	deduped := points[:0]
+	for _, point := range points {
+		if n := len(deduped); n > 0 && deduped[n-1].hash == point.hash {
+			deduped[n-1] = point
+			continue
+		}
+		deduped = append(deduped, point)
+	}
+	return deduped
+}
+
+// ownerOf returns the node owning hash on a sorted ring: the first virtual
+// node at or after it, wrapping around
+func ownerOf(points []ringPoint, hash uint32) string {
+	if len(points) == 0 {
+		return ""
+	}
+
+	idx := sort.Search(len(points), func(i int) bool {
+		return points[i].hash >= hash
+	})
+	if idx >= len(points) {
+		idx = 0
+	}
+	return points[idx].nodeID
+}
+
+// diffRings compares primary ownership between two sorted rings
+func diffRings(before, after []ringPoint) (float64, []Range) {
+	// Between consecutive boundaries of either ring, ownership is constant
+	// in both, so each arc either moved entirely or not at all
+	boundaries := make([]uint32, 0, len(before)+len(after))
+	for _, point := range before {
+		boundaries = append(boundaries, point.hash)
+	}
+	for _, point := range after {
+		boundaries = append(boundaries, point.hash)
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+	boundaries = dedupHashes(boundaries)
+
+	if len(boundaries) == 0 {
+		return 0, nil
+	}
+
+	var moved []Range
+	for i, end := range boundaries {
+		// The first arc wraps around from the last boundary
+		start := boundaries[(i+len(boundaries)-1)%len(boundaries)]
+
+		from, to := ownerOf(before, end), ownerOf(after, end)
+		if from == to {
+			continue
+		}
+
+		// Merge with the previous arc if it moved the same way
+		if n := len(moved); n > 0 && moved[n-1].End == start && moved[n-1].From == from && moved[n-1].To == to {
+			moved[n-1].End = end
+			continue
+		}
+		moved = append(moved, Range{Start: start, End: end, From: from, To: to})
+	}
+
+	// The last arc may continue into the first one through 0
+	if n := len(moved); n > 1 && moved[n-1].End == moved[0].Start && moved[n-1].From == moved[0].From && moved[n-1].To == moved[0].To {
+		moved[0].Start = moved[n-1].Start
+		moved = moved[:n-1]
+	}
+
+	var length uint64
+	for _, r := range moved {
+		length += arcLength(r.Start, r.End)
+	}
+
+	return float64(length) / ringSize, moved
+}
+
+// dedupHashes removes repeats from a sorted slice
+func dedupHashes(hashes []uint32) []uint32 {
+	deduped := hashes[:0]
+	for _, hash := range hashes {
+		if n := len(deduped); n == 0 || deduped[n-1] != hash {
+			deduped = append(deduped, hash)
+		}
+	}
+	return deduped
+}
+
+// arcLength returns the number of hashes in (start, end]
+func arcLength(start, end uint32) uint64 {
+	if start < end {
+		return uint64(end - start)
+	}
+	return ringSize - uint64(start-end)
+}
@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHashRing_SimulateKnownPositions(t *testing.T) {
+	// One virtual node per physical node at known positions: A=100, B=200, C=300
+	positions := map[string]uint32{
+		"nodeA-vnode-0": 100,
+		"nodeB-vnode-0": 200,
+		"nodeC-vnode-0": 300,
+		"nodeD-vnode-0": 150,
+	}
+	hashFn := func(key string) uint32 { return positions[key] }
+
+	ring := NewHashRing(1, WithHashFunc(hashFn))
+	ring.AddNode("nodeA")
+	ring.AddNode("nodeB")
+	ring.AddNode("nodeC")
+
+	// D at 150 takes (100, 150] from B
+	fraction, ranges := ring.SimulateAddNode("nodeD")
+	if len(ranges) != 1 || ranges[0] != (Range{Start: 100, End: 150, From: "nodeB", To: "nodeD"}) {
+		t.Errorf("SimulateAddNode: unexpected ranges %v", ranges)
+	}
+	if want := 50.0 / ringSize; fraction != want {
+		t.Errorf("SimulateAddNode: expected fraction %g, got %g", want, fraction)
+	}
+
+	// Removing A hands (300, 100], which wraps through 0, to B
+	fraction, ranges = ring.SimulateRemoveNode("nodeA")
+	if len(ranges) != 1 || ranges[0] != (Range{Start: 300, End: 100, From: "nodeA", To: "nodeB"}) {
+		t.Errorf("SimulateRemoveNode: unexpected ranges %v", ranges)
+	}
+	if want := float64(ringSize-200) / ringSize; fraction != want {
+		t.Errorf("SimulateRemoveNode: expected fraction %g, got %g", want, fraction)
+	}
+
+	// No-ops
+	if fraction, ranges := ring.SimulateAddNode("nodeA"); fraction != 0 || ranges != nil {
+		t.Errorf("Adding an existing node: got %g, %v", fraction, ranges)
+	}
+	if fraction, ranges := ring.SimulateRemoveNode("nodeD"); fraction != 0 || ranges != nil {
+		t.Errorf("Removing a missing node: got %g, %v", fraction, ranges)
+	}
+
+	// The first node of an empty ring takes the whole keyspace
+	empty := NewHashRing(1, WithHashFunc(hashFn))
+	fraction, ranges = empty.SimulateAddNode("nodeA")
+	if fraction != 1 || len(ranges) != 1 || ranges[0].From != "" || ranges[0].Start != ranges[0].End {
+		t.Errorf("Adding to an empty ring: got %g, %v", fraction, ranges)
+	}
+}
+
+func TestHashRing_SimulateMatchesActualMoves(t *testing.T) {
+	ring := NewHashRing(256)
+	ring.AddNode("node1")
+	ring.AddNode("node2")
+	ring.AddNode("node3")
+	generation := ring.Generation()
+
+	removeFraction, removeRanges := ring.SimulateRemoveNode("node2")
+	addFraction, addRanges := ring.SimulateAddNode("node4")
+
+	// Simulating doesn't change the ring
+	if ring.Generation() != generation || ring.GetNodeCount() != 3 {
+		t.Fatal("Simulation modified the ring")
+	}
+
+	for _, r := range removeRanges {
+		if r.From != "node2" || r.To == "node2" {
+			t.Errorf("Removal moved an unexpected range: %v", r)
+		}
+	}
+	for _, r := range addRanges {
+		if r.To != "node4" {
+			t.Errorf("Addition moved an unexpected range: %v", r)
+		}
+	}
+
+	// The predicted fractions match what actually happens to sample keys
+	measure := func(change func(*HashRing)) float64 {
+		changed := NewHashRing(256)
+		for _, node := range ring.GetNodes() {
+			changed.AddNode(node)
+		}
+		change(changed)
+
+		const numKeys = 20000
+		moved := 0
+		for i := 0; i < numKeys; i++ {
+			key := fmt.Sprintf("key_%d", i)
+			before, _ := ring.GetNode(key)
+			after, _ := changed.GetNode(key)
+			if before != after {
+				moved++
+			}
+		}
+		return float64(moved) / numKeys
+	}
+
+	removeActual := measure(func(r *HashRing) { r.RemoveNode("node2") })
+	addActual := measure(func(r *HashRing) { r.AddNode("node4") })
+	t.Logf("Remove node2: predicted %.2f%%, measured %.2f%%", removeFraction*100, removeActual*100)
+	t.Logf("Add node4: predicted %.2f%%, measured %.2f%%", addFraction*100, addActual*100)
+
+	if math.Abs(removeFraction-removeActual) > 0.02 {
+		t.Errorf("Remove: predicted %.4f, measured %.4f", removeFraction, removeActual)
+	}
+	if math.Abs(addFraction-addActual) > 0.02 {
+		t.Errorf("Add: predicted %.4f, measured %.4f", addFraction, addActual)
+	}
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import "sync"
+
+// SetMaxConcurrentReplicaCalls caps how many replica calls Put, Get, Delete
+// and RepairKey run at once, across all requests. Each request still sends
+// to all of its replicas straight away, but the calls run on n shared
+// workers and the ones beyond them wait their turn in order, so the number
+// of goroutines stays bounded however many requests are in flight. A request
+// still returns as soon as its quorum answers; its remaining calls run when
+// a worker frees up.
+//
+// n <= 0 removes the cap (the default): every replica call gets its own
+// goroutine.
+func (cc *ClusterClient) SetMaxConcurrentReplicaCalls(n int) {
+	var pool *replicaPool
+	if n > 0 {
+		pool = newReplicaPool(n)
+	}
+	if old := cc.replicaCalls.Swap(pool); old != nil {
+		old.close()
+	}
+}
+
+// goReplica runs one replica call on the worker pool, or on a goroutine of
+// its own when there is no cap
+func (cc *ClusterClient) goReplica(call func()) {
+	if pool := cc.replicaCalls.Load(); pool != nil && pool.submit(call) {
+		return
+	}
+	go call()
+}
+
+// replicaPool runs calls on a fixed set of workers, queueing the rest
+type replicaPool struct {
+	mu     sync.Mutex
+	cond   *sync.Cond // Signalled when a call is queued or the pool closes
+	queue  []func()
+	closed bool
+}
+
+// newReplicaPool starts a pool with the given number of workers
+func newReplicaPool(workers int) *replicaPool {
+	p := &replicaPool{}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// submit queues a call. It returns false once the pool is closed.
+func (p *replicaPool) submit(call func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.queue = append(p.queue, call)
+	p.cond.Signal()
+	return true
+}
+
+// close stops taking calls; the workers exit once the queue is empty
+func (p *replicaPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	p.cond.Broadcast()
+}
+
+// work runs queued calls until the pool is closed and drained
+func (p *replicaPool) work() {
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if len(p.queue) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		call := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+
+		call()
+	}
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyGauge tracks how many calls are in flight and the peak
+type concurrencyGauge struct {
+	active atomic.Int64
+	peak   atomic.Int64
+}
+
+func (g *concurrencyGauge) enter() {
+	n := g.active.Add(1)
+	for {
+		peak := g.peak.Load()
+		if n <= peak || g.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+func (g *concurrencyGauge) leave() {
+	g.active.Add(-1)
+}
+
+func TestClusterClient_MaxConcurrentReplicaCalls(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 3)
+	cc.SetMaxConcurrentReplicaCalls(2)
+
+	gauge := &concurrencyGauge{}
+	for _, node := range nodes {
+		node.gauge = gauge
+		node.setPutDelay(20 * time.Millisecond)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- cc.Put(context.Background(), fmt.Sprintf("key:%d", i), []byte("v"))
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Put failed: %v", err)
+		}
+	}
+
+	// Every replica got every write, at most two at a time
+	cc.background.Wait()
+	waitForPuts := func() bool {
+		for _, node := range nodes {
+			if node.putCount() != 10 {
+				return false
+			}
+		}
+		return true
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for !waitForPuts() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !waitForPuts() {
+		t.Error("Expected the queued replica calls to reach every node")
+	}
+	if peak := gauge.peak.Load(); peak > 2 {
+		t.Errorf("Expected at most 2 replica calls in flight, saw %d", peak)
+	}
+
+	// Lifting the cap goes back to a goroutine per call
+	cc.SetMaxConcurrentReplicaCalls(0)
+	if err := cc.Put(context.Background(), "key:uncapped", []byte("v")); err != nil {
+		t.Errorf("Put without a cap failed: %v", err)
+	}
+}
+
+// Compare peak goroutines and throughput with and without a cap while many
+// clients write at once:
+//
+//	go test ./cluster -run '^$' -bench ReplicaFanOut
+func BenchmarkClusterClient_ReplicaFanOut(b *testing.B) {
+	for _, limit := range []int{0, 16} {
+		name := "uncapped"
+		if limit > 0 {
+			name = fmt.Sprintf("cap=%d", limit)
+		}
+		b.Run(name, func(b *testing.B) {
+			cc, nodes := startFakeCluster(b, 3)
+			cc.SetMaxConcurrentReplicaCalls(limit)
+			for _, node := range nodes {
+				node.setPutDelay(time.Millisecond)
+			}
+
+			var peak atomic.Int64
+			stop := make(chan struct{})
+			sampled := make(chan struct{})
+			go func() {
+				defer close(sampled)
+				ticker := time.NewTicker(time.Millisecond)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-stop:
+						return
+					case <-ticker.C:
+						if n := int64(runtime.NumGoroutine()); n > peak.Load() {
+							peak.Store(n)
+						}
+					}
+				}
+			}()
+
+			var seq atomic.Int64
+			b.SetParallelism(64)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					key := fmt.Sprintf("key:%d", seq.Add(1))
+					if err := cc.Put(context.Background(), key, []byte("v")); err != nil {
+						b.Errorf("Put failed: %v", err)
+					}
+				}
+			})
+			b.StopTimer()
+
+			close(stop)
+			<-sampled
+			b.ReportMetric(float64(peak.Load()), "peak-goroutines")
+			cc.background.Wait()
+		})
+	}
+}
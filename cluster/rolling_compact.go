@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"kvstore/proto"
+)
+
+// RollingCompactTimeout bounds one node's compaction during RollingCompact
+const RollingCompactTimeout = 30 * time.Minute
+
+// RollingCompact compacts every node in the cluster one at a time, waiting
+// for each node's Compact RPC to finish before starting the next, so at most
+// one replica of any key is busy compacting and the others keep serving
+// reads at full speed. Nodes go in node ID order; this node's own store, if
+// set with SetLocalStore, is compacted directly.
+//
+// A node that fails to compact doesn't stop the others; the errors of every
+// node that failed are returned together. Each node's own periodic
+// compaction still runs on its schedule.
+func (cc *ClusterClient) RollingCompact() error {
+	nodes := cc.registry.GetAllNodes()
+	nodeIDs := make([]string, len(nodes))
+	for i, node := range nodes {
+		nodeIDs[i] = node.ID
+	}
+	sort.Strings(nodeIDs)
+
+	log.Printf("🔄 ROLLING COMPACT: %d nodes, one at a time", len(nodeIDs))
+
+	var errs []error
+	for i, nodeID := range nodeIDs {
+		start := time.Now()
+		if err := cc.compactNode(nodeID); err != nil {
+			log.Printf("❌ ROLLING COMPACT %s (%d/%d) failed: %v", nodeID, i+1, len(nodeIDs), err)
+			errs = append(errs, fmt.Errorf("compact %s: %w", nodeID, err))
+			continue
+		}
+		log.Printf("✅ ROLLING COMPACT %s (%d/%d) done in %v", nodeID, i+1, len(nodeIDs), time.Since(start).Round(time.Millisecond))
+	}
+
+	return errors.Join(errs...)
+}
+
+// compactNode runs a compaction on one node and waits for it to finish
+func (cc *ClusterClient) compactNode(nodeID string) error {
+	if store, local := cc.localStoreFor(nodeID); local {
+		return store.CompactionManager().ForceCompact()
+	}
+
+	client, exists := cc.getClient(nodeID)
+	if !exists {
+		return fmt.Errorf("no client for node")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), RollingCompactTimeout)
+	defer cancel()
+	resp, err := client.Compact(ctx, &proto.CompactRequest{})
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterClient_RollingCompact(t *testing.T) {
+	cc, nodes := startFakeCluster(t, 4)
+
+	gauge := &concurrencyGauge{}
+	for _, node := range nodes {
+		node.gauge = gauge
+	}
+
+	if err := cc.RollingCompact(); err != nil {
+		t.Fatalf("RollingCompact failed: %v", err)
+	}
+	if peak := gauge.peak.Load(); peak != 1 {
+		t.Errorf("Expected one compaction at a time, saw %d at once", peak)
+	}
+	for nodeID, node := range nodes {
+		if node.compacts != 1 {
+			t.Errorf("%s: expected 1 compaction, got %d", nodeID, node.compacts)
+		}
+	}
+
+	// A failing node is reported without holding up the rest
+	nodes["node2"].setFailed(true)
+	err := cc.RollingCompact()
+	if err == nil || !strings.Contains(err.Error(), "node2") || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Expected node2's failure to be reported, got %v", err)
+	}
+	for nodeID, node := range nodes {
+		if node.compacts != 2 {
+			t.Errorf("%s: expected 2 compactions, got %d", nodeID, node.compacts)
+		}
+	}
+	if peak := gauge.peak.Load(); peak != 1 {
+		t.Errorf("Expected one compaction at a time, saw %d at once", peak)
+	}
+}
@@ -0,0 +1,58 @@
+package cluster
+
+// Router maps keys to the nodes that own them, for thin clients that skip
+// the ClusterClient's replication and send each request straight to a
+// key's owner, e.g. with client.NewKVClient(addr). Built with the same
+// virtual node count as the cluster's NodeRegistry, it routes every key to
+// the node the registry would. A Router is safe for concurrent use.
+type Router struct {
+	addresses map[string]string // nodeID -> address
+	hashRing  *HashRing
+}
+
+// Route is a node a key is routed to
+type Route struct {
+	NodeID  string
+	Address string
+}
+
+// NewRouter creates a router over the nodes in addresses (nodeID ->
+// address). virtualNodes and opts configure the hash ring and must match
+// the cluster's for routes to agree with it.
+func NewRouter(addresses map[string]string, virtualNodes int, opts ...HashRingOption) *Router {
+	r := &Router{
+		addresses: make(map[string]string, len(addresses)),
+		hashRing:  NewHashRing(virtualNodes, opts...),
+	}
+	for nodeID, address := range addresses {
+		r.addresses[nodeID] = address
+		r.hashRing.AddNode(nodeID)
+	}
+	return r
+}
+
+// OwnerFor returns the node that owns key (the first of its replicas) and
+// its address. It returns ErrEmptyRing if the router has no nodes.
+func (r *Router) OwnerFor(key string) (nodeID, addr string, err error) {
+	nodeID, err = r.hashRing.GetNode(key)
+	if err != nil {
+		return "", "", err
+	}
+	return nodeID, r.addresses[nodeID], nil
+}
+
+// ReplicasFor returns the first n nodes holding key, owner first, in the
+// same order as the cluster's preference list. Fewer are returned if the
+// router has fewer than n nodes, and ErrEmptyRing if it has none.
+func (r *Router) ReplicasFor(key string, n int) ([]Route, error) {
+	nodeIDs, err := r.hashRing.GetPreferenceList(key, n)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]Route, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		routes[i] = Route{NodeID: nodeID, Address: r.addresses[nodeID]}
+	}
+	return routes, nil
+}
@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRouter_AgreesWithNodeRegistry(t *testing.T) {
+	addresses := map[string]string{
+		"node1": "localhost:50051",
+		"node2": "localhost:50052",
+		"node3": "localhost:50053",
+		"node4": "localhost:50054",
+	}
+
+	registry := NewNodeRegistry(64)
+	for nodeID, address := range addresses {
+		if err := registry.RegisterNode(nodeID, address); err != nil {
+			t.Fatalf("Failed to register %s: %v", nodeID, err)
+		}
+	}
+	router := NewRouter(addresses, 64)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+
+		want, err := registry.GetNodeForKey(key)
+		if err != nil {
+			t.Fatalf("GetNodeForKey(%s) failed: %v", key, err)
+		}
+		nodeID, addr, err := router.OwnerFor(key)
+		if err != nil {
+			t.Fatalf("OwnerFor(%s) failed: %v", key, err)
+		}
+		if nodeID != want.ID || addr != want.Address {
+			t.Fatalf("OwnerFor(%s) = %s at %s, registry says %s at %s", key, nodeID, addr, want.ID, want.Address)
+		}
+
+		replicas, err := router.ReplicasFor(key, 3)
+		if err != nil {
+			t.Fatalf("ReplicasFor(%s) failed: %v", key, err)
+		}
+		wantIDs, _ := registry.hashRing.GetPreferenceList(key, 3)
+		var gotIDs []string
+		for _, route := range replicas {
+			gotIDs = append(gotIDs, route.NodeID)
+			if route.Address != addresses[route.NodeID] {
+				t.Errorf("ReplicasFor(%s): %s routed to %s", key, route.NodeID, route.Address)
+			}
+		}
+		if !reflect.DeepEqual(gotIDs, wantIDs) {
+			t.Fatalf("ReplicasFor(%s) = %v, registry preference list is %v", key, gotIDs, wantIDs)
+		}
+		if gotIDs[0] != nodeID {
+			t.Fatalf("ReplicasFor(%s) starts at %s, owner is %s", key, gotIDs[0], nodeID)
+		}
+	}
+}
+
+func TestRouter_Empty(t *testing.T) {
+	router := NewRouter(nil, 64)
+
+	if _, _, err := router.OwnerFor("key"); !errors.Is(err, ErrEmptyRing) {
+		t.Errorf("Expected ErrEmptyRing from OwnerFor, got %v", err)
+	}
+	if _, err := router.ReplicasFor("key", 3); !errors.Is(err, ErrEmptyRing) {
+		t.Errorf("Expected ErrEmptyRing from ReplicasFor, got %v", err)
+	}
+}
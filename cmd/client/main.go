@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"kvstore/client"
 	"kvstore/proto"
@@ -27,6 +29,13 @@ func main() {
 	}
 	defer kvClient.Close()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err = kvClient.WaitForReady(ctx)
+	cancel()
+	if err != nil {
+		log.Fatalf("❌ Failed to connect: %v", err)
+	}
+
 	log.Println("✅ Connected to server")
 	log.Println()
 	printHelp()
@@ -93,6 +102,50 @@ func main() {
 				fmt.Println("🗑️  Deleted")
 			}
 
+		case "SCAN", "KEYS":
+			if len(parts) > 3 {
+				fmt.Printf("Usage: %s [start] [end]\n", cmd)
+				continue
+			}
+			var start, end string
+			if len(parts) > 1 {
+				start = parts[1]
+			}
+			if len(parts) > 2 {
+				end = parts[2]
+			}
+
+			if cmd == "KEYS" {
+				keys, err := kvClient.ScanKeys(start, end)
+				if err != nil {
+					fmt.Printf("❌ Error: %v\n", err)
+					continue
+				}
+				for _, key := range keys {
+					fmt.Printf("🔑 %s\n", key)
+				}
+				fmt.Printf("(%d keys)\n", len(keys))
+				continue
+			}
+
+			entries, err := kvClient.Scan(start, end)
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+				continue
+			}
+			for _, entry := range entries {
+				fmt.Printf("📦 %s = %s\n", entry.Key, entry.Value)
+			}
+			fmt.Printf("(%d entries)\n", len(entries))
+
+		case "PING":
+			rtt, err := kvClient.Ping()
+			if err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+			} else {
+				fmt.Printf("🏓 Pong in %v\n", rtt)
+			}
+
 		case "STATS":
 			stats, err := kvClient.Stats()
 			if err != nil {
@@ -109,6 +162,13 @@ func main() {
 				fmt.Println("✅ Compaction completed")
 			}
 
+		case "SYNC":
+			if err := kvClient.Sync(); err != nil {
+				fmt.Printf("❌ Error: %v\n", err)
+			} else {
+				fmt.Println("💾 WAL synced to disk")
+			}
+
 		case "HELP":
 			printHelp()
 
@@ -146,8 +206,12 @@ func printHelp() {
   PUT <key> <value>    Store a key-value pair
   GET <key>            Retrieve value by key
   DELETE <key>         Delete a key
+  SCAN [start] [end]   List key-value pairs in [start, end)
+  KEYS [start] [end]   List keys in [start, end) without values
+  PING                 Measure round-trip latency to the server
   STATS                Show server statistics
   COMPACT              Trigger manual compaction
+  SYNC                 Fsync the server's WAL (durability barrier)
   HELP                 Show this help message
   QUIT / EXIT          Disconnect from server
 `
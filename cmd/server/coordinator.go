@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"kvstore/cluster"
+	"kvstore/storage"
+)
+
+// coordinatorRetryInterval is how long to wait before dialing the peers again
+const coordinatorRetryInterval = 5 * time.Second
+
+// errCoordinatorConnecting fails Puts until the peers have been reached
+var errCoordinatorConnecting = errors.New("coordinator is still connecting to its peers")
+
+// parsePeers parses "id=host:port,id=host:port" into node ID -> address
+func parsePeers(spec string) (map[string]string, error) {
+	peers := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, addr, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || addr == "" {
+			return nil, fmt.Errorf("invalid peer %q: want id=host:port", entry)
+		}
+		if _, dup := peers[id]; dup {
+			return nil, fmt.Errorf("peer %s listed twice", id)
+		}
+		peers[id] = addr
+	}
+	if len(peers) == 0 {
+		return nil, errors.New("no peers listed")
+	}
+	return peers, nil
+}
+
+// pendingCoordinator is the server's coordinator while the ClusterClient
+// connects. Peers started at the same time are not serving yet, so the
+// ClusterClient is built after this server starts serving, and Puts fail
+// rather than being written to this node alone until it is ready.
+type pendingCoordinator struct {
+	cc atomic.Pointer[cluster.ClusterClient]
+}
+
+// PutWithResult implements server.Coordinator
+func (p *pendingCoordinator) PutWithResult(ctx context.Context, key string, value []byte) (*cluster.WriteResult, error) {
+	cc := p.cc.Load()
+	if cc == nil {
+		return nil, errCoordinatorConnecting
+	}
+	return cc.PutWithResult(ctx, key, value)
+}
+
+// connect dials the peers until they all answer, then registers this node
+// (served from store, without a connection to itself) and starts
+// coordinating. It runs until it succeeds.
+func (p *pendingCoordinator) connect(selfID, selfAddr string, peers map[string]string, store *storage.LSMStore) {
+	for {
+		cc, err := cluster.NewClusterClient(peers)
+		if err == nil {
+			if err = cc.GetRegistry().RegisterNode(selfID, selfAddr); err == nil {
+				cc.SetLocalStore(selfID, store)
+				p.cc.Store(cc)
+				log.Printf("🧭 Coordinator mode: replicating Puts across %s and %d peer(s)", selfID, len(peers))
+				return
+			}
+			cc.Close()
+		}
+		log.Printf("⚠️  Coordinator: %v; retrying in %v", err, coordinatorRetryInterval)
+		time.Sleep(coordinatorRetryInterval)
+	}
+}
+
+// Close closes the ClusterClient if it connected
+func (p *pendingCoordinator) Close() {
+	if cc := p.cc.Load(); cc != nil {
+		cc.Close()
+	}
+}
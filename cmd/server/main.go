@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"net"
 	"os"
 	"os/signal"
@@ -12,43 +13,210 @@ import (
 	"kvstore/proto"
 	"kvstore/server"
 	"kvstore/storage"
+	"kvstore/tracing"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	// Command-line flags
 	port := flag.Int("port", 50051, "Port to listen on")
 	dataDir := flag.String("data", "./data", "Directory for storing data files")
+	walDir := flag.String("wal-dir", "", "Directory for the WAL, e.g. on a fast disk (default: -data)")
+	sstableDir := flag.String("sstable-dir", "", "Directory for SSTables, e.g. on bulk storage (default: -data)")
+	nodeID := flag.String("node-id", "", "Node ID reported to clients (default: node-<port>)")
+	logFormatFlag := flag.String("log-format", "text", "Request log format: text or json")
+	enableReflection := flag.Bool("reflection", true, "Serve gRPC reflection (lets grpcurl list and call RPCs without the proto files)")
+	busyCeilingMB := flag.Int64("busy-ceiling-mb", 0, "Reject writes with ResourceExhausted while flushing once the MemTable reaches this size (0 blocks instead)")
+	tombstoneTTL := flag.Duration("tombstone-ttl", storage.DefaultTombstoneTTL, "Keep tombstones this long before compaction purges them")
+	readOnly := flag.Bool("read-only", false, "Serve reads only; Put, Delete, WriteBatch and Compact fail with FailedPrecondition")
+	allowTruncate := flag.Bool("allow-truncate", false, "Enable the admin Truncate RPC, which deletes every key (test and development servers only)")
+	maxMessageMB := flag.Int("max-message-mb", proto.DefaultMaxMessageSize/(1024*1024), "Largest gRPC message the server sends or accepts; bigger values need PutStream/GetStream")
+	walSyncInterval := flag.Duration("wal-sync-interval", 0, "Fsync the WAL in the background this often, bounding writes lost to a machine crash (0: only on Sync and flush)")
+	maxMemTableAge := flag.Duration("max-memtable-age", storage.DefaultMaxMemTableAge, "Flush the MemTable once its oldest write is this old (0 disables)")
+	traceExporter := flag.String("trace-exporter", "none", "Export request trace spans: none or log (JSON lines on stderr)")
+	rebuildSSTable := flag.String("rebuild-sstable", "", "Rebuild the index, bloom filter and footer of a damaged SSTable file, then exit (run with the server stopped)")
+	rateLimit := flag.Float64("rate-limit", 0, "Requests per second each client (peer address) may send; more fail with ResourceExhausted (0 disables)")
+	rateLimitBurst := flag.Int("rate-limit-burst", 0, "Requests a client may send at once above -rate-limit (default: -rate-limit, rounded up)")
+	coordinatorPeers := flag.String("coordinator-peers", "", "Coordinator mode: replicate each client Put to the other nodes, given as id=host:port,... (with -node-id naming this one)")
+	hotKeyThreshold := flag.Float64("hot-key-threshold", 0, "Log the busiest keys when one averages more than this many reads and writes per second over 10s (0 disables)")
+	storeBloom := flag.Bool("store-bloom", false, "Keep one bloom filter over every SSTable's keys so lookups of absent keys skip the SSTables (about 2.4 bytes of memory per key)")
+	verifyChecksums := flag.Bool("verify-checksums", true, "Validate SSTable record checksums on every read; false reads faster but may return damaged values (Get can override per request)")
+	socketPath := flag.String("socket", "", "Also serve on this Unix domain socket, for clients on the same host (with -port 0, serve only on it)")
+	slowRequestThreshold := flag.Duration("slow-request-threshold", server.DefaultSlowRequestThreshold, "Log a warning for any request taking longer than this (0 disables)")
+	verify := flag.Bool("verify", false, "Check the integrity of every SSTable in -sstable-dir (default: -data), then exit (run with the server stopped)")
 	flag.Parse()
 
+	// Admin mode: repair one table offline and exit without serving
+	if *rebuildSSTable != "" {
+		if err := storage.RebuildSSTable(*rebuildSSTable); err != nil {
+			log.Fatalf("❌ Failed to rebuild SSTable: %v", err)
+		}
+		log.Printf("✅ SSTable %s rebuilt", *rebuildSSTable)
+		return
+	}
+
+	// Admin mode: check every table offline and exit without serving
+	if *verify {
+		dir := *sstableDir
+		if dir == "" {
+			dir = *dataDir
+		}
+		report, err := storage.VerifySSTableDir(dir)
+		if err != nil {
+			log.Fatalf("❌ Failed to verify SSTables: %v", err)
+		}
+		for _, table := range report.Tables {
+			if table.OK() {
+				log.Printf("✅ %s: %d entries, format v%d", table.Path, table.Entries, table.Version)
+				continue
+			}
+			log.Printf("❌ %s:", table.Path)
+			for _, problem := range table.Problems {
+				log.Printf("     %s", problem)
+			}
+		}
+		if !report.OK() {
+			log.Fatalf("❌ %d of %d SSTables are corrupt", report.Corrupt, len(report.Tables))
+		}
+		log.Printf("✅ All %d SSTables verified", len(report.Tables))
+		return
+	}
+
+	logFormat, err := server.ParseLogFormat(*logFormatFlag)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	exporter, err := tracing.ParseExporter(*traceExporter)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
 	printBanner()
 
 	// Create LSM store
 	log.Printf("📁 Initializing data directory: %s", *dataDir)
-	store, err := storage.NewLSMStore(*dataDir)
+	store, err := storage.NewLSMStoreWithOptions(*dataDir, storage.StoreOptions{
+		WALDir:     *walDir,
+		SSTableDir: *sstableDir,
+		StoreBloom: *storeBloom,
+	})
 	if err != nil {
 		log.Fatalf("❌ Failed to create store: %v", err)
 	}
 	defer store.Close()
+	store.SetMaxMemTableAge(*maxMemTableAge)
+	store.SetTombstoneTTL(*tombstoneTTL)
+	store.SetNonBlockingWrites(*busyCeilingMB * 1024 * 1024)
+	store.SetWALSyncInterval(*walSyncInterval)
+	store.SetHotKeyThreshold(*hotKeyThreshold)
+	store.SetVerifyChecksumsOnRead(*verifyChecksums)
 
 	log.Println("✅ LSM Store initialized")
-	log.Printf("💾 MemTable threshold: 64MB")
+	if *walDir != "" {
+		log.Printf("📁 WAL directory: %s", *walDir)
+	}
+	if *sstableDir != "" {
+		log.Printf("📁 SSTable directory: %s", *sstableDir)
+	}
+	log.Printf("💾 MemTable threshold: 64MB, max age: %v", *maxMemTableAge)
 	log.Printf("🔄 Compaction: Enabled")
+	if *walSyncInterval > 0 {
+		log.Printf("💾 Background WAL fsync every %v", *walSyncInterval)
+	}
+	if !*verifyChecksums {
+		log.Println("⚠️  Record checksums are not validated on reads")
+	}
+	if *hotKeyThreshold > 0 {
+		log.Printf("🔥 Hot key logging above %g ops/s per key", *hotKeyThreshold)
+	}
 
 	// Create gRPC server
-	grpcServer := grpc.NewServer()
+	maxMessageSize := *maxMessageMB * 1024 * 1024
+	serverOpts := append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	}, tracing.ServerOptions()...)
+	if *rateLimit > 0 {
+		burst := *rateLimitBurst
+		if burst <= 0 {
+			burst = int(math.Ceil(*rateLimit))
+		}
+		serverOpts = append(serverOpts, server.NewRateLimiter(*rateLimit, burst).ServerOptions()...)
+		log.Printf("🚦 Rate limit: %g requests/s per client, burst %d", *rateLimit, burst)
+	}
+	logger := server.NewLogger(logFormat, os.Stderr)
+	if *slowRequestThreshold > 0 {
+		serverOpts = append(serverOpts, server.NewSlowRequestLogger(*slowRequestThreshold, logger).ServerOptions()...)
+		log.Printf("🐢 Logging requests slower than %v", *slowRequestThreshold)
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
+	log.Printf("📦 Max gRPC message size: %dMB", *maxMessageMB)
+	if exporter != nil {
+		tracing.SetExporter(exporter)
+		log.Printf("🔭 Tracing: exporting spans to %s", *traceExporter)
+	}
 	kvServer := server.NewGRPCServer(store)
+	if *nodeID == "" {
+		*nodeID = fmt.Sprintf("node-%d", *port)
+	}
+	kvServer.SetNodeID(*nodeID)
+	kvServer.SetLogger(logger)
+	if *readOnly {
+		kvServer.SetReadOnly(true)
+		log.Println("🔒 Read-only mode: writes are rejected")
+	}
+	var coordinator *pendingCoordinator
+	var peers map[string]string
+	if *coordinatorPeers != "" {
+		if peers, err = parsePeers(*coordinatorPeers); err != nil {
+			log.Fatalf("❌ Invalid -coordinator-peers: %v", err)
+		}
+		if _, self := peers[*nodeID]; self {
+			log.Fatalf("❌ Invalid -coordinator-peers: lists this node (%s); list only the other nodes", *nodeID)
+		}
+		coordinator = &pendingCoordinator{}
+		kvServer.SetCoordinator(coordinator)
+	}
+	if *allowTruncate {
+		kvServer.SetAllowTruncate(true)
+		log.Println("🧹 Truncate RPC enabled: any client can delete every key")
+	}
 	proto.RegisterKVStoreServer(grpcServer, kvServer)
 
-	// Listen on TCP port
-	addr := fmt.Sprintf(":%d", *port)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		log.Fatalf("❌ Failed to listen on %s: %v", addr, err)
+	// Reflection only describes the service schema; calls made through it go
+	// through the same handlers as any other client, so it exposes no data
+	// beyond what the normal RPCs already allow
+	if *enableReflection {
+		reflection.Register(grpcServer)
+		log.Println("🔎 gRPC reflection: Enabled")
 	}
 
-	log.Printf("🚀 gRPC Server listening on %s", addr)
+	// Listen on TCP port, and/or a Unix socket
+	var listeners []net.Listener
+	if *port != 0 || *socketPath == "" {
+		addr := fmt.Sprintf(":%d", *port)
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("❌ Failed to listen on %s: %v", addr, err)
+		}
+		listeners = append(listeners, listener)
+		log.Printf("🚀 gRPC Server listening on %s", addr)
+	} else if coordinator != nil {
+		log.Fatalf("❌ -coordinator-peers needs a TCP port for peers to reach this node")
+	}
+	if *socketPath != "" {
+		listener, err := server.ListenUnix(*socketPath)
+		if err != nil {
+			log.Fatalf("❌ Failed to listen on socket %s: %v", *socketPath, err)
+		}
+		listeners = append(listeners, listener)
+		log.Printf("🚀 gRPC Server listening on unix:%s", *socketPath)
+	}
+	if coordinator != nil {
+		go coordinator.connect(*nodeID, fmt.Sprintf("localhost:%d", *port), peers, store)
+	}
 	log.Println("📡 Ready to accept connections...")
 	log.Println()
 	log.Println("Connect using: ./client -server localhost:50051")
@@ -63,13 +231,24 @@ func main() {
 		log.Println()
 		log.Println("🛑 Shutting down gracefully...")
 		grpcServer.GracefulStop()
+		if coordinator != nil {
+			coordinator.Close()
+		}
 		kvServer.Close()
 		log.Println("👋 Goodbye!")
 		os.Exit(0)
 	}()
 
-	// Start serving
-	if err := grpcServer.Serve(listener); err != nil {
+	// Start serving; stopping the server closes the listeners, which
+	// removes the socket file
+	for _, listener := range listeners[1:] {
+		go func(listener net.Listener) {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("❌ Failed to serve on %s: %v", listener.Addr(), err)
+			}
+		}(listener)
+	}
+	if err := grpcServer.Serve(listeners[0]); err != nil {
 		log.Fatalf("❌ Failed to serve: %v", err)
 	}
 }
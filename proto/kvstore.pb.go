@@ -21,13 +21,111 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type GetRequest_ChecksumMode int32
+
+const (
+	GetRequest_CHECKSUM_DEFAULT GetRequest_ChecksumMode = 0 // as the server is configured (-verify-checksums)
+	GetRequest_CHECKSUM_VERIFY  GetRequest_ChecksumMode = 1 // validate, even if the server skips them by default
+	GetRequest_CHECKSUM_SKIP    GetRequest_ChecksumMode = 2 // don't validate, for speed
+)
+
+// Enum value maps for GetRequest_ChecksumMode.
+var (
+	GetRequest_ChecksumMode_name = map[int32]string{
+		0: "CHECKSUM_DEFAULT",
+		1: "CHECKSUM_VERIFY",
+		2: "CHECKSUM_SKIP",
+	}
+	GetRequest_ChecksumMode_value = map[string]int32{
+		"CHECKSUM_DEFAULT": 0,
+		"CHECKSUM_VERIFY":  1,
+		"CHECKSUM_SKIP":    2,
+	}
+)
+
+func (x GetRequest_ChecksumMode) Enum() *GetRequest_ChecksumMode {
+	p := new(GetRequest_ChecksumMode)
+	*p = x
+	return p
+}
+
+func (x GetRequest_ChecksumMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GetRequest_ChecksumMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[0].Descriptor()
+}
+
+func (GetRequest_ChecksumMode) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[0]
+}
+
+func (x GetRequest_ChecksumMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GetRequest_ChecksumMode.Descriptor instead.
+func (GetRequest_ChecksumMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type ReplicaPutRequest_Crdt int32
+
+const (
+	ReplicaPutRequest_CRDT_NONE     ReplicaPutRequest_Crdt = 0 // last-write-wins: the value replaces the stored one
+	ReplicaPutRequest_CRDT_GCOUNTER ReplicaPutRequest_Crdt = 1 // the value (an encoded G-Counter, or empty) is merged into the stored counter
+)
+
+// Enum value maps for ReplicaPutRequest_Crdt.
+var (
+	ReplicaPutRequest_Crdt_name = map[int32]string{
+		0: "CRDT_NONE",
+		1: "CRDT_GCOUNTER",
+	}
+	ReplicaPutRequest_Crdt_value = map[string]int32{
+		"CRDT_NONE":     0,
+		"CRDT_GCOUNTER": 1,
+	}
+)
+
+func (x ReplicaPutRequest_Crdt) Enum() *ReplicaPutRequest_Crdt {
+	p := new(ReplicaPutRequest_Crdt)
+	*p = x
+	return p
+}
+
+func (x ReplicaPutRequest_Crdt) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReplicaPutRequest_Crdt) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_kvstore_proto_enumTypes[1].Descriptor()
+}
+
+func (ReplicaPutRequest_Crdt) Type() protoreflect.EnumType {
+	return &file_proto_kvstore_proto_enumTypes[1]
+}
+
+func (x ReplicaPutRequest_Crdt) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReplicaPutRequest_Crdt.Descriptor instead.
+func (ReplicaPutRequest_Crdt) EnumDescriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{33, 0}
+}
+
 // Put request message
 type PutRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value           []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	IdempotencyKey  string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`     // optional; a retry with the same key is applied once
+	Ns              string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`                                                   // optional namespace (column family); empty is the default namespace
+	ExpectedVersion int64                  `protobuf:"varint,5,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"` // optional; if set, write only if the key is at this version, else ABORTED
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *PutRequest) Reset() {
@@ -74,11 +172,33 @@ func (x *PutRequest) GetValue() []byte {
 	return nil
 }
 
+func (x *PutRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *PutRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *PutRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 // Put response message
 type PutResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Version       int64                  `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"` // the new value's version, for a conditional Put
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -127,28 +247,38 @@ func (x *PutResponse) GetError() string {
 	return ""
 }
 
-// Get request message
-type GetRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *PutResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
 }
 
-func (x *GetRequest) Reset() {
-	*x = GetRequest{}
+// PutIfAbsent request message
+type PutIfAbsentRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value          []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // optional; a retry with the same key gets the first outcome
+	Ns             string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`                                               // optional namespace (column family); empty is the default namespace
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PutIfAbsentRequest) Reset() {
+	*x = PutIfAbsentRequest{}
 	mi := &file_proto_kvstore_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetRequest) String() string {
+func (x *PutIfAbsentRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetRequest) ProtoMessage() {}
+func (*PutIfAbsentRequest) ProtoMessage() {}
 
-func (x *GetRequest) ProtoReflect() protoreflect.Message {
+func (x *PutIfAbsentRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -160,42 +290,62 @@ func (x *GetRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
-func (*GetRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use PutIfAbsentRequest.ProtoReflect.Descriptor instead.
+func (*PutIfAbsentRequest) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *GetRequest) GetKey() string {
+func (x *PutIfAbsentRequest) GetKey() string {
 	if x != nil {
 		return x.Key
 	}
 	return ""
 }
 
-// Get response message
-type GetResponse struct {
+func (x *PutIfAbsentRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *PutIfAbsentRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *PutIfAbsentRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+// PutIfAbsent response message
+type PutIfAbsentResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
-	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Written       bool                   `protobuf:"varint,1,opt,name=written,proto3" json:"written,omitempty"` // false if the key already existed
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetResponse) Reset() {
-	*x = GetResponse{}
+func (x *PutIfAbsentResponse) Reset() {
+	*x = PutIfAbsentResponse{}
 	mi := &file_proto_kvstore_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetResponse) String() string {
+func (x *PutIfAbsentResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetResponse) ProtoMessage() {}
+func (*PutIfAbsentResponse) ProtoMessage() {}
 
-func (x *GetResponse) ProtoReflect() protoreflect.Message {
+func (x *PutIfAbsentResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -207,54 +357,49 @@ func (x *GetResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
-func (*GetResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use PutIfAbsentResponse.ProtoReflect.Descriptor instead.
+func (*PutIfAbsentResponse) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *GetResponse) GetValue() []byte {
-	if x != nil {
-		return x.Value
-	}
-	return nil
-}
-
-func (x *GetResponse) GetFound() bool {
+func (x *PutIfAbsentResponse) GetWritten() bool {
 	if x != nil {
-		return x.Found
+		return x.Written
 	}
 	return false
 }
 
-func (x *GetResponse) GetError() string {
+func (x *PutIfAbsentResponse) GetError() string {
 	if x != nil {
 		return x.Error
 	}
 	return ""
 }
 
-// Delete request message
-type DeleteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+// Get request message
+type GetRequest struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Key           string                  `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Ns            string                  `protobuf:"bytes,2,opt,name=ns,proto3" json:"ns,omitempty"`                                                     // optional namespace (column family); empty is the default namespace
+	Checksums     GetRequest_ChecksumMode `protobuf:"varint,3,opt,name=checksums,proto3,enum=kvstore.GetRequest_ChecksumMode" json:"checksums,omitempty"` // whether this read validates SSTable record checksums
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteRequest) Reset() {
-	*x = DeleteRequest{}
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
 	mi := &file_proto_kvstore_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteRequest) String() string {
+func (x *GetRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteRequest) ProtoMessage() {}
+func (*GetRequest) ProtoMessage() {}
 
-func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -266,41 +411,57 @@ func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *DeleteRequest) GetKey() string {
+func (x *GetRequest) GetKey() string {
 	if x != nil {
 		return x.Key
 	}
 	return ""
 }
 
-// Delete response message
-type DeleteResponse struct {
+func (x *GetRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *GetRequest) GetChecksums() GetRequest_ChecksumMode {
+	if x != nil {
+		return x.Checksums
+	}
+	return GetRequest_CHECKSUM_DEFAULT
+}
+
+// Get response message
+type GetResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"` // the value's version, to pass as a Put's expected_version
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteResponse) Reset() {
-	*x = DeleteResponse{}
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
 	mi := &file_proto_kvstore_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteResponse) String() string {
+func (x *GetResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteResponse) ProtoMessage() {}
+func (*GetResponse) ProtoMessage() {}
 
-func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -312,46 +473,64 @@ func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
-func (*DeleteResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *DeleteResponse) GetSuccess() bool {
+func (x *GetResponse) GetValue() []byte {
 	if x != nil {
-		return x.Success
+		return x.Value
+	}
+	return nil
+}
+
+func (x *GetResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
 	}
 	return false
 }
 
-func (x *DeleteResponse) GetError() string {
+func (x *GetResponse) GetError() string {
 	if x != nil {
 		return x.Error
 	}
 	return ""
 }
 
-// Stats request message
-type StatsRequest struct {
+func (x *GetResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// GetRange request message
+type GetRangeRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Offset        int64                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"` // first byte of the value to return
+	Length        int64                  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"` // number of bytes; offset+length must not exceed the value size
+	Ns            string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`          // optional namespace (column family); empty is the default namespace
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatsRequest) Reset() {
-	*x = StatsRequest{}
+func (x *GetRangeRequest) Reset() {
+	*x = GetRangeRequest{}
 	mi := &file_proto_kvstore_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatsRequest) String() string {
+func (x *GetRangeRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatsRequest) ProtoMessage() {}
+func (*GetRangeRequest) ProtoMessage() {}
 
-func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+func (x *GetRangeRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -363,40 +542,62 @@ func (x *StatsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
-func (*StatsRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetRangeRequest.ProtoReflect.Descriptor instead.
+func (*GetRangeRequest) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{6}
 }
 
-// Stats response message
-type StatsResponse struct {
-	state                         protoimpl.MessageState `protogen:"open.v1"`
-	MemtableSize                  int64                  `protobuf:"varint,1,opt,name=memtable_size,json=memtableSize,proto3" json:"memtable_size,omitempty"`
-	NumSstables                   int32                  `protobuf:"varint,2,opt,name=num_sstables,json=numSstables,proto3" json:"num_sstables,omitempty"`
-	BloomFilterHits               int64                  `protobuf:"varint,3,opt,name=bloom_filter_hits,json=bloomFilterHits,proto3" json:"bloom_filter_hits,omitempty"`
-	BloomFilterMisses             int64                  `protobuf:"varint,4,opt,name=bloom_filter_misses,json=bloomFilterMisses,proto3" json:"bloom_filter_misses,omitempty"`
-	CompactionTotalCompactions    int64                  `protobuf:"varint,5,opt,name=compaction_total_compactions,json=compactionTotalCompactions,proto3" json:"compaction_total_compactions,omitempty"`
-	CompactionTotalKeysRemoved    int64                  `protobuf:"varint,6,opt,name=compaction_total_keys_removed,json=compactionTotalKeysRemoved,proto3" json:"compaction_total_keys_removed,omitempty"`
-	CompactionTotalBytesReclaimed int64                  `protobuf:"varint,7,opt,name=compaction_total_bytes_reclaimed,json=compactionTotalBytesReclaimed,proto3" json:"compaction_total_bytes_reclaimed,omitempty"`
-	CompactionLastCompaction      string                 `protobuf:"bytes,8,opt,name=compaction_last_compaction,json=compactionLastCompaction,proto3" json:"compaction_last_compaction,omitempty"`
-	unknownFields                 protoimpl.UnknownFields
-	sizeCache                     protoimpl.SizeCache
+func (x *GetRangeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
 }
 
-func (x *StatsResponse) Reset() {
-	*x = StatsResponse{}
+func (x *GetRangeRequest) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *GetRangeRequest) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+func (x *GetRangeRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+// Exists request message
+type ExistsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Ns            string                 `protobuf:"bytes,2,opt,name=ns,proto3" json:"ns,omitempty"` // optional namespace (column family); empty is the default namespace
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExistsRequest) Reset() {
+	*x = ExistsRequest{}
 	mi := &file_proto_kvstore_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatsResponse) String() string {
+func (x *ExistsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatsResponse) ProtoMessage() {}
+func (*ExistsRequest) ProtoMessage() {}
 
-func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+func (x *ExistsRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_proto_kvstore_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -408,89 +609,2429 @@ func (x *StatsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
-func (*StatsResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ExistsRequest.ProtoReflect.Descriptor instead.
+func (*ExistsRequest) Descriptor() ([]byte, []int) {
 	return file_proto_kvstore_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *StatsResponse) GetMemtableSize() int64 {
+func (x *ExistsRequest) GetKey() string {
 	if x != nil {
-		return x.MemtableSize
+		return x.Key
 	}
-	return 0
+	return ""
 }
 
-func (x *StatsResponse) GetNumSstables() int32 {
+func (x *ExistsRequest) GetNs() string {
 	if x != nil {
-		return x.NumSstables
+		return x.Ns
 	}
-	return 0
+	return ""
+}
+
+// Exists response message
+type ExistsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exists        bool                   `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExistsResponse) Reset() {
+	*x = ExistsResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExistsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExistsResponse) ProtoMessage() {}
+
+func (x *ExistsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExistsResponse.ProtoReflect.Descriptor instead.
+func (*ExistsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ExistsResponse) GetExists() bool {
+	if x != nil {
+		return x.Exists
+	}
+	return false
+}
+
+func (x *ExistsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// A slice of a value streamed by GetStream
+type ValueChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueChunk) Reset() {
+	*x = ValueChunk{}
+	mi := &file_proto_kvstore_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueChunk) ProtoMessage() {}
+
+func (x *ValueChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueChunk.ProtoReflect.Descriptor instead.
+func (*ValueChunk) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ValueChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// PutStream request message; the key only needs to be set on the first
+// message, the value is the concatenation of all chunks
+type PutStreamRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Chunk          []byte                 `protobuf:"bytes,2,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,3,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // optional, first message only
+	Ns             string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`                                               // optional namespace, first message only
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PutStreamRequest) Reset() {
+	*x = PutStreamRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutStreamRequest) ProtoMessage() {}
+
+func (x *PutStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutStreamRequest.ProtoReflect.Descriptor instead.
+func (*PutStreamRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PutStreamRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *PutStreamRequest) GetChunk() []byte {
+	if x != nil {
+		return x.Chunk
+	}
+	return nil
+}
+
+func (x *PutStreamRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *PutStreamRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+// Delete request message
+type DeleteRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // optional; a retry with the same key is applied once
+	Ns             string                 `protobuf:"bytes,3,opt,name=ns,proto3" json:"ns,omitempty"`                                               // optional namespace (column family); empty is the default namespace
+	CheckExisted   bool                   `protobuf:"varint,4,opt,name=check_existed,json=checkExisted,proto3" json:"check_existed,omitempty"`      // look the key up first and report it in existed (costs a read)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *DeleteRequest) GetCheckExisted() bool {
+	if x != nil {
+		return x.CheckExisted
+	}
+	return false
+}
+
+// Delete response message
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Existed       bool                   `protobuf:"varint,3,opt,name=existed,proto3" json:"existed,omitempty"` // the key had a live value; only set with check_existed
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *DeleteResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *DeleteResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DeleteResponse) GetExisted() bool {
+	if x != nil {
+		return x.Existed
+	}
+	return false
+}
+
+// Stats request message
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{13}
+}
+
+// Stats response message
+type StatsResponse struct {
+	state                         protoimpl.MessageState `protogen:"open.v1"`
+	MemtableSize                  int64                  `protobuf:"varint,1,opt,name=memtable_size,json=memtableSize,proto3" json:"memtable_size,omitempty"`
+	NumSstables                   int32                  `protobuf:"varint,2,opt,name=num_sstables,json=numSstables,proto3" json:"num_sstables,omitempty"`
+	BloomFilterHits               int64                  `protobuf:"varint,3,opt,name=bloom_filter_hits,json=bloomFilterHits,proto3" json:"bloom_filter_hits,omitempty"`
+	BloomFilterMisses             int64                  `protobuf:"varint,4,opt,name=bloom_filter_misses,json=bloomFilterMisses,proto3" json:"bloom_filter_misses,omitempty"`
+	CompactionTotalCompactions    int64                  `protobuf:"varint,5,opt,name=compaction_total_compactions,json=compactionTotalCompactions,proto3" json:"compaction_total_compactions,omitempty"`
+	CompactionTotalKeysRemoved    int64                  `protobuf:"varint,6,opt,name=compaction_total_keys_removed,json=compactionTotalKeysRemoved,proto3" json:"compaction_total_keys_removed,omitempty"`
+	CompactionTotalBytesReclaimed int64                  `protobuf:"varint,7,opt,name=compaction_total_bytes_reclaimed,json=compactionTotalBytesReclaimed,proto3" json:"compaction_total_bytes_reclaimed,omitempty"`
+	CompactionLastCompaction      string                 `protobuf:"bytes,8,opt,name=compaction_last_compaction,json=compactionLastCompaction,proto3" json:"compaction_last_compaction,omitempty"`
+	unknownFields                 protoimpl.UnknownFields
+	sizeCache                     protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *StatsResponse) GetMemtableSize() int64 {
+	if x != nil {
+		return x.MemtableSize
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetNumSstables() int32 {
+	if x != nil {
+		return x.NumSstables
+	}
+	return 0
 }
 
 func (x *StatsResponse) GetBloomFilterHits() int64 {
 	if x != nil {
-		return x.BloomFilterHits
+		return x.BloomFilterHits
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetBloomFilterMisses() int64 {
+	if x != nil {
+		return x.BloomFilterMisses
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompactionTotalCompactions() int64 {
+	if x != nil {
+		return x.CompactionTotalCompactions
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompactionTotalKeysRemoved() int64 {
+	if x != nil {
+		return x.CompactionTotalKeysRemoved
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompactionTotalBytesReclaimed() int64 {
+	if x != nil {
+		return x.CompactionTotalBytesReclaimed
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCompactionLastCompaction() string {
+	if x != nil {
+		return x.CompactionLastCompaction
+	}
+	return ""
+}
+
+// Compact request message
+type CompactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactRequest) Reset() {
+	*x = CompactRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactRequest) ProtoMessage() {}
+
+func (x *CompactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactRequest.ProtoReflect.Descriptor instead.
+func (*CompactRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{15}
+}
+
+// Compact response message
+type CompactResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompactResponse) Reset() {
+	*x = CompactResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompactResponse) ProtoMessage() {}
+
+func (x *CompactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompactResponse.ProtoReflect.Descriptor instead.
+func (*CompactResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CompactResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *CompactResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Truncate request message
+type TruncateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TruncateRequest) Reset() {
+	*x = TruncateRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TruncateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TruncateRequest) ProtoMessage() {}
+
+func (x *TruncateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TruncateRequest.ProtoReflect.Descriptor instead.
+func (*TruncateRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{17}
+}
+
+// Truncate response message
+type TruncateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TruncateResponse) Reset() {
+	*x = TruncateResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TruncateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TruncateResponse) ProtoMessage() {}
+
+func (x *TruncateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TruncateResponse.ProtoReflect.Descriptor instead.
+func (*TruncateResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TruncateResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *TruncateResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Sync request message
+type SyncRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{19}
+}
+
+// Sync response message
+type SyncResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncResponse) Reset() {
+	*x = SyncResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncResponse) ProtoMessage() {}
+
+func (x *SyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncResponse.ProtoReflect.Descriptor instead.
+func (*SyncResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SyncResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SyncResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Flush request message
+type FlushRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushRequest) Reset() {
+	*x = FlushRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushRequest) ProtoMessage() {}
+
+func (x *FlushRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushRequest.ProtoReflect.Descriptor instead.
+func (*FlushRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{21}
+}
+
+// Flush response message
+type FlushResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FlushResponse) Reset() {
+	*x = FlushResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FlushResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FlushResponse) ProtoMessage() {}
+
+func (x *FlushResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FlushResponse.ProtoReflect.Descriptor instead.
+func (*FlushResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *FlushResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *FlushResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// A single operation inside a WriteBatch
+type BatchOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Delete        bool                   `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"` // true removes the key (value is ignored)
+	Ns            string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`          // optional namespace (column family); empty is the default namespace
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchOperation) Reset() {
+	*x = BatchOperation{}
+	mi := &file_proto_kvstore_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchOperation) ProtoMessage() {}
+
+func (x *BatchOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchOperation.ProtoReflect.Descriptor instead.
+func (*BatchOperation) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *BatchOperation) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *BatchOperation) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *BatchOperation) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+func (x *BatchOperation) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+// WriteBatch request message
+type WriteBatchRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Operations     []*BatchOperation      `protobuf:"bytes,1,rep,name=operations,proto3" json:"operations,omitempty"`
+	IdempotencyKey string                 `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // optional; a retry with the same key is applied once
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WriteBatchRequest) Reset() {
+	*x = WriteBatchRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBatchRequest) ProtoMessage() {}
+
+func (x *WriteBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBatchRequest.ProtoReflect.Descriptor instead.
+func (*WriteBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *WriteBatchRequest) GetOperations() []*BatchOperation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *WriteBatchRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// WriteBatch response message
+type WriteBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteBatchResponse) Reset() {
+	*x = WriteBatchResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteBatchResponse) ProtoMessage() {}
+
+func (x *WriteBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteBatchResponse.ProtoReflect.Descriptor instead.
+func (*WriteBatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WriteBatchResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WriteBatchResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Ping request message
+type PingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         uint64                 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingRequest) ProtoMessage() {}
+
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *PingRequest) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+// Ping response message
+type PingResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Nonce          uint64                 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"` // echoed from the request
+	NodeId         string                 `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	MonotonicNanos int64                  `protobuf:"varint,3,opt,name=monotonic_nanos,json=monotonicNanos,proto3" json:"monotonic_nanos,omitempty"` // nanoseconds since the server started (monotonic clock)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PingResponse) Reset() {
+	*x = PingResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PingResponse) ProtoMessage() {}
+
+func (x *PingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PingResponse.ProtoReflect.Descriptor instead.
+func (*PingResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PingResponse) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *PingResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *PingResponse) GetMonotonicNanos() int64 {
+	if x != nil {
+		return x.MonotonicNanos
+	}
+	return 0
+}
+
+// Scan request message
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartKey      string                 `protobuf:"bytes,1,opt,name=start_key,json=startKey,proto3" json:"start_key,omitempty"`  // inclusive, empty scans from the first key
+	EndKey        string                 `protobuf:"bytes,2,opt,name=end_key,json=endKey,proto3" json:"end_key,omitempty"`        // exclusive, empty scans to the last key
+	KeysOnly      bool                   `protobuf:"varint,3,opt,name=keys_only,json=keysOnly,proto3" json:"keys_only,omitempty"` // skip reading and returning values
+	Ns            string                 `protobuf:"bytes,4,opt,name=ns,proto3" json:"ns,omitempty"`                              // optional namespace; bounds and returned keys are within it
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`                       // return at most this many entries, 0 = no limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ScanRequest) GetStartKey() string {
+	if x != nil {
+		return x.StartKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetEndKey() string {
+	if x != nil {
+		return x.EndKey
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetKeysOnly() bool {
+	if x != nil {
+		return x.KeysOnly
+	}
+	return false
+}
+
+func (x *ScanRequest) GetNs() string {
+	if x != nil {
+		return x.Ns
+	}
+	return ""
+}
+
+func (x *ScanRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// Key-value pair returned by Scan
+type KeyValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_proto_kvstore_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// Scan response message
+type ScanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*KeyValue            `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanResponse) Reset() {
+	*x = ScanResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanResponse) ProtoMessage() {}
+
+func (x *ScanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanResponse.ProtoReflect.Descriptor instead.
+func (*ScanResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ScanResponse) GetEntries() []*KeyValue {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *ScanResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Watch request message
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"` // only keys starting with prefix; empty watches every key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+// A mutation streamed by Watch
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`          // empty for deletes
+	Delete        bool                   `protobuf:"varint,3,opt,name=delete,proto3" json:"delete,omitempty"`       // true for a delete, false for a put
+	Timestamp     int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // UnixNano time of the write
+	Lagged        bool                   `protobuf:"varint,5,opt,name=lagged,proto3" json:"lagged,omitempty"`       // the watcher fell behind and events were dropped before this one; other fields are empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_proto_kvstore_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *WatchEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchEvent) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetDelete() bool {
+	if x != nil {
+		return x.Delete
+	}
+	return false
+}
+
+func (x *WatchEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetLagged() bool {
+	if x != nil {
+		return x.Lagged
+	}
+	return false
+}
+
+// ReplicaPut request message
+type ReplicaPutRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Key            string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value          []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp      int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Version        int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Crdt           ReplicaPutRequest_Crdt `protobuf:"varint,5,opt,name=crdt,proto3,enum=kvstore.ReplicaPutRequest_Crdt" json:"crdt,omitempty"`      // how the value is applied
+	Increment      uint64                 `protobuf:"varint,6,opt,name=increment,proto3" json:"increment,omitempty"`                                // CRDT_GCOUNTER: added to counter_node's count after the merge
+	CounterNode    string                 `protobuf:"bytes,7,opt,name=counter_node,json=counterNode,proto3" json:"counter_node,omitempty"`          // CRDT_GCOUNTER: the node whose count increment is added to
+	IdempotencyKey string                 `protobuf:"bytes,8,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"` // CRDT_GCOUNTER increments: optional; a retry with the same key is counted once
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ReplicaPutRequest) Reset() {
+	*x = ReplicaPutRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaPutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaPutRequest) ProtoMessage() {}
+
+func (x *ReplicaPutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaPutRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaPutRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ReplicaPutRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ReplicaPutRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ReplicaPutRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ReplicaPutRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReplicaPutRequest) GetCrdt() ReplicaPutRequest_Crdt {
+	if x != nil {
+		return x.Crdt
+	}
+	return ReplicaPutRequest_CRDT_NONE
+}
+
+func (x *ReplicaPutRequest) GetIncrement() uint64 {
+	if x != nil {
+		return x.Increment
+	}
+	return 0
+}
+
+func (x *ReplicaPutRequest) GetCounterNode() string {
+	if x != nil {
+		return x.CounterNode
+	}
+	return ""
+}
+
+func (x *ReplicaPutRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+// ReplicaPut response message
+type ReplicaPutResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"` // CRDT writes: the replica's counter after the write
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaPutResponse) Reset() {
+	*x = ReplicaPutResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaPutResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaPutResponse) ProtoMessage() {}
+
+func (x *ReplicaPutResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaPutResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaPutResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ReplicaPutResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ReplicaPutResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ReplicaPutResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// ReplicaGet request message
+type ReplicaGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaGetRequest) Reset() {
+	*x = ReplicaGetRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaGetRequest) ProtoMessage() {}
+
+func (x *ReplicaGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaGetRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaGetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ReplicaGetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// ReplicaGet response message
+type ReplicaGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Found         bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Timestamp     int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaGetResponse) Reset() {
+	*x = ReplicaGetResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaGetResponse) ProtoMessage() {}
+
+func (x *ReplicaGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaGetResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaGetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ReplicaGetResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ReplicaGetResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *ReplicaGetResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *ReplicaGetResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *ReplicaGetResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ReplicaBatchGet request message
+type ReplicaBatchGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaBatchGetRequest) Reset() {
+	*x = ReplicaBatchGetRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaBatchGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaBatchGetRequest) ProtoMessage() {}
+
+func (x *ReplicaBatchGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaBatchGetRequest.ProtoReflect.Descriptor instead.
+func (*ReplicaBatchGetRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ReplicaBatchGetRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+// ReplicaBatchGet response message
+type ReplicaBatchGetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*ReplicaGetResponse  `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // one per requested key, in request order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicaBatchGetResponse) Reset() {
+	*x = ReplicaBatchGetResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicaBatchGetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicaBatchGetResponse) ProtoMessage() {}
+
+func (x *ReplicaBatchGetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicaBatchGetResponse.ProtoReflect.Descriptor instead.
+func (*ReplicaBatchGetResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ReplicaBatchGetResponse) GetResults() []*ReplicaGetResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// Raft log entry
+type LogEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint64                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Term          uint64                 `protobuf:"varint,2,opt,name=term,proto3" json:"term,omitempty"`
+	Command       []byte                 `protobuf:"bytes,3,opt,name=command,proto3" json:"command,omitempty"`
+	Type          uint32                 `protobuf:"varint,4,opt,name=type,proto3" json:"type,omitempty"` // 0 = state machine command, 1 = cluster configuration
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogEntry) Reset() {
+	*x = LogEntry{}
+	mi := &file_proto_kvstore_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntry) ProtoMessage() {}
+
+func (x *LogEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *LogEntry) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *LogEntry) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *LogEntry) GetCommand() []byte {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *LogEntry) GetType() uint32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+// RequestVote request message
+type RequestVoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	CandidateId   string                 `protobuf:"bytes,2,opt,name=candidate_id,json=candidateId,proto3" json:"candidate_id,omitempty"`
+	LastLogIndex  uint64                 `protobuf:"varint,3,opt,name=last_log_index,json=lastLogIndex,proto3" json:"last_log_index,omitempty"`
+	LastLogTerm   uint64                 `protobuf:"varint,4,opt,name=last_log_term,json=lastLogTerm,proto3" json:"last_log_term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestVoteRequest) Reset() {
+	*x = RequestVoteRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestVoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteRequest) ProtoMessage() {}
+
+func (x *RequestVoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteRequest.ProtoReflect.Descriptor instead.
+func (*RequestVoteRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *RequestVoteRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteRequest) GetCandidateId() string {
+	if x != nil {
+		return x.CandidateId
+	}
+	return ""
+}
+
+func (x *RequestVoteRequest) GetLastLogIndex() uint64 {
+	if x != nil {
+		return x.LastLogIndex
+	}
+	return 0
+}
+
+func (x *RequestVoteRequest) GetLastLogTerm() uint64 {
+	if x != nil {
+		return x.LastLogTerm
+	}
+	return 0
+}
+
+// RequestVote response message
+type RequestVoteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	VoteGranted   bool                   `protobuf:"varint,2,opt,name=vote_granted,json=voteGranted,proto3" json:"vote_granted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestVoteResponse) Reset() {
+	*x = RequestVoteResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestVoteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestVoteResponse) ProtoMessage() {}
+
+func (x *RequestVoteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestVoteResponse.ProtoReflect.Descriptor instead.
+func (*RequestVoteResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *RequestVoteResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *RequestVoteResponse) GetVoteGranted() bool {
+	if x != nil {
+		return x.VoteGranted
+	}
+	return false
+}
+
+// AppendEntries request message
+type AppendEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	PrevLogIndex  uint64                 `protobuf:"varint,3,opt,name=prev_log_index,json=prevLogIndex,proto3" json:"prev_log_index,omitempty"`
+	PrevLogTerm   uint64                 `protobuf:"varint,4,opt,name=prev_log_term,json=prevLogTerm,proto3" json:"prev_log_term,omitempty"`
+	Entries       []*LogEntry            `protobuf:"bytes,5,rep,name=entries,proto3" json:"entries,omitempty"`
+	LeaderCommit  uint64                 `protobuf:"varint,6,opt,name=leader_commit,json=leaderCommit,proto3" json:"leader_commit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesRequest) Reset() {
+	*x = AppendEntriesRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesRequest) ProtoMessage() {}
+
+func (x *AppendEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesRequest.ProtoReflect.Descriptor instead.
+func (*AppendEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *AppendEntriesRequest) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *AppendEntriesRequest) GetPrevLogIndex() uint64 {
+	if x != nil {
+		return x.PrevLogIndex
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetPrevLogTerm() uint64 {
+	if x != nil {
+		return x.PrevLogTerm
+	}
+	return 0
+}
+
+func (x *AppendEntriesRequest) GetEntries() []*LogEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *AppendEntriesRequest) GetLeaderCommit() uint64 {
+	if x != nil {
+		return x.LeaderCommit
+	}
+	return 0
+}
+
+// AppendEntries response message
+type AppendEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Term          uint64                 `protobuf:"varint,1,opt,name=term,proto3" json:"term,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ConflictTerm  uint64                 `protobuf:"varint,3,opt,name=conflict_term,json=conflictTerm,proto3" json:"conflict_term,omitempty"`
+	ConflictIndex uint64                 `protobuf:"varint,4,opt,name=conflict_index,json=conflictIndex,proto3" json:"conflict_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppendEntriesResponse) Reset() {
+	*x = AppendEntriesResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppendEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppendEntriesResponse) ProtoMessage() {}
+
+func (x *AppendEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppendEntriesResponse.ProtoReflect.Descriptor instead.
+func (*AppendEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *AppendEntriesResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+func (x *AppendEntriesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AppendEntriesResponse) GetConflictTerm() uint64 {
+	if x != nil {
+		return x.ConflictTerm
+	}
+	return 0
+}
+
+func (x *AppendEntriesResponse) GetConflictIndex() uint64 {
+	if x != nil {
+		return x.ConflictIndex
+	}
+	return 0
+}
+
+// Leader request message
+type LeaderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaderRequest) Reset() {
+	*x = LeaderRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaderRequest) ProtoMessage() {}
+
+func (x *LeaderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaderRequest.ProtoReflect.Descriptor instead.
+func (*LeaderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{44}
+}
+
+// Leader response message
+type LeaderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Known         bool                   `protobuf:"varint,1,opt,name=known,proto3" json:"known,omitempty"` // false while no leader is known (e.g. during an election)
+	LeaderId      string                 `protobuf:"bytes,2,opt,name=leader_id,json=leaderId,proto3" json:"leader_id,omitempty"`
+	LeaderAddress string                 `protobuf:"bytes,3,opt,name=leader_address,json=leaderAddress,proto3" json:"leader_address,omitempty"`
+	Term          uint64                 `protobuf:"varint,4,opt,name=term,proto3" json:"term,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeaderResponse) Reset() {
+	*x = LeaderResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeaderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaderResponse) ProtoMessage() {}
+
+func (x *LeaderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaderResponse.ProtoReflect.Descriptor instead.
+func (*LeaderResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *LeaderResponse) GetKnown() bool {
+	if x != nil {
+		return x.Known
+	}
+	return false
+}
+
+func (x *LeaderResponse) GetLeaderId() string {
+	if x != nil {
+		return x.LeaderId
+	}
+	return ""
+}
+
+func (x *LeaderResponse) GetLeaderAddress() string {
+	if x != nil {
+		return x.LeaderAddress
+	}
+	return ""
+}
+
+func (x *LeaderResponse) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
 	}
 	return 0
 }
 
-func (x *StatsResponse) GetBloomFilterMisses() int64 {
+// RingInfo request message
+type RingInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SimulatedKeys int32                  `protobuf:"varint,1,opt,name=simulated_keys,json=simulatedKeys,proto3" json:"simulated_keys,omitempty"` // keys to simulate for the distribution; 0 uses the server default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RingInfoRequest) Reset() {
+	*x = RingInfoRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RingInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RingInfoRequest) ProtoMessage() {}
+
+func (x *RingInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[46]
 	if x != nil {
-		return x.BloomFilterMisses
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RingInfoRequest.ProtoReflect.Descriptor instead.
+func (*RingInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *RingInfoRequest) GetSimulatedKeys() int32 {
+	if x != nil {
+		return x.SimulatedKeys
 	}
 	return 0
 }
 
-func (x *StatsResponse) GetCompactionTotalCompactions() int64 {
+// One physical node's share of the hash ring
+type RingNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	VirtualNodes  int32                  `protobuf:"varint,3,opt,name=virtual_nodes,json=virtualNodes,proto3" json:"virtual_nodes,omitempty"`
+	SimulatedKeys int32                  `protobuf:"varint,4,opt,name=simulated_keys,json=simulatedKeys,proto3" json:"simulated_keys,omitempty"` // simulated keys this node is primary for
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RingNode) Reset() {
+	*x = RingNode{}
+	mi := &file_proto_kvstore_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RingNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RingNode) ProtoMessage() {}
+
+func (x *RingNode) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[47]
 	if x != nil {
-		return x.CompactionTotalCompactions
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RingNode.ProtoReflect.Descriptor instead.
+func (*RingNode) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *RingNode) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *RingNode) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RingNode) GetVirtualNodes() int32 {
+	if x != nil {
+		return x.VirtualNodes
 	}
 	return 0
 }
 
-func (x *StatsResponse) GetCompactionTotalKeysRemoved() int64 {
+func (x *RingNode) GetSimulatedKeys() int32 {
 	if x != nil {
-		return x.CompactionTotalKeysRemoved
+		return x.SimulatedKeys
 	}
 	return 0
 }
 
-func (x *StatsResponse) GetCompactionTotalBytesReclaimed() int64 {
+// RingInfo response message
+type RingInfoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*RingNode            `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"`                                       // sorted by node_id
+	Generation    uint64                 `protobuf:"varint,2,opt,name=generation,proto3" json:"generation,omitempty"`                            // changes on every membership change
+	SimulatedKeys int32                  `protobuf:"varint,3,opt,name=simulated_keys,json=simulatedKeys,proto3" json:"simulated_keys,omitempty"` // keys actually simulated
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RingInfoResponse) Reset() {
+	*x = RingInfoResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RingInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RingInfoResponse) ProtoMessage() {}
+
+func (x *RingInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[48]
 	if x != nil {
-		return x.CompactionTotalBytesReclaimed
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RingInfoResponse.ProtoReflect.Descriptor instead.
+func (*RingInfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *RingInfoResponse) GetNodes() []*RingNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *RingInfoResponse) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
 	}
 	return 0
 }
 
-func (x *StatsResponse) GetCompactionLastCompaction() string {
+func (x *RingInfoResponse) GetSimulatedKeys() int32 {
 	if x != nil {
-		return x.CompactionLastCompaction
+		return x.SimulatedKeys
 	}
-	return ""
+	return 0
 }
 
-// Compact request message
-type CompactRequest struct {
+// GetQuorum request message
+type GetQuorumRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CompactRequest) Reset() {
-	*x = CompactRequest{}
-	mi := &file_proto_kvstore_proto_msgTypes[8]
+func (x *GetQuorumRequest) Reset() {
+	*x = GetQuorumRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CompactRequest) String() string {
+func (x *GetQuorumRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CompactRequest) ProtoMessage() {}
+func (*GetQuorumRequest) ProtoMessage() {}
 
-func (x *CompactRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[8]
+func (x *GetQuorumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -501,35 +3042,35 @@ func (x *CompactRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CompactRequest.ProtoReflect.Descriptor instead.
-func (*CompactRequest) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use GetQuorumRequest.ProtoReflect.Descriptor instead.
+func (*GetQuorumRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{49}
 }
 
-// Compact response message
-type CompactResponse struct {
+// SetQuorum request message
+type SetQuorumRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	W             int32                  `protobuf:"varint,1,opt,name=w,proto3" json:"w,omitempty"` // write quorum, 1..N
+	R             int32                  `protobuf:"varint,2,opt,name=r,proto3" json:"r,omitempty"` // read quorum, 1..N; W+R must exceed N
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CompactResponse) Reset() {
-	*x = CompactResponse{}
-	mi := &file_proto_kvstore_proto_msgTypes[9]
+func (x *SetQuorumRequest) Reset() {
+	*x = SetQuorumRequest{}
+	mi := &file_proto_kvstore_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CompactResponse) String() string {
+func (x *SetQuorumRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CompactResponse) ProtoMessage() {}
+func (*SetQuorumRequest) ProtoMessage() {}
 
-func (x *CompactResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_kvstore_proto_msgTypes[9]
+func (x *SetQuorumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -540,49 +3081,152 @@ func (x *CompactResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CompactResponse.ProtoReflect.Descriptor instead.
-func (*CompactResponse) Descriptor() ([]byte, []int) {
-	return file_proto_kvstore_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use SetQuorumRequest.ProtoReflect.Descriptor instead.
+func (*SetQuorumRequest) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *CompactResponse) GetSuccess() bool {
+func (x *SetQuorumRequest) GetW() int32 {
 	if x != nil {
-		return x.Success
+		return x.W
 	}
-	return false
+	return 0
 }
 
-func (x *CompactResponse) GetError() string {
+func (x *SetQuorumRequest) GetR() int32 {
 	if x != nil {
-		return x.Error
+		return x.R
 	}
-	return ""
+	return 0
+}
+
+// Quorum response message, with the values in effect after the call
+type QuorumResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	N             int32                  `protobuf:"varint,1,opt,name=n,proto3" json:"n,omitempty"` // replication factor
+	W             int32                  `protobuf:"varint,2,opt,name=w,proto3" json:"w,omitempty"`
+	R             int32                  `protobuf:"varint,3,opt,name=r,proto3" json:"r,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuorumResponse) Reset() {
+	*x = QuorumResponse{}
+	mi := &file_proto_kvstore_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuorumResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuorumResponse) ProtoMessage() {}
+
+func (x *QuorumResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_kvstore_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuorumResponse.ProtoReflect.Descriptor instead.
+func (*QuorumResponse) Descriptor() ([]byte, []int) {
+	return file_proto_kvstore_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *QuorumResponse) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *QuorumResponse) GetW() int32 {
+	if x != nil {
+		return x.W
+	}
+	return 0
+}
+
+func (x *QuorumResponse) GetR() int32 {
+	if x != nil {
+		return x.R
+	}
+	return 0
 }
 
 var File_proto_kvstore_proto protoreflect.FileDescriptor
 
 const file_proto_kvstore_proto_rawDesc = "" +
 	"\n" +
-	"\x13proto/kvstore.proto\x12\akvstore\"4\n" +
+	"\x13proto/kvstore.proto\x12\akvstore\"\x98\x01\n" +
 	"\n" +
 	"PutRequest\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\fR\x05value\"=\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\x12)\n" +
+	"\x10expected_version\x18\x05 \x01(\x03R\x0fexpectedVersion\"W\n" +
 	"\vPutResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"\x1e\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\x03R\aversion\"u\n" +
+	"\x12PutIfAbsentRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\"E\n" +
+	"\x13PutIfAbsentResponse\x12\x18\n" +
+	"\awritten\x18\x01 \x01(\bR\awritten\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xbc\x01\n" +
 	"\n" +
 	"GetRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"O\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x0e\n" +
+	"\x02ns\x18\x02 \x01(\tR\x02ns\x12>\n" +
+	"\tchecksums\x18\x03 \x01(\x0e2 .kvstore.GetRequest.ChecksumModeR\tchecksums\"L\n" +
+	"\fChecksumMode\x12\x14\n" +
+	"\x10CHECKSUM_DEFAULT\x10\x00\x12\x13\n" +
+	"\x0fCHECKSUM_VERIFY\x10\x01\x12\x11\n" +
+	"\rCHECKSUM_SKIP\x10\x02\"i\n" +
 	"\vGetResponse\x12\x14\n" +
 	"\x05value\x18\x01 \x01(\fR\x05value\x12\x14\n" +
 	"\x05found\x18\x02 \x01(\bR\x05found\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"!\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\"c\n" +
+	"\x0fGetRangeRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x03R\x06offset\x12\x16\n" +
+	"\x06length\x18\x03 \x01(\x03R\x06length\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\"1\n" +
+	"\rExistsRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x0e\n" +
+	"\x02ns\x18\x02 \x01(\tR\x02ns\">\n" +
+	"\x0eExistsResponse\x12\x16\n" +
+	"\x06exists\x18\x01 \x01(\bR\x06exists\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\" \n" +
+	"\n" +
+	"ValueChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"s\n" +
+	"\x10PutStreamRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05chunk\x18\x02 \x01(\fR\x05chunk\x12'\n" +
+	"\x0fidempotency_key\x18\x03 \x01(\tR\x0eidempotencyKey\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\"\x7f\n" +
 	"\rDeleteRequest\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\"@\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12\x0e\n" +
+	"\x02ns\x18\x03 \x01(\tR\x02ns\x12#\n" +
+	"\rcheck_existed\x18\x04 \x01(\bR\fcheckExisted\"Z\n" +
 	"\x0eDeleteResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"\x0e\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x18\n" +
+	"\aexisted\x18\x03 \x01(\bR\aexisted\"\x0e\n" +
 	"\fStatsRequest\"\xbf\x03\n" +
 	"\rStatsResponse\x12#\n" +
 	"\rmemtable_size\x18\x01 \x01(\x03R\fmemtableSize\x12!\n" +
@@ -596,13 +3240,169 @@ const file_proto_kvstore_proto_rawDesc = "" +
 	"\x0eCompactRequest\"A\n" +
 	"\x0fCompactResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error2\x9e\x02\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x11\n" +
+	"\x0fTruncateRequest\"B\n" +
+	"\x10TruncateResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\r\n" +
+	"\vSyncRequest\">\n" +
+	"\fSyncResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x0e\n" +
+	"\fFlushRequest\"?\n" +
+	"\rFlushResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"`\n" +
+	"\x0eBatchOperation\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\bR\x06delete\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\"u\n" +
+	"\x11WriteBatchRequest\x127\n" +
+	"\n" +
+	"operations\x18\x01 \x03(\v2\x17.kvstore.BatchOperationR\n" +
+	"operations\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\"D\n" +
+	"\x12WriteBatchResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"#\n" +
+	"\vPingRequest\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\x04R\x05nonce\"f\n" +
+	"\fPingResponse\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\x04R\x05nonce\x12\x17\n" +
+	"\anode_id\x18\x02 \x01(\tR\x06nodeId\x12'\n" +
+	"\x0fmonotonic_nanos\x18\x03 \x01(\x03R\x0emonotonicNanos\"\x86\x01\n" +
+	"\vScanRequest\x12\x1b\n" +
+	"\tstart_key\x18\x01 \x01(\tR\bstartKey\x12\x17\n" +
+	"\aend_key\x18\x02 \x01(\tR\x06endKey\x12\x1b\n" +
+	"\tkeys_only\x18\x03 \x01(\bR\bkeysOnly\x12\x0e\n" +
+	"\x02ns\x18\x04 \x01(\tR\x02ns\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"2\n" +
+	"\bKeyValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"Q\n" +
+	"\fScanResponse\x12+\n" +
+	"\aentries\x18\x01 \x03(\v2\x11.kvstore.KeyValueR\aentries\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"&\n" +
+	"\fWatchRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"\x82\x01\n" +
+	"\n" +
+	"WatchEvent\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x16\n" +
+	"\x06delete\x18\x03 \x01(\bR\x06delete\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12\x16\n" +
+	"\x06lagged\x18\x05 \x01(\bR\x06lagged\"\xbc\x02\n" +
+	"\x11ReplicaPutRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x123\n" +
+	"\x04crdt\x18\x05 \x01(\x0e2\x1f.kvstore.ReplicaPutRequest.CrdtR\x04crdt\x12\x1c\n" +
+	"\tincrement\x18\x06 \x01(\x04R\tincrement\x12!\n" +
+	"\fcounter_node\x18\a \x01(\tR\vcounterNode\x12'\n" +
+	"\x0fidempotency_key\x18\b \x01(\tR\x0eidempotencyKey\"(\n" +
+	"\x04Crdt\x12\r\n" +
+	"\tCRDT_NONE\x10\x00\x12\x11\n" +
+	"\rCRDT_GCOUNTER\x10\x01\"Z\n" +
+	"\x12ReplicaPutResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\"%\n" +
+	"\x11ReplicaGetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x8e\x01\n" +
+	"\x12ReplicaGetResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\fR\x05value\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\",\n" +
+	"\x16ReplicaBatchGetRequest\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\"P\n" +
+	"\x17ReplicaBatchGetResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.kvstore.ReplicaGetResponseR\aresults\"b\n" +
+	"\bLogEntry\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x12\n" +
+	"\x04term\x18\x02 \x01(\x04R\x04term\x12\x18\n" +
+	"\acommand\x18\x03 \x01(\fR\acommand\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\rR\x04type\"\x95\x01\n" +
+	"\x12RequestVoteRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12!\n" +
+	"\fcandidate_id\x18\x02 \x01(\tR\vcandidateId\x12$\n" +
+	"\x0elast_log_index\x18\x03 \x01(\x04R\flastLogIndex\x12\"\n" +
+	"\rlast_log_term\x18\x04 \x01(\x04R\vlastLogTerm\"L\n" +
+	"\x13RequestVoteResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12!\n" +
+	"\fvote_granted\x18\x02 \x01(\bR\vvoteGranted\"\xe3\x01\n" +
+	"\x14AppendEntriesRequest\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12$\n" +
+	"\x0eprev_log_index\x18\x03 \x01(\x04R\fprevLogIndex\x12\"\n" +
+	"\rprev_log_term\x18\x04 \x01(\x04R\vprevLogTerm\x12+\n" +
+	"\aentries\x18\x05 \x03(\v2\x11.kvstore.LogEntryR\aentries\x12#\n" +
+	"\rleader_commit\x18\x06 \x01(\x04R\fleaderCommit\"\x91\x01\n" +
+	"\x15AppendEntriesResponse\x12\x12\n" +
+	"\x04term\x18\x01 \x01(\x04R\x04term\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12#\n" +
+	"\rconflict_term\x18\x03 \x01(\x04R\fconflictTerm\x12%\n" +
+	"\x0econflict_index\x18\x04 \x01(\x04R\rconflictIndex\"\x0f\n" +
+	"\rLeaderRequest\"~\n" +
+	"\x0eLeaderResponse\x12\x14\n" +
+	"\x05known\x18\x01 \x01(\bR\x05known\x12\x1b\n" +
+	"\tleader_id\x18\x02 \x01(\tR\bleaderId\x12%\n" +
+	"\x0eleader_address\x18\x03 \x01(\tR\rleaderAddress\x12\x12\n" +
+	"\x04term\x18\x04 \x01(\x04R\x04term\"8\n" +
+	"\x0fRingInfoRequest\x12%\n" +
+	"\x0esimulated_keys\x18\x01 \x01(\x05R\rsimulatedKeys\"\x89\x01\n" +
+	"\bRingNode\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12#\n" +
+	"\rvirtual_nodes\x18\x03 \x01(\x05R\fvirtualNodes\x12%\n" +
+	"\x0esimulated_keys\x18\x04 \x01(\x05R\rsimulatedKeys\"\x82\x01\n" +
+	"\x10RingInfoResponse\x12'\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x11.kvstore.RingNodeR\x05nodes\x12\x1e\n" +
+	"\n" +
+	"generation\x18\x02 \x01(\x04R\n" +
+	"generation\x12%\n" +
+	"\x0esimulated_keys\x18\x03 \x01(\x05R\rsimulatedKeys\"\x12\n" +
+	"\x10GetQuorumRequest\".\n" +
+	"\x10SetQuorumRequest\x12\f\n" +
+	"\x01w\x18\x01 \x01(\x05R\x01w\x12\f\n" +
+	"\x01r\x18\x02 \x01(\x05R\x01r\":\n" +
+	"\x0eQuorumResponse\x12\f\n" +
+	"\x01n\x18\x01 \x01(\x05R\x01n\x12\f\n" +
+	"\x01w\x18\x02 \x01(\x05R\x01w\x12\f\n" +
+	"\x01r\x18\x03 \x01(\x05R\x01r2\xea\f\n" +
 	"\aKVStore\x120\n" +
-	"\x03Put\x12\x13.kvstore.PutRequest\x1a\x14.kvstore.PutResponse\x120\n" +
+	"\x03Put\x12\x13.kvstore.PutRequest\x1a\x14.kvstore.PutResponse\x12H\n" +
+	"\vPutIfAbsent\x12\x1b.kvstore.PutIfAbsentRequest\x1a\x1c.kvstore.PutIfAbsentResponse\x120\n" +
 	"\x03Get\x12\x13.kvstore.GetRequest\x1a\x14.kvstore.GetResponse\x129\n" +
+	"\x06Exists\x12\x16.kvstore.ExistsRequest\x1a\x17.kvstore.ExistsResponse\x12:\n" +
+	"\bGetRange\x12\x18.kvstore.GetRangeRequest\x1a\x14.kvstore.GetResponse\x127\n" +
+	"\tGetStream\x12\x13.kvstore.GetRequest\x1a\x13.kvstore.ValueChunk0\x01\x12>\n" +
+	"\tPutStream\x12\x19.kvstore.PutStreamRequest\x1a\x14.kvstore.PutResponse(\x01\x129\n" +
 	"\x06Delete\x12\x16.kvstore.DeleteRequest\x1a\x17.kvstore.DeleteResponse\x126\n" +
 	"\x05Stats\x12\x15.kvstore.StatsRequest\x1a\x16.kvstore.StatsResponse\x12<\n" +
-	"\aCompact\x12\x17.kvstore.CompactRequest\x1a\x18.kvstore.CompactResponseB\x0fZ\rkvstore/protob\x06proto3"
+	"\aCompact\x12\x17.kvstore.CompactRequest\x1a\x18.kvstore.CompactResponse\x123\n" +
+	"\x04Sync\x12\x14.kvstore.SyncRequest\x1a\x15.kvstore.SyncResponse\x126\n" +
+	"\x05Flush\x12\x15.kvstore.FlushRequest\x1a\x16.kvstore.FlushResponse\x12?\n" +
+	"\bTruncate\x12\x18.kvstore.TruncateRequest\x1a\x19.kvstore.TruncateResponse\x123\n" +
+	"\x04Scan\x12\x14.kvstore.ScanRequest\x1a\x15.kvstore.ScanResponse\x125\n" +
+	"\x05Watch\x12\x15.kvstore.WatchRequest\x1a\x13.kvstore.WatchEvent0\x01\x123\n" +
+	"\x04Ping\x12\x14.kvstore.PingRequest\x1a\x15.kvstore.PingResponse\x12E\n" +
+	"\n" +
+	"WriteBatch\x12\x1a.kvstore.WriteBatchRequest\x1a\x1b.kvstore.WriteBatchResponse\x12E\n" +
+	"\n" +
+	"ReplicaPut\x12\x1a.kvstore.ReplicaPutRequest\x1a\x1b.kvstore.ReplicaPutResponse\x12E\n" +
+	"\n" +
+	"ReplicaGet\x12\x1a.kvstore.ReplicaGetRequest\x1a\x1b.kvstore.ReplicaGetResponse\x12T\n" +
+	"\x0fReplicaBatchGet\x12\x1f.kvstore.ReplicaBatchGetRequest\x1a .kvstore.ReplicaBatchGetResponse\x12H\n" +
+	"\vRequestVote\x12\x1b.kvstore.RequestVoteRequest\x1a\x1c.kvstore.RequestVoteResponse\x12N\n" +
+	"\rAppendEntries\x12\x1d.kvstore.AppendEntriesRequest\x1a\x1e.kvstore.AppendEntriesResponse\x129\n" +
+	"\x06Leader\x12\x16.kvstore.LeaderRequest\x1a\x17.kvstore.LeaderResponse\x12?\n" +
+	"\bRingInfo\x12\x18.kvstore.RingInfoRequest\x1a\x19.kvstore.RingInfoResponse\x12?\n" +
+	"\tGetQuorum\x12\x19.kvstore.GetQuorumRequest\x1a\x17.kvstore.QuorumResponse\x12?\n" +
+	"\tSetQuorum\x12\x19.kvstore.SetQuorumRequest\x1a\x17.kvstore.QuorumResponseB\x0fZ\rkvstore/protob\x06proto3"
 
 var (
 	file_proto_kvstore_proto_rawDescOnce sync.Once
@@ -616,35 +3416,129 @@ func file_proto_kvstore_proto_rawDescGZIP() []byte {
 	return file_proto_kvstore_proto_rawDescData
 }
 
-var file_proto_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_proto_kvstore_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_kvstore_proto_msgTypes = make([]protoimpl.MessageInfo, 52)
 var file_proto_kvstore_proto_goTypes = []any{
-	(*PutRequest)(nil),      // 0: kvstore.PutRequest
-	(*PutResponse)(nil),     // 1: kvstore.PutResponse
-	(*GetRequest)(nil),      // 2: kvstore.GetRequest
-	(*GetResponse)(nil),     // 3: kvstore.GetResponse
-	(*DeleteRequest)(nil),   // 4: kvstore.DeleteRequest
-	(*DeleteResponse)(nil),  // 5: kvstore.DeleteResponse
-	(*StatsRequest)(nil),    // 6: kvstore.StatsRequest
-	(*StatsResponse)(nil),   // 7: kvstore.StatsResponse
-	(*CompactRequest)(nil),  // 8: kvstore.CompactRequest
-	(*CompactResponse)(nil), // 9: kvstore.CompactResponse
+	(GetRequest_ChecksumMode)(0),    // 0: kvstore.GetRequest.ChecksumMode
+	(ReplicaPutRequest_Crdt)(0),     // 1: kvstore.ReplicaPutRequest.Crdt
+	(*PutRequest)(nil),              // 2: kvstore.PutRequest
+	(*PutResponse)(nil),             // 3: kvstore.PutResponse
+	(*PutIfAbsentRequest)(nil),      // 4: kvstore.PutIfAbsentRequest
+	(*PutIfAbsentResponse)(nil),     // 5: kvstore.PutIfAbsentResponse
+	(*GetRequest)(nil),              // 6: kvstore.GetRequest
+	(*GetResponse)(nil),             // 7: kvstore.GetResponse
+	(*GetRangeRequest)(nil),         // 8: kvstore.GetRangeRequest
+	(*ExistsRequest)(nil),           // 9: kvstore.ExistsRequest
+	(*ExistsResponse)(nil),          // 10: kvstore.ExistsResponse
+	(*ValueChunk)(nil),              // 11: kvstore.ValueChunk
+	(*PutStreamRequest)(nil),        // 12: kvstore.PutStreamRequest
+	(*DeleteRequest)(nil),           // 13: kvstore.DeleteRequest
+	(*DeleteResponse)(nil),          // 14: kvstore.DeleteResponse
+	(*StatsRequest)(nil),            // 15: kvstore.StatsRequest
+	(*StatsResponse)(nil),           // 16: kvstore.StatsResponse
+	(*CompactRequest)(nil),          // 17: kvstore.CompactRequest
+	(*CompactResponse)(nil),         // 18: kvstore.CompactResponse
+	(*TruncateRequest)(nil),         // 19: kvstore.TruncateRequest
+	(*TruncateResponse)(nil),        // 20: kvstore.TruncateResponse
+	(*SyncRequest)(nil),             // 21: kvstore.SyncRequest
+	(*SyncResponse)(nil),            // 22: kvstore.SyncResponse
+	(*FlushRequest)(nil),            // 23: kvstore.FlushRequest
+	(*FlushResponse)(nil),           // 24: kvstore.FlushResponse
+	(*BatchOperation)(nil),          // 25: kvstore.BatchOperation
+	(*WriteBatchRequest)(nil),       // 26: kvstore.WriteBatchRequest
+	(*WriteBatchResponse)(nil),      // 27: kvstore.WriteBatchResponse
+	(*PingRequest)(nil),             // 28: kvstore.PingRequest
+	(*PingResponse)(nil),            // 29: kvstore.PingResponse
+	(*ScanRequest)(nil),             // 30: kvstore.ScanRequest
+	(*KeyValue)(nil),                // 31: kvstore.KeyValue
+	(*ScanResponse)(nil),            // 32: kvstore.ScanResponse
+	(*WatchRequest)(nil),            // 33: kvstore.WatchRequest
+	(*WatchEvent)(nil),              // 34: kvstore.WatchEvent
+	(*ReplicaPutRequest)(nil),       // 35: kvstore.ReplicaPutRequest
+	(*ReplicaPutResponse)(nil),      // 36: kvstore.ReplicaPutResponse
+	(*ReplicaGetRequest)(nil),       // 37: kvstore.ReplicaGetRequest
+	(*ReplicaGetResponse)(nil),      // 38: kvstore.ReplicaGetResponse
+	(*ReplicaBatchGetRequest)(nil),  // 39: kvstore.ReplicaBatchGetRequest
+	(*ReplicaBatchGetResponse)(nil), // 40: kvstore.ReplicaBatchGetResponse
+	(*LogEntry)(nil),                // 41: kvstore.LogEntry
+	(*RequestVoteRequest)(nil),      // 42: kvstore.RequestVoteRequest
+	(*RequestVoteResponse)(nil),     // 43: kvstore.RequestVoteResponse
+	(*AppendEntriesRequest)(nil),    // 44: kvstore.AppendEntriesRequest
+	(*AppendEntriesResponse)(nil),   // 45: kvstore.AppendEntriesResponse
+	(*LeaderRequest)(nil),           // 46: kvstore.LeaderRequest
+	(*LeaderResponse)(nil),          // 47: kvstore.LeaderResponse
+	(*RingInfoRequest)(nil),         // 48: kvstore.RingInfoRequest
+	(*RingNode)(nil),                // 49: kvstore.RingNode
+	(*RingInfoResponse)(nil),        // 50: kvstore.RingInfoResponse
+	(*GetQuorumRequest)(nil),        // 51: kvstore.GetQuorumRequest
+	(*SetQuorumRequest)(nil),        // 52: kvstore.SetQuorumRequest
+	(*QuorumResponse)(nil),          // 53: kvstore.QuorumResponse
 }
 var file_proto_kvstore_proto_depIdxs = []int32{
-	0, // 0: kvstore.KVStore.Put:input_type -> kvstore.PutRequest
-	2, // 1: kvstore.KVStore.Get:input_type -> kvstore.GetRequest
-	4, // 2: kvstore.KVStore.Delete:input_type -> kvstore.DeleteRequest
-	6, // 3: kvstore.KVStore.Stats:input_type -> kvstore.StatsRequest
-	8, // 4: kvstore.KVStore.Compact:input_type -> kvstore.CompactRequest
-	1, // 5: kvstore.KVStore.Put:output_type -> kvstore.PutResponse
-	3, // 6: kvstore.KVStore.Get:output_type -> kvstore.GetResponse
-	5, // 7: kvstore.KVStore.Delete:output_type -> kvstore.DeleteResponse
-	7, // 8: kvstore.KVStore.Stats:output_type -> kvstore.StatsResponse
-	9, // 9: kvstore.KVStore.Compact:output_type -> kvstore.CompactResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: kvstore.GetRequest.checksums:type_name -> kvstore.GetRequest.ChecksumMode
+	25, // 1: kvstore.WriteBatchRequest.operations:type_name -> kvstore.BatchOperation
+	31, // 2: kvstore.ScanResponse.entries:type_name -> kvstore.KeyValue
+	1,  // 3: kvstore.ReplicaPutRequest.crdt:type_name -> kvstore.ReplicaPutRequest.Crdt
+	38, // 4: kvstore.ReplicaBatchGetResponse.results:type_name -> kvstore.ReplicaGetResponse
+	41, // 5: kvstore.AppendEntriesRequest.entries:type_name -> kvstore.LogEntry
+	49, // 6: kvstore.RingInfoResponse.nodes:type_name -> kvstore.RingNode
+	2,  // 7: kvstore.KVStore.Put:input_type -> kvstore.PutRequest
+	4,  // 8: kvstore.KVStore.PutIfAbsent:input_type -> kvstore.PutIfAbsentRequest
+	6,  // 9: kvstore.KVStore.Get:input_type -> kvstore.GetRequest
+	9,  // 10: kvstore.KVStore.Exists:input_type -> kvstore.ExistsRequest
+	8,  // 11: kvstore.KVStore.GetRange:input_type -> kvstore.GetRangeRequest
+	6,  // 12: kvstore.KVStore.GetStream:input_type -> kvstore.GetRequest
+	12, // 13: kvstore.KVStore.PutStream:input_type -> kvstore.PutStreamRequest
+	13, // 14: kvstore.KVStore.Delete:input_type -> kvstore.DeleteRequest
+	15, // 15: kvstore.KVStore.Stats:input_type -> kvstore.StatsRequest
+	17, // 16: kvstore.KVStore.Compact:input_type -> kvstore.CompactRequest
+	21, // 17: kvstore.KVStore.Sync:input_type -> kvstore.SyncRequest
+	23, // 18: kvstore.KVStore.Flush:input_type -> kvstore.FlushRequest
+	19, // 19: kvstore.KVStore.Truncate:input_type -> kvstore.TruncateRequest
+	30, // 20: kvstore.KVStore.Scan:input_type -> kvstore.ScanRequest
+	33, // 21: kvstore.KVStore.Watch:input_type -> kvstore.WatchRequest
+	28, // 22: kvstore.KVStore.Ping:input_type -> kvstore.PingRequest
+	26, // 23: kvstore.KVStore.WriteBatch:input_type -> kvstore.WriteBatchRequest
+	35, // 24: kvstore.KVStore.ReplicaPut:input_type -> kvstore.ReplicaPutRequest
+	37, // 25: kvstore.KVStore.ReplicaGet:input_type -> kvstore.ReplicaGetRequest
+	39, // 26: kvstore.KVStore.ReplicaBatchGet:input_type -> kvstore.ReplicaBatchGetRequest
+	42, // 27: kvstore.KVStore.RequestVote:input_type -> kvstore.RequestVoteRequest
+	44, // 28: kvstore.KVStore.AppendEntries:input_type -> kvstore.AppendEntriesRequest
+	46, // 29: kvstore.KVStore.Leader:input_type -> kvstore.LeaderRequest
+	48, // 30: kvstore.KVStore.RingInfo:input_type -> kvstore.RingInfoRequest
+	51, // 31: kvstore.KVStore.GetQuorum:input_type -> kvstore.GetQuorumRequest
+	52, // 32: kvstore.KVStore.SetQuorum:input_type -> kvstore.SetQuorumRequest
+	3,  // 33: kvstore.KVStore.Put:output_type -> kvstore.PutResponse
+	5,  // 34: kvstore.KVStore.PutIfAbsent:output_type -> kvstore.PutIfAbsentResponse
+	7,  // 35: kvstore.KVStore.Get:output_type -> kvstore.GetResponse
+	10, // 36: kvstore.KVStore.Exists:output_type -> kvstore.ExistsResponse
+	7,  // 37: kvstore.KVStore.GetRange:output_type -> kvstore.GetResponse
+	11, // 38: kvstore.KVStore.GetStream:output_type -> kvstore.ValueChunk
+	3,  // 39: kvstore.KVStore.PutStream:output_type -> kvstore.PutResponse
+	14, // 40: kvstore.KVStore.Delete:output_type -> kvstore.DeleteResponse
+	16, // 41: kvstore.KVStore.Stats:output_type -> kvstore.StatsResponse
+	18, // 42: kvstore.KVStore.Compact:output_type -> kvstore.CompactResponse
+	22, // 43: kvstore.KVStore.Sync:output_type -> kvstore.SyncResponse
+	24, // 44: kvstore.KVStore.Flush:output_type -> kvstore.FlushResponse
+	20, // 45: kvstore.KVStore.Truncate:output_type -> kvstore.TruncateResponse
+	32, // 46: kvstore.KVStore.Scan:output_type -> kvstore.ScanResponse
+	34, // 47: kvstore.KVStore.Watch:output_type -> kvstore.WatchEvent
+	29, // 48: kvstore.KVStore.Ping:output_type -> kvstore.PingResponse
+	27, // 49: kvstore.KVStore.WriteBatch:output_type -> kvstore.WriteBatchResponse
+	36, // 50: kvstore.KVStore.ReplicaPut:output_type -> kvstore.ReplicaPutResponse
+	38, // 51: kvstore.KVStore.ReplicaGet:output_type -> kvstore.ReplicaGetResponse
+	40, // 52: kvstore.KVStore.ReplicaBatchGet:output_type -> kvstore.ReplicaBatchGetResponse
+	43, // 53: kvstore.KVStore.RequestVote:output_type -> kvstore.RequestVoteResponse
+	45, // 54: kvstore.KVStore.AppendEntries:output_type -> kvstore.AppendEntriesResponse
+	47, // 55: kvstore.KVStore.Leader:output_type -> kvstore.LeaderResponse
+	50, // 56: kvstore.KVStore.RingInfo:output_type -> kvstore.RingInfoResponse
+	53, // 57: kvstore.KVStore.GetQuorum:output_type -> kvstore.QuorumResponse
+	53, // 58: kvstore.KVStore.SetQuorum:output_type -> kvstore.QuorumResponse
+	33, // [33:59] is the sub-list for method output_type
+	7,  // [7:33] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_proto_kvstore_proto_init() }
@@ -657,13 +3551,14 @@ func file_proto_kvstore_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_kvstore_proto_rawDesc), len(file_proto_kvstore_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   10,
+			NumEnums:      2,
+			NumMessages:   52,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_kvstore_proto_goTypes,
 		DependencyIndexes: file_proto_kvstore_proto_depIdxs,
+		EnumInfos:         file_proto_kvstore_proto_enumTypes,
 		MessageInfos:      file_proto_kvstore_proto_msgTypes,
 	}.Build()
 	File_proto_kvstore_proto = out.File
@@ -20,11 +20,32 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	KVStore_Put_FullMethodName     = "/kvstore.KVStore/Put"
-	KVStore_Get_FullMethodName     = "/kvstore.KVStore/Get"
-	KVStore_Delete_FullMethodName  = "/kvstore.KVStore/Delete"
-	KVStore_Stats_FullMethodName   = "/kvstore.KVStore/Stats"
-	KVStore_Compact_FullMethodName = "/kvstore.KVStore/Compact"
+	KVStore_Put_FullMethodName             = "/kvstore.KVStore/Put"
+	KVStore_PutIfAbsent_FullMethodName     = "/kvstore.KVStore/PutIfAbsent"
+	KVStore_Get_FullMethodName             = "/kvstore.KVStore/Get"
+	KVStore_Exists_FullMethodName          = "/kvstore.KVStore/Exists"
+	KVStore_GetRange_FullMethodName        = "/kvstore.KVStore/GetRange"
+	KVStore_GetStream_FullMethodName       = "/kvstore.KVStore/GetStream"
+	KVStore_PutStream_FullMethodName       = "/kvstore.KVStore/PutStream"
+	KVStore_Delete_FullMethodName          = "/kvstore.KVStore/Delete"
+	KVStore_Stats_FullMethodName           = "/kvstore.KVStore/Stats"
+	KVStore_Compact_FullMethodName         = "/kvstore.KVStore/Compact"
+	KVStore_Sync_FullMethodName            = "/kvstore.KVStore/Sync"
+	KVStore_Flush_FullMethodName           = "/kvstore.KVStore/Flush"
+	KVStore_Truncate_FullMethodName        = "/kvstore.KVStore/Truncate"
+	KVStore_Scan_FullMethodName            = "/kvstore.KVStore/Scan"
+	KVStore_Watch_FullMethodName           = "/kvstore.KVStore/Watch"
+	KVStore_Ping_FullMethodName            = "/kvstore.KVStore/Ping"
+	KVStore_WriteBatch_FullMethodName      = "/kvstore.KVStore/WriteBatch"
+	KVStore_ReplicaPut_FullMethodName      = "/kvstore.KVStore/ReplicaPut"
+	KVStore_ReplicaGet_FullMethodName      = "/kvstore.KVStore/ReplicaGet"
+	KVStore_ReplicaBatchGet_FullMethodName = "/kvstore.KVStore/ReplicaBatchGet"
+	KVStore_RequestVote_FullMethodName     = "/kvstore.KVStore/RequestVote"
+	KVStore_AppendEntries_FullMethodName   = "/kvstore.KVStore/AppendEntries"
+	KVStore_Leader_FullMethodName          = "/kvstore.KVStore/Leader"
+	KVStore_RingInfo_FullMethodName        = "/kvstore.KVStore/RingInfo"
+	KVStore_GetQuorum_FullMethodName       = "/kvstore.KVStore/GetQuorum"
+	KVStore_SetQuorum_FullMethodName       = "/kvstore.KVStore/SetQuorum"
 )
 
 // KVStoreClient is the client API for KVStore service.
@@ -35,14 +56,56 @@ const (
 type KVStoreClient interface {
 	// Put stores a key-value pair
 	Put(ctx context.Context, in *PutRequest, opts ...grpc.CallOption) (*PutResponse, error)
+	// PutIfAbsent stores a key-value pair only if the key does not exist
+	PutIfAbsent(ctx context.Context, in *PutIfAbsentRequest, opts ...grpc.CallOption) (*PutIfAbsentResponse, error)
 	// Get retrieves a value by key
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Exists reports whether a key has a value, without returning the value
+	Exists(ctx context.Context, in *ExistsRequest, opts ...grpc.CallOption) (*ExistsResponse, error)
+	// GetRange retrieves a byte range of a value by key
+	GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// GetStream retrieves a value by key as a sequence of chunks (large values)
+	GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ValueChunk], error)
+	// PutStream stores a value sent as a sequence of chunks (large values)
+	PutStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PutStreamRequest, PutResponse], error)
 	// Delete removes a key-value pair
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
 	// Stats returns storage statistics
 	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
 	// Compact triggers manual compaction
 	Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*CompactResponse, error)
+	// Sync fsyncs the WAL so every write acknowledged before it is durable
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncResponse, error)
+	// Flush writes the MemTable to an SSTable without waiting for the size threshold
+	Flush(ctx context.Context, in *FlushRequest, opts ...grpc.CallOption) (*FlushResponse, error)
+	// Truncate deletes every key (admin only: disabled unless the server is started with -allow-truncate)
+	Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*TruncateResponse, error)
+	// Scan returns the key-value pairs in a key range
+	Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error)
+	// Watch streams every mutation made after the call, optionally filtered by key prefix
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error)
+	// Ping echoes a nonce without touching storage (latency and liveness checks)
+	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
+	// WriteBatch applies several puts and deletes atomically
+	WriteBatch(ctx context.Context, in *WriteBatchRequest, opts ...grpc.CallOption) (*WriteBatchResponse, error)
+	// ReplicaPut stores a versioned value on a replica (internal replication)
+	ReplicaPut(ctx context.Context, in *ReplicaPutRequest, opts ...grpc.CallOption) (*ReplicaPutResponse, error)
+	// ReplicaGet reads a versioned value from a replica (quorum reads)
+	ReplicaGet(ctx context.Context, in *ReplicaGetRequest, opts ...grpc.CallOption) (*ReplicaGetResponse, error)
+	// ReplicaBatchGet reads several versioned values from a replica in one call (batched quorum reads)
+	ReplicaBatchGet(ctx context.Context, in *ReplicaBatchGetRequest, opts ...grpc.CallOption) (*ReplicaBatchGetResponse, error)
+	// RequestVote is invoked by Raft candidates to gather votes
+	RequestVote(ctx context.Context, in *RequestVoteRequest, opts ...grpc.CallOption) (*RequestVoteResponse, error)
+	// AppendEntries is invoked by the Raft leader to replicate log entries (also used as heartbeat)
+	AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error)
+	// Leader returns the current Raft leader, if one is known
+	Leader(ctx context.Context, in *LeaderRequest, opts ...grpc.CallOption) (*LeaderResponse, error)
+	// RingInfo describes the consistent hash ring (nodes, virtual nodes, simulated key balance)
+	RingInfo(ctx context.Context, in *RingInfoRequest, opts ...grpc.CallOption) (*RingInfoResponse, error)
+	// GetQuorum returns the replication factor and W/R quorums in effect
+	GetQuorum(ctx context.Context, in *GetQuorumRequest, opts ...grpc.CallOption) (*QuorumResponse, error)
+	// SetQuorum changes W and R at runtime (admin; validated against N and W+R > N)
+	SetQuorum(ctx context.Context, in *SetQuorumRequest, opts ...grpc.CallOption) (*QuorumResponse, error)
 }
 
 type kVStoreClient struct {
@@ -63,6 +126,16 @@ func (c *kVStoreClient) Put(ctx context.Context, in *PutRequest, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *kVStoreClient) PutIfAbsent(ctx context.Context, in *PutIfAbsentRequest, opts ...grpc.CallOption) (*PutIfAbsentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutIfAbsentResponse)
+	err := c.cc.Invoke(ctx, KVStore_PutIfAbsent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *kVStoreClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(GetResponse)
@@ -73,6 +146,58 @@ func (c *kVStoreClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *kVStoreClient) Exists(ctx context.Context, in *ExistsRequest, opts ...grpc.CallOption) (*ExistsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExistsResponse)
+	err := c.cc.Invoke(ctx, KVStore_Exists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) GetRange(ctx context.Context, in *GetRangeRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, KVStore_GetRange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) GetStream(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ValueChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVStore_ServiceDesc.Streams[0], KVStore_GetStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetRequest, ValueChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_GetStreamClient = grpc.ServerStreamingClient[ValueChunk]
+
+func (c *kVStoreClient) PutStream(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PutStreamRequest, PutResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVStore_ServiceDesc.Streams[1], KVStore_PutStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PutStreamRequest, PutResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_PutStreamClient = grpc.ClientStreamingClient[PutStreamRequest, PutResponse]
+
 func (c *kVStoreClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DeleteResponse)
@@ -103,6 +228,175 @@ func (c *kVStoreClient) Compact(ctx context.Context, in *CompactRequest, opts ..
 	return out, nil
 }
 
+func (c *kVStoreClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*SyncResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SyncResponse)
+	err := c.cc.Invoke(ctx, KVStore_Sync_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Flush(ctx context.Context, in *FlushRequest, opts ...grpc.CallOption) (*FlushResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FlushResponse)
+	err := c.cc.Invoke(ctx, KVStore_Flush_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Truncate(ctx context.Context, in *TruncateRequest, opts ...grpc.CallOption) (*TruncateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TruncateResponse)
+	err := c.cc.Invoke(ctx, KVStore_Truncate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Scan(ctx context.Context, in *ScanRequest, opts ...grpc.CallOption) (*ScanResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScanResponse)
+	err := c.cc.Invoke(ctx, KVStore_Scan_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVStore_ServiceDesc.Streams[2], KVStore_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_WatchClient = grpc.ServerStreamingClient[WatchEvent]
+
+func (c *kVStoreClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PingResponse)
+	err := c.cc.Invoke(ctx, KVStore_Ping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) WriteBatch(ctx context.Context, in *WriteBatchRequest, opts ...grpc.CallOption) (*WriteBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteBatchResponse)
+	err := c.cc.Invoke(ctx, KVStore_WriteBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) ReplicaPut(ctx context.Context, in *ReplicaPutRequest, opts ...grpc.CallOption) (*ReplicaPutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaPutResponse)
+	err := c.cc.Invoke(ctx, KVStore_ReplicaPut_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) ReplicaGet(ctx context.Context, in *ReplicaGetRequest, opts ...grpc.CallOption) (*ReplicaGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaGetResponse)
+	err := c.cc.Invoke(ctx, KVStore_ReplicaGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) ReplicaBatchGet(ctx context.Context, in *ReplicaBatchGetRequest, opts ...grpc.CallOption) (*ReplicaBatchGetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicaBatchGetResponse)
+	err := c.cc.Invoke(ctx, KVStore_ReplicaBatchGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) RequestVote(ctx context.Context, in *RequestVoteRequest, opts ...grpc.CallOption) (*RequestVoteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestVoteResponse)
+	err := c.cc.Invoke(ctx, KVStore_RequestVote_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) AppendEntries(ctx context.Context, in *AppendEntriesRequest, opts ...grpc.CallOption) (*AppendEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AppendEntriesResponse)
+	err := c.cc.Invoke(ctx, KVStore_AppendEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) Leader(ctx context.Context, in *LeaderRequest, opts ...grpc.CallOption) (*LeaderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LeaderResponse)
+	err := c.cc.Invoke(ctx, KVStore_Leader_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) RingInfo(ctx context.Context, in *RingInfoRequest, opts ...grpc.CallOption) (*RingInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RingInfoResponse)
+	err := c.cc.Invoke(ctx, KVStore_RingInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) GetQuorum(ctx context.Context, in *GetQuorumRequest, opts ...grpc.CallOption) (*QuorumResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuorumResponse)
+	err := c.cc.Invoke(ctx, KVStore_GetQuorum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVStoreClient) SetQuorum(ctx context.Context, in *SetQuorumRequest, opts ...grpc.CallOption) (*QuorumResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuorumResponse)
+	err := c.cc.Invoke(ctx, KVStore_SetQuorum_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KVStoreServer is the server API for KVStore service.
 // All implementations must embed UnimplementedKVStoreServer
 // for forward compatibility.
@@ -111,14 +405,56 @@ func (c *kVStoreClient) Compact(ctx context.Context, in *CompactRequest, opts ..
 type KVStoreServer interface {
 	// Put stores a key-value pair
 	Put(context.Context, *PutRequest) (*PutResponse, error)
+	// PutIfAbsent stores a key-value pair only if the key does not exist
+	PutIfAbsent(context.Context, *PutIfAbsentRequest) (*PutIfAbsentResponse, error)
 	// Get retrieves a value by key
 	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Exists reports whether a key has a value, without returning the value
+	Exists(context.Context, *ExistsRequest) (*ExistsResponse, error)
+	// GetRange retrieves a byte range of a value by key
+	GetRange(context.Context, *GetRangeRequest) (*GetResponse, error)
+	// GetStream retrieves a value by key as a sequence of chunks (large values)
+	GetStream(*GetRequest, grpc.ServerStreamingServer[ValueChunk]) error
+	// PutStream stores a value sent as a sequence of chunks (large values)
+	PutStream(grpc.ClientStreamingServer[PutStreamRequest, PutResponse]) error
 	// Delete removes a key-value pair
 	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
 	// Stats returns storage statistics
 	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
 	// Compact triggers manual compaction
 	Compact(context.Context, *CompactRequest) (*CompactResponse, error)
+	// Sync fsyncs the WAL so every write acknowledged before it is durable
+	Sync(context.Context, *SyncRequest) (*SyncResponse, error)
+	// Flush writes the MemTable to an SSTable without waiting for the size threshold
+	Flush(context.Context, *FlushRequest) (*FlushResponse, error)
+	// Truncate deletes every key (admin only: disabled unless the server is started with -allow-truncate)
+	Truncate(context.Context, *TruncateRequest) (*TruncateResponse, error)
+	// Scan returns the key-value pairs in a key range
+	Scan(context.Context, *ScanRequest) (*ScanResponse, error)
+	// Watch streams every mutation made after the call, optionally filtered by key prefix
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error
+	// Ping echoes a nonce without touching storage (latency and liveness checks)
+	Ping(context.Context, *PingRequest) (*PingResponse, error)
+	// WriteBatch applies several puts and deletes atomically
+	WriteBatch(context.Context, *WriteBatchRequest) (*WriteBatchResponse, error)
+	// ReplicaPut stores a versioned value on a replica (internal replication)
+	ReplicaPut(context.Context, *ReplicaPutRequest) (*ReplicaPutResponse, error)
+	// ReplicaGet reads a versioned value from a replica (quorum reads)
+	ReplicaGet(context.Context, *ReplicaGetRequest) (*ReplicaGetResponse, error)
+	// ReplicaBatchGet reads several versioned values from a replica in one call (batched quorum reads)
+	ReplicaBatchGet(context.Context, *ReplicaBatchGetRequest) (*ReplicaBatchGetResponse, error)
+	// RequestVote is invoked by Raft candidates to gather votes
+	RequestVote(context.Context, *RequestVoteRequest) (*RequestVoteResponse, error)
+	// AppendEntries is invoked by the Raft leader to replicate log entries (also used as heartbeat)
+	AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error)
+	// Leader returns the current Raft leader, if one is known
+	Leader(context.Context, *LeaderRequest) (*LeaderResponse, error)
+	// RingInfo describes the consistent hash ring (nodes, virtual nodes, simulated key balance)
+	RingInfo(context.Context, *RingInfoRequest) (*RingInfoResponse, error)
+	// GetQuorum returns the replication factor and W/R quorums in effect
+	GetQuorum(context.Context, *GetQuorumRequest) (*QuorumResponse, error)
+	// SetQuorum changes W and R at runtime (admin; validated against N and W+R > N)
+	SetQuorum(context.Context, *SetQuorumRequest) (*QuorumResponse, error)
 	mustEmbedUnimplementedKVStoreServer()
 }
 
@@ -132,9 +468,24 @@ type UnimplementedKVStoreServer struct{}
 func (UnimplementedKVStoreServer) Put(context.Context, *PutRequest) (*PutResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Put not implemented")
 }
+func (UnimplementedKVStoreServer) PutIfAbsent(context.Context, *PutIfAbsentRequest) (*PutIfAbsentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PutIfAbsent not implemented")
+}
 func (UnimplementedKVStoreServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedKVStoreServer) Exists(context.Context, *ExistsRequest) (*ExistsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Exists not implemented")
+}
+func (UnimplementedKVStoreServer) GetRange(context.Context, *GetRangeRequest) (*GetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRange not implemented")
+}
+func (UnimplementedKVStoreServer) GetStream(*GetRequest, grpc.ServerStreamingServer[ValueChunk]) error {
+	return status.Error(codes.Unimplemented, "method GetStream not implemented")
+}
+func (UnimplementedKVStoreServer) PutStream(grpc.ClientStreamingServer[PutStreamRequest, PutResponse]) error {
+	return status.Error(codes.Unimplemented, "method PutStream not implemented")
+}
 func (UnimplementedKVStoreServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
 }
@@ -144,6 +495,54 @@ func (UnimplementedKVStoreServer) Stats(context.Context, *StatsRequest) (*StatsR
 func (UnimplementedKVStoreServer) Compact(context.Context, *CompactRequest) (*CompactResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Compact not implemented")
 }
+func (UnimplementedKVStoreServer) Sync(context.Context, *SyncRequest) (*SyncResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedKVStoreServer) Flush(context.Context, *FlushRequest) (*FlushResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Flush not implemented")
+}
+func (UnimplementedKVStoreServer) Truncate(context.Context, *TruncateRequest) (*TruncateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Truncate not implemented")
+}
+func (UnimplementedKVStoreServer) Scan(context.Context, *ScanRequest) (*ScanResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Scan not implemented")
+}
+func (UnimplementedKVStoreServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchEvent]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedKVStoreServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Ping not implemented")
+}
+func (UnimplementedKVStoreServer) WriteBatch(context.Context, *WriteBatchRequest) (*WriteBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method WriteBatch not implemented")
+}
+func (UnimplementedKVStoreServer) ReplicaPut(context.Context, *ReplicaPutRequest) (*ReplicaPutResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReplicaPut not implemented")
+}
+func (UnimplementedKVStoreServer) ReplicaGet(context.Context, *ReplicaGetRequest) (*ReplicaGetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReplicaGet not implemented")
+}
+func (UnimplementedKVStoreServer) ReplicaBatchGet(context.Context, *ReplicaBatchGetRequest) (*ReplicaBatchGetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReplicaBatchGet not implemented")
+}
+func (UnimplementedKVStoreServer) RequestVote(context.Context, *RequestVoteRequest) (*RequestVoteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RequestVote not implemented")
+}
+func (UnimplementedKVStoreServer) AppendEntries(context.Context, *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AppendEntries not implemented")
+}
+func (UnimplementedKVStoreServer) Leader(context.Context, *LeaderRequest) (*LeaderResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Leader not implemented")
+}
+func (UnimplementedKVStoreServer) RingInfo(context.Context, *RingInfoRequest) (*RingInfoResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RingInfo not implemented")
+}
+func (UnimplementedKVStoreServer) GetQuorum(context.Context, *GetQuorumRequest) (*QuorumResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetQuorum not implemented")
+}
+func (UnimplementedKVStoreServer) SetQuorum(context.Context, *SetQuorumRequest) (*QuorumResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetQuorum not implemented")
+}
 func (UnimplementedKVStoreServer) mustEmbedUnimplementedKVStoreServer() {}
 func (UnimplementedKVStoreServer) testEmbeddedByValue()                 {}
 
@@ -183,6 +582,24 @@ func _KVStore_Put_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVStore_PutIfAbsent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutIfAbsentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).PutIfAbsent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_PutIfAbsent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).PutIfAbsent(ctx, req.(*PutIfAbsentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KVStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetRequest)
 	if err := dec(in); err != nil {
@@ -201,6 +618,60 @@ func _KVStore_Get_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVStore_Exists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Exists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Exists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Exists(ctx, req.(*ExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_GetRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).GetRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_GetRange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).GetRange(ctx, req.(*GetRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_GetStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).GetStream(m, &grpc.GenericServerStream[GetRequest, ValueChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_GetStreamServer = grpc.ServerStreamingServer[ValueChunk]
+
+func _KVStore_PutStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KVStoreServer).PutStream(&grpc.GenericServerStream[PutStreamRequest, PutResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_PutStreamServer = grpc.ClientStreamingServer[PutStreamRequest, PutResponse]
+
 func _KVStore_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteRequest)
 	if err := dec(in); err != nil {
@@ -255,6 +726,287 @@ func _KVStore_Compact_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVStore_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Sync_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Flush_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Flush(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Flush_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Flush(ctx, req.(*FlushRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Truncate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TruncateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Truncate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Truncate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Truncate(ctx, req.(*TruncateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Scan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Scan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Scan_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Scan(ctx, req.(*ScanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVStoreServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVStore_WatchServer = grpc.ServerStreamingServer[WatchEvent]
+
+func _KVStore_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Ping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Ping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Ping(ctx, req.(*PingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_WriteBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).WriteBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_WriteBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).WriteBatch(ctx, req.(*WriteBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_ReplicaPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaPutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).ReplicaPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_ReplicaPut_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).ReplicaPut(ctx, req.(*ReplicaPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_ReplicaGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).ReplicaGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_ReplicaGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).ReplicaGet(ctx, req.(*ReplicaGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_ReplicaBatchGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicaBatchGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).ReplicaBatchGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_ReplicaBatchGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).ReplicaBatchGet(ctx, req.(*ReplicaBatchGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_RequestVote_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestVoteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).RequestVote(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_RequestVote_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).RequestVote(ctx, req.(*RequestVoteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_AppendEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AppendEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).AppendEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_AppendEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).AppendEntries(ctx, req.(*AppendEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_Leader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).Leader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_Leader_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).Leader(ctx, req.(*LeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_RingInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RingInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).RingInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_RingInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).RingInfo(ctx, req.(*RingInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_GetQuorum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQuorumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).GetQuorum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_GetQuorum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).GetQuorum(ctx, req.(*GetQuorumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVStore_SetQuorum_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetQuorumRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVStoreServer).SetQuorum(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVStore_SetQuorum_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVStoreServer).SetQuorum(ctx, req.(*SetQuorumRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // KVStore_ServiceDesc is the grpc.ServiceDesc for KVStore service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -266,10 +1018,22 @@ var KVStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Put",
 			Handler:    _KVStore_Put_Handler,
 		},
+		{
+			MethodName: "PutIfAbsent",
+			Handler:    _KVStore_PutIfAbsent_Handler,
+		},
 		{
 			MethodName: "Get",
 			Handler:    _KVStore_Get_Handler,
 		},
+		{
+			MethodName: "Exists",
+			Handler:    _KVStore_Exists_Handler,
+		},
+		{
+			MethodName: "GetRange",
+			Handler:    _KVStore_GetRange_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _KVStore_Delete_Handler,
@@ -282,7 +1046,83 @@ var KVStore_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Compact",
 			Handler:    _KVStore_Compact_Handler,
 		},
+		{
+			MethodName: "Sync",
+			Handler:    _KVStore_Sync_Handler,
+		},
+		{
+			MethodName: "Flush",
+			Handler:    _KVStore_Flush_Handler,
+		},
+		{
+			MethodName: "Truncate",
+			Handler:    _KVStore_Truncate_Handler,
+		},
+		{
+			MethodName: "Scan",
+			Handler:    _KVStore_Scan_Handler,
+		},
+		{
+			MethodName: "Ping",
+			Handler:    _KVStore_Ping_Handler,
+		},
+		{
+			MethodName: "WriteBatch",
+			Handler:    _KVStore_WriteBatch_Handler,
+		},
+		{
+			MethodName: "ReplicaPut",
+			Handler:    _KVStore_ReplicaPut_Handler,
+		},
+		{
+			MethodName: "ReplicaGet",
+			Handler:    _KVStore_ReplicaGet_Handler,
+		},
+		{
+			MethodName: "ReplicaBatchGet",
+			Handler:    _KVStore_ReplicaBatchGet_Handler,
+		},
+		{
+			MethodName: "RequestVote",
+			Handler:    _KVStore_RequestVote_Handler,
+		},
+		{
+			MethodName: "AppendEntries",
+			Handler:    _KVStore_AppendEntries_Handler,
+		},
+		{
+			MethodName: "Leader",
+			Handler:    _KVStore_Leader_Handler,
+		},
+		{
+			MethodName: "RingInfo",
+			Handler:    _KVStore_RingInfo_Handler,
+		},
+		{
+			MethodName: "GetQuorum",
+			Handler:    _KVStore_GetQuorum_Handler,
+		},
+		{
+			MethodName: "SetQuorum",
+			Handler:    _KVStore_SetQuorum_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetStream",
+			Handler:       _KVStore_GetStream_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "PutStream",
+			Handler:       _KVStore_PutStream_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _KVStore_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/kvstore.proto",
 }
@@ -0,0 +1,11 @@
+package proto
+
+// DefaultMaxMessageSize is the largest gRPC message (16MB) the server and
+// every client (KVClient, ClusterClient, the Raft client) send or accept,
+// raised from gRPC's 4MB default so that single Puts, Gets and Scan or
+// WriteBatch payloads of a few MB don't fail with ResourceExhausted.
+//
+// A bigger limit lets each in-flight call buffer that much memory on both
+// ends, so it is not raised further: values up to storage.MaxValueSize (32MB)
+// should use GetStream and PutStream, which send them in small chunks.
+const DefaultMaxMessageSize = 16 * 1024 * 1024
@@ -10,17 +10,27 @@ import (
 func (rn *RaftNode) startElection() {
 	rn.mu.Lock()
 
+	// A node outside the configuration (joining or removed) never campaigns
+	if !rn.isMemberLocked() {
+		rn.mu.Unlock()
+		rn.resetElectionTimer()
+		return
+	}
+
 	// Become candidate
 	oldState := rn.state
 	rn.state = Candidate
 	rn.currentTerm++
 	rn.votedFor = rn.id
+	rn.leaderID = ""
 	currentTerm := rn.currentTerm
 
 	// Get log info for RequestVote
 	lastLogIndex := uint64(len(rn.log) - 1)
 	lastLogTerm := rn.log[lastLogIndex].Term
 
+	peers := rn.peers
+	votesNeeded := rn.quorumLocked()
 	rn.mu.Unlock()
 
 	rn.logger.LogStateChange(oldState, Candidate, currentTerm)
@@ -31,22 +41,29 @@ func (rn *RaftNode) startElection() {
 
 	// Vote for self
 	votesReceived := 1
-	votesNeeded := len(rn.peers)/2 + 1
+
+	// A single-node cluster wins on its own vote
+	if votesReceived >= votesNeeded {
+		rn.logger.LogElectionWon(currentTerm, uint64(votesReceived), uint64(votesNeeded))
+		rn.becomeLeader(currentTerm)
+		return
+	}
 
 	// Request votes from all peers
-	voteCh := make(chan bool, len(rn.peers))
+	voteCh := make(chan bool, len(peers))
 
-	for _, peer := range rn.peers {
-		go func(peerID string) {
+	for _, peer := range peers {
+		peerID := peer
+		rn.goPeerRPC(func() {
 			vote := rn.requestVote(peerID, currentTerm, lastLogIndex, lastLogTerm)
 			voteCh <- vote
-		}(peer)
+		})
 	}
 
 	// Collect votes (with timeout)
 	timeout := time.After(rn.electionTimeout)
 
-	for i := 0; i < len(rn.peers); i++ {
+	for i := 0; i < len(peers); i++ {
 		select {
 		case vote := <-voteCh:
 			if vote {
@@ -84,20 +101,22 @@ func (rn *RaftNode) becomeLeader(term uint64) {
 
 	oldState := rn.state
 	rn.state = Leader
+	rn.leaderID = rn.id
 	rn.logger.LogStateChange(oldState, Leader, term)
 
-	// Initialize leader state
+	// Initialize leader state. Every peer starts out counted as reachable:
+	// they just voted, or are given an election timeout to answer.
 	lastLogIndex := uint64(len(rn.log) - 1)
+	now := time.Now()
 	for peer := range rn.nextIndex {
 		rn.nextIndex[peer] = lastLogIndex + 1
 		rn.matchIndex[peer] = 0
+		rn.lastContact[peer] = now
 	}
 
 	// Stop election timer, start heartbeat timer
-	if rn.electionTimer != nil {
-		rn.electionTimer.Stop()
-		rn.logger.Debug("Stopped election timer")
-	}
+	rn.electionTimer.Stop()
+	rn.logger.Debug("Stopped election timer")
 
 	if rn.heartbeatTimer != nil {
 		rn.heartbeatTimer.Stop()
@@ -118,7 +137,14 @@ func (rn *RaftNode) requestVote(peerID string, term, lastLogIndex, lastLogTerm u
 		LastLogTerm:  lastLogTerm,
 	}
 
-	resp, err := rn.rpcClient.RequestVote(rn.peerAddresses[peerID], req)
+	rn.mu.RLock()
+	address := rn.peerAddresses[peerID]
+	rn.mu.RUnlock()
+
+	resp, err := rn.rpcClient.RequestVote(address, req)
+	if rn.shuttingDown() {
+		return false
+	}
 	if err != nil {
 		rn.logger.Debug("RequestVote to %s failed: %v", peerID, err)
 		return false
@@ -155,6 +181,7 @@ func (rn *RaftNode) RequestVote(req *RequestVoteRequest) *RequestVoteResponse {
 	if req.Term > rn.currentTerm {
 		rn.currentTerm = req.Term
 		rn.votedFor = ""
+		rn.leaderID = ""
 		rn.state = Follower
 	}
 
@@ -215,6 +242,7 @@ func (rn *RaftNode) stepDown(term uint64) {
 		oldState := rn.state
 		rn.currentTerm = term
 		rn.votedFor = ""
+		rn.leaderID = ""
 		rn.state = Follower
 
 		if oldState != Follower {
@@ -228,7 +256,44 @@ func (rn *RaftNode) stepDown(term uint64) {
 	}
 }
 
-// sendHeartbeats sends empty AppendEntries RPCs to all peers
+// checkQuorum reports whether this node is still the leader, stepping down
+// first if fewer than a majority of the cluster (itself included) answered
+// an AppendEntries within the last election timeout. A leader cut off on the
+// minority side of a partition would otherwise go on accepting writes that
+// can never commit while the majority elects a new leader.
+func (rn *RaftNode) checkQuorum() bool {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	if rn.state != Leader {
+		return false
+	}
+
+	contacted := 0
+	if rn.isMemberLocked() {
+		contacted++
+	}
+	cutoff := time.Now().Add(-rn.electionTimeout)
+	for _, peer := range rn.peers {
+		if rn.lastContact[peer].After(cutoff) {
+			contacted++
+		}
+	}
+	if contacted >= rn.quorumLocked() {
+		return true
+	}
+
+	rn.logger.Warn("⬇️  Lost contact with a majority (%d/%d reachable), stepping down", contacted, len(rn.servers))
+	rn.logger.LogStateChange(rn.state, Follower, rn.currentTerm)
+	rn.state = Follower
+	rn.leaderID = ""
+	rn.heartbeatTimer.Stop()
+	rn.resetElectionTimer()
+	return false
+}
+
+// sendHeartbeats sends AppendEntries RPCs to all peers. Peers that are
+// behind also receive their missing entries, so heartbeats retry replication.
 func (rn *RaftNode) sendHeartbeats() {
 	rn.mu.RLock()
 	if rn.state != Leader {
@@ -237,47 +302,18 @@ func (rn *RaftNode) sendHeartbeats() {
 	}
 
 	currentTerm := rn.currentTerm
-	commitIndex := rn.commitIndex
-	peerCount := len(rn.peers)
+	peers := rn.peers
 	rn.mu.RUnlock()
 
-	rn.logger.LogHeartbeatSent(currentTerm, peerCount)
-
-	for _, peer := range rn.peers {
-		go func(peerID string) {
-			// Get log info for this peer
-			rn.mu.RLock()
-			prevLogIndex := rn.nextIndex[peerID] - 1
-			prevLogTerm := uint64(0)
-			if prevLogIndex > 0 && prevLogIndex < uint64(len(rn.log)) {
-				prevLogTerm = rn.log[prevLogIndex].Term
-			}
-			rn.mu.RUnlock()
-
-			// Send empty AppendEntries (heartbeat)
-			req := &AppendEntriesRequest{
-				Term:         currentTerm,
-				LeaderID:     rn.id,
-				PrevLogIndex: prevLogIndex,
-				PrevLogTerm:  prevLogTerm,
-				Entries:      nil, // empty = heartbeat
-				LeaderCommit: commitIndex,
-			}
-
-			resp, err := rn.rpcClient.AppendEntries(rn.peerAddresses[peerID], req)
-			if err != nil {
-				return
-			}
+	rn.logger.LogHeartbeatSent(currentTerm, len(peers))
 
-			// If peer has higher term, step down
-			if resp.Term > currentTerm {
-				rn.stepDown(resp.Term)
-			}
-		}(peer)
+	for _, peer := range peers {
+		peerID := peer
+		rn.goPeerRPC(func() { rn.sendAppendEntries(peerID, currentTerm) })
 	}
 }
 
-// AppendEntries RPC handler (for Week 7: heartbeats only)
+// AppendEntries RPC handler
 func (rn *RaftNode) AppendEntries(req *AppendEntriesRequest) *AppendEntriesResponse {
 	rn.mu.Lock()
 
@@ -294,29 +330,87 @@ func (rn *RaftNode) AppendEntries(req *AppendEntriesRequest) *AppendEntriesRespo
 	if req.Term > rn.currentTerm {
 		rn.currentTerm = req.Term
 		rn.votedFor = ""
-		if rn.state != Follower {
-			oldState := rn.state
-			rn.state = Follower
-			rn.logger.LogStateChange(oldState, Follower, req.Term)
-		}
 	}
 
-	currentTerm := rn.currentTerm
+	// A candidate that hears from the leader of its term also steps back
+	if rn.state != Follower {
+		oldState := rn.state
+		rn.state = Follower
+		rn.logger.LogStateChange(oldState, Follower, req.Term)
+	}
+
+	// Remember who the leader is so clients can be redirected
+	if rn.leaderID != req.LeaderID {
+		rn.leaderID = req.LeaderID
+		rn.logger.LogLeaderDiscovered(req.LeaderID, req.Term)
+	}
+
+	resp := rn.appendEntriesLocked(req)
 	rn.mu.Unlock()
 
 	// Reset election timeout - we heard from the leader (OUTSIDE the lock)
 	rn.resetElectionTimer()
 
-	// For Week 7: just log heartbeat reception
 	if len(req.Entries) == 0 {
 		rn.logger.LogHeartbeatReceived(req.LeaderID, req.Term)
 	} else {
 		rn.logger.LogAppendEntries(req.LeaderID, req.Term, req.PrevLogIndex, len(req.Entries))
 	}
 
-	// Week 7: Always succeed (we'll add log consistency checks in Week 8)
+	return resp
+}
+
+// appendEntriesLocked runs the log consistency check, appends the leader's
+// entries and follows the leader's commit index (must be called with lock held)
+func (rn *RaftNode) appendEntriesLocked(req *AppendEntriesRequest) *AppendEntriesResponse {
+	// Reply false if log doesn't contain an entry at prevLogIndex
+	if req.PrevLogIndex >= uint64(len(rn.log)) {
+		return &AppendEntriesResponse{
+			Term:          rn.currentTerm,
+			Success:       false,
+			ConflictIndex: uint64(len(rn.log)),
+		}
+	}
+
+	// ...or if that entry's term doesn't match prevLogTerm
+	if conflictTerm := rn.log[req.PrevLogIndex].Term; conflictTerm != req.PrevLogTerm {
+		conflictIndex := req.PrevLogIndex
+		for conflictIndex > 1 && rn.log[conflictIndex-1].Term == conflictTerm {
+			conflictIndex--
+		}
+		return &AppendEntriesResponse{
+			Term:          rn.currentTerm,
+			Success:       false,
+			ConflictTerm:  conflictTerm,
+			ConflictIndex: conflictIndex,
+		}
+	}
+
+	// Delete conflicting entries and append any new ones. Entries we
+	// already have are kept, so a stale (reordered) request can't truncate.
+	for i, entry := range req.Entries {
+		if entry.Index < uint64(len(rn.log)) {
+			if rn.log[entry.Index].Term == entry.Term {
+				continue
+			}
+			rn.log = rn.log[:entry.Index]
+		}
+		rn.log = append(rn.log, req.Entries[i:]...)
+
+		// A configuration takes effect as soon as it is in the log, and
+		// one that was cut off stops being in effect
+		rn.reloadConfigurationLocked(entry.Index)
+		break
+	}
+
+	// commitIndex = min(leaderCommit, index of last new entry)
+	lastNewEntryIndex := req.PrevLogIndex + uint64(len(req.Entries))
+	if req.LeaderCommit > rn.commitIndex {
+		rn.setCommitIndexLocked(min(req.LeaderCommit, lastNewEntryIndex))
+	}
+
 	return &AppendEntriesResponse{
-		Term:    currentTerm,
+		Term:    rn.currentTerm,
 		Success: true,
 	}
 }
@@ -2,9 +2,16 @@
 package raft
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	pb "kvstore/proto"
 )
 
 // Test 1: Initial state is Follower
@@ -26,23 +33,24 @@ func TestInitialState(t *testing.T) {
 
 // Test 2: Single node becomes leader
 func TestSingleNodeElection(t *testing.T) {
-	rn := createTestNode("node1", []string{})
+	config := testNodeConfig("node1", []string{})
+	timer := newManualElectionTimer()
+	config.ElectionTimer = timer
+
+	rn := NewRaftNode(config)
 	defer rn.Shutdown()
 
 	rn.Start()
+	timer.fire()
 
-	// Wait for election
-	time.Sleep(300 * time.Millisecond)
-
-	_, isLeader := rn.GetState()
-	if !isLeader {
+	if !waitFor(2*time.Second, rn.IsLeader) {
 		t.Error("Single node should become leader")
 	}
 }
 
 // Test 3: Leader election in 3-node cluster
 func TestBasicElection(t *testing.T) {
-	nodes := createTestCluster(3)
+	nodes, timers := createManualCluster(3)
 	defer shutdownCluster(nodes)
 
 	// Start all nodes
@@ -50,8 +58,12 @@ func TestBasicElection(t *testing.T) {
 		node.Start()
 	}
 
-	// Wait for election
-	time.Sleep(500 * time.Millisecond)
+	// Only node2 times out, so it is the only candidate
+	timers[1].fire()
+
+	if !waitFor(2*time.Second, nodes[1].IsLeader) {
+		t.Fatal("node2 should win the election")
+	}
 
 	// Check that exactly one leader was elected
 	leaders := countLeaders(nodes)
@@ -59,65 +71,55 @@ func TestBasicElection(t *testing.T) {
 		t.Errorf("Expected 1 leader, got %d", leaders)
 	}
 
-	// Check all nodes agree on term
-	terms := make(map[uint64]int)
-	for _, node := range nodes {
-		term, _ := node.GetState()
-		terms[term]++
+	// Check all nodes agree on term once the heartbeats arrive
+	termsAgree := func() bool {
+		leaderTerm, _ := nodes[1].GetState()
+		for _, node := range nodes {
+			if term, _ := node.GetState(); term != leaderTerm {
+				return false
+			}
+		}
+		return true
 	}
-
-	if len(terms) != 1 {
-		t.Errorf("Nodes don't agree on term: %v", terms)
+	if !waitFor(2*time.Second, termsAgree) {
+		t.Error("Nodes don't agree on term")
 	}
 }
 
 // Test 4: Re-election after leader failure
 func TestReElection(t *testing.T) {
-	nodes := createTestCluster(3)
-	defer shutdownCluster(nodes)
+	nodes, timers := createManualCluster(3)
+	defer shutdownCluster(nodes[1:])
 
 	// Start all nodes
 	for _, node := range nodes {
 		node.Start()
 	}
 
-	// Wait for initial election
-	time.Sleep(500 * time.Millisecond)
-
-	// Find and kill the leader
-	var leader *RaftNode
-	for _, node := range nodes {
-		if _, isLeader := node.GetState(); isLeader {
-			leader = node
-			break
-		}
-	}
-
-	if leader == nil {
+	// Elect node1, then kill it
+	timers[0].fire()
+	if !waitFor(2*time.Second, nodes[0].IsLeader) {
 		t.Fatal("No leader elected")
 	}
 
+	leader := nodes[0]
 	oldTerm, _ := leader.GetState()
 	leader.Shutdown()
 
-	// Wait for re-election
-	time.Sleep(500 * time.Millisecond)
-
-	// Check new leader elected
-	remainingNodes := []*RaftNode{}
-	for _, node := range nodes {
-		if node != leader {
-			remainingNodes = append(remainingNodes, node)
-		}
+	// node3 times out first and takes over with node2's vote
+	timers[2].fire()
+	if !waitFor(2*time.Second, nodes[2].IsLeader) {
+		t.Fatal("node3 should win the re-election")
 	}
 
+	remainingNodes := nodes[1:]
 	leaders := countLeaders(remainingNodes)
 	if leaders != 1 {
 		t.Errorf("Expected 1 new leader, got %d", leaders)
 	}
 
 	// Check term increased
-	newTerm, _ := remainingNodes[0].GetState()
+	newTerm, _ := nodes[2].GetState()
 	if newTerm <= oldTerm {
 		t.Errorf("Term should increase after re-election: old=%d, new=%d", oldTerm, newTerm)
 	}
@@ -134,11 +136,9 @@ func TestNetworkPartitionHealing(t *testing.T) {
 	}
 
 	// Wait for initial election
-	time.Sleep(500 * time.Millisecond)
-
-	initialLeaders := countLeaders(nodes)
-	if initialLeaders != 1 {
-		t.Errorf("Expected 1 leader, got %d", initialLeaders)
+	oneLeader := func() bool { return countLeaders(nodes) == 1 }
+	if !waitFor(5*time.Second, oneLeader) {
+		t.Errorf("Expected 1 leader, got %d", countLeaders(nodes))
 	}
 
 	// Wait and ensure still only 1 leader
@@ -155,29 +155,43 @@ func TestRandomizedTimeout(t *testing.T) {
 	nodes := createTestCluster(5)
 	defer shutdownCluster(nodes)
 
-	// Start nodes
+	// Start nodes with the default randomized timers
 	for _, node := range nodes {
 		node.Start()
 	}
 
-	// Run multiple election cycles
-	maxAttempts := 10
-	for i := 0; i < maxAttempts; i++ {
-		time.Sleep(500 * time.Millisecond)
+	// A split vote just times out again with fresh random timeouts
+	oneLeader := func() bool { return countLeaders(nodes) == 1 }
+	if !waitFor(5*time.Second, oneLeader) {
+		t.Error("Failed to elect leader after multiple attempts (possible split vote issue)")
+	}
+}
 
-		leaders := countLeaders(nodes)
-		if leaders == 1 {
-			// Success!
-			return
-		}
+// Test 6b: An injected timer decides which node campaigns first
+func TestDeterministicElection(t *testing.T) {
+	nodes, timers := createManualCluster(5)
+	defer shutdownCluster(nodes)
 
-		// Reset for next attempt
-		for _, node := range nodes {
-			node.stepDown(node.currentTerm + 1)
-		}
+	for _, node := range nodes {
+		node.Start()
+	}
+
+	timers[3].fire()
+
+	if !waitFor(2*time.Second, nodes[3].IsLeader) {
+		t.Fatal("node4 should win the election")
+	}
+	if leaders := countLeaders(nodes); leaders != 1 {
+		t.Errorf("Expected 1 leader, got %d", leaders)
 	}
 
-	t.Error("Failed to elect leader after multiple attempts (possible split vote issue)")
+	learnedLeader := func() bool {
+		id, _, ok := nodes[0].Leader()
+		return ok && id == "node4"
+	}
+	if !waitFor(2*time.Second, learnedLeader) {
+		t.Error("Followers should learn node4 is leader")
+	}
 }
 
 // Test 7: Follower refuses to vote if candidate's log is outdated
@@ -240,15 +254,164 @@ func TestOneVotePerTerm(t *testing.T) {
 	}
 }
 
+// Test 9: Leader is learned from AppendEntries and forgotten on a new term
+func TestLeaderTracking(t *testing.T) {
+	node := createTestNode("node1", []string{"node2", "node3"})
+	defer node.Shutdown()
+
+	if _, ok := node.LeaderAddress(); ok {
+		t.Error("New node should not know a leader")
+	}
+
+	node.AppendEntries(&AppendEntriesRequest{
+		Term:     1,
+		LeaderID: "node2",
+	})
+
+	address, ok := node.LeaderAddress()
+	if !ok || address != "localhost:50052" {
+		t.Errorf("Expected leader at localhost:50052, got %q (known=%v)", address, ok)
+	}
+
+	// A new election starts: the old leader is no longer valid
+	node.RequestVote(&RequestVoteRequest{
+		Term:        2,
+		CandidateID: "node3",
+	})
+
+	if address, ok := node.LeaderAddress(); ok {
+		t.Errorf("Leader should be unknown during election, got %q", address)
+	}
+}
+
+// Test 10: Leader RPC reports the leader or "no leader known"
+func TestLeaderRPC(t *testing.T) {
+	node := createTestNode("node1", []string{"node2", "node3"})
+	defer node.Shutdown()
+
+	server := NewGRPCRaftServer(node)
+
+	resp, err := server.Leader(context.Background(), &pb.LeaderRequest{})
+	if err != nil {
+		t.Fatalf("Leader RPC failed: %v", err)
+	}
+	if resp.Known {
+		t.Errorf("Expected no leader known, got %s", resp.LeaderId)
+	}
+
+	node.AppendEntries(&AppendEntriesRequest{
+		Term:     3,
+		LeaderID: "node3",
+	})
+
+	resp, err = server.Leader(context.Background(), &pb.LeaderRequest{})
+	if err != nil {
+		t.Fatalf("Leader RPC failed: %v", err)
+	}
+	if !resp.Known || resp.LeaderId != "node3" || resp.LeaderAddress != "localhost:50053" {
+		t.Errorf("Expected node3 at localhost:50053, got %+v", resp)
+	}
+	if resp.Term != 3 {
+		t.Errorf("Expected term 3, got %d", resp.Term)
+	}
+}
+
+// Test: a leader cut off from the majority steps down on its own, and the
+// majority elects a new leader
+func TestLeaderStepsDownWithoutQuorum(t *testing.T) {
+	nodes, timers := createManualCluster(3)
+	defer shutdownCluster(nodes)
+
+	network := &partitionedNetwork{isolated: make(map[string]bool)}
+	for _, node := range nodes {
+		node.rpcClient = &partitionedClient{RPCClient: node.rpcClient, network: network, from: node.address}
+		node.Start()
+	}
+
+	timers[0].fire()
+	if !waitFor(2*time.Second, nodes[0].IsLeader) {
+		t.Fatal("node1 should win the election")
+	}
+	leader := nodes[0]
+	term, _ := leader.GetState()
+
+	// Stays leader while the followers answer
+	time.Sleep(2 * leader.electionTimeout)
+	if !leader.IsLeader() {
+		t.Fatal("Leader stepped down while in contact with the cluster")
+	}
+
+	network.isolate(leader.address)
+
+	steppedDown := func() bool { return !leader.IsLeader() }
+	if !waitFor(time.Second, steppedDown) {
+		t.Fatal("Isolated leader did not step down")
+	}
+	// It stepped down on its own, not because it saw a higher term
+	if newTerm, _ := leader.GetState(); newTerm != term {
+		t.Errorf("Expected the isolated node to stay in term %d, got %d", term, newTerm)
+	}
+	if _, _, ok := leader.Submit([]byte("x")); ok {
+		t.Error("Isolated node still accepts writes")
+	}
+
+	timers[1].fire()
+	if !waitFor(2*time.Second, nodes[1].IsLeader) {
+		t.Fatal("The majority did not elect a new leader")
+	}
+	if leaders := countLeaders(nodes); leaders != 1 {
+		t.Errorf("Expected 1 leader, got %d", leaders)
+	}
+}
+
+// Test: nodes shut down at any point of an election or of leading leave no
+// peer RPCs running, and every goroutine of theirs exits
+func TestShutdownWaitsForPeerRPCs(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		nodes := createTestCluster(3)
+		for _, node := range nodes {
+			node.Start()
+		}
+		time.Sleep(time.Duration(i*40) * time.Millisecond)
+		shutdownCluster(nodes)
+
+		for _, stack := range raftGoroutines() {
+			if strings.Contains(stack, "sendAppendEntries") || strings.Contains(stack, "requestVote") {
+				t.Fatalf("Peer RPC still running after Shutdown:\n%s", stack)
+			}
+		}
+		if !waitFor(time.Second, func() bool { return len(raftGoroutines()) == 0 }) {
+			t.Fatalf("Goroutines still running after Shutdown:\n%s", strings.Join(raftGoroutines(), "\n\n"))
+		}
+	}
+}
+
 // Helper functions
 
+// raftGoroutines returns the stacks of running goroutines in the raft
+// package, other than the tests'
+func raftGoroutines() []string {
+	buf := make([]byte, 1<<20)
+	var stacks []string
+	for _, stack := range strings.Split(string(buf[:runtime.Stack(buf, true)]), "\n\n") {
+		if strings.Contains(stack, "kvstore/raft.") && !strings.Contains(stack, "testing.tRunner") {
+			stacks = append(stacks, stack)
+		}
+	}
+	return stacks
+}
+
 func createTestNode(id string, peers []string) *RaftNode {
+	return NewRaftNode(testNodeConfig(id, peers))
+}
+
+func testNodeConfig(id string, peers []string) *Config {
 	peerAddrs := make(map[string]string)
 	for _, peer := range peers {
 		peerAddrs[peer] = "localhost:5005" + peer[len(peer)-1:]
 	}
 
-	config := &Config{
+	return &Config{
 		ID:               id,
 		Peers:            peers,
 		PeerAddresses:    peerAddrs,
@@ -257,11 +420,28 @@ func createTestNode(id string, peers []string) *RaftNode {
 		HeartbeatTimeout: 50 * time.Millisecond,
 		StateMachine:     &MockStateMachine{},
 	}
-
-	return NewRaftNode(config)
 }
 
 func createTestCluster(n int) []*RaftNode {
+	return createTestClusterWithTimers(make([]ElectionTimer, n))
+}
+
+// createManualCluster creates a cluster whose nodes only start an election
+// when the test fires their timer
+func createManualCluster(n int) ([]*RaftNode, []*manualElectionTimer) {
+	timers := make([]*manualElectionTimer, n)
+	electionTimers := make([]ElectionTimer, n)
+	for i := range timers {
+		timers[i] = newManualElectionTimer()
+		electionTimers[i] = timers[i]
+	}
+	return createTestClusterWithTimers(electionTimers), timers
+}
+
+// createTestClusterWithTimers creates a cluster of len(timers) nodes; a nil
+// timer uses the default randomized one
+func createTestClusterWithTimers(timers []ElectionTimer) []*RaftNode {
+	n := len(timers)
 	nodes := make([]*RaftNode, n)
 	peers := make([]string, n)
 	peerAddrs := make(map[string]string)
@@ -289,6 +469,7 @@ func createTestCluster(n int) []*RaftNode {
 			ElectionTimeout:  150 * time.Millisecond,
 			HeartbeatTimeout: 50 * time.Millisecond,
 			StateMachine:     &MockStateMachine{},
+			ElectionTimer:    timers[i],
 		}
 
 		nodes[i] = NewRaftNode(config)
@@ -313,6 +494,76 @@ func countLeaders(nodes []*RaftNode) int {
 	return count
 }
 
+// waitFor polls cond until it holds or the timeout expires
+func waitFor(timeout time.Duration, cond func() bool) bool {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return true
+}
+
+// manualElectionTimer never times out on its own; the test calls fire
+type manualElectionTimer struct {
+	ch chan time.Time
+}
+
+func newManualElectionTimer() *manualElectionTimer {
+	return &manualElectionTimer{ch: make(chan time.Time, 1)}
+}
+
+func (m *manualElectionTimer) C() <-chan time.Time { return m.ch }
+func (m *manualElectionTimer) Reset()              {}
+func (m *manualElectionTimer) Stop()               {}
+
+// fire makes the node start an election as if its timeout had elapsed
+func (m *manualElectionTimer) fire() {
+	m.ch <- time.Now()
+}
+
+// partitionedNetwork cuts isolated nodes off from the rest of the cluster
+type partitionedNetwork struct {
+	mu       sync.Mutex
+	isolated map[string]bool // node addresses
+}
+
+func (n *partitionedNetwork) isolate(address string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.isolated[address] = true
+}
+
+// connected reports whether RPCs get from one address to the other
+func (n *partitionedNetwork) connected(from, to string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.isolated[from] == n.isolated[to]
+}
+
+// partitionedClient fails RPCs that cross the partition in either direction
+type partitionedClient struct {
+	RPCClient
+	network *partitionedNetwork
+	from    string
+}
+
+func (c *partitionedClient) RequestVote(address string, req *RequestVoteRequest) (*RequestVoteResponse, error) {
+	if !c.network.connected(c.from, address) {
+		return nil, errors.New("partitioned")
+	}
+	return c.RPCClient.RequestVote(address, req)
+}
+
+func (c *partitionedClient) AppendEntries(address string, req *AppendEntriesRequest) (*AppendEntriesResponse, error) {
+	if !c.network.connected(c.from, address) {
+		return nil, errors.New("partitioned")
+	}
+	return c.RPCClient.AppendEntries(address, req)
+}
+
 // MockStateMachine for testing
 type MockStateMachine struct{}
 
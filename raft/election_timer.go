@@ -0,0 +1,46 @@
+// raft/election_timer.go
+package raft
+
+import "time"
+
+// ElectionTimer is the source of election timeouts. A follower or candidate
+// that sees C fire starts an election. The default is randomized; tests can
+// inject a timer they fire by hand to choose which node campaigns first.
+type ElectionTimer interface {
+	// C fires when the election timeout elapses. It must return the same
+	// channel for the life of the timer.
+	C() <-chan time.Time
+	// Reset restarts the countdown (heard from the leader, granted a vote,
+	// started an election)
+	Reset()
+	// Stop cancels the countdown (became leader, shutting down)
+	Stop()
+}
+
+// randomizedElectionTimer times out after base + [0, 150ms) so that nodes
+// rarely time out together and split the vote
+type randomizedElectionTimer struct {
+	base  time.Duration
+	timer *time.Timer
+}
+
+// newRandomizedElectionTimer creates a stopped timer; Reset starts it
+func newRandomizedElectionTimer(base time.Duration) *randomizedElectionTimer {
+	timer := time.NewTimer(base)
+	timer.Stop()
+	return &randomizedElectionTimer{base: base, timer: timer}
+}
+
+func (t *randomizedElectionTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Reset rearms the same timer rather than replacing it, so the event loop
+// already waiting on C sees the new deadline
+func (t *randomizedElectionTimer) Reset() {
+	t.timer.Reset(t.base + time.Duration(randomInt(0, 150))*time.Millisecond)
+}
+
+func (t *randomizedElectionTimer) Stop() {
+	t.timer.Stop()
+}
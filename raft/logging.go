@@ -115,6 +115,10 @@ func (l *Logger) LogApply(index uint64, command string) {
 	l.Info("⚡ Applied command at index=%d: %s", index, command)
 }
 
+func (l *Logger) LogLeaderDiscovered(leaderID string, term uint64) {
+	l.Info("👑 Following leader %s (term=%d)", leaderID, term)
+}
+
 func (l *Logger) LogStepDown(oldTerm, newTerm uint64) {
 	l.Info("⬇️  Stepping down: term %d → %d", oldTerm, newTerm)
 }
@@ -0,0 +1,191 @@
+// raft/membership.go
+package raft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// EntryType distinguishes state machine commands from configuration changes
+type EntryType uint32
+
+const (
+	EntryCommand EntryType = iota // Command is applied to the state machine
+	EntryConfig                   // Command is the new cluster configuration
+)
+
+// ErrNotLeader is returned by operations only the leader can perform
+var ErrNotLeader = errors.New("not the leader")
+
+// ErrMembershipChangePending is returned when a membership change is
+// requested before the previous one has committed
+var ErrMembershipChangePending = errors.New("previous membership change not committed yet")
+
+// AddServer starts adding a server to the cluster. The new node should be
+// running with Config.Joining set; the leader sends it the whole log.
+//
+// Membership changes one server at a time, so any majority of the old
+// configuration overlaps any majority of the new one. The leader appends the
+// new configuration to its log and uses it straight away, as every node does
+// once the entry reaches its log; AddServer returns once the entry is
+// appended. The change is complete when the entry commits, and until then
+// further changes fail with ErrMembershipChangePending.
+func (rn *RaftNode) AddServer(id, address string) error {
+	return rn.changeMembership(func(servers map[string]string) error {
+		if _, ok := servers[id]; ok {
+			return fmt.Errorf("server %s is already a member", id)
+		}
+		servers[id] = address
+		return nil
+	})
+}
+
+// RemoveServer starts removing a server from the cluster, as AddServer does
+// for adding one. A leader that removes itself keeps leading until the change
+// commits and then steps down.
+func (rn *RaftNode) RemoveServer(id string) error {
+	return rn.changeMembership(func(servers map[string]string) error {
+		if _, ok := servers[id]; !ok {
+			return fmt.Errorf("server %s is not a member", id)
+		}
+		if len(servers) == 1 {
+			return fmt.Errorf("cannot remove %s, the last member", id)
+		}
+		delete(servers, id)
+		return nil
+	})
+}
+
+// changeMembership appends a configuration entry with the servers edited
+// by change and starts replicating it
+func (rn *RaftNode) changeMembership(change func(servers map[string]string) error) error {
+	rn.mu.Lock()
+	if rn.state != Leader {
+		rn.mu.Unlock()
+		return ErrNotLeader
+	}
+	if rn.configIndex > rn.commitIndex {
+		rn.mu.Unlock()
+		return ErrMembershipChangePending
+	}
+
+	servers := make(map[string]string, len(rn.servers)+1)
+	for id, address := range rn.servers {
+		servers[id] = address
+	}
+	if err := change(servers); err != nil {
+		rn.mu.Unlock()
+		return err
+	}
+
+	command, err := json.Marshal(servers)
+	if err != nil {
+		rn.mu.Unlock()
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	entry := &LogEntry{
+		Index:   uint64(len(rn.log)),
+		Term:    rn.currentTerm,
+		Command: command,
+		Type:    EntryConfig,
+	}
+	rn.log = append(rn.log, entry)
+	rn.setConfigurationLocked(servers, entry.Index)
+	rn.mu.Unlock()
+
+	rn.logger.Info("📝 Appended configuration %s: %v", FormatLogEntry(entry), sortedServers(servers))
+
+	// Wake the event loop to replicate
+	select {
+	case rn.newEntryCh <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// setConfigurationLocked puts a configuration into effect (must be called
+// with lock held). The leader starts tracking servers that joined.
+func (rn *RaftNode) setConfigurationLocked(servers map[string]string, index uint64) {
+	rn.servers = servers
+	rn.configIndex = index
+
+	// A fresh slice: callers iterate over copies of rn.peers outside the lock
+	peers := make([]string, 0, len(servers))
+	for _, id := range sortedServers(servers) {
+		rn.peerAddresses[id] = servers[id]
+		if id != rn.id {
+			peers = append(peers, id)
+		}
+	}
+	rn.peers = peers
+
+	for _, peer := range rn.peers {
+		if _, ok := rn.nextIndex[peer]; !ok {
+			rn.nextIndex[peer] = uint64(len(rn.log))
+			rn.matchIndex[peer] = 0
+			rn.lastContact[peer] = time.Now() // A grace period to reach it
+		}
+	}
+	for peer := range rn.nextIndex {
+		if _, ok := servers[peer]; !ok {
+			delete(rn.nextIndex, peer)
+			delete(rn.matchIndex, peer)
+			delete(rn.lastContact, peer)
+		}
+	}
+}
+
+// reloadConfigurationLocked puts the latest configuration in the log back
+// into effect after the entries from index from onwards were replaced (must
+// be called with lock held)
+func (rn *RaftNode) reloadConfigurationLocked(from uint64) {
+	for i := uint64(len(rn.log)) - 1; i > 0; i-- {
+		if i < from && rn.configIndex < from {
+			return // The configuration in effect is still in the log
+		}
+		if rn.log[i].Type != EntryConfig {
+			continue
+		}
+
+		var servers map[string]string
+		if err := json.Unmarshal(rn.log[i].Command, &servers); err != nil {
+			rn.logger.Error("Failed to decode configuration %s: %v", FormatLogEntry(rn.log[i]), err)
+			continue
+		}
+		rn.setConfigurationLocked(servers, i)
+		rn.logger.Info("Configuration %s in effect: %v", FormatLogEntry(rn.log[i]), sortedServers(servers))
+		return
+	}
+
+	if rn.configIndex != 0 {
+		rn.setConfigurationLocked(rn.baseServers, 0)
+	}
+}
+
+// isMemberLocked reports whether this node is a voting member of the
+// configuration in effect (must be called with lock held)
+func (rn *RaftNode) isMemberLocked() bool {
+	_, ok := rn.servers[rn.id]
+	return ok
+}
+
+// quorumLocked returns how many members make a majority of the
+// configuration in effect (must be called with lock held)
+func (rn *RaftNode) quorumLocked() int {
+	return len(rn.servers)/2 + 1
+}
+
+// sortedServers returns the IDs of a configuration in order
+func sortedServers(servers map[string]string) []string {
+	ids := make([]string, 0, len(servers))
+	for id := range servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,176 @@
+// raft/membership_test.go
+package raft
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Test: a 3-node cluster grows to 5 one server at a time, then keeps
+// electing a single leader and replicating to every member
+func TestAddServerGrowsCluster(t *testing.T) {
+	nodes := createTestCluster(3)
+	for _, id := range []string{"node4", "node5"} {
+		config := testNodeConfig(id, nil)
+		config.Joining = true
+		nodes = append(nodes, NewRaftNode(config))
+	}
+	defer func() { shutdownCluster(nodes) }()
+
+	machines := make(map[*RaftNode]*recordingStateMachine)
+	for _, node := range nodes {
+		machines[node] = &recordingStateMachine{}
+		node.stateMachine = machines[node]
+		node.Start()
+	}
+
+	leader := waitForLeader(t, nodes[:3], 2*time.Second)
+	submit := func(leader *RaftNode, key string) {
+		t.Helper()
+		cmd, _ := json.Marshal(Command{Type: "PUT", Key: key, Value: []byte("v")})
+		if _, _, ok := leader.Submit(cmd); !ok {
+			t.Fatal("Leader rejected Submit")
+		}
+	}
+	submit(leader, "before")
+
+	for _, id := range []string{"node4", "node5"} {
+		address := "localhost:5005" + id[len(id)-1:]
+		if err := leader.AddServer(id, address); err != nil {
+			t.Fatalf("AddServer(%s) failed: %v", id, err)
+		}
+		committed := func() bool {
+			leader.mu.RLock()
+			defer leader.mu.RUnlock()
+			return leader.configIndex <= leader.commitIndex
+		}
+		if !waitFor(2*time.Second, committed) {
+			t.Fatalf("Adding %s never committed", id)
+		}
+	}
+	if err := leader.AddServer("node5", "localhost:50055"); err == nil {
+		t.Error("Expected adding an existing member to fail")
+	}
+
+	submit(leader, "after")
+
+	// Every node, including the new ones, has the 5-node configuration and
+	// applied both commands
+	converged := func() bool {
+		for _, node := range nodes {
+			node.mu.RLock()
+			members := len(node.servers)
+			node.mu.RUnlock()
+			if members != 5 || machines[node].count() != 2 {
+				return false
+			}
+		}
+		return true
+	}
+	if !waitFor(3*time.Second, converged) {
+		for _, node := range nodes {
+			node.mu.RLock()
+			t.Errorf("%s: members=%v applied=%d", node.id, sortedServers(node.servers), machines[node].count())
+			node.mu.RUnlock()
+		}
+		t.Fatal("Cluster did not converge on 5 members")
+	}
+
+	// The old leader fails; the other four elect exactly one replacement,
+	// which needs 3 of the 5 votes
+	leader.Shutdown()
+	var survivors []*RaftNode
+	for _, node := range nodes {
+		if node != leader {
+			survivors = append(survivors, node)
+		}
+	}
+	nodes = survivors
+
+	oneLeader := func() bool { return countLeaders(survivors) == 1 }
+	if !waitFor(5*time.Second, oneLeader) {
+		t.Fatalf("Expected 1 leader among the survivors, got %d", countLeaders(survivors))
+	}
+
+	newLeader := waitForLeader(t, survivors, time.Second)
+	submit(newLeader, "new-leader")
+
+	replicated := func() bool {
+		for _, node := range survivors {
+			if machines[node].count() != 3 {
+				return false
+			}
+		}
+		return true
+	}
+	if !waitFor(3*time.Second, replicated) {
+		t.Fatal("New leader did not replicate to the survivors")
+	}
+}
+
+// Test: a membership change is refused until the previous one commits
+func TestMembershipChangePending(t *testing.T) {
+	rn := createTestNode("node1", []string{"node2", "node3"})
+	rn.currentTerm = 1
+	rn.state = Leader
+
+	if err := rn.AddServer("node4", "localhost:50054"); err != nil {
+		t.Fatalf("AddServer failed: %v", err)
+	}
+
+	rn.mu.RLock()
+	peers, quorum := fmt.Sprint(rn.peers), rn.quorumLocked()
+	rn.mu.RUnlock()
+	if peers != "[node2 node3 node4]" || quorum != 3 {
+		t.Errorf("Expected peers [node2 node3 node4] with quorum 3, got %s with quorum %d", peers, quorum)
+	}
+
+	if err := rn.RemoveServer("node2"); !errors.Is(err, ErrMembershipChangePending) {
+		t.Errorf("Expected ErrMembershipChangePending, got %v", err)
+	}
+
+	// Once the configuration commits the next change goes through
+	rn.mu.Lock()
+	rn.commitIndex = rn.configIndex
+	rn.mu.Unlock()
+	if err := rn.RemoveServer("node2"); err != nil {
+		t.Errorf("RemoveServer failed: %v", err)
+	}
+
+	rn.state = Follower
+	if err := rn.AddServer("node5", "localhost:50055"); !errors.Is(err, ErrNotLeader) {
+		t.Errorf("Expected ErrNotLeader, got %v", err)
+	}
+}
+
+// A follower whose log loses an uncommitted configuration entry falls back
+// to the configuration before it
+func TestConfigurationRevertsOnTruncation(t *testing.T) {
+	rn := createTestNode("node1", []string{"node2", "node3"})
+
+	servers, _ := json.Marshal(map[string]string{
+		"node1": "localhost:50051", "node2": "localhost:50052",
+		"node3": "localhost:50053", "node4": "localhost:50054",
+	})
+	rn.AppendEntries(&AppendEntriesRequest{
+		Term:     1,
+		LeaderID: "node2",
+		Entries:  []*LogEntry{{Index: 1, Term: 1, Command: servers, Type: EntryConfig}},
+	})
+	if members := len(rn.servers); members != 4 {
+		t.Fatalf("Expected the appended configuration in effect, got %d members", members)
+	}
+
+	// The next leader never had it
+	rn.AppendEntries(&AppendEntriesRequest{
+		Term:     2,
+		LeaderID: "node3",
+		Entries:  []*LogEntry{{Index: 1, Term: 2, Command: []byte("x")}},
+	})
+	if members := fmt.Sprint(sortedServers(rn.servers)); members != "[node1 node2 node3]" {
+		t.Errorf("Expected the initial configuration back, got %s", members)
+	}
+}
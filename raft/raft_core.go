@@ -4,8 +4,14 @@ package raft
 import (
 	"sync"
 	"time"
+
+	"google.golang.org/grpc"
 )
 
+// peerRPCShutdownTimeout is how long Shutdown waits for RPCs to peers that
+// are still in flight; it outlasts the RPC client's own timeout
+const peerRPCShutdownTimeout = 3 * time.Second
+
 // NodeState represents the current state of a Raft node
 type NodeState int
 
@@ -42,30 +48,47 @@ type RaftNode struct {
 	lastApplied uint64 // highest log entry applied to state machine
 	state       NodeState
 
+	// Leader tracking (learned from AppendEntries, empty while unknown)
+	leaderID string
+
 	// Volatile state (leaders only - reinitialized after election)
-	nextIndex  map[string]uint64 // for each peer, index of next log entry to send
-	matchIndex map[string]uint64 // for each peer, highest log entry known to be replicated
+	nextIndex   map[string]uint64    // for each peer, index of next log entry to send
+	matchIndex  map[string]uint64    // for each peer, highest log entry known to be replicated
+	lastContact map[string]time.Time // for each peer, when it last answered an AppendEntries
 
 	// Node identity
 	id            string
-	peers         []string // other node IDs
+	peers         []string // other node IDs in the current configuration
 	address       string   // this node's address
 	peerAddresses map[string]string
 
+	// Cluster membership: the latest configuration entry in the log is in
+	// effect, committed or not (single-server changes, §4.1 of the Raft thesis)
+	servers     map[string]string // every voting member, including this node -> address
+	configIndex uint64            // log index of the configuration in effect (0 = baseServers)
+	baseServers map[string]string // configuration before any configuration entry
+
 	// Timers
 	electionTimeout  time.Duration
 	heartbeatTimeout time.Duration
-	electionTimer    *time.Timer
+	electionTimer    ElectionTimer
 	heartbeatTimer   *time.Timer
 
 	// Channels
 	applyCh    chan ApplyMsg // send committed entries here
 	shutdownCh chan struct{} // signal shutdown
 	newEntryCh chan struct{} // signal new log entry for leader
+	commitCh   chan struct{} // signal commitIndex advanced
+
+	// Shutdown barrier: Shutdown waits for peer RPC goroutines to finish
+	peerRPCs   sync.WaitGroup // RequestVote and AppendEntries goroutines still running
+	shutdownMu sync.Mutex     // Orders peerRPCs.Add before Shutdown's Wait
+	shutdown   bool           // Shutdown has begun; no new peer RPCs start
 
 	// RPC transport
-	rpcServer RPCServer
-	rpcClient RPCClient
+	rpcServer     RPCServer
+	rpcClient     RPCClient
+	serverOptions []grpc.ServerOption // Config.ServerOptions
 
 	// State machine (your LSM store)
 	stateMachine StateMachine
@@ -78,7 +101,8 @@ type RaftNode struct {
 type LogEntry struct {
 	Index   uint64
 	Term    uint64
-	Command []byte // serialized command (PUT/DELETE)
+	Command []byte    // serialized command (PUT/DELETE), or the configuration for EntryConfig
+	Type    EntryType // EntryCommand unless the entry changes membership
 }
 
 // ApplyMsg is sent on applyCh when an entry is committed
@@ -104,14 +128,25 @@ type Config struct {
 	ElectionTimeout  time.Duration // 150-300ms randomized
 	HeartbeatTimeout time.Duration // 50ms
 	StateMachine     StateMachine
+
+	// ElectionTimer overrides the election timeout source (nil = randomized
+	// ElectionTimeout + [0, 150ms))
+	ElectionTimer ElectionTimer
+
+	// Joining starts the node outside the cluster, ignoring Peers: it never
+	// campaigns and waits for the leader's AddServer to bring it in
+	Joining bool
+
+	// ServerOptions are added to the node's gRPC server, e.g. the
+	// interceptors of server.SlowRequestLogger
+	ServerOptions []grpc.ServerOption
 }
 
 // NewRaftNode creates a new Raft node
 func NewRaftNode(config *Config) *RaftNode {
 	rn := &RaftNode{
 		id:               config.ID,
-		peers:            config.Peers,
-		peerAddresses:    config.PeerAddresses,
+		peerAddresses:    make(map[string]string),
 		address:          config.Address,
 		currentTerm:      0,
 		votedFor:         "",
@@ -121,20 +156,35 @@ func NewRaftNode(config *Config) *RaftNode {
 		state:            Follower,
 		nextIndex:        make(map[string]uint64),
 		matchIndex:       make(map[string]uint64),
+		lastContact:      make(map[string]time.Time),
 		electionTimeout:  config.ElectionTimeout,
 		heartbeatTimeout: config.HeartbeatTimeout,
+		electionTimer:    config.ElectionTimer,
 		applyCh:          make(chan ApplyMsg, 100),
 		shutdownCh:       make(chan struct{}),
 		newEntryCh:       make(chan struct{}, 1),
+		commitCh:         make(chan struct{}, 1),
 		stateMachine:     config.StateMachine,
+		serverOptions:    config.ServerOptions,
 		logger:           NewLogger(config.ID, DEBUG), // DEBUG to see heartbeats
 	}
 
-	// Initialize peer tracking
-	for _, peer := range rn.peers {
-		rn.nextIndex[peer] = 1
-		rn.matchIndex[peer] = 0
+	if rn.electionTimer == nil {
+		rn.electionTimer = newRandomizedElectionTimer(config.ElectionTimeout)
+	}
+
+	// The initial configuration is this node and its peers
+	rn.baseServers = make(map[string]string)
+	if !config.Joining {
+		rn.baseServers[rn.id] = rn.address
+		for _, peer := range config.Peers {
+			rn.baseServers[peer] = config.PeerAddresses[peer]
+		}
 	}
+	for peer, address := range config.PeerAddresses {
+		rn.peerAddresses[peer] = address
+	}
+	rn.setConfigurationLocked(rn.baseServers, 0)
 
 	// Initialize RPC components
 	rn.rpcServer = NewGRPCRaftServer(rn)
@@ -148,7 +198,6 @@ func (rn *RaftNode) Start() error {
 	rn.logger.Info("Starting Raft node at %s", rn.address)
 
 	// Initialize timers BEFORE starting event loop
-	rn.electionTimer = time.NewTimer(rn.electionTimeout)
 	rn.heartbeatTimer = time.NewTimer(rn.heartbeatTimeout)
 	rn.heartbeatTimer.Stop() // Stop heartbeat timer initially (only leaders send heartbeats)
 
@@ -157,11 +206,12 @@ func (rn *RaftNode) Start() error {
 		return err
 	}
 
-	// Randomize election timer
+	// Start the election countdown
 	rn.resetElectionTimer()
 
 	// Main event loop
 	go rn.run()
+	go rn.applyLoop()
 
 	return nil
 }
@@ -173,14 +223,14 @@ func (rn *RaftNode) run() {
 		case <-rn.shutdownCh:
 			return
 
-		case <-rn.electionTimer.C:
+		case <-rn.electionTimer.C():
 			// Election timeout - become candidate
 			rn.logger.LogElectionTimeout()
 			rn.startElection()
 
 		case <-rn.heartbeatTimer.C:
-			// Leader sends heartbeats
-			if rn.getState() == Leader {
+			// Leader sends heartbeats, unless it has lost touch with a majority
+			if rn.checkQuorum() {
 				rn.sendHeartbeats()
 				rn.resetHeartbeatTimer()
 			}
@@ -194,11 +244,6 @@ func (rn *RaftNode) run() {
 	}
 }
 
-// replicateLog replicates new log entries (placeholder for Week 8)
-func (rn *RaftNode) replicateLog() {
-	// Week 8: Implement log replication
-}
-
 // GetState returns current term and whether this node is the leader
 func (rn *RaftNode) GetState() (uint64, bool) {
 	rn.mu.RLock()
@@ -206,39 +251,115 @@ func (rn *RaftNode) GetState() (uint64, bool) {
 	return rn.currentTerm, rn.state == Leader
 }
 
+// IsLeader reports whether this node is currently the leader
+func (rn *RaftNode) IsLeader() bool {
+	_, isLeader := rn.GetState()
+	return isLeader
+}
+
+// LeaderAddress returns the address of the current leader.
+// The boolean is false when no leader is known (e.g. during an election).
+func (rn *RaftNode) LeaderAddress() (string, bool) {
+	_, address, ok := rn.Leader()
+	return address, ok
+}
+
+// Leader returns the ID and address of the current leader, if known
+func (rn *RaftNode) Leader() (string, string, bool) {
+	rn.mu.RLock()
+	defer rn.mu.RUnlock()
+
+	if rn.leaderID == "" {
+		return "", "", false
+	}
+	if rn.leaderID == rn.id {
+		return rn.id, rn.address, true
+	}
+
+	address, ok := rn.peerAddresses[rn.leaderID]
+	if !ok {
+		return "", "", false
+	}
+	return rn.leaderID, address, true
+}
+
 func (rn *RaftNode) getState() NodeState {
 	rn.mu.RLock()
 	defer rn.mu.RUnlock()
 	return rn.state
 }
 
-// Shutdown stops the Raft node
+// Shutdown stops the Raft node. It waits, up to peerRPCShutdownTimeout, for
+// RPCs to peers that are in flight, so none of them acts on the node after
+// it stops. Calling Shutdown again does nothing.
 func (rn *RaftNode) Shutdown() {
-	rn.logger.Info("Shutting down Raft node")
+	rn.shutdownMu.Lock()
+	if rn.shutdown {
+		rn.shutdownMu.Unlock()
+		return
+	}
+	rn.shutdown = true
 	close(rn.shutdownCh)
+	rn.shutdownMu.Unlock()
+
+	rn.logger.Info("Shutting down Raft node")
 
 	// Stop timers
-	if rn.electionTimer != nil {
-		rn.electionTimer.Stop()
-	}
+	rn.electionTimer.Stop()
+	rn.mu.Lock()
 	if rn.heartbeatTimer != nil {
 		rn.heartbeatTimer.Stop()
 	}
+	rn.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		rn.peerRPCs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(peerRPCShutdownTimeout):
+		rn.logger.Warn("Peer RPCs still running after %v, stopping anyway", peerRPCShutdownTimeout)
+	}
 
 	rn.rpcServer.Stop()
 }
 
-// Helper: reset election timer with randomized timeout
-func (rn *RaftNode) resetElectionTimer() {
-	if rn.electionTimer != nil {
-		rn.electionTimer.Stop()
+// goPeerRPC runs an RPC to a peer on its own goroutine, which Shutdown waits
+// for. Once Shutdown has begun it does nothing.
+func (rn *RaftNode) goPeerRPC(rpc func()) {
+	rn.shutdownMu.Lock()
+	defer rn.shutdownMu.Unlock()
+	if rn.shutdown {
+		return
+	}
+
+	rn.peerRPCs.Add(1)
+	go func() {
+		defer rn.peerRPCs.Done()
+		rpc()
+	}()
+}
+
+// shuttingDown reports whether Shutdown has begun
+func (rn *RaftNode) shuttingDown() bool {
+	select {
+	case <-rn.shutdownCh:
+		return true
+	default:
+		return false
 	}
-	// Randomize: baseTimeout + [0, 150ms]
-	timeout := rn.electionTimeout + time.Duration(randomInt(0, 150))*time.Millisecond
-	rn.electionTimer = time.NewTimer(timeout)
+}
+
+// Helper: restart the election countdown
+func (rn *RaftNode) resetElectionTimer() {
+	rn.electionTimer.Reset()
 }
 
 func (rn *RaftNode) resetHeartbeatTimer() {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
 	if rn.heartbeatTimer != nil {
 		rn.heartbeatTimer.Stop()
 	}
@@ -0,0 +1,224 @@
+// raft/replication.go
+package raft
+
+import "time"
+
+// Submit appends a command to the leader's log and starts replicating it.
+// Returns the index and term the entry was appended at, and false if this
+// node is not the leader (the command is dropped).
+func (rn *RaftNode) Submit(command []byte) (uint64, uint64, bool) {
+	rn.mu.Lock()
+	if rn.state != Leader {
+		rn.mu.Unlock()
+		return 0, 0, false
+	}
+
+	entry := &LogEntry{
+		Index:   uint64(len(rn.log)),
+		Term:    rn.currentTerm,
+		Command: command,
+	}
+	rn.log = append(rn.log, entry)
+	rn.mu.Unlock()
+
+	rn.logger.Debug("📝 Appended entry %s", FormatLogEntry(entry))
+
+	// Wake the event loop to replicate
+	select {
+	case rn.newEntryCh <- struct{}{}:
+	default:
+	}
+
+	return entry.Index, entry.Term, true
+}
+
+// replicateLog sends any outstanding entries to every peer
+func (rn *RaftNode) replicateLog() {
+	rn.mu.Lock()
+	if rn.state != Leader {
+		rn.mu.Unlock()
+		return
+	}
+	term := rn.currentTerm
+	peers := rn.peers
+
+	// With no peers the leader alone is a majority
+	rn.advanceCommitIndexLocked()
+	rn.mu.Unlock()
+
+	for _, peer := range peers {
+		peerID := peer
+		rn.goPeerRPC(func() { rn.sendAppendEntries(peerID, term) })
+	}
+}
+
+// sendAppendEntries sends the entries from nextIndex onwards to one peer
+// (none for an up-to-date peer, which makes it a heartbeat) and handles the reply
+func (rn *RaftNode) sendAppendEntries(peerID string, term uint64) {
+	rn.mu.RLock()
+	if rn.state != Leader || rn.currentTerm != term {
+		rn.mu.RUnlock()
+		return
+	}
+
+	nextIndex := rn.nextIndex[peerID]
+	if nextIndex < 1 {
+		nextIndex = 1
+	}
+	if nextIndex > uint64(len(rn.log)) {
+		nextIndex = uint64(len(rn.log))
+	}
+	prevLogIndex := nextIndex - 1
+	prevLogTerm := rn.log[prevLogIndex].Term
+
+	var entries []*LogEntry
+	if nextIndex < uint64(len(rn.log)) {
+		entries = append(entries, rn.log[nextIndex:]...)
+	}
+
+	req := &AppendEntriesRequest{
+		Term:         term,
+		LeaderID:     rn.id,
+		PrevLogIndex: prevLogIndex,
+		PrevLogTerm:  prevLogTerm,
+		Entries:      entries,
+		LeaderCommit: rn.commitIndex,
+	}
+	address := rn.peerAddresses[peerID]
+	rn.mu.RUnlock()
+
+	resp, err := rn.rpcClient.AppendEntries(address, req)
+	if err != nil || rn.shuttingDown() {
+		return
+	}
+
+	// If peer has higher term, step down
+	if resp.Term > term {
+		rn.stepDown(resp.Term)
+		return
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	// Ignore replies that arrive after we lost leadership
+	if rn.state != Leader || rn.currentTerm != term {
+		return
+	}
+
+	// The peer may have left the configuration while the RPC was in flight
+	if _, ok := rn.nextIndex[peerID]; !ok {
+		return
+	}
+	rn.lastContact[peerID] = time.Now()
+
+	if resp.Success {
+		match := prevLogIndex + uint64(len(entries))
+		if match > rn.matchIndex[peerID] {
+			rn.matchIndex[peerID] = match
+		}
+		if match+1 > rn.nextIndex[peerID] {
+			rn.nextIndex[peerID] = match + 1
+		}
+		rn.advanceCommitIndexLocked()
+		return
+	}
+
+	// Log inconsistency: back up to the first index of the conflicting
+	// term and retry on the next heartbeat
+	rn.nextIndex[peerID] = max(1, min(resp.ConflictIndex, prevLogIndex))
+}
+
+// advanceCommitIndexLocked commits the highest entry from the current term
+// that a majority has replicated (must be called with lock held).
+// Entries from earlier terms are committed indirectly, as in §5.4.2.
+func (rn *RaftNode) advanceCommitIndexLocked() {
+	majority := rn.quorumLocked()
+
+	for n := uint64(len(rn.log) - 1); n > rn.commitIndex; n-- {
+		if rn.log[n].Term != rn.currentTerm {
+			break
+		}
+
+		replicas := 0
+		if rn.isMemberLocked() {
+			replicas++ // ourselves, unless we are being removed
+		}
+		for _, peer := range rn.peers {
+			if rn.matchIndex[peer] >= n {
+				replicas++
+			}
+		}
+
+		if replicas >= majority {
+			rn.setCommitIndexLocked(n)
+			break
+		}
+	}
+
+	// A leader that removed itself hands over once the removal commits
+	if rn.state == Leader && !rn.isMemberLocked() && rn.configIndex <= rn.commitIndex {
+		rn.logger.LogStateChange(rn.state, Follower, rn.currentTerm)
+		rn.state = Follower
+		rn.leaderID = ""
+		if rn.heartbeatTimer != nil {
+			rn.heartbeatTimer.Stop()
+		}
+	}
+}
+
+// setCommitIndexLocked moves commitIndex forward and wakes the apply loop
+// (must be called with lock held)
+func (rn *RaftNode) setCommitIndexLocked(index uint64) {
+	if index <= rn.commitIndex {
+		return
+	}
+
+	rn.commitIndex = index
+	rn.logger.LogCommit(index, rn.log[index].Term)
+
+	select {
+	case rn.commitCh <- struct{}{}:
+	default:
+	}
+}
+
+// applyLoop applies committed entries to the state machine in log order
+func (rn *RaftNode) applyLoop() {
+	for {
+		select {
+		case <-rn.shutdownCh:
+			return
+		case <-rn.commitCh:
+		}
+
+		rn.mu.RLock()
+		var pending []*LogEntry
+		if rn.commitIndex > rn.lastApplied {
+			pending = append(pending, rn.log[rn.lastApplied+1:rn.commitIndex+1]...)
+		}
+		rn.mu.RUnlock()
+
+		for _, entry := range pending {
+			if entry.Type == EntryConfig {
+				// Already in effect since it was appended
+				rn.logger.Info("Configuration %s committed", FormatLogEntry(entry))
+				rn.mu.Lock()
+				rn.lastApplied = entry.Index
+				rn.mu.Unlock()
+				continue
+			}
+
+			if rn.stateMachine != nil {
+				if _, err := rn.stateMachine.Apply(entry.Command); err != nil {
+					rn.logger.Error("Failed to apply entry %s: %v", FormatLogEntry(entry), err)
+				}
+			}
+			rn.logger.LogApply(entry.Index, FormatCommand(entry.Command))
+
+			rn.mu.Lock()
+			rn.lastApplied = entry.Index
+			rn.mu.Unlock()
+		}
+	}
+}
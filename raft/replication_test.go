@@ -0,0 +1,169 @@
+// raft/replication_test.go
+package raft
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test: all nodes converge on the same commitIndex and apply every entry
+func TestReplicationCommitConvergence(t *testing.T) {
+	nodes := createTestCluster(3)
+	defer shutdownCluster(nodes)
+
+	machines := make([]*recordingStateMachine, len(nodes))
+	for i, node := range nodes {
+		machines[i] = &recordingStateMachine{}
+		node.stateMachine = machines[i]
+		node.Start()
+	}
+
+	leader := waitForLeader(t, nodes, 2*time.Second)
+
+	const entries = 5
+	for i := 1; i <= entries; i++ {
+		cmd, _ := json.Marshal(Command{Type: "PUT", Key: fmt.Sprintf("key%d", i), Value: []byte("v")})
+		index, _, ok := leader.Submit(cmd)
+		if !ok {
+			t.Fatal("Leader rejected Submit")
+		}
+		if index != uint64(i) {
+			t.Errorf("Expected entry %d at index %d, got %d", i, i, index)
+		}
+	}
+
+	// Followers learn the final commitIndex on the next heartbeat
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		converged := true
+		for i, node := range nodes {
+			node.mu.RLock()
+			commitIndex, lastApplied := node.commitIndex, node.lastApplied
+			node.mu.RUnlock()
+			if commitIndex != entries || lastApplied != entries || machines[i].count() != entries {
+				converged = false
+			}
+		}
+		if converged {
+			break
+		}
+		if time.Now().After(deadline) {
+			for _, node := range nodes {
+				node.mu.RLock()
+				t.Errorf("%s: commitIndex=%d lastApplied=%d", node.id, node.commitIndex, node.lastApplied)
+				node.mu.RUnlock()
+			}
+			t.Fatal("Nodes did not converge on the same commitIndex")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// Every node applied the same commands in the same order
+	for i := 1; i < len(machines); i++ {
+		for j, cmd := range machines[i].applied() {
+			if string(cmd) != string(machines[0].applied()[j]) {
+				t.Errorf("%s applied %s at position %d, expected %s", nodes[i].id, cmd, j, machines[0].applied()[j])
+			}
+		}
+	}
+
+	// Followers reject Submit
+	for _, node := range nodes {
+		if node != leader {
+			if _, _, ok := node.Submit([]byte("x")); ok {
+				t.Errorf("%s accepted Submit as a follower", node.id)
+			}
+		}
+	}
+}
+
+// Test: a follower truncates entries that conflict with the leader's log
+func TestAppendEntriesConflict(t *testing.T) {
+	rn := createTestNode("node1", []string{"node2", "node3"})
+	rn.log = append(rn.log,
+		&LogEntry{Index: 1, Term: 1},
+		&LogEntry{Index: 2, Term: 1},
+		&LogEntry{Index: 3, Term: 2}, // never committed
+	)
+	rn.currentTerm = 2
+
+	// Leader of term 3 doesn't have index 3
+	resp := rn.AppendEntries(&AppendEntriesRequest{
+		Term:         3,
+		LeaderID:     "node2",
+		PrevLogIndex: 3,
+		PrevLogTerm:  3,
+	})
+	if resp.Success {
+		t.Fatal("Expected consistency check to fail")
+	}
+	if resp.ConflictTerm != 2 || resp.ConflictIndex != 3 {
+		t.Errorf("Expected conflict at term 2 index 3, got term %d index %d", resp.ConflictTerm, resp.ConflictIndex)
+	}
+
+	resp = rn.AppendEntries(&AppendEntriesRequest{
+		Term:         3,
+		LeaderID:     "node2",
+		PrevLogIndex: 2,
+		PrevLogTerm:  1,
+		Entries:      []*LogEntry{{Index: 3, Term: 3}, {Index: 4, Term: 3}},
+		LeaderCommit: 3,
+	})
+	if !resp.Success {
+		t.Fatal("Expected AppendEntries to succeed")
+	}
+
+	rn.mu.RLock()
+	defer rn.mu.RUnlock()
+	if len(rn.log) != 5 || rn.log[3].Term != 3 {
+		t.Errorf("Expected conflicting entry replaced, got %d entries (index 3 term %d)", len(rn.log)-1, rn.log[3].Term)
+	}
+	if rn.commitIndex != 3 {
+		t.Errorf("Expected commitIndex 3, got %d", rn.commitIndex)
+	}
+}
+
+// Helper functions
+
+func waitForLeader(t *testing.T, nodes []*RaftNode, timeout time.Duration) *RaftNode {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, node := range nodes {
+			if _, isLeader := node.GetState(); isLeader {
+				return node
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("No leader elected")
+	return nil
+}
+
+// recordingStateMachine remembers every applied command
+type recordingStateMachine struct {
+	MockStateMachine
+	mu       sync.Mutex
+	commands [][]byte
+}
+
+func (m *recordingStateMachine) Apply(command []byte) (interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commands = append(m.commands, command)
+	return nil, nil
+}
+
+func (m *recordingStateMachine) applied() [][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]byte(nil), m.commands...)
+}
+
+func (m *recordingStateMachine) count() int {
+	return len(m.applied())
+}
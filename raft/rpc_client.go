@@ -3,35 +3,85 @@ package raft
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	pb "kvstore/proto"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
-// GRPCRaftClient implements the RPC client for Raft
+const (
+	// redialBaseDelay is how long the client waits before redialing a peer
+	// whose connection just broke; it doubles on every further failure
+	redialBaseDelay = 50 * time.Millisecond
+
+	// redialMaxDelay caps the redial backoff. It is kept below typical
+	// election timeouts so a restarted peer is reachable again within about
+	// one election.
+	redialMaxDelay = 1 * time.Second
+)
+
+// ErrNoLeader is returned when a node does not currently know the leader
+var ErrNoLeader = errors.New("no leader known")
+
+// ErrPeerBackoff is returned without contacting a peer whose connection
+// recently broke, until its redial backoff has passed
+var ErrPeerBackoff = errors.New("peer unreachable, waiting to redial")
+
+// GRPCRaftClient implements the RPC client for Raft.
+// A connection whose transport breaks (e.g. the peer restarted) is dropped
+// and redialed on a later call, with exponential backoff per peer so a down
+// peer isn't hammered by every heartbeat.
 type GRPCRaftClient struct {
 	connections map[string]*grpc.ClientConn
+	redials     map[string]*redialState // address -> backoff after a broken connection
 	timeout     time.Duration
+	mu          sync.Mutex // Guards connections and redials
+}
+
+// redialState tracks the backoff for one peer
+type redialState struct {
+	failures int
+	retryAt  time.Time
 }
 
 // NewGRPCRaftClient creates a new gRPC client
 func NewGRPCRaftClient() *GRPCRaftClient {
 	return &GRPCRaftClient{
 		connections: make(map[string]*grpc.ClientConn),
+		redials:     make(map[string]*redialState),
 		timeout:     2 * time.Second,
 	}
 }
 
 // getConnection gets or creates a connection to a peer
 func (c *GRPCRaftClient) getConnection(address string) (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if conn, ok := c.connections[address]; ok {
 		return conn, nil
 	}
 
-	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if redial, ok := c.redials[address]; ok {
+		if wait := time.Until(redial.retryAt); wait > 0 {
+			return nil, fmt.Errorf("%w: %s (retry in %v)", ErrPeerBackoff, address, wait.Round(time.Millisecond))
+		}
+	}
+
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(pb.DefaultMaxMessageSize),
+			grpc.MaxCallSendMsgSize(pb.DefaultMaxMessageSize),
+		),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +90,42 @@ func (c *GRPCRaftClient) getConnection(address string) (*grpc.ClientConn, error)
 	return conn, nil
 }
 
+// handleResult updates a peer's connection state after an RPC on conn.
+// A dead transport evicts the connection and backs off before redialing;
+// any success clears the backoff.
+func (c *GRPCRaftClient) handleResult(address string, conn *grpc.ClientConn, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil {
+		delete(c.redials, address)
+		return
+	}
+	if status.Code(err) != codes.Unavailable {
+		return // The peer answered (or timed out); the connection is fine
+	}
+
+	if c.connections[address] == conn {
+		delete(c.connections, address)
+		conn.Close()
+	}
+
+	redial, ok := c.redials[address]
+	if !ok {
+		redial = &redialState{}
+		c.redials[address] = redial
+	}
+	redial.failures++
+	delay := redialBaseDelay
+	for i := 1; i < redial.failures && delay < redialMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > redialMaxDelay {
+		delay = redialMaxDelay
+	}
+	redial.retryAt = time.Now().Add(delay)
+}
+
 // RequestVote sends a RequestVote RPC to a peer
 func (c *GRPCRaftClient) RequestVote(address string, req *RequestVoteRequest) (*RequestVoteResponse, error) {
 	conn, err := c.getConnection(address)
@@ -61,6 +147,7 @@ func (c *GRPCRaftClient) RequestVote(address string, req *RequestVoteRequest) (*
 	defer cancel()
 
 	pbResp, err := client.RequestVote(ctx, pbReq)
+	c.handleResult(address, conn, err)
 	if err != nil {
 		return nil, err
 	}
@@ -88,6 +175,7 @@ func (c *GRPCRaftClient) AppendEntries(address string, req *AppendEntriesRequest
 			Index:   entry.Index,
 			Term:    entry.Term,
 			Command: entry.Command,
+			Type:    uint32(entry.Type),
 		}
 	}
 
@@ -104,6 +192,7 @@ func (c *GRPCRaftClient) AppendEntries(address string, req *AppendEntriesRequest
 	defer cancel()
 
 	pbResp, err := client.AppendEntries(ctx, pbReq)
+	c.handleResult(address, conn, err)
 	if err != nil {
 		return nil, err
 	}
@@ -117,9 +206,39 @@ func (c *GRPCRaftClient) AppendEntries(address string, req *AppendEntriesRequest
 	}, nil
 }
 
+// Leader asks a node who the current leader is and returns its ID and address.
+// Returns ErrNoLeader if the node does not know (e.g. during an election).
+func (c *GRPCRaftClient) Leader(address string) (string, string, error) {
+	conn, err := c.getConnection(address)
+	if err != nil {
+		return "", "", err
+	}
+
+	client := pb.NewKVStoreClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	pbResp, err := client.Leader(ctx, &pb.LeaderRequest{})
+	c.handleResult(address, conn, err)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !pbResp.Known {
+		return "", "", ErrNoLeader
+	}
+
+	return pbResp.LeaderId, pbResp.LeaderAddress, nil
+}
+
 // Close closes all connections
 func (c *GRPCRaftClient) Close() {
-	for _, conn := range c.connections {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for address, conn := range c.connections {
 		conn.Close()
+		delete(c.connections, address)
 	}
 }
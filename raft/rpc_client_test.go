@@ -0,0 +1,135 @@
+// raft/rpc_client_test.go
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Test: concurrent RPCs through one client share the connection map safely
+// (run with -race)
+func TestRPCClientConcurrentRequestVote(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := lis.Addr().String()
+	lis.Close()
+
+	server := NewGRPCRaftServer(createTestNode("node9", nil))
+	if err := server.Start(address); err != nil {
+		t.Fatalf("Failed to start peer: %v", err)
+	}
+	defer server.Stop()
+
+	client := NewGRPCRaftClient()
+
+	// Distinct candidates, so the peer grants (and resets its timer) only once
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := &RequestVoteRequest{Term: 1, CandidateID: fmt.Sprintf("candidate%d", i)}
+			if _, err := client.RequestVote(address, req); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("RequestVote failed: %v", err)
+	}
+
+	client.mu.Lock()
+	conns := len(client.connections)
+	client.mu.Unlock()
+	if conns != 1 {
+		t.Errorf("Expected one shared connection, got %d", conns)
+	}
+
+	// Close may race with calls still being made
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		client.RequestVote(address, &RequestVoteRequest{Term: 1, CandidateID: "late"})
+	}()
+	client.Close()
+	wg.Wait()
+	client.Close()
+}
+
+// Test: RPCs to a peer resume after it restarts on the same address
+func TestRPCClientReconnectsAfterPeerRestart(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	address := lis.Addr().String()
+	lis.Close()
+
+	peer := createTestNode("node9", nil)
+	server := NewGRPCRaftServer(peer)
+	if err := server.Start(address); err != nil {
+		t.Fatalf("Failed to start peer: %v", err)
+	}
+
+	client := NewGRPCRaftClient()
+	client.timeout = 500 * time.Millisecond
+	defer client.Close()
+
+	vote := func() error {
+		_, err := client.RequestVote(address, &RequestVoteRequest{Term: 1, CandidateID: "node1"})
+		return err
+	}
+	if err := vote(); err != nil {
+		t.Fatalf("RequestVote failed: %v", err)
+	}
+
+	// Peer goes down: the broken connection is dropped and redials back off
+	server.Stop()
+	if err := vote(); err == nil {
+		t.Fatal("Expected RequestVote to a stopped peer to fail")
+	}
+	if err := vote(); !errors.Is(err, ErrPeerBackoff) {
+		t.Errorf("Expected ErrPeerBackoff right after a failure, got %v", err)
+	}
+
+	// Peer comes back on the same address
+	server = NewGRPCRaftServer(peer)
+	for i := 0; ; i++ {
+		if err = server.Start(address); err == nil {
+			break
+		}
+		if i == 50 {
+			t.Fatalf("Failed to restart peer: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	defer server.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err := vote()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("RPCs did not resume after the peer restarted: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if _, backingOff := client.redials[address]; backingOff {
+		t.Error("Expected backoff to be cleared after a successful RPC")
+	}
+}
@@ -33,7 +33,10 @@ func (s *GRPCRaftServer) Start(address string) error {
 	}
 	s.listener = lis
 
-	s.server = grpc.NewServer()
+	s.server = grpc.NewServer(append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(pb.DefaultMaxMessageSize),
+		grpc.MaxSendMsgSize(pb.DefaultMaxMessageSize),
+	}, s.node.serverOptions...)...)
 	pb.RegisterKVStoreServer(s.server, s)
 
 	go func() {
@@ -72,6 +75,19 @@ func (s *GRPCRaftServer) RequestVote(ctx context.Context, req *pb.RequestVoteReq
 	}, nil
 }
 
+// Leader handles Leader RPC
+func (s *GRPCRaftServer) Leader(ctx context.Context, req *pb.LeaderRequest) (*pb.LeaderResponse, error) {
+	term, _ := s.node.GetState()
+
+	leaderID, address, known := s.node.Leader()
+	return &pb.LeaderResponse{
+		Known:         known,
+		LeaderId:      leaderID,
+		LeaderAddress: address,
+		Term:          term,
+	}, nil
+}
+
 // AppendEntries handles AppendEntries RPC
 func (s *GRPCRaftServer) AppendEntries(ctx context.Context, req *pb.AppendEntriesRequest) (*pb.AppendEntriesResponse, error) {
 	// Convert protobuf entries to internal type
@@ -81,6 +97,7 @@ func (s *GRPCRaftServer) AppendEntries(ctx context.Context, req *pb.AppendEntrie
 			Index:   entry.Index,
 			Term:    entry.Term,
 			Command: entry.Command,
+			Type:    EntryType(entry.Type),
 		}
 	}
 
@@ -4,6 +4,7 @@ package raft
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
 )
 
@@ -41,6 +42,15 @@ type Command struct {
 	Value []byte `json:"value,omitempty"`
 }
 
+// FormatCommand formats a serialized command for logging
+func FormatCommand(data []byte) string {
+	var cmd Command
+	if err := json.Unmarshal(data, &cmd); err != nil || cmd.Type == "" {
+		return fmt.Sprintf("<%d bytes>", len(data))
+	}
+	return fmt.Sprintf("%s %s", cmd.Type, cmd.Key)
+}
+
 // FormatTerm formats a term for logging
 func FormatTerm(term uint64) string {
 	return fmt.Sprintf("T%d", term)
@@ -0,0 +1,137 @@
+package replication
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// gcounterMagic starts every encoded GCounter, so a counter is never
+// mistaken for a plain value or the other way round
+var gcounterMagic = []byte("GCNT\x01")
+
+// ErrNotGCounter is returned when a value read as a counter isn't one, e.g.
+// because the key was written with Put
+var ErrNotGCounter = errors.New("value is not a G-Counter")
+
+// GCounter is a grow-only counter CRDT. Each replica counts the increments
+// it applied under its own node ID, and the counter's value is the sum of
+// the counts. Two copies merge by keeping each node's higher count, so
+// replicas that took concurrent increments converge without losing any,
+// whatever order the merges arrive in.
+type GCounter map[string]uint64
+
+// Value returns the counter's total
+func (c GCounter) Value() uint64 {
+	var total uint64
+	for _, count := range c {
+		total += count
+	}
+	return total
+}
+
+// Increment adds delta to nodeID's count
+func (c GCounter) Increment(nodeID string, delta uint64) {
+	c[nodeID] += delta
+}
+
+// Merge raises each of c's counts to other's where other's is higher
+func (c GCounter) Merge(other GCounter) {
+	for nodeID, count := range other {
+		if count > c[nodeID] {
+			c[nodeID] = count
+		}
+	}
+}
+
+// Covers reports whether c has every count of other, so merging other
+// into c would change nothing
+func (c GCounter) Covers(other GCounter) bool {
+	for nodeID, count := range other {
+		if c[nodeID] < count {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode returns the counter's stored form: the magic, the number of
+// nodes, then each node ID and count in node ID order
+func (c GCounter) Encode() []byte {
+	nodeIDs := make([]string, 0, len(c))
+	for nodeID := range c {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+
+	buf := append([]byte(nil), gcounterMagic...)
+	buf = binary.AppendUvarint(buf, uint64(len(nodeIDs)))
+	for _, nodeID := range nodeIDs {
+		buf = binary.AppendUvarint(buf, uint64(len(nodeID)))
+		buf = append(buf, nodeID...)
+		buf = binary.AppendUvarint(buf, c[nodeID])
+	}
+	return buf
+}
+
+// DecodeGCounter parses a counter written by Encode
+func DecodeGCounter(data []byte) (GCounter, error) {
+	if !bytes.HasPrefix(data, gcounterMagic) {
+		return nil, ErrNotGCounter
+	}
+	r := bytes.NewReader(data[len(gcounterMagic):])
+
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > uint64(r.Len()) {
+		return nil, fmt.Errorf("%w: bad node count", ErrNotGCounter)
+	}
+	counter := make(GCounter, n)
+	for i := uint64(0); i < n; i++ {
+		idLen, err := binary.ReadUvarint(r)
+		if err != nil || idLen > uint64(r.Len()) {
+			return nil, fmt.Errorf("%w: bad node ID", ErrNotGCounter)
+		}
+		nodeID := make([]byte, idLen)
+		r.Read(nodeID)
+		count, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad count for node %s", ErrNotGCounter, nodeID)
+		}
+		counter[string(nodeID)] = count
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrNotGCounter, r.Len())
+	}
+	return counter, nil
+}
+
+// GCounterUpdate returns how a replica applies a G-Counter write to its
+// stored counter: incoming (an encoded counter, or empty) is merged in, then
+// increment is added to nodeID's count. Its signature matches
+// storage.UpdateFunc, so the replica can apply it atomically with
+// LSMStore.Update.
+func GCounterUpdate(incoming []byte, nodeID string, increment uint64) func(current []byte, found bool) ([]byte, error) {
+	return func(current []byte, found bool) ([]byte, error) {
+		counter := make(GCounter)
+		if found {
+			stored, err := DecodeGCounter(current)
+			if err != nil {
+				return nil, err
+			}
+			counter = stored
+		}
+		if len(incoming) > 0 {
+			other, err := DecodeGCounter(incoming)
+			if err != nil {
+				return nil, err
+			}
+			counter.Merge(other)
+		}
+		if increment > 0 {
+			counter.Increment(nodeID, increment)
+		}
+		return counter.Encode(), nil
+	}
+}
@@ -0,0 +1,49 @@
+package replication
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGCounter_MergeAndEncode(t *testing.T) {
+	a := GCounter{"node1": 5, "node2": 1}
+	b := GCounter{"node2": 3, "node3": 2}
+
+	// Merging keeps each node's higher count, in either order
+	ab, ba := GCounter{}, GCounter{}
+	ab.Merge(a)
+	ab.Merge(b)
+	ba.Merge(b)
+	ba.Merge(a)
+	if ab.Value() != 10 || ba.Value() != 10 {
+		t.Errorf("Expected 10 both ways, got %d and %d", ab.Value(), ba.Value())
+	}
+	if !ab.Covers(a) || !ab.Covers(b) || a.Covers(ab) {
+		t.Error("Covers disagrees with the merge")
+	}
+
+	decoded, err := DecodeGCounter(ab.Encode())
+	if err != nil {
+		t.Fatalf("DecodeGCounter failed: %v", err)
+	}
+	if len(decoded) != 3 || decoded.Value() != 10 || decoded["node2"] != 3 {
+		t.Errorf("Round trip changed the counter: %v", decoded)
+	}
+
+	if _, err := DecodeGCounter([]byte("plain value")); !errors.Is(err, ErrNotGCounter) {
+		t.Errorf("Expected ErrNotGCounter, got %v", err)
+	}
+	if _, err := DecodeGCounter(ab.Encode()[:8]); !errors.Is(err, ErrNotGCounter) {
+		t.Errorf("Expected a truncated counter rejected, got %v", err)
+	}
+
+	// An update merges, then adds to its own node's count
+	updated, err := GCounterUpdate(a.Encode(), "node3", 4)(b.Encode(), true)
+	if err != nil {
+		t.Fatalf("GCounterUpdate failed: %v", err)
+	}
+	counter, _ := DecodeGCounter(updated)
+	if counter["node1"] != 5 || counter["node2"] != 3 || counter["node3"] != 6 {
+		t.Errorf("Unexpected counter after update: %v", counter)
+	}
+}
@@ -1,6 +1,7 @@
 package replication
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -10,6 +11,15 @@ import (
 	"time"
 )
 
+const (
+	// DefaultMaxHintBytes is the default disk budget across all hints (256MB)
+	DefaultMaxHintBytes = 256 * 1024 * 1024
+
+	// hintEvictionLowWater is the fraction of the byte budget that eviction
+	// frees down to, so a full store doesn't rewrite hint logs on every write
+	hintEvictionLowWater = 0.9
+)
+
 // Hint represents a write that should be replayed to a node when it comes back
 type Hint struct {
 	TargetNode string    `json:"target_node"` // Node that should receive this write
@@ -18,15 +28,24 @@ type Hint struct {
 	Timestamp  int64     `json:"timestamp"`
 	Version    int64     `json:"version"`
 	CreatedAt  time.Time `json:"created_at"`
+	size       int64     // Bytes used on disk (one JSON line)
 }
 
-// HintedHandoff manages hints for temporarily unavailable nodes
+// HintedHandoff manages hints for temporarily unavailable nodes.
+// Hints are persisted as an append-only log of JSON lines per node; the log
+// is only rewritten when hints are removed.
 type HintedHandoff struct {
-	hints    map[string][]Hint // targetNode -> list of hints
-	hintsDir string            // Directory to persist hints
-	mu       sync.RWMutex
-	maxHints int           // Maximum hints per node
-	maxAge   time.Duration // Maximum age of hints
+	hints      map[string][]Hint // targetNode -> list of hints (oldest first)
+	hintsDir   string            // Directory to persist hints
+	mu         sync.RWMutex
+	maxHints   int           // Maximum hints per node
+	maxAge     time.Duration // Maximum age of hints
+	maxBytes   int64         // Disk budget across all hints
+	totalBytes int64         // Bytes currently used by all hints
+
+	stopCleanup chan struct{} // Closed by Close to stop the cleanup task
+	cleanupDone chan struct{} // Closed when the cleanup task has exited
+	closeOnce   sync.Once
 }
 
 // NewHintedHandoff creates a new hinted handoff manager
@@ -40,6 +59,7 @@ func NewHintedHandoff(hintsDir string) (*HintedHandoff, error) {
 		hintsDir: hintsDir,
 		maxHints: 10000,          // Max 10k hints per node
 		maxAge:   24 * time.Hour, // Keep hints for 24 hours max
+		maxBytes: DefaultMaxHintBytes,
 	}
 
 	// Load existing hints from disk
@@ -69,10 +89,27 @@ func (hh *HintedHandoff) StoreHint(targetNode, key string, value []byte, timesta
 		return fmt.Errorf("max hints reached for node %s", targetNode)
 	}
 
+	line, err := json.Marshal(hint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hint: %w", err)
+	}
+	line = append(line, '\n')
+	hint.size = int64(len(line))
+
+	if hint.size > hh.maxBytes {
+		return fmt.Errorf("hint for key %s (%d bytes) exceeds hint byte budget (%d bytes)", key, hint.size, hh.maxBytes)
+	}
+
+	// Make room by evicting the oldest hints across all nodes
+	if hh.totalBytes+hint.size > hh.maxBytes {
+		hh.evictOldestLocked(int64(float64(hh.maxBytes)*hintEvictionLowWater) - hint.size)
+	}
+
 	hh.hints[targetNode] = append(hh.hints[targetNode], hint)
+	hh.totalBytes += hint.size
 
-	// Persist to disk synchronously (fixed for Windows compatibility)
-	if err := hh.persistHintsLocked(targetNode); err != nil {
+	// Persist to disk synchronously by appending to the node's hint log
+	if err := hh.appendHintLocked(targetNode, line); err != nil {
 		log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
 	}
 
@@ -101,11 +138,13 @@ func (hh *HintedHandoff) ClearHints(targetNode string) error {
 	hh.mu.Lock()
 	defer hh.mu.Unlock()
 
+	for _, hint := range hh.hints[targetNode] {
+		hh.totalBytes -= hint.size
+	}
 	delete(hh.hints, targetNode)
 
 	// Remove hints file from disk
-	hintsFile := filepath.Join(hh.hintsDir, fmt.Sprintf("hints_%s.json", targetNode))
-	if err := os.Remove(hintsFile); err != nil && !os.IsNotExist(err) {
+	if err := os.Remove(hh.hintsFile(targetNode)); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to remove hints file: %w", err)
 	}
 
@@ -124,12 +163,51 @@ func (hh *HintedHandoff) RemoveHint(targetNode string, hintIndex int) {
 	}
 
 	// Remove hint at index
+	hh.totalBytes -= hints[hintIndex].size
 	hh.hints[targetNode] = append(hints[:hintIndex], hints[hintIndex+1:]...)
 
 	// If no more hints, delete the entry
 	if len(hh.hints[targetNode]) == 0 {
 		delete(hh.hints, targetNode)
 	}
+
+	if err := hh.rewriteHintsLocked(targetNode); err != nil {
+		log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
+	}
+}
+
+// RemoveHints removes the hints for a node that remove returns true for
+// (e.g. the ones a replay delivered) and returns how many it removed. The
+// node's hint log is rewritten once.
+func (hh *HintedHandoff) RemoveHints(targetNode string, remove func(Hint) bool) int {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+
+	hints := hh.hints[targetNode]
+	kept := hints[:0]
+	removed := 0
+	for _, hint := range hints {
+		if remove(hint) {
+			hh.totalBytes -= hint.size
+			removed++
+			continue
+		}
+		kept = append(kept, hint)
+	}
+	if removed == 0 {
+		return 0
+	}
+
+	if len(kept) == 0 {
+		delete(hh.hints, targetNode)
+	} else {
+		hh.hints[targetNode] = kept
+	}
+
+	if err := hh.rewriteHintsLocked(targetNode); err != nil {
+		log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
+	}
+	return removed
 }
 
 // CleanupOldHints removes hints older than maxAge
@@ -146,15 +224,24 @@ func (hh *HintedHandoff) CleanupOldHints() int {
 			if hint.CreatedAt.After(cutoff) {
 				newHints = append(newHints, hint)
 			} else {
+				hh.totalBytes -= hint.size
 				removed++
 			}
 		}
 
+		if len(newHints) == len(hints) {
+			continue
+		}
+
 		if len(newHints) == 0 {
 			delete(hh.hints, targetNode)
 		} else {
 			hh.hints[targetNode] = newHints
 		}
+
+		if err := hh.rewriteHintsLocked(targetNode); err != nil {
+			log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
+		}
 	}
 
 	if removed > 0 {
@@ -184,68 +271,310 @@ func (hh *HintedHandoff) GetHintCountForNode(targetNode string) int {
 	return len(hh.hints[targetNode])
 }
 
-// persistHintsLocked saves hints for a node to disk (must be called with lock held)
-func (hh *HintedHandoff) persistHintsLocked(targetNode string) error {
-	hints, exists := hh.hints[targetNode]
-	if !exists || len(hints) == 0 {
-		return nil
+// GetHintBytes returns the bytes used on disk by all hints
+func (hh *HintedHandoff) GetHintBytes() int64 {
+	hh.mu.RLock()
+	defer hh.mu.RUnlock()
+	return hh.totalBytes
+}
+
+// SetMaxBytes sets the disk budget across all hints, evicting the oldest
+// hints if the current usage is already above it
+func (hh *HintedHandoff) SetMaxBytes(maxBytes int64) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+
+	hh.maxBytes = maxBytes
+	if hh.totalBytes > hh.maxBytes {
+		hh.evictOldestLocked(hh.maxBytes)
 	}
+}
 
-	hintsFile := filepath.Join(hh.hintsDir, fmt.Sprintf("hints_%s.json", targetNode))
+// evictOldestLocked drops the oldest hints across all nodes until at most
+// targetBytes are in use (must be called with lock held)
+func (hh *HintedHandoff) evictOldestLocked(targetBytes int64) {
+	evicted := 0
+	touched := make(map[string]bool)
+
+	for hh.totalBytes > targetBytes && len(hh.hints) > 0 {
+		// Each node's hints are oldest first, so compare the heads
+		oldestNode := ""
+		for targetNode, hints := range hh.hints {
+			if oldestNode == "" || hints[0].CreatedAt.Before(hh.hints[oldestNode][0].CreatedAt) {
+				oldestNode = targetNode
+			}
+		}
 
-	data, err := json.MarshalIndent(hints, "", "  ")
+		hints := hh.hints[oldestNode]
+		hh.totalBytes -= hints[0].size
+		if len(hints) == 1 {
+			delete(hh.hints, oldestNode)
+		} else {
+			hh.hints[oldestNode] = hints[1:]
+		}
+
+		touched[oldestNode] = true
+		evicted++
+	}
+
+	for targetNode := range touched {
+		if err := hh.rewriteHintsLocked(targetNode); err != nil {
+			log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
+		}
+	}
+
+	if evicted > 0 {
+		log.Printf("🧹 Evicted %d oldest hints to stay within %d byte budget", evicted, hh.maxBytes)
+	}
+}
+
+// hintsFile returns the path of a node's hint log
+func (hh *HintedHandoff) hintsFile(targetNode string) string {
+	return filepath.Join(hh.hintsDir, fmt.Sprintf("hints_%s.log", targetNode))
+}
+
+// appendHintLocked appends one encoded hint to a node's log (must be called with lock held)
+func (hh *HintedHandoff) appendHintLocked(targetNode string, line []byte) error {
+	file, err := os.OpenFile(hh.hintsFile(targetNode), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open hints file: %w", err)
+	}
+
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append hint: %w", err)
+	}
+
+	return file.Close()
+}
+
+// rewriteHintsLocked replaces a node's log with its current hints, or removes
+// it if none are left (must be called with lock held)
+func (hh *HintedHandoff) rewriteHintsLocked(targetNode string) error {
+	hintsFile := hh.hintsFile(targetNode)
+
+	hints := hh.hints[targetNode]
+	if len(hints) == 0 {
+		if err := os.Remove(hintsFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove hints file: %w", err)
+		}
+		return nil
+	}
+
+	tmpFile := hintsFile + ".tmp"
+	file, err := os.Create(tmpFile)
 	if err != nil {
-		return fmt.Errorf("failed to marshal hints: %w", err)
+		return fmt.Errorf("failed to create hints file: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, hint := range hints {
+		if err := encoder.Encode(hint); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write hint: %w", err)
+		}
 	}
 
-	if err := os.WriteFile(hintsFile, data, 0644); err != nil {
+	if err := writer.Flush(); err != nil {
+		file.Close()
 		return fmt.Errorf("failed to write hints file: %w", err)
 	}
+	if err := file.Close(); err != nil {
+		return err
+	}
 
-	return nil
+	return os.Rename(tmpFile, hintsFile)
 }
 
-// loadHints loads hints from disk
+// loadHints loads hints from disk. Hints already older than maxAge are
+// dropped and their node's log rewritten, as CleanupOldHints would have.
 func (hh *HintedHandoff) loadHints() error {
-	files, err := filepath.Glob(filepath.Join(hh.hintsDir, "hints_*.json"))
+	// Migrate hint files written by older versions (one JSON array per node)
+	if err := hh.migrateLegacyHints(); err != nil {
+		log.Printf("⚠️  Failed to migrate legacy hints: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(hh.hintsDir, "hints_*.log"))
 	if err != nil {
 		return err
 	}
 
 	totalHints := 0
+	expired := 0
+	cutoff := time.Now().Add(-hh.maxAge)
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Printf("⚠️  Failed to read hints file %s: %v", path, err)
+			continue
+		}
+
+		var hints []Hint
+		reader := bufio.NewReader(file)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 && line[len(line)-1] == '\n' {
+				var hint Hint
+				if jsonErr := json.Unmarshal(line, &hint); jsonErr != nil {
+					log.Printf("⚠️  Skipping corrupt hint in %s: %v", path, jsonErr)
+				} else {
+					hint.size = int64(len(line))
+					hints = append(hints, hint)
+				}
+			}
+			if err != nil {
+				// A partial last line is a torn append and is dropped
+				break
+			}
+		}
+		file.Close()
+
+		if len(hints) == 0 {
+			continue
+		}
+		targetNode := hints[0].TargetNode
+
+		live := hints[:0]
+		for _, hint := range hints {
+			if hint.CreatedAt.After(cutoff) {
+				live = append(live, hint)
+			}
+		}
+
+		if len(live) > 0 {
+			hh.hints[targetNode] = live
+			for _, hint := range live {
+				hh.totalBytes += hint.size
+			}
+			totalHints += len(live)
+		}
+
+		if len(live) < len(hints) {
+			expired += len(hints) - len(live)
+			if err := hh.rewriteHintsLocked(targetNode); err != nil {
+				log.Printf("⚠️  Failed to persist hints for %s: %v", targetNode, err)
+			}
+		}
+	}
+
+	if totalHints > 0 {
+		log.Printf("📂 Loaded %d hints from disk", totalHints)
+	}
+	if expired > 0 {
+		log.Printf("🧹 Dropped %d expired hints on load", expired)
+	}
+
+	if hh.totalBytes > hh.maxBytes {
+		hh.evictOldestLocked(hh.maxBytes)
+	}
+
+	return nil
+}
+
+// migrateLegacyHints converts hints_<node>.json files into hint logs
+func (hh *HintedHandoff) migrateLegacyHints() error {
+	files, err := filepath.Glob(filepath.Join(hh.hintsDir, "hints_*.json"))
+	if err != nil {
+		return err
+	}
+
 	for _, file := range files {
 		data, err := os.ReadFile(file)
 		if err != nil {
-			log.Printf("⚠️  Failed to read hints file %s: %v", file, err)
-			continue
+			return err
 		}
 
 		var hints []Hint
 		if err := json.Unmarshal(data, &hints); err != nil {
-			log.Printf("⚠️  Failed to unmarshal hints from %s: %v", file, err)
-			continue
+			return fmt.Errorf("failed to unmarshal hints from %s: %w", file, err)
 		}
 
 		if len(hints) > 0 {
 			targetNode := hints[0].TargetNode
 			hh.hints[targetNode] = hints
-			totalHints += len(hints)
+			if err := hh.rewriteHintsLocked(targetNode); err != nil {
+				return err
+			}
+			delete(hh.hints, targetNode) // Reloaded from the new log
 		}
-	}
 
-	if totalHints > 0 {
-		log.Printf("📂 Loaded %d hints from disk", totalHints)
+		if err := os.Remove(file); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// StartCleanupTask starts a background task to cleanup old hints
+// StartCleanupTask starts a background task to cleanup old hints. It runs
+// until Close.
 func (hh *HintedHandoff) StartCleanupTask(interval time.Duration) {
+	hh.mu.Lock()
+	if hh.stopCleanup != nil {
+		hh.mu.Unlock()
+		return // Already running
+	}
+	hh.stopCleanup = make(chan struct{})
+	hh.cleanupDone = make(chan struct{})
+	stop, done := hh.stopCleanup, hh.cleanupDone
+	hh.mu.Unlock()
+
 	ticker := time.NewTicker(interval)
 	go func() {
-		for range ticker.C {
-			hh.CleanupOldHints()
+		defer close(done)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				hh.CleanupOldHints()
+			}
 		}
 	}()
 }
+
+// Close stops the cleanup task, waiting for a cleanup in progress, and
+// fsyncs every hint log so the hints survive a machine crash. Hints are
+// written to their logs as they are stored, so nothing else is pending.
+// Hints can still be stored and read after Close.
+func (hh *HintedHandoff) Close() error {
+	var err error
+	hh.closeOnce.Do(func() {
+		hh.mu.RLock()
+		stop, done := hh.stopCleanup, hh.cleanupDone
+		hh.mu.RUnlock()
+
+		if stop != nil {
+			close(stop)
+			<-done
+		}
+
+		err = hh.syncHints()
+	})
+	return err
+}
+
+// syncHints fsyncs the hint log of every node with hints
+func (hh *HintedHandoff) syncHints() error {
+	hh.mu.RLock()
+	defer hh.mu.RUnlock()
+
+	for targetNode := range hh.hints {
+		file, err := os.OpenFile(hh.hintsFile(targetNode), os.O_WRONLY, 0)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open hints file: %w", err)
+		}
+		err = file.Sync()
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to sync hints for %s: %w", targetNode, err)
+		}
+	}
+	return nil
+}
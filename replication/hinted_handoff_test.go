@@ -1,6 +1,11 @@
 package replication
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -121,6 +126,70 @@ func TestHintedHandoff_CleanupOldHints(t *testing.T) {
 	}
 }
 
+func TestHintedHandoff_ExpiredHintsStayGone(t *testing.T) {
+	tmpDir := t.TempDir()
+	hh, err := NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create hinted handoff: %v", err)
+	}
+	hh.maxAge = 100 * time.Millisecond
+
+	hh.StoreHint("node2", "old", []byte("value"), time.Now().UnixNano(), 1)
+	hh.StoreHint("node3", "old", []byte("value"), time.Now().UnixNano(), 1)
+	time.Sleep(150 * time.Millisecond)
+	hh.StoreHint("node2", "new", []byte("value"), time.Now().UnixNano(), 2)
+
+	if removed := hh.CleanupOldHints(); removed != 2 {
+		t.Fatalf("Expected 2 hints removed, got %d", removed)
+	}
+
+	// Restart: the cleanup must have reached disk
+	hh, err = NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen hinted handoff: %v", err)
+	}
+	if hints := hh.GetHints("node2"); len(hints) != 1 || hints[0].Key != "new" {
+		t.Errorf("Expected only the new hint for node2, got %+v", hints)
+	}
+	if count := hh.GetHintCountForNode("node3"); count != 0 {
+		t.Errorf("Expected no hints for node3, got %d", count)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "hints_node3.log")); !os.IsNotExist(err) {
+		t.Errorf("Expected node3's hint log to be removed, got %v", err)
+	}
+
+	// Hints that expired while the node was down are dropped on load
+	var lines []byte
+	for _, hint := range []Hint{
+		{TargetNode: "node4", Key: "stale", CreatedAt: time.Now().Add(-25 * time.Hour)},
+		{TargetNode: "node4", Key: "fresh", CreatedAt: time.Now()},
+	} {
+		line, _ := json.Marshal(hint)
+		lines = append(append(lines, line...), '\n')
+	}
+	node4Log := filepath.Join(tmpDir, "hints_node4.log")
+	if err := os.WriteFile(node4Log, lines, 0644); err != nil {
+		t.Fatalf("Failed to write hint log: %v", err)
+	}
+
+	hh, err = NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen hinted handoff: %v", err)
+	}
+	if hints := hh.GetHints("node4"); len(hints) != 1 || hints[0].Key != "fresh" {
+		t.Errorf("Expected only the fresh hint for node4, got %+v", hints)
+	}
+
+	// ...and from the log, so they don't count against the byte budget
+	data, err := os.ReadFile(node4Log)
+	if err != nil {
+		t.Fatalf("Failed to read hint log: %v", err)
+	}
+	if bytes.Contains(data, []byte("stale")) {
+		t.Errorf("Expired hint still on disk: %s", data)
+	}
+}
+
 func TestHintedHandoff_MaxHints(t *testing.T) {
 	tmpDir := t.TempDir()
 	hh, err := NewHintedHandoff(tmpDir)
@@ -199,3 +268,93 @@ func TestHintedHandoff_GetHintCount(t *testing.T) {
 		t.Errorf("Expected 2 hints for node3, got %d", node3Count)
 	}
 }
+
+func TestHintedHandoff_ByteBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	hh, err := NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create hinted handoff: %v", err)
+	}
+
+	maxBytes := int64(200 * 1024)
+	hh.SetMaxBytes(maxBytes)
+
+	// 100 hints of 10KB each is ~5x the budget
+	value := make([]byte, 10*1024)
+	for i := 0; i < 100; i++ {
+		node := fmt.Sprintf("node%d", i%2+2)
+		if err := hh.StoreHint(node, fmt.Sprintf("key_%d", i), value, time.Now().UnixNano(), int64(i)); err != nil {
+			t.Fatalf("Failed to store hint %d: %v", i, err)
+		}
+
+		if hh.GetHintBytes() > maxBytes {
+			t.Fatalf("Hint bytes %d exceed budget %d after hint %d", hh.GetHintBytes(), maxBytes, i)
+		}
+	}
+
+	// Disk usage matches the accounting
+	var diskBytes int64
+	files, _ := filepath.Glob(filepath.Join(tmpDir, "hints_*"))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", file, err)
+		}
+		diskBytes += info.Size()
+	}
+	if diskBytes != hh.GetHintBytes() || diskBytes > maxBytes {
+		t.Errorf("Expected %d bytes on disk (budget %d), got %d", hh.GetHintBytes(), maxBytes, diskBytes)
+	}
+
+	// The oldest hints were evicted, the newest kept
+	hints := hh.GetHints("node3")
+	if len(hints) == 0 || hints[len(hints)-1].Key != "key_99" {
+		t.Fatal("Expected the newest hint to be kept")
+	}
+	for _, hint := range append(hh.GetHints("node2"), hints...) {
+		if hint.Key == "key_0" || hint.Key == "key_1" {
+			t.Errorf("Expected oldest hint %s to be evicted", hint.Key)
+		}
+	}
+
+	// A reload sees the same hints
+	hh2, err := NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reload hinted handoff: %v", err)
+	}
+	if hh2.GetHintCount() != hh.GetHintCount() || hh2.GetHintBytes() != hh.GetHintBytes() {
+		t.Errorf("Expected %d hints / %d bytes after reload, got %d / %d",
+			hh.GetHintCount(), hh.GetHintBytes(), hh2.GetHintCount(), hh2.GetHintBytes())
+	}
+
+	// A single hint larger than the whole budget is rejected
+	if err := hh.StoreHint("node2", "huge", make([]byte, maxBytes), time.Now().UnixNano(), 1000); err == nil {
+		t.Error("Expected error for hint larger than the byte budget")
+	}
+}
+
+func TestHintedHandoff_LegacyJSONMigration(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	legacy := []Hint{
+		{TargetNode: "node2", Key: "key1", Value: []byte("value1"), CreatedAt: time.Now()},
+		{TargetNode: "node2", Key: "key2", Value: []byte("value2"), CreatedAt: time.Now()},
+	}
+	data, _ := json.Marshal(legacy)
+	if err := os.WriteFile(filepath.Join(tmpDir, "hints_node2.json"), data, 0644); err != nil {
+		t.Fatalf("Failed to write legacy hints: %v", err)
+	}
+
+	hh, err := NewHintedHandoff(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create hinted handoff: %v", err)
+	}
+
+	hints := hh.GetHints("node2")
+	if len(hints) != 2 || hints[0].Key != "key1" || hints[1].Key != "key2" {
+		t.Fatalf("Expected legacy hints to be loaded, got %+v", hints)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "hints_node2.json")); !os.IsNotExist(err) {
+		t.Error("Expected legacy hints file to be removed after migration")
+	}
+}
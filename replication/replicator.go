@@ -35,6 +35,31 @@ func GetPreferenceList(hashRing interface{}, key string, n int) ([]string, error
 	return nil, fmt.Errorf("not implemented - will be used in cluster_client")
 }
 
+// ConflictResolver decides what a read returns when the replicas holding a
+// key disagree. Resolve is given the responses of the replicas that have the
+// key and may return one of them or a new response merging their values,
+// e.g. summing a counter. A merged response is written back to replicas
+// that are behind its version by read repair.
+type ConflictResolver interface {
+	Resolve(responses []ReplicaResponse) *ReplicaResponse
+}
+
+// ConflictResolverFunc adapts a function to a ConflictResolver
+type ConflictResolverFunc func(responses []ReplicaResponse) *ReplicaResponse
+
+// Resolve calls f(responses)
+func (f ConflictResolverFunc) Resolve(responses []ReplicaResponse) *ReplicaResponse {
+	return f(responses)
+}
+
+// LastWriteWins is the default ConflictResolver; see ResolveConflict
+type LastWriteWins struct{}
+
+// Resolve returns the response with the latest timestamp
+func (LastWriteWins) Resolve(responses []ReplicaResponse) *ReplicaResponse {
+	return ResolveConflict(responses)
+}
+
 // ResolveConflict resolves conflicts between multiple versions using Last-Write-Wins
 func ResolveConflict(responses []ReplicaResponse) *ReplicaResponse {
 	if len(responses) == 0 {
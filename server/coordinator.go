@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kvstore/cluster"
+	"kvstore/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Coordinator replicates a Put across the key's preference list and enforces
+// the write quorum. It is implemented by *cluster.ClusterClient.
+type Coordinator interface {
+	PutWithResult(ctx context.Context, key string, value []byte) (*cluster.WriteResult, error)
+}
+
+// SetCoordinator puts the server in coordinator mode: a client's Put is
+// handed to coordinator, which computes the key's preference list, writes to
+// the N replicas with ReplicaPut and succeeds once W of them have the value,
+// so clients need only one Put to any node instead of running a
+// ClusterClient themselves. The coordinator should use this server's store
+// as its local replica (ClusterClient.SetLocalStore).
+//
+// Only Put is coordinated. A ClusterClient deletes through the replicas' own
+// Delete RPC, so coordinating Delete here would have every replica coordinate
+// it again; and the coordinator's keyspace has no namespaces, so a Put with
+// one fails with codes.InvalidArgument.
+func (s *GRPCServer) SetCoordinator(coordinator Coordinator) {
+	s.coordinator = coordinator
+}
+
+// coordinatedPut replicates a Put through the coordinator
+func (s *GRPCServer) coordinatedPut(ctx context.Context, req *proto.PutRequest) (*proto.PutResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Put", KeySize: len(req.Key), ValueSize: len(req.Value)}
+	s.logger.Info(fields, "🧭 PUT (coordinator): key=%s, value_size=%d bytes", req.Key, len(req.Value))
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT rejected: %v", err)
+		return nil, err
+	}
+	if req.Ns != "" {
+		err := status.Error(codes.InvalidArgument, "namespaces are not supported in coordinator mode")
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT rejected: %v", err)
+		return nil, err
+	}
+	if req.ExpectedVersion != 0 {
+		err := status.Error(codes.InvalidArgument, "conditional writes are not supported in coordinator mode")
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT rejected: %v", err)
+		return nil, err
+	}
+
+	result, err := s.coordinator.PutWithResult(ctx, req.Key, req.Value)
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			s.logger.Warn(fields, "⚠️  PUT (coordinator) abandoned: %v", err)
+			return nil, status.FromContextError(err).Err()
+		}
+		s.logger.Error(fields, "❌ PUT (coordinator) failed: %v", err)
+		return &proto.PutResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.logger.Info(fields, "✅ PUT (coordinator) success: key=%s, replicas=%v", req.Key, result.SucceededNodes)
+	return &proto.PutResponse{
+		Success: true,
+	}, nil
+}
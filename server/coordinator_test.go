@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net"
+	"testing"
+
+	"kvstore/client"
+	"kvstore/cluster"
+	"kvstore/proto"
+	"kvstore/storage"
+
+	"google.golang.org/grpc"
+)
+
+// startTestNode serves a GRPCServer over TCP and returns it, its store and
+// its address
+func startTestNode(t *testing.T) (*GRPCServer, *storage.LSMStore, string) {
+	t.Helper()
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	kvServer := NewGRPCServer(store)
+	srv := grpc.NewServer()
+	proto.RegisterKVStoreServer(srv, kvServer)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return kvServer, store, lis.Addr().String()
+}
+
+// Test: a plain client Put to a coordinator lands on every replica with the
+// same timestamp
+func TestGRPCServer_CoordinatorReplicatesPut(t *testing.T) {
+	coordinatorServer, coordinatorStore, coordinatorAddr := startTestNode(t)
+	_, store2, addr2 := startTestNode(t)
+	_, store3, addr3 := startTestNode(t)
+
+	// Hints are written relative to the working directory
+	t.Chdir(t.TempDir())
+	cc, err := cluster.NewClusterClient(map[string]string{"node2": addr2, "node3": addr3})
+	if err != nil {
+		t.Fatalf("Failed to create cluster client: %v", err)
+	}
+	defer cc.Close()
+	if err := cc.GetRegistry().RegisterNode("node1", coordinatorAddr); err != nil {
+		t.Fatalf("RegisterNode failed: %v", err)
+	}
+	cc.SetLocalStore("node1", coordinatorStore)
+	// W=N, so the Put returns only once every replica has the value
+	if err := cc.SetQuorum(3, 1); err != nil {
+		t.Fatalf("SetQuorum failed: %v", err)
+	}
+	coordinatorServer.SetCoordinator(cc)
+
+	kv, err := client.NewKVClient(coordinatorAddr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer kv.Close()
+
+	if err := kv.Put("user:42", []byte("alice")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	_, want, err := coordinatorStore.GetWithTimestamp("user:42")
+	if err != nil {
+		t.Fatalf("Coordinator's own replica missing the key: %v", err)
+	}
+	for name, store := range map[string]*storage.LSMStore{"node2": store2, "node3": store3} {
+		value, timestamp, err := store.GetWithTimestamp("user:42")
+		if err != nil {
+			t.Fatalf("%s doesn't have the key: %v", name, err)
+		}
+		if string(value) != "alice" || timestamp != want {
+			t.Errorf("%s: expected alice@%d, got %s@%d", name, want, value, timestamp)
+		}
+	}
+
+	if err := kv.PutNS("tenant", "user:42", []byte("bob")); err == nil {
+		t.Error("Expected a namespaced Put to be rejected in coordinator mode")
+	}
+}
@@ -2,90 +2,458 @@ package server
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"time"
 
 	"kvstore/proto"
 	"kvstore/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// StreamChunkSize is the size of the chunks GetStream sends values in (64KB)
+const StreamChunkSize = 64 * 1024
+
+// ErrReadOnly is returned for writes and compactions on a read-only server
+var ErrReadOnly = status.Error(codes.FailedPrecondition, "server is read-only")
+
 // GRPCServer implements the KVStore gRPC service
 type GRPCServer struct {
 	proto.UnimplementedKVStoreServer
-	store *storage.LSMStore
+	store     *storage.LSMStore
+	nodeID    string
+	startTime time.Time // Reference point for monotonic Ping timestamps
+	logger    *Logger
+
+	leaderChecker LeaderChecker // nil outside Raft deployments
+	readOnly      bool          // operator-set; rejects everything that modifies the store
+	allowTruncate bool          // operator-set; Truncate is refused unless set
+	idempotency   *idempotencyCache
+	ringInfo      RingInfoProvider // nil unless this node holds a NodeRegistry
+	quorum        QuorumController // nil unless this node runs a ClusterClient
+	coordinator   Coordinator      // nil unless Puts are replicated server-side
 }
 
 // NewGRPCServer creates a new gRPC server
 func NewGRPCServer(store *storage.LSMStore) *GRPCServer {
 	return &GRPCServer{
-		store: store,
+		store:       store,
+		startTime:   time.Now(),
+		logger:      NewLogger(LogFormatText, nil),
+		idempotency: newIdempotencyCache(DefaultIdempotencyCacheSize),
 	}
 }
 
-// Put stores a key-value pair
+// SetLogger replaces the logger used by the handlers
+func (s *GRPCServer) SetLogger(logger *Logger) {
+	s.logger = logger
+}
+
+// SetLeaderChecker makes writes (Put, PutStream, Delete, WriteBatch) fail
+// with codes.FailedPrecondition on nodes that are not the leader
+func (s *GRPCServer) SetLeaderChecker(checker LeaderChecker) {
+	s.leaderChecker = checker
+}
+
+// SetReadOnly makes the server a read replica: Put, PutStream, Delete,
+// WriteBatch, Compact and Flush fail with ErrReadOnly while Get, Scan and Stats are
+// still served. This is independent of Raft leadership.
+func (s *GRPCServer) SetReadOnly(readOnly bool) {
+	s.readOnly = readOnly
+}
+
+// SetAllowTruncate enables the Truncate RPC, which deletes every key. It is
+// off by default so a stray call can't wipe a production node; enable it
+// only for test harnesses and development servers.
+func (s *GRPCServer) SetAllowTruncate(allow bool) {
+	s.allowTruncate = allow
+}
+
+// SetNodeID sets the node ID reported by Ping
+func (s *GRPCServer) SetNodeID(nodeID string) {
+	s.nodeID = nodeID
+}
+
+// Put stores a key-value pair, replicating it first in coordinator mode
+// (SetCoordinator). A request carrying an idempotency key that was already
+// applied returns the original response without writing again. With an
+// expected version, the write is conditional (see storage.PutIfVersion) and
+// fails with codes.Aborted if the key has moved on.
 func (s *GRPCServer) Put(ctx context.Context, req *proto.PutRequest) (*proto.PutResponse, error) {
-	log.Printf("📝 PUT: key=%s, value_size=%d bytes", req.Key, len(req.Value))
+	resp, duplicate, err := idempotent(s.idempotency, "Put", req.IdempotencyKey, func() (*proto.PutResponse, error) {
+		if s.coordinator != nil {
+			return s.coordinatedPut(ctx, req)
+		}
+		return s.put(req)
+	})
+	if duplicate {
+		s.logger.Info(Fields{RPC: "Put", KeySize: len(req.Key)}, "🔁 PUT duplicate: key=%s, idempotency_key=%s", req.Key, req.IdempotencyKey)
+	}
+	return resp, err
+}
+
+func (s *GRPCServer) put(req *proto.PutRequest) (*proto.PutResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Put", KeySize: len(req.Key), ValueSize: len(req.Value)}
+	s.logger.Info(fields, "📝 PUT: key=%s, value_size=%d bytes", req.Key, len(req.Value))
 
-	err := s.store.Put(req.Key, req.Value)
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT rejected: %v", err)
+		return nil, err
+	}
+
+	// A conditional Put checks the version under the store lock
+	var version int64
+	var err error
+	if req.ExpectedVersion != 0 {
+		version, err = s.store.PutIfVersionNS(req.Ns, req.Key, req.Value, req.ExpectedVersion)
+	} else {
+		err = s.store.PutNS(req.Ns, req.Key, req.Value)
+	}
+	fields.Latency = time.Since(start)
 	if err != nil {
-		log.Printf("❌ PUT failed: %v", err)
+		fields.Err = err
+		if errors.Is(err, storage.ErrStoreBusy) {
+			s.logger.Warn(fields, "⚠️  PUT shed: %v", err)
+			return nil, busyError(err)
+		}
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			s.logger.Warn(fields, "⚠️  PUT rejected: %v", err)
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+		s.logger.Error(fields, "❌ PUT failed: %v", err)
 		return &proto.PutResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, nil
 	}
 
+	s.logger.Info(fields, "✅ PUT success: key=%s", req.Key)
 	return &proto.PutResponse{
 		Success: true,
+		Version: version,
+	}, nil
+}
+
+// PutIfAbsent stores a key-value pair only if the key does not exist. With
+// an idempotency key, a retry gets the first attempt's outcome, so a retried
+// write that went through still reports written.
+func (s *GRPCServer) PutIfAbsent(ctx context.Context, req *proto.PutIfAbsentRequest) (*proto.PutIfAbsentResponse, error) {
+	resp, duplicate, err := idempotent(s.idempotency, "PutIfAbsent", req.IdempotencyKey, func() (*proto.PutIfAbsentResponse, error) {
+		return s.putIfAbsent(req)
+	})
+	if duplicate {
+		s.logger.Info(Fields{RPC: "PutIfAbsent", KeySize: len(req.Key)}, "🔁 PUT_IF_ABSENT duplicate: key=%s, idempotency_key=%s", req.Key, req.IdempotencyKey)
+	}
+	return resp, err
+}
+
+func (s *GRPCServer) putIfAbsent(req *proto.PutIfAbsentRequest) (*proto.PutIfAbsentResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "PutIfAbsent", KeySize: len(req.Key), ValueSize: len(req.Value)}
+	s.logger.Info(fields, "📝 PUT_IF_ABSENT: key=%s, value_size=%d bytes", req.Key, len(req.Value))
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT_IF_ABSENT rejected: %v", err)
+		return nil, err
+	}
+
+	written, err := s.store.PutIfAbsentNS(req.Ns, req.Key, req.Value)
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		if errors.Is(err, storage.ErrStoreBusy) {
+			s.logger.Warn(fields, "⚠️  PUT_IF_ABSENT shed: %v", err)
+			return nil, busyError(err)
+		}
+		s.logger.Error(fields, "❌ PUT_IF_ABSENT failed: %v", err)
+		return &proto.PutIfAbsentResponse{
+			Written: written,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	if !written {
+		s.logger.Info(fields, "⏭️  PUT_IF_ABSENT skipped, key exists: key=%s", req.Key)
+	} else {
+		s.logger.Info(fields, "✅ PUT_IF_ABSENT success: key=%s", req.Key)
+	}
+	return &proto.PutIfAbsentResponse{
+		Written: written,
 	}, nil
 }
 
 // Get retrieves a value by key
 func (s *GRPCServer) Get(ctx context.Context, req *proto.GetRequest) (*proto.GetResponse, error) {
-	log.Printf("🔍 GET: key=%s", req.Key)
+	start := time.Now()
+	fields := Fields{RPC: "Get", KeySize: len(req.Key)}
+	s.logger.Info(fields, "🔍 GET: key=%s", req.Key)
+
+	switch req.Checksums {
+	case proto.GetRequest_CHECKSUM_VERIFY:
+		ctx = storage.WithVerifyChecksums(ctx, true)
+	case proto.GetRequest_CHECKSUM_SKIP:
+		ctx = storage.WithVerifyChecksums(ctx, false)
+	}
+	value, timestamp, err := s.store.GetWithTimestampNSContext(ctx, req.Ns, req.Key)
+	fields.Latency = time.Since(start)
+	if err != nil {
+		if err == storage.ErrKeyNotFound {
+			s.logger.Warn(fields, "⚠️  Key not found: %s", req.Key)
+			return &proto.GetResponse{
+				Found: false,
+			}, nil
+		}
+		fields.Err = err
+		s.logger.Error(fields, "❌ GET failed: %v", err)
+		return &proto.GetResponse{
+			Found: false,
+			Error: err.Error(),
+		}, nil
+	}
+
+	fields.ValueSize = len(value)
+	s.logger.Info(fields, "✅ GET success: key=%s, value_size=%d bytes", req.Key, len(value))
+	return &proto.GetResponse{
+		Value:   value,
+		Found:   true,
+		Version: timestamp,
+	}, nil
+}
+
+// GetRange retrieves length bytes of a value starting at offset. A range
+// outside the value fails with codes.OutOfRange; a missing key is reported
+// like Get, with Found unset.
+func (s *GRPCServer) GetRange(ctx context.Context, req *proto.GetRangeRequest) (*proto.GetResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "GetRange", KeySize: len(req.Key)}
+	s.logger.Info(fields, "🔍 GET RANGE: key=%s, offset=%d, length=%d", req.Key, req.Offset, req.Length)
 
-	value, err := s.store.Get(req.Key)
+	value, err := s.store.GetRangeNS(req.Ns, req.Key, int(req.Offset), int(req.Length))
+	fields.Latency = time.Since(start)
 	if err != nil {
 		if err == storage.ErrKeyNotFound {
-			log.Printf("⚠️  Key not found: %s", req.Key)
+			s.logger.Warn(fields, "⚠️  Key not found: %s", req.Key)
 			return &proto.GetResponse{
 				Found: false,
 			}, nil
 		}
-		log.Printf("❌ GET failed: %v", err)
+		fields.Err = err
+		if errors.Is(err, storage.ErrRangeOutOfBounds) {
+			s.logger.Warn(fields, "⚠️  GET RANGE out of bounds: %v", err)
+			return nil, status.Error(codes.OutOfRange, err.Error())
+		}
+		s.logger.Error(fields, "❌ GET RANGE failed: %v", err)
 		return &proto.GetResponse{
 			Found: false,
 			Error: err.Error(),
 		}, nil
 	}
 
-	log.Printf("✅ GET success: key=%s, value_size=%d bytes", req.Key, len(value))
+	fields.ValueSize = len(value)
+	s.logger.Info(fields, "✅ GET RANGE success: key=%s, %d bytes", req.Key, len(value))
 	return &proto.GetResponse{
 		Value: value,
 		Found: true,
 	}, nil
 }
 
-// Delete removes a key-value pair
+// Exists reports whether a key has a value without sending the value back.
+// It is a point read like Get, with the same consistency: it reflects every
+// write this node has applied, deletes included. In the cluster path it
+// still counts as a consistent point read, so replicated callers should
+// resolve it through the same quorum read as Get rather than ask one node.
+func (s *GRPCServer) Exists(ctx context.Context, req *proto.ExistsRequest) (*proto.ExistsResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Exists", KeySize: len(req.Key)}
+	s.logger.Info(fields, "🔍 EXISTS: key=%s", req.Key)
+
+	exists, err := s.store.ExistsNS(req.Ns, req.Key)
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		s.logger.Error(fields, "❌ EXISTS failed: %v", err)
+		return &proto.ExistsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	s.logger.Info(fields, "✅ EXISTS: key=%s, exists=%t", req.Key, exists)
+	return &proto.ExistsResponse{
+		Exists: exists,
+	}, nil
+}
+
+// GetStream retrieves a value by key and sends it in StreamChunkSize chunks.
+// The value is still read whole from the store; streaming keeps each gRPC
+// message small so values above the default 4MB message limit can be served.
+// A missing key ends the stream with codes.NotFound.
+func (s *GRPCServer) GetStream(req *proto.GetRequest, stream grpc.ServerStreamingServer[proto.ValueChunk]) error {
+	start := time.Now()
+	fields := Fields{RPC: "GetStream", KeySize: len(req.Key)}
+	s.logger.Info(fields, "🔍 GET STREAM: key=%s", req.Key)
+
+	value, err := s.store.GetNSContext(stream.Context(), req.Ns, req.Key)
+	if err != nil {
+		fields.Latency = time.Since(start)
+		if err == storage.ErrKeyNotFound {
+			s.logger.Warn(fields, "⚠️  Key not found: %s", req.Key)
+			return status.Errorf(codes.NotFound, "key not found: %s", req.Key)
+		}
+		fields.Err = err
+		s.logger.Error(fields, "❌ GET STREAM failed: %v", err)
+		return status.Errorf(codes.Internal, "get failed: %v", err)
+	}
+
+	fields.ValueSize = len(value)
+	for offset := 0; offset < len(value); offset += StreamChunkSize {
+		end := min(offset+StreamChunkSize, len(value))
+		if err := stream.Send(&proto.ValueChunk{Data: value[offset:end]}); err != nil {
+			fields.Latency, fields.Err = time.Since(start), err
+			s.logger.Error(fields, "❌ GET STREAM send failed: %v", err)
+			return err
+		}
+	}
+
+	fields.Latency = time.Since(start)
+	s.logger.Info(fields, "✅ GET STREAM success: key=%s, value_size=%d bytes", req.Key, len(value))
+	return nil
+}
+
+// PutStream stores a value received as a sequence of chunks.
+// Chunks are buffered until the client closes the stream, so the value is
+// limited to storage.MaxValueSize; larger uploads are rejected early.
+func (s *GRPCServer) PutStream(stream grpc.ClientStreamingServer[proto.PutStreamRequest, proto.PutResponse]) error {
+	start := time.Now()
+	fields := Fields{RPC: "PutStream"}
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  PUT STREAM rejected: %v", err)
+		return err
+	}
+
+	var key, ns, idempotencyKey string
+	var value []byte
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fields.Latency, fields.Err = time.Since(start), err
+			s.logger.Error(fields, "❌ PUT STREAM receive failed: %v", err)
+			return err
+		}
+
+		if key == "" {
+			key = req.Key
+			ns = req.Ns
+			idempotencyKey = req.IdempotencyKey
+		}
+		if len(value)+len(req.Chunk) > storage.MaxValueSize {
+			err := fmt.Errorf("%w: more than %d bytes", storage.ErrValueTooLarge, storage.MaxValueSize)
+			fields.Latency, fields.Err = time.Since(start), err
+			s.logger.Error(fields, "❌ PUT STREAM failed: %v", err)
+			return stream.SendAndClose(&proto.PutResponse{Error: err.Error()})
+		}
+		value = append(value, req.Chunk...)
+	}
+
+	fields.KeySize, fields.ValueSize = len(key), len(value)
+	if key == "" {
+		err := errors.New("no key in stream")
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Error(fields, "❌ PUT STREAM failed: %v", err)
+		return stream.SendAndClose(&proto.PutResponse{Error: err.Error()})
+	}
+	s.logger.Info(fields, "📝 PUT STREAM: key=%s, value_size=%d bytes", key, len(value))
+
+	resp, duplicate, err := idempotent(s.idempotency, "PutStream", idempotencyKey, func() (*proto.PutResponse, error) {
+		err := s.store.PutNS(ns, key, value)
+		fields.Latency = time.Since(start)
+		if err != nil {
+			fields.Err = err
+			if errors.Is(err, storage.ErrStoreBusy) {
+				s.logger.Warn(fields, "⚠️  PUT STREAM shed: %v", err)
+				return nil, busyError(err)
+			}
+			s.logger.Error(fields, "❌ PUT STREAM failed: %v", err)
+			return &proto.PutResponse{Error: err.Error()}, nil
+		}
+
+		s.logger.Info(fields, "✅ PUT STREAM success: key=%s", key)
+		return &proto.PutResponse{Success: true}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		s.logger.Info(fields, "🔁 PUT STREAM duplicate: key=%s, idempotency_key=%s", key, idempotencyKey)
+	}
+	return stream.SendAndClose(resp)
+}
+
+// Delete removes a key-value pair. Like Put, it honors idempotency keys.
 func (s *GRPCServer) Delete(ctx context.Context, req *proto.DeleteRequest) (*proto.DeleteResponse, error) {
-	log.Printf("🗑️  DELETE: key=%s", req.Key)
+	resp, duplicate, err := idempotent(s.idempotency, "Delete", req.IdempotencyKey, func() (*proto.DeleteResponse, error) {
+		return s.deleteKey(req)
+	})
+	if duplicate {
+		s.logger.Info(Fields{RPC: "Delete", KeySize: len(req.Key)}, "🔁 DELETE duplicate: key=%s, idempotency_key=%s", req.Key, req.IdempotencyKey)
+	}
+	return resp, err
+}
+
+func (s *GRPCServer) deleteKey(req *proto.DeleteRequest) (*proto.DeleteResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Delete", KeySize: len(req.Key)}
+	s.logger.Info(fields, "🗑️  DELETE: key=%s", req.Key)
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  DELETE rejected: %v", err)
+		return nil, err
+	}
 
-	err := s.store.Delete(req.Key)
+	// Only look the key up when the caller asked whether it existed
+	var existed bool
+	var err error
+	if req.CheckExisted {
+		existed, err = s.store.DeleteWithResultNS(req.Ns, req.Key)
+	} else {
+		err = s.store.DeleteNS(req.Ns, req.Key)
+	}
+	fields.Latency = time.Since(start)
 	if err != nil {
-		log.Printf("❌ DELETE failed: %v", err)
+		fields.Err = err
+		if errors.Is(err, storage.ErrStoreBusy) {
+			s.logger.Warn(fields, "⚠️  DELETE shed: %v", err)
+			return nil, busyError(err)
+		}
+		s.logger.Error(fields, "❌ DELETE failed: %v", err)
 		return &proto.DeleteResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, nil
 	}
 
+	s.logger.Info(fields, "✅ DELETE success: key=%s", req.Key)
 	return &proto.DeleteResponse{
 		Success: true,
+		Existed: existed,
 	}, nil
 }
 
 // Stats returns storage statistics
 func (s *GRPCServer) Stats(ctx context.Context, req *proto.StatsRequest) (*proto.StatsResponse, error) {
-	log.Printf("📊 STATS requested")
+	s.logger.Info(Fields{RPC: "Stats"}, "📊 STATS requested")
 
 	stats := s.store.Stats()
 
@@ -115,23 +483,275 @@ func (s *GRPCServer) Stats(ctx context.Context, req *proto.StatsRequest) (*proto
 
 // Compact triggers manual compaction
 func (s *GRPCServer) Compact(ctx context.Context, req *proto.CompactRequest) (*proto.CompactResponse, error) {
-	log.Printf("🔄 COMPACT requested")
+	start := time.Now()
+	fields := Fields{RPC: "Compact"}
+	s.logger.Info(fields, "🔄 COMPACT requested")
+
+	if s.readOnly {
+		fields.Latency, fields.Err = time.Since(start), ErrReadOnly
+		s.logger.Warn(fields, "⚠️  COMPACT rejected: %v", ErrReadOnly)
+		return nil, ErrReadOnly
+	}
 
 	err := s.store.CompactionManager().ForceCompact()
+	fields.Latency = time.Since(start)
 	if err != nil {
-		log.Printf("❌ COMPACT failed: %v", err)
+		fields.Err = err
+		s.logger.Error(fields, "❌ COMPACT failed: %v", err)
 		return &proto.CompactResponse{
 			Success: false,
 			Error:   err.Error(),
 		}, nil
 	}
 
-	log.Printf("✅ COMPACT completed")
+	s.logger.Info(fields, "✅ COMPACT completed")
 	return &proto.CompactResponse{
 		Success: true,
 	}, nil
 }
 
+// Flush writes the store's MemTable to an SSTable without waiting for the
+// size threshold
+func (s *GRPCServer) Flush(ctx context.Context, req *proto.FlushRequest) (*proto.FlushResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Flush"}
+
+	if s.readOnly {
+		fields.Latency, fields.Err = time.Since(start), ErrReadOnly
+		s.logger.Warn(fields, "⚠️  FLUSH rejected: %v", ErrReadOnly)
+		return nil, ErrReadOnly
+	}
+
+	err := s.store.Flush()
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		s.logger.Error(fields, "❌ FLUSH failed: %v", err)
+		return &proto.FlushResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.logger.Info(fields, "💾 FLUSH completed")
+	return &proto.FlushResponse{
+		Success: true,
+	}, nil
+}
+
+// Truncate deletes every key in the store. It fails with
+// codes.PermissionDenied unless enabled with SetAllowTruncate.
+func (s *GRPCServer) Truncate(ctx context.Context, req *proto.TruncateRequest) (*proto.TruncateResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Truncate"}
+	s.logger.Warn(fields, "🧹 TRUNCATE requested")
+
+	if !s.allowTruncate {
+		err := status.Error(codes.PermissionDenied, "truncate is disabled on this server (start it with -allow-truncate)")
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  TRUNCATE rejected: %v", err)
+		return nil, err
+	}
+	if s.readOnly {
+		fields.Latency, fields.Err = time.Since(start), ErrReadOnly
+		s.logger.Warn(fields, "⚠️  TRUNCATE rejected: %v", ErrReadOnly)
+		return nil, ErrReadOnly
+	}
+
+	err := s.store.Truncate()
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		s.logger.Error(fields, "❌ TRUNCATE failed: %v", err)
+		return &proto.TruncateResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.logger.Warn(fields, "✅ TRUNCATE completed: every key deleted")
+	return &proto.TruncateResponse{
+		Success: true,
+	}, nil
+}
+
+// Sync fsyncs the store's WAL, so every write acknowledged before the call
+// survives a machine crash. Allowed on read-only servers: it changes nothing.
+func (s *GRPCServer) Sync(ctx context.Context, req *proto.SyncRequest) (*proto.SyncResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Sync"}
+
+	err := s.store.Sync()
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		s.logger.Error(fields, "❌ SYNC failed: %v", err)
+		return &proto.SyncResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.logger.Info(fields, "💾 SYNC completed")
+	return &proto.SyncResponse{
+		Success: true,
+	}, nil
+}
+
+// WriteBatch applies several puts and deletes atomically. Like Put, it
+// honors idempotency keys.
+func (s *GRPCServer) WriteBatch(ctx context.Context, req *proto.WriteBatchRequest) (*proto.WriteBatchResponse, error) {
+	resp, duplicate, err := idempotent(s.idempotency, "WriteBatch", req.IdempotencyKey, func() (*proto.WriteBatchResponse, error) {
+		return s.writeBatch(req)
+	})
+	if duplicate {
+		s.logger.Info(Fields{RPC: "WriteBatch"}, "🔁 WRITE BATCH duplicate: idempotency_key=%s", req.IdempotencyKey)
+	}
+	return resp, err
+}
+
+func (s *GRPCServer) writeBatch(req *proto.WriteBatchRequest) (*proto.WriteBatchResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "WriteBatch"}
+	s.logger.Info(fields, "📦 WRITE BATCH: %d operations", len(req.Operations))
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  WRITE BATCH rejected: %v", err)
+		return nil, err
+	}
+
+	ops := make([]storage.BatchOp, len(req.Operations))
+	for i, op := range req.Operations {
+		if op.Delete {
+			ops[i] = storage.BatchOp{Op: storage.OpDelete, Namespace: op.Ns, Key: op.Key}
+		} else {
+			ops[i] = storage.BatchOp{Op: storage.OpPut, Namespace: op.Ns, Key: op.Key, Value: op.Value}
+		}
+	}
+
+	err := s.store.WriteBatch(ops)
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		if errors.Is(err, storage.ErrStoreBusy) {
+			s.logger.Warn(fields, "⚠️  WRITE BATCH shed: %v", err)
+			return nil, busyError(err)
+		}
+		s.logger.Error(fields, "❌ WRITE BATCH failed: %v", err)
+		return &proto.WriteBatchResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	s.logger.Info(fields, "✅ WRITE BATCH applied: %d operations", len(req.Operations))
+	return &proto.WriteBatchResponse{
+		Success: true,
+	}, nil
+}
+
+// Ping echoes the nonce with the node ID and a monotonic timestamp.
+// It never touches the store, so it stays cheap for health checks.
+func (s *GRPCServer) Ping(ctx context.Context, req *proto.PingRequest) (*proto.PingResponse, error) {
+	return &proto.PingResponse{
+		Nonce:          req.Nonce,
+		NodeId:         s.nodeID,
+		MonotonicNanos: time.Since(s.startTime).Nanoseconds(),
+	}, nil
+}
+
+// Scan returns the key-value pairs in [start_key, end_key)
+func (s *GRPCServer) Scan(ctx context.Context, req *proto.ScanRequest) (*proto.ScanResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "Scan"}
+	s.logger.Info(fields, "🔍 SCAN: ns=%q, start=%q, end=%q, keys_only=%v, limit=%d", req.Ns, req.StartKey, req.EndKey, req.KeysOnly, req.Limit)
+
+	// With keys_only, values are not read and entry.Value is nil
+	entries, err := s.store.ScanLimitNS(req.Ns, req.StartKey, req.EndKey, req.KeysOnly, int(req.Limit))
+	if err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Error(fields, "❌ SCAN failed: %v", err)
+		return &proto.ScanResponse{Error: err.Error()}, nil
+	}
+
+	response := &proto.ScanResponse{}
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, &proto.KeyValue{
+			Key:   string(entry.Key),
+			Value: entry.Value,
+		})
+	}
+
+	fields.Latency = time.Since(start)
+	s.logger.Info(fields, "✅ SCAN returned %d entries", len(response.Entries))
+	return response, nil
+}
+
+// Watch streams every Put and Delete made after the call to keys starting
+// with req.Prefix. Response headers are sent once the watcher is registered,
+// so a client that waits for them sees every later write. A client that reads
+// too slowly gets a lagged event where events were dropped.
+func (s *GRPCServer) Watch(req *proto.WatchRequest, stream grpc.ServerStreamingServer[proto.WatchEvent]) error {
+	start := time.Now()
+	fields := Fields{RPC: "Watch"}
+	s.logger.Info(fields, "👀 WATCH: prefix=%q", req.Prefix)
+
+	watcher := s.store.Watch(req.Prefix, storage.DefaultWatchBuffer)
+	defer watcher.Close()
+
+	if err := stream.SendHeader(nil); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Error(fields, "❌ WATCH failed: %v", err)
+		return err
+	}
+
+	sent := 0
+	for {
+		select {
+		case <-stream.Context().Done():
+			fields.Latency = time.Since(start)
+			s.logger.Info(fields, "👋 WATCH ended: prefix=%q, %d events sent", req.Prefix, sent)
+			return nil
+
+		case event, ok := <-watcher.Events():
+			if !ok {
+				fields.Latency = time.Since(start)
+				s.logger.Info(fields, "👋 WATCH ended (store closed): prefix=%q, %d events sent", req.Prefix, sent)
+				return status.Error(codes.Unavailable, "store closed")
+			}
+
+			if err := stream.Send(&proto.WatchEvent{
+				Key:       event.Key,
+				Value:     event.Value,
+				Delete:    event.Op == storage.OpDelete,
+				Timestamp: event.Timestamp,
+				Lagged:    event.Lagged,
+			}); err != nil {
+				fields.Latency, fields.Err = time.Since(start), err
+				s.logger.Error(fields, "❌ WATCH send failed: %v", err)
+				return err
+			}
+			sent++
+		}
+	}
+}
+
+// checkWritable returns ErrReadOnly on a read-only server, or an
+// *ErrNotLeader if this node may not accept writes
+func (s *GRPCServer) checkWritable() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return s.checkLeader()
+}
+
+// busyError maps storage.ErrStoreBusy to codes.ResourceExhausted so clients
+// can tell load shedding apart from failed writes and back off
+func busyError(err error) error {
+	return status.Error(codes.ResourceExhausted, err.Error())
+}
+
 // Close gracefully shuts down the server
 func (s *GRPCServer) Close() error {
 	if s.store != nil {
@@ -2,12 +2,45 @@ package server
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"kvstore/cluster"
 	"kvstore/proto"
+	"kvstore/raft"
+	"kvstore/replication"
 	"kvstore/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// RaftNode is the production LeaderChecker
+var _ LeaderChecker = (*raft.RaftNode)(nil)
+
+// NodeRegistry is the production RingInfoProvider
+var _ RingInfoProvider = (*cluster.NodeRegistry)(nil)
+
+// ClusterClient is the production QuorumController
+var _ QuorumController = (*cluster.ClusterClient)(nil)
+
+// fakeLeaderChecker reports a fixed leadership state
+type fakeLeaderChecker struct {
+	leader        bool
+	leaderID      string
+	leaderAddress string
+}
+
+func (f *fakeLeaderChecker) IsLeader() bool {
+	return f.leader
+}
+
+func (f *fakeLeaderChecker) Leader() (string, string, bool) {
+	return f.leaderID, f.leaderAddress, f.leaderID != ""
+}
+
 func TestGRPCServer_PutAndGet(t *testing.T) {
 	// Create test store
 	tmpDir := t.TempDir()
@@ -48,6 +81,165 @@ func TestGRPCServer_PutAndGet(t *testing.T) {
 	}
 }
 
+func TestGRPCServer_PutIfAbsent(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	first := &proto.PutIfAbsentRequest{Key: "config", Value: []byte("v1"), IdempotencyKey: "init-1"}
+	if resp, err := server.PutIfAbsent(ctx, first); err != nil || !resp.Written {
+		t.Fatalf("Expected first PutIfAbsent to write, got %v (err: %v)", resp, err)
+	}
+	resp, err := server.PutIfAbsent(ctx, &proto.PutIfAbsentRequest{Key: "config", Value: []byte("v2")})
+	if err != nil || resp.Written || resp.Error != "" {
+		t.Fatalf("Expected PutIfAbsent on an existing key to do nothing, got %v (err: %v)", resp, err)
+	}
+	if value, _ := store.Get("config"); string(value) != "v1" {
+		t.Errorf("Expected v1, got %q", value)
+	}
+
+	// A retry of a write that went through still reports written
+	if resp, err := server.PutIfAbsent(ctx, first); err != nil || !resp.Written {
+		t.Errorf("Expected the cached outcome for the replay, got %v (err: %v)", resp, err)
+	}
+
+	// Namespaces are separate keyspaces
+	if resp, err := server.PutIfAbsent(ctx, &proto.PutIfAbsentRequest{Ns: "tenant", Key: "config", Value: []byte("v3")}); err != nil || !resp.Written {
+		t.Errorf("Expected PutIfAbsent in another namespace to write, got %v (err: %v)", resp, err)
+	}
+
+	server.SetReadOnly(true)
+	if _, err := server.PutIfAbsent(ctx, &proto.PutIfAbsentRequest{Key: "other", Value: []byte("v")}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition in read-only mode, got %v", err)
+	}
+}
+
+func TestGRPCServer_ConditionalPut(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	if _, err := server.Put(ctx, &proto.PutRequest{Key: "balance", Value: []byte("100")}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	read, err := server.Get(ctx, &proto.GetRequest{Key: "balance"})
+	if err != nil || !read.Found || read.Version == 0 {
+		t.Fatalf("Expected the value with its version, got %v (err: %v)", read, err)
+	}
+
+	// The first writer holding the version wins
+	resp, err := server.Put(ctx, &proto.PutRequest{Key: "balance", Value: []byte("90"), ExpectedVersion: read.Version})
+	if err != nil || !resp.Success || resp.Version <= read.Version {
+		t.Fatalf("Expected the conditional Put to write a newer version, got %v (err: %v)", resp, err)
+	}
+
+	// A second writer with the same, now stale, version is rejected
+	_, err = server.Put(ctx, &proto.PutRequest{Key: "balance", Value: []byte("80"), ExpectedVersion: read.Version})
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("Expected Aborted for a stale version, got %v", err)
+	}
+	if read, _ := server.Get(ctx, &proto.GetRequest{Key: "balance"}); string(read.Value) != "90" || read.Version != resp.Version {
+		t.Errorf("Expected 90 at version %d, got %v", resp.Version, read)
+	}
+
+	// A missing key has no version to match
+	_, err = server.Put(ctx, &proto.PutRequest{Key: "missing", Value: []byte("v"), ExpectedVersion: read.Version})
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("Expected Aborted for a missing key, got %v", err)
+	}
+}
+
+func TestGRPCServer_Exists(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	if err := store.Put("present", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put("deleted", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("deleted"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.PutNS("tenant", "scoped", []byte("value")); err != nil {
+		t.Fatalf("PutNS failed: %v", err)
+	}
+
+	tests := []struct {
+		ns, key string
+		want    bool
+	}{
+		{"", "present", true},
+		{"", "absent", false},
+		{"", "deleted", false},
+		{"tenant", "scoped", true},
+		{"", "scoped", false},
+	}
+	for _, tt := range tests {
+		resp, err := server.Exists(ctx, &proto.ExistsRequest{Ns: tt.ns, Key: tt.key})
+		if err != nil {
+			t.Fatalf("Exists failed: %v", err)
+		}
+		if resp.Error != "" || resp.Exists != tt.want {
+			t.Errorf("Exists(%q, %q) = %v (error %q), want %v", tt.ns, tt.key, resp.Exists, resp.Error, tt.want)
+		}
+	}
+}
+
+func TestGRPCServer_GetRange(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	if err := store.PutNS("tenant", "blob", []byte("0123456789")); err != nil {
+		t.Fatalf("PutNS failed: %v", err)
+	}
+
+	resp, err := server.GetRange(ctx, &proto.GetRangeRequest{Ns: "tenant", Key: "blob", Offset: 2, Length: 3})
+	if err != nil {
+		t.Fatalf("GetRange failed: %v", err)
+	}
+	if !resp.Found || string(resp.Value) != "234" {
+		t.Errorf("Expected \"234\", got %+v", resp)
+	}
+
+	for _, req := range []*proto.GetRangeRequest{
+		{Ns: "tenant", Key: "blob", Offset: 11, Length: 0},
+		{Ns: "tenant", Key: "blob", Offset: 4, Length: 20},
+	} {
+		if _, err := server.GetRange(ctx, req); status.Code(err) != codes.OutOfRange {
+			t.Errorf("GetRange(offset=%d, length=%d): expected OutOfRange, got %v", req.Offset, req.Length, err)
+		}
+	}
+
+	resp, err = server.GetRange(ctx, &proto.GetRangeRequest{Key: "blob", Length: 1})
+	if err != nil || resp.Found {
+		t.Errorf("Expected the key not found outside its namespace, got %+v (err: %v)", resp, err)
+	}
+}
+
 func TestGRPCServer_Delete(t *testing.T) {
 	tmpDir := t.TempDir()
 	store, err := storage.NewLSMStore(tmpDir)
@@ -86,6 +278,18 @@ func TestGRPCServer_Delete(t *testing.T) {
 	if getResp.Found {
 		t.Error("Deleted key should not be found")
 	}
+
+	// Asked to, Delete reports whether there was anything to delete
+	server.Put(ctx, &proto.PutRequest{Key: "delete_me", Value: []byte("temp")})
+	for _, want := range []bool{true, false} {
+		delResp, err := server.Delete(ctx, &proto.DeleteRequest{Key: "delete_me", CheckExisted: true})
+		if err != nil || !delResp.Success {
+			t.Fatalf("Delete failed: %v, %+v", err, delResp)
+		}
+		if delResp.Existed != want {
+			t.Errorf("Expected existed=%v, got %v", want, delResp.Existed)
+		}
+	}
 }
 
 func TestGRPCServer_Stats(t *testing.T) {
@@ -141,3 +345,528 @@ func TestGRPCServer_Compact(t *testing.T) {
 		t.Errorf("Compact unsuccessful: %s", compactResp.Error)
 	}
 }
+
+func TestGRPCServer_Truncate(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("key", []byte("value")); err != nil {
+		t.Fatalf("Failed to seed store: %v", err)
+	}
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	// Off by default
+	if _, err := server.Truncate(ctx, &proto.TruncateRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("Expected PermissionDenied, got %v", err)
+	}
+	if _, err := store.Get("key"); err != nil {
+		t.Fatalf("Expected the data untouched, got %v", err)
+	}
+
+	server.SetAllowTruncate(true)
+	resp, err := server.Truncate(ctx, &proto.TruncateRequest{})
+	if err != nil || !resp.Success {
+		t.Fatalf("Truncate failed: %v, %v", resp, err)
+	}
+	if _, err := store.Get("key"); !errors.Is(err, storage.ErrKeyNotFound) {
+		t.Errorf("Expected the key gone, got %v", err)
+	}
+}
+
+func TestGRPCServer_ScanKeysOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	for _, k := range []string{"user:1", "user:2", "order:1"} {
+		if _, err := server.Put(ctx, &proto.PutRequest{Key: k, Value: []byte("v")}); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	resp, err := server.Scan(ctx, &proto.ScanRequest{
+		StartKey: "user:",
+		EndKey:   "user;",
+		KeysOnly: true,
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("Scan unsuccessful: %s", resp.Error)
+	}
+
+	if len(resp.Entries) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(resp.Entries))
+	}
+	for i, k := range []string{"user:1", "user:2"} {
+		if resp.Entries[i].Key != k {
+			t.Errorf("Entry %d: expected key %s, got %s", i, k, resp.Entries[i].Key)
+		}
+		if len(resp.Entries[i].Value) != 0 {
+			t.Errorf("Expected no value for %s in keys-only scan", k)
+		}
+	}
+}
+
+func TestGRPCServer_Ping(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	server.SetNodeID("node1")
+	ctx := context.Background()
+
+	first, err := server.Ping(ctx, &proto.PingRequest{Nonce: 42})
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if first.Nonce != 42 {
+		t.Errorf("Expected nonce 42 to be echoed, got %d", first.Nonce)
+	}
+	if first.NodeId != "node1" {
+		t.Errorf("Expected node ID node1, got %s", first.NodeId)
+	}
+
+	second, err := server.Ping(ctx, &proto.PingRequest{Nonce: 43})
+	if err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if second.MonotonicNanos < first.MonotonicNanos {
+		t.Errorf("Monotonic timestamp went backwards: %d -> %d", first.MonotonicNanos, second.MonotonicNanos)
+	}
+}
+
+func TestGRPCServer_WriteBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	server.Put(ctx, &proto.PutRequest{Key: "old", Value: []byte("v")})
+
+	resp, err := server.WriteBatch(ctx, &proto.WriteBatchRequest{
+		Operations: []*proto.BatchOperation{
+			{Key: "new1", Value: []byte("a")},
+			{Key: "new2", Value: []byte("b")},
+			{Key: "old", Delete: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("WriteBatch unsuccessful: %s", resp.Error)
+	}
+
+	for k, v := range map[string]string{"new1": "a", "new2": "b"} {
+		getResp, _ := server.Get(ctx, &proto.GetRequest{Key: k})
+		if !getResp.Found || string(getResp.Value) != v {
+			t.Errorf("Key %s: expected %s, got %s", k, v, getResp.Value)
+		}
+	}
+
+	getResp, _ := server.Get(ctx, &proto.GetRequest{Key: "old"})
+	if getResp.Found {
+		t.Error("Expected old to be deleted by the batch")
+	}
+}
+
+func TestGRPCServer_RejectsWritesOnFollower(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	// Without a leader checker every node accepts writes
+	if resp, err := server.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v1")}); err != nil || !resp.Success {
+		t.Fatalf("Put without leader checker failed: %v", err)
+	}
+
+	checker := &fakeLeaderChecker{leaderID: "node2", leaderAddress: "10.0.0.2:50051"}
+	server.SetLeaderChecker(checker)
+
+	_, err = server.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v2")})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition on a follower, got %v", err)
+	}
+	if !strings.Contains(status.Convert(err).Message(), "10.0.0.2:50051") {
+		t.Errorf("Expected the leader address in the error, got %q", status.Convert(err).Message())
+	}
+
+	if _, err := server.Delete(ctx, &proto.DeleteRequest{Key: "k"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected Delete to be rejected, got %v", err)
+	}
+	batch := &proto.WriteBatchRequest{Operations: []*proto.BatchOperation{{Key: "k", Value: []byte("v3")}}}
+	if _, err := server.WriteBatch(ctx, batch); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected WriteBatch to be rejected, got %v", err)
+	}
+
+	// Reads are still served, and the rejected writes were not applied
+	getResp, err := server.Get(ctx, &proto.GetRequest{Key: "k"})
+	if err != nil || string(getResp.Value) != "v1" {
+		t.Errorf("Expected v1 after rejected writes, got %q (err: %v)", getResp.GetValue(), err)
+	}
+
+	// Once this node becomes the leader, writes go through again
+	checker.leader = true
+	if resp, err := server.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v4")}); err != nil || !resp.Success {
+		t.Errorf("Put on the leader failed: %v", err)
+	}
+}
+
+func TestGRPCServer_Namespaces(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	for _, ns := range []string{"", "a", "b"} {
+		if resp, err := server.Put(ctx, &proto.PutRequest{Ns: ns, Key: "k", Value: []byte("v" + ns)}); err != nil || !resp.Success {
+			t.Fatalf("Put in %q failed: %v", ns, err)
+		}
+	}
+	if resp, err := server.Delete(ctx, &proto.DeleteRequest{Ns: "b", Key: "k"}); err != nil || !resp.Success {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	for ns, want := range map[string]string{"": "v", "a": "va"} {
+		resp, err := server.Get(ctx, &proto.GetRequest{Ns: ns, Key: "k"})
+		if err != nil || !resp.Found || string(resp.Value) != want {
+			t.Errorf("Get in %q: expected %s, got %q (found: %v, err: %v)", ns, want, resp.GetValue(), resp.GetFound(), err)
+		}
+	}
+	if resp, _ := server.Get(ctx, &proto.GetRequest{Ns: "b", Key: "k"}); resp.Found {
+		t.Error("Delete in b leaked into other namespaces or was not applied")
+	}
+
+	scanResp, err := server.Scan(ctx, &proto.ScanRequest{Ns: "a"})
+	if err != nil || len(scanResp.Entries) != 1 || scanResp.Entries[0].Key != "k" {
+		t.Errorf("Scan in a: expected only k, got %v (err: %v)", scanResp.GetEntries(), err)
+	}
+}
+
+func TestGRPCServer_RingInfo(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	// A node without a ring says so
+	if _, err := server.RingInfo(ctx, &proto.RingInfoRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition without a ring, got %v", err)
+	}
+
+	registry := cluster.NewNodeRegistry(16)
+	registry.RegisterNode("node2", "localhost:50052")
+	registry.RegisterNode("node1", "localhost:50051")
+	server.SetRingInfoProvider(registry)
+
+	resp, err := server.RingInfo(ctx, &proto.RingInfoRequest{SimulatedKeys: 1000})
+	if err != nil {
+		t.Fatalf("RingInfo failed: %v", err)
+	}
+	if resp.SimulatedKeys != 1000 || len(resp.Nodes) != 2 {
+		t.Fatalf("Expected 2 nodes and 1000 keys, got %d nodes and %d keys", len(resp.Nodes), resp.SimulatedKeys)
+	}
+	if resp.Nodes[0].NodeId != "node1" || resp.Nodes[0].Address != "localhost:50051" || resp.Nodes[0].VirtualNodes != 16 {
+		t.Errorf("Unexpected first node: %+v", resp.Nodes[0])
+	}
+	if resp.Nodes[0].SimulatedKeys+resp.Nodes[1].SimulatedKeys != 1000 {
+		t.Errorf("Simulated keys don't add up: %+v", resp.Nodes)
+	}
+}
+
+func TestGRPCServer_SetQuorum(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	if _, err := server.SetQuorum(ctx, &proto.SetQuorumRequest{W: 3, R: 1}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition without a cluster client, got %v", err)
+	}
+
+	// Hints are written relative to the working directory
+	t.Chdir(t.TempDir())
+	cc, err := cluster.NewClusterClient(map[string]string{})
+	if err != nil {
+		t.Fatalf("Failed to create cluster client: %v", err)
+	}
+	defer cc.Close()
+	server.SetQuorumController(cc)
+
+	if _, err := server.SetQuorum(ctx, &proto.SetQuorumRequest{W: 4, R: 2}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for W > N, got %v", err)
+	}
+
+	resp, err := server.SetQuorum(ctx, &proto.SetQuorumRequest{W: 3, R: 1})
+	if err != nil {
+		t.Fatalf("SetQuorum failed: %v", err)
+	}
+	if resp.N != 3 || resp.W != 3 || resp.R != 1 {
+		t.Errorf("Expected N=3 W=3 R=1, got %+v", resp)
+	}
+	if got, _ := server.GetQuorum(ctx, &proto.GetQuorumRequest{}); got.W != 3 || got.R != 1 {
+		t.Errorf("GetQuorum doesn't reflect the change: %+v", got)
+	}
+}
+
+func TestGRPCServer_ReadOnly(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("existing", []byte("v1")); err != nil {
+		t.Fatalf("Failed to seed store: %v", err)
+	}
+
+	server := NewGRPCServer(store)
+	server.SetReadOnly(true)
+	ctx := context.Background()
+
+	if _, err := server.Put(ctx, &proto.PutRequest{Key: "existing", Value: []byte("v2")}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition for Put, got %v", err)
+	}
+	if _, err := server.Delete(ctx, &proto.DeleteRequest{Key: "existing"}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for Delete, got %v", err)
+	}
+	if _, err := server.Compact(ctx, &proto.CompactRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for Compact, got %v", err)
+	}
+
+	// Pre-existing data is still served
+	getResp, err := server.Get(ctx, &proto.GetRequest{Key: "existing"})
+	if err != nil || !getResp.Found || string(getResp.Value) != "v1" {
+		t.Errorf("Expected v1, got %q (found: %v, err: %v)", getResp.GetValue(), getResp.GetFound(), err)
+	}
+	if _, err := server.Stats(ctx, &proto.StatsRequest{}); err != nil {
+		t.Errorf("Stats failed: %v", err)
+	}
+}
+
+func TestGRPCServer_IdempotentReplay(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	// There is no Increment yet, so use a Put that is overwritten before the
+	// replay: applying the replay would revert the key to v1
+	first := &proto.PutRequest{Key: "k", Value: []byte("v1"), IdempotencyKey: "put-1"}
+	if resp, err := server.Put(ctx, first); err != nil || !resp.Success {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if resp, err := server.Put(ctx, &proto.PutRequest{Key: "k", Value: []byte("v2")}); err != nil || !resp.Success {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	resp, err := server.Put(ctx, first)
+	if err != nil || !resp.Success {
+		t.Fatalf("Expected the cached success for the replay, got %v (err: %v)", resp, err)
+	}
+	if value, _ := store.Get("k"); string(value) != "v2" {
+		t.Errorf("Replayed Put was applied again: got %q, want v2", value)
+	}
+
+	// The same idempotency key on a different RPC is a different request
+	batch := &proto.WriteBatchRequest{
+		Operations:     []*proto.BatchOperation{{Key: "k", Value: []byte("v3")}},
+		IdempotencyKey: "put-1",
+	}
+	if resp, err := server.WriteBatch(ctx, batch); err != nil || !resp.Success {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	if value, _ := store.Get("k"); string(value) != "v3" {
+		t.Errorf("Expected the batch to apply, got %q", value)
+	}
+
+	// A rejected write was not applied, so its retry runs
+	server.SetReadOnly(true)
+	retried := &proto.DeleteRequest{Key: "k", IdempotencyKey: "delete-1"}
+	if _, err := server.Delete(ctx, retried); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition, got %v", err)
+	}
+	server.SetReadOnly(false)
+	if resp, err := server.Delete(ctx, retried); err != nil || !resp.Success {
+		t.Fatalf("Retried Delete failed: %v", err)
+	}
+	if _, err := store.Get("k"); err != storage.ErrKeyNotFound {
+		t.Errorf("Expected k to be deleted by the retry, got %v", err)
+	}
+}
+
+func TestGRPCServer_ReplicaIncrementReplay(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	server := NewGRPCServer(store)
+	ctx := context.Background()
+
+	increment := func(req *proto.ReplicaPutRequest) uint64 {
+		t.Helper()
+		resp, err := server.ReplicaPut(ctx, req)
+		if err != nil || !resp.Success {
+			t.Fatalf("ReplicaPut failed: %v, %v", resp, err)
+		}
+		counter, err := replication.DecodeGCounter(resp.Value)
+		if err != nil {
+			t.Fatalf("Bad counter in the response: %v", err)
+		}
+		return counter.Value()
+	}
+
+	// A retried increment whose answer was lost is counted once
+	first := &proto.ReplicaPutRequest{
+		Key:            "views",
+		Crdt:           proto.ReplicaPutRequest_CRDT_GCOUNTER,
+		Increment:      5,
+		CounterNode:    "node1",
+		IdempotencyKey: "incr-1",
+	}
+	if value := increment(first); value != 5 {
+		t.Fatalf("Expected 5 after the first increment, got %d", value)
+	}
+	if value := increment(first); value != 5 {
+		t.Errorf("Expected the replay to return the first answer, got %d", value)
+	}
+
+	stored, err := store.Get("views")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	counter, err := replication.DecodeGCounter(stored)
+	if err != nil || counter.Value() != 5 {
+		t.Errorf("Replayed increment was counted again: got %v, %v", counter, err)
+	}
+
+	// Another increment, with its own key, still counts
+	second := &proto.ReplicaPutRequest{
+		Key:            "views",
+		Crdt:           proto.ReplicaPutRequest_CRDT_GCOUNTER,
+		Increment:      5,
+		CounterNode:    "node1",
+		IdempotencyKey: "incr-2",
+	}
+	if value := increment(second); value != 10 {
+		t.Errorf("Expected 10 after a second increment, got %d", value)
+	}
+}
+
+func TestIdempotencyCache_FailuresNotCached(t *testing.T) {
+	cache := newIdempotencyCache(10)
+
+	// A response reporting a failure is not replayed to the retry
+	runs := 0
+	put := func() (*proto.PutResponse, error) {
+		runs++
+		if runs == 1 {
+			return &proto.PutResponse{Success: false, Error: "disk full"}, nil
+		}
+		return &proto.PutResponse{Success: true}, nil
+	}
+	if resp, _, _ := idempotent(cache, "Put", "k", put); resp.Success {
+		t.Fatal("Expected the first attempt to fail")
+	}
+	resp, duplicate, err := idempotent(cache, "Put", "k", put)
+	if err != nil || duplicate || !resp.Success || runs != 2 {
+		t.Fatalf("Expected the retry to run and succeed, got %v (duplicate: %v, runs: %d, err: %v)", resp, duplicate, runs, err)
+	}
+
+	// Nor is a panic: the key is forgotten, so a duplicate doesn't wait forever
+	func() {
+		defer func() { recover() }()
+		idempotent(cache, "Put", "p", func() (*proto.PutResponse, error) {
+			panic("boom")
+		})
+	}()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		idempotent(cache, "Put", "p", func() (*proto.PutResponse, error) {
+			return &proto.PutResponse{Success: true}, nil
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Retry after a panic is stuck waiting on the first attempt")
+	}
+}
+
+func TestIdempotencyCache_Bounded(t *testing.T) {
+	cache := newIdempotencyCache(2)
+
+	runs := 0
+	run := func(key string) {
+		idempotent(cache, "Put", key, func() (*proto.PutResponse, error) {
+			runs++
+			return &proto.PutResponse{Success: true}, nil
+		})
+	}
+
+	run("a")
+	run("b")
+	run("a") // Duplicate; a becomes most recently used
+	run("c") // Evicts b
+	if runs != 3 {
+		t.Fatalf("Expected 3 runs, got %d", runs)
+	}
+	if cache.len() != 2 {
+		t.Errorf("Expected 2 cached keys, got %d", cache.len())
+	}
+
+	run("a")
+	if runs != 3 {
+		t.Error("Expected a to still be cached")
+	}
+	run("b")
+	if runs != 4 {
+		t.Error("Expected evicted b to run again")
+	}
+}
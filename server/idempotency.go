@@ -0,0 +1,148 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+)
+
+const (
+	// DefaultIdempotencyCacheSize is how many recent idempotency keys the
+	// server remembers. A retry that arrives after its key was evicted is
+	// applied again.
+	DefaultIdempotencyCacheSize = 10000
+)
+
+// idempotencyEntry is the outcome of one idempotent request. done is closed
+// once resp is set, so duplicates that arrive mid-request wait for it.
+type idempotencyEntry struct {
+	cacheKey string
+	done     chan struct{}
+	resp     any
+}
+
+// idempotencyCache is an LRU of idempotency key -> response.
+// Only successful responses are cached: a request that fails, with a gRPC
+// error (not leader, busy, read-only) or an Error in its response, was not
+// applied, so its key is forgotten and a retry runs again.
+type idempotencyCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+	mu       sync.Mutex
+}
+
+// newIdempotencyCache creates a cache holding up to capacity keys
+func newIdempotencyCache(capacity int) *idempotencyCache {
+	if capacity <= 0 {
+		capacity = DefaultIdempotencyCacheSize
+	}
+
+	return &idempotencyCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// begin returns the entry for a key and whether it already existed.
+// A new entry is in flight until finish or forget is called.
+func (c *idempotencyCache) begin(cacheKey string) (*idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.entries[cacheKey]; exists {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*idempotencyEntry), true
+	}
+
+	entry := &idempotencyEntry{cacheKey: cacheKey, done: make(chan struct{})}
+	c.entries[cacheKey] = c.order.PushFront(entry)
+
+	// Evict least recently used entries
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*idempotencyEntry).cacheKey)
+	}
+
+	return entry, false
+}
+
+// finish records the response of an in-flight entry
+func (c *idempotencyCache) finish(entry *idempotencyEntry, resp any) {
+	entry.resp = resp
+	close(entry.done)
+}
+
+// forget drops an in-flight entry that did not produce a response.
+// Duplicates already waiting on it run the request themselves.
+func (c *idempotencyCache) forget(entry *idempotencyEntry) {
+	c.mu.Lock()
+	if elem, exists := c.entries[entry.cacheKey]; exists && elem.Value == entry {
+		c.order.Remove(elem)
+		delete(c.entries, entry.cacheKey)
+	}
+	c.mu.Unlock()
+
+	close(entry.done)
+}
+
+// len returns the number of remembered keys
+func (c *idempotencyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// idempotent runs fn once per (rpc, idempotency key). A duplicate gets the
+// first call's response instead of running fn, and the boolean reports it so
+// callers can log it. Requests without a key always run.
+func idempotent[T any](c *idempotencyCache, rpc, key string, fn func() (T, error)) (T, bool, error) {
+	if key == "" {
+		resp, err := fn()
+		return resp, false, err
+	}
+
+	for {
+		entry, duplicate := c.begin(rpc + "\x00" + key)
+		if !duplicate {
+			resp, err := runFirst(c, entry, fn)
+			return resp, false, err
+		}
+
+		<-entry.done
+		if resp, ok := entry.resp.(T); ok {
+			return resp, true, nil
+		}
+		// The first attempt failed without a response; run it ourselves
+	}
+}
+
+// failureResponse is implemented by the write responses, which report a
+// failed write in their Error field rather than as a gRPC error
+type failureResponse interface {
+	GetError() string
+}
+
+// runFirst runs fn for the first request with a key and caches its
+// response. The key is forgotten instead if fn fails, returns a response
+// reporting a failure, or panics, so duplicates waiting on it aren't stuck.
+func runFirst[T any](c *idempotencyCache, entry *idempotencyEntry, fn func() (T, error)) (T, error) {
+	finished := false
+	defer func() {
+		if !finished {
+			c.forget(entry)
+		}
+	}()
+
+	resp, err := fn()
+	if err != nil {
+		return resp, err
+	}
+	if failure, ok := any(resp).(failureResponse); ok && failure.GetError() != "" {
+		return resp, nil
+	}
+	c.finish(entry, resp)
+	finished = true
+	return resp, nil
+}
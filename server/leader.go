@@ -0,0 +1,53 @@
+package server
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LeaderChecker reports whether this node may accept writes.
+// It is implemented by the consensus layer (e.g. *raft.RaftNode); without
+// one, every node accepts writes.
+type LeaderChecker interface {
+	IsLeader() bool
+	// Leader returns the ID and address of the current leader, if known
+	Leader() (string, string, bool)
+}
+
+// ErrNotLeader is returned when a write reaches a node that is not the
+// leader. Applying it locally would bypass consensus, so the client should
+// retry against the leader instead.
+type ErrNotLeader struct {
+	LeaderID      string
+	LeaderAddress string
+	Known         bool // false while no leader is known (e.g. during an election)
+}
+
+func (e *ErrNotLeader) Error() string {
+	if !e.Known {
+		return "not the leader (no leader known)"
+	}
+	return fmt.Sprintf("not the leader (leader is %s at %s)", e.LeaderID, e.LeaderAddress)
+}
+
+// GRPCStatus maps the error to codes.FailedPrecondition with the leader hint
+func (e *ErrNotLeader) GRPCStatus() *status.Status {
+	return status.New(codes.FailedPrecondition, e.Error())
+}
+
+// checkLeader returns an *ErrNotLeader if a leader checker is set and this
+// node is not the leader
+func (s *GRPCServer) checkLeader() error {
+	if s.leaderChecker == nil || s.leaderChecker.IsLeader() {
+		return nil
+	}
+
+	leaderID, address, known := s.leaderChecker.Leader()
+	return &ErrNotLeader{
+		LeaderID:      leaderID,
+		LeaderAddress: address,
+		Known:         known,
+	}
+}
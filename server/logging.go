@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LogFormat selects how the server writes log events
+type LogFormat int
+
+const (
+	// LogFormatText writes the human-friendly emoji lines (default)
+	LogFormatText LogFormat = iota
+	// LogFormatJSON writes one JSON object per event for log aggregators
+	LogFormatJSON
+)
+
+// ParseLogFormat parses a -log-format flag value ("text" or "json")
+func ParseLogFormat(s string) (LogFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "text":
+		return LogFormatText, nil
+	case "json":
+		return LogFormatJSON, nil
+	default:
+		return LogFormatText, fmt.Errorf("unknown log format %q (expected text or json)", s)
+	}
+}
+
+// Fields are the structured attributes attached to a log event.
+// Zero values are omitted from JSON output.
+type Fields struct {
+	RPC       string
+	KeySize   int
+	ValueSize int
+	Latency   time.Duration
+	Err       error
+}
+
+// Logger is the logging abstraction used by the gRPC handlers
+type Logger struct {
+	format LogFormat
+	text   *log.Logger
+	json   *slog.Logger
+}
+
+// NewLogger creates a logger writing to out (os.Stderr if nil)
+func NewLogger(format LogFormat, out io.Writer) *Logger {
+	if out == nil {
+		out = os.Stderr
+	}
+
+	return &Logger{
+		format: format,
+		text:   log.New(out, "", log.LstdFlags),
+		json:   slog.New(slog.NewJSONHandler(out, nil)),
+	}
+}
+
+func (l *Logger) Info(fields Fields, format string, args ...interface{}) {
+	l.log(slog.LevelInfo, fields, format, args...)
+}
+
+func (l *Logger) Warn(fields Fields, format string, args ...interface{}) {
+	l.log(slog.LevelWarn, fields, format, args...)
+}
+
+func (l *Logger) Error(fields Fields, format string, args ...interface{}) {
+	l.log(slog.LevelError, fields, format, args...)
+}
+
+func (l *Logger) log(level slog.Level, fields Fields, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+
+	if l.format == LogFormatText {
+		l.text.Print(msg)
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 5)
+	if fields.RPC != "" {
+		attrs = append(attrs, slog.String("rpc", fields.RPC))
+	}
+	if fields.KeySize > 0 {
+		attrs = append(attrs, slog.Int("key_size", fields.KeySize))
+	}
+	if fields.ValueSize > 0 {
+		attrs = append(attrs, slog.Int("value_size", fields.ValueSize))
+	}
+	if fields.Latency > 0 {
+		attrs = append(attrs, slog.Float64("latency_ms", float64(fields.Latency)/float64(time.Millisecond)))
+	}
+	if fields.Err != nil {
+		attrs = append(attrs, slog.String("error", fields.Err.Error()))
+	}
+
+	l.json.LogAttrs(context.Background(), level, plainMessage(msg), attrs...)
+}
+
+// plainMessage strips the leading emoji from a text log line
+func plainMessage(msg string) string {
+	return strings.TrimLeftFunc(msg, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
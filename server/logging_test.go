@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"kvstore/proto"
+	"kvstore/storage"
+)
+
+func TestParseLogFormat(t *testing.T) {
+	for input, expected := range map[string]LogFormat{"": LogFormatText, "text": LogFormatText, "JSON": LogFormatJSON} {
+		format, err := ParseLogFormat(input)
+		if err != nil || format != expected {
+			t.Errorf("ParseLogFormat(%q) = %v, %v; expected %v", input, format, err, expected)
+		}
+	}
+
+	if _, err := ParseLogFormat("xml"); err == nil {
+		t.Error("Expected error for unknown log format")
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := storage.NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	var buf bytes.Buffer
+	server := NewGRPCServer(store)
+	server.SetLogger(NewLogger(LogFormatJSON, &buf))
+
+	server.Put(context.Background(), &proto.PutRequest{Key: "user:1", Value: []byte("alice")})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log events, got %d: %s", len(lines), buf.String())
+	}
+
+	// The completion event carries the structured fields
+	var event map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &event); err != nil {
+		t.Fatalf("Log line is not valid JSON: %v (%s)", err, lines[1])
+	}
+
+	if event["level"] != "INFO" {
+		t.Errorf("Expected level INFO, got %v", event["level"])
+	}
+	if _, ok := event["time"]; !ok {
+		t.Error("Expected a timestamp")
+	}
+	if event["rpc"] != "Put" {
+		t.Errorf("Expected rpc Put, got %v", event["rpc"])
+	}
+	if event["key_size"] != float64(len("user:1")) {
+		t.Errorf("Expected key_size %d, got %v", len("user:1"), event["key_size"])
+	}
+	if _, ok := event["latency_ms"]; !ok {
+		t.Error("Expected latency_ms field")
+	}
+	if msg := event["msg"].(string); !strings.HasPrefix(msg, "PUT success") {
+		t.Errorf("Expected emoji-free message, got %q", msg)
+	}
+}
+
+func TestLogger_TextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LogFormatText, &buf)
+
+	logger.Info(Fields{RPC: "Get", KeySize: 3}, "🔍 GET: key=%s", "abc")
+
+	if !strings.Contains(buf.String(), "🔍 GET: key=abc") {
+		t.Errorf("Expected human-friendly line, got %q", buf.String())
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kvstore/cluster"
+	"kvstore/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// QuorumController reads and changes the W/R quorums for the GetQuorum and
+// SetQuorum RPCs. It is implemented by *cluster.ClusterClient; without one,
+// both RPCs fail with codes.FailedPrecondition.
+type QuorumController interface {
+	Quorum() cluster.Quorum
+	SetQuorum(w, r int) error
+}
+
+// SetQuorumController serves the quorums of a node that runs a ClusterClient
+func (s *GRPCServer) SetQuorumController(controller QuorumController) {
+	s.quorum = controller
+}
+
+// GetQuorum returns the replication factor and the W/R quorums in effect
+func (s *GRPCServer) GetQuorum(ctx context.Context, req *proto.GetQuorumRequest) (*proto.QuorumResponse, error) {
+	if s.quorum == nil {
+		return nil, status.Error(codes.FailedPrecondition, "this node does not run a cluster client")
+	}
+	return quorumResponse(s.quorum.Quorum()), nil
+}
+
+// SetQuorum changes W and R at runtime. Values outside 1..N, or with
+// W+R <= N, fail with codes.InvalidArgument and leave the quorums as they
+// were.
+func (s *GRPCServer) SetQuorum(ctx context.Context, req *proto.SetQuorumRequest) (*proto.QuorumResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "SetQuorum"}
+
+	if s.quorum == nil {
+		return nil, status.Error(codes.FailedPrecondition, "this node does not run a cluster client")
+	}
+
+	if err := s.quorum.SetQuorum(int(req.W), int(req.R)); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  SET QUORUM rejected: %v", err)
+		if errors.Is(err, cluster.ErrInvalidQuorum) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, err
+	}
+
+	quorum := s.quorum.Quorum()
+	fields.Latency = time.Since(start)
+	s.logger.Info(fields, "⚖️  SET QUORUM: W=%d, R=%d (N=%d)", quorum.W, quorum.R, quorum.N)
+	return quorumResponse(quorum), nil
+}
+
+// quorumResponse converts a quorum to its RPC form
+func quorumResponse(quorum cluster.Quorum) *proto.QuorumResponse {
+	return &proto.QuorumResponse{N: int32(quorum.N), W: int32(quorum.W), R: int32(quorum.R)}
+}
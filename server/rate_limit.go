@@ -0,0 +1,132 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimitIdleSweep is how often idle clients' buckets are dropped
+const rateLimitIdleSweep = time.Minute
+
+// RateLimiter caps how fast each client may send requests, so one
+// misbehaving client can't starve the others. Every client has a token
+// bucket that refills at a steady rate up to a burst size; each unary call,
+// and each stream when it opens, takes a token, and a call that finds the
+// bucket empty fails with codes.ResourceExhausted without reaching the
+// handler. Clients are told apart by their peer address, so each connection
+// has its own bucket.
+type RateLimiter struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Bucket size
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket // peer address -> bucket
+	lastSweep time.Time
+	now       func() time.Time // Overridden in tests
+}
+
+// tokenBucket is one client's allowance
+type tokenBucket struct {
+	tokens float64
+	last   time.Time // When tokens was last refilled
+}
+
+// NewRateLimiter allows each client requestsPerSecond (> 0) on average,
+// with bursts of up to burst requests. A burst below 1 is raised to 1.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:      requestsPerSecond,
+		burst:     float64(max(burst, 1)),
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from client's bucket and reports whether there was one
+func (l *RateLimiter) Allow(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweepLocked(now)
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// sweepLocked drops the buckets of clients idle long enough to have refilled,
+// which a new bucket would match, so departed clients don't accumulate
+// (must be called with mu held)
+func (l *RateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitIdleSweep {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// check admits or rejects one call from the peer in ctx
+func (l *RateLimiter) check(ctx context.Context, method string) error {
+	client := "unknown"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client = p.Addr.String()
+	}
+	if l.Allow(client) {
+		return nil
+	}
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded for %s: %s allows %g requests/s (burst %g)",
+		client, method, l.rate, l.burst)
+}
+
+// UnaryServerInterceptor rejects unary calls from clients over their rate
+func (l *RateLimiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor rejects new streams from clients over their rate;
+// messages on a stream already open are not limited
+func (l *RateLimiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// ServerOptions installs the rate limiter's interceptors on a server
+func (l *RateLimiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(l.StreamServerInterceptor()),
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/storage"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Test: a client bursting past its limit is throttled while another client,
+// on its own connection, is not
+func TestRateLimiter_ThrottlesPerClient(t *testing.T) {
+	store, err := storage.NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// The clock only moves when the test says so
+	var clock atomic.Int64
+	clock.Store(time.Now().UnixNano())
+	limiter := NewRateLimiter(2, 5)
+	limiter.now = func() time.Time { return time.Unix(0, clock.Load()) }
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := grpc.NewServer(limiter.ServerOptions()...)
+	proto.RegisterKVStoreServer(srv, NewGRPCServer(store))
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	dial := func() proto.KVStoreClient {
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return proto.NewKVStoreClient(conn)
+	}
+	noisy, polite := dial(), dial()
+
+	ping := func(client proto.KVStoreClient) error {
+		_, err := client.Ping(context.Background(), &proto.PingRequest{})
+		return err
+	}
+
+	rejected := 0
+	for i := 0; i < 20; i++ {
+		if err := ping(noisy); status.Code(err) == codes.ResourceExhausted {
+			rejected++
+		} else if err != nil {
+			t.Fatalf("Ping failed: %v", err)
+		}
+	}
+	if rejected != 15 {
+		t.Errorf("Expected 15 of 20 burst requests rejected (burst 5), got %d", rejected)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := ping(polite); err != nil {
+			t.Fatalf("Request %d from the conforming client failed: %v", i, err)
+		}
+	}
+
+	// A second refills two tokens
+	clock.Add(int64(time.Second))
+	for i := 0; i < 2; i++ {
+		if err := ping(noisy); err != nil {
+			t.Fatalf("Expected a refilled token, got %v", err)
+		}
+	}
+	if err := ping(noisy); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted once the refill is used, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"kvstore/proto"
+	"kvstore/replication"
+	"kvstore/storage"
+)
+
+// ReplicaPut stores a versioned value sent by a coordinator (a
+// ClusterClient, or a server in coordinator mode). The value keeps the
+// coordinator's timestamp, so every replica of a write agrees on its version.
+// It never replicates further. A CRDT_GCOUNTER write is merged into the
+// stored counter instead of replacing it (see replication.GCounterUpdate);
+// an increment carrying an idempotency key that was already counted returns
+// the original response without counting it again.
+func (s *GRPCServer) ReplicaPut(ctx context.Context, req *proto.ReplicaPutRequest) (*proto.ReplicaPutResponse, error) {
+	resp, duplicate, err := idempotent(s.idempotency, "ReplicaPut", req.IdempotencyKey, func() (*proto.ReplicaPutResponse, error) {
+		return s.replicaPut(req)
+	})
+	if duplicate {
+		s.logger.Info(Fields{RPC: "ReplicaPut", KeySize: len(req.Key)}, "🔁 REPLICA PUT duplicate: key=%s, idempotency_key=%s", req.Key, req.IdempotencyKey)
+	}
+	return resp, err
+}
+
+func (s *GRPCServer) replicaPut(req *proto.ReplicaPutRequest) (*proto.ReplicaPutResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "ReplicaPut", KeySize: len(req.Key), ValueSize: len(req.Value)}
+
+	if err := s.checkWritable(); err != nil {
+		fields.Latency, fields.Err = time.Since(start), err
+		s.logger.Warn(fields, "⚠️  REPLICA PUT rejected: %v", err)
+		return nil, err
+	}
+
+	var counter []byte
+	var err error
+	if req.Crdt == proto.ReplicaPutRequest_CRDT_GCOUNTER {
+		counter, err = s.store.Update(req.Key, replication.GCounterUpdate(req.Value, req.CounterNode, req.Increment))
+	} else {
+		err = s.store.PutWithTimestamp(req.Key, req.Value, req.Timestamp)
+	}
+	fields.Latency = time.Since(start)
+	if err != nil {
+		fields.Err = err
+		if errors.Is(err, storage.ErrStoreBusy) {
+			s.logger.Warn(fields, "⚠️  REPLICA PUT shed: %v", err)
+			return nil, busyError(err)
+		}
+		s.logger.Error(fields, "❌ REPLICA PUT failed: %v", err)
+		return &proto.ReplicaPutResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	s.logger.Info(fields, "📥 REPLICA PUT: key=%s, timestamp=%d", req.Key, req.Timestamp)
+	return &proto.ReplicaPutResponse{Success: true, Value: counter}, nil
+}
+
+// ReplicaGet reads a value with the timestamp and version a coordinator
+// needs to resolve replicas against each other
+func (s *GRPCServer) ReplicaGet(ctx context.Context, req *proto.ReplicaGetRequest) (*proto.ReplicaGetResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "ReplicaGet", KeySize: len(req.Key)}
+
+	value, timestamp, err := s.store.GetWithTimestampContext(ctx, req.Key)
+	fields.Latency = time.Since(start)
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return &proto.ReplicaGetResponse{Found: false}, nil
+	}
+	if err != nil {
+		fields.Err = err
+		s.logger.Error(fields, "❌ REPLICA GET failed: %v", err)
+		return &proto.ReplicaGetResponse{Found: false, Error: err.Error()}, nil
+	}
+
+	fields.ValueSize = len(value)
+	return &proto.ReplicaGetResponse{
+		Value:     value,
+		Found:     true,
+		Timestamp: timestamp,
+		Version:   replication.GenerateVersion(timestamp),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"kvstore/cluster"
+	"kvstore/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RingInfoProvider describes the consistent hash ring for the RingInfo RPC.
+// It is implemented by *cluster.NodeRegistry (see ClusterClient.GetRegistry);
+// without one, RingInfo fails with codes.FailedPrecondition.
+type RingInfoProvider interface {
+	RingInfo(simulatedKeys int) cluster.RingInfo
+}
+
+// SetRingInfoProvider serves the ring of a node that holds a NodeRegistry
+func (s *GRPCServer) SetRingInfoProvider(provider RingInfoProvider) {
+	s.ringInfo = provider
+}
+
+// RingInfo returns the ring's physical nodes, sorted by ID, with their
+// virtual-node counts and simulated key ownership, for dashboards that watch
+// balance across membership changes
+func (s *GRPCServer) RingInfo(ctx context.Context, req *proto.RingInfoRequest) (*proto.RingInfoResponse, error) {
+	start := time.Now()
+	fields := Fields{RPC: "RingInfo"}
+
+	if s.ringInfo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "this node does not hold a hash ring")
+	}
+
+	info := s.ringInfo.RingInfo(int(req.SimulatedKeys))
+	resp := &proto.RingInfoResponse{
+		Generation:    info.Generation,
+		SimulatedKeys: int32(info.SimulatedKeys),
+	}
+	for _, node := range info.Nodes {
+		resp.Nodes = append(resp.Nodes, &proto.RingNode{
+			NodeId:        node.ID,
+			Address:       node.Address,
+			VirtualNodes:  int32(node.VirtualNodes),
+			SimulatedKeys: int32(node.SimulatedKeys),
+		})
+	}
+
+	fields.Latency = time.Since(start)
+	s.logger.Info(fields, "💍 RING INFO: %d nodes, generation %d, %d keys simulated", len(resp.Nodes), info.Generation, info.SimulatedKeys)
+	return resp, nil
+}
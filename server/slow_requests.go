@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// DefaultSlowRequestThreshold is how long a call may take before
+// SlowRequestLogger reports it, unless configured otherwise
+const DefaultSlowRequestThreshold = 100 * time.Millisecond
+
+// SlowRequestLogger logs a warning for every unary call that takes longer
+// than a threshold, with its method, key and value sizes and elapsed time,
+// so Puts stalled behind a flush or Gets reading cold SSTables show up
+// without logging every request
+type SlowRequestLogger struct {
+	threshold time.Duration
+	logger    *Logger
+}
+
+// NewSlowRequestLogger reports calls slower than threshold (> 0) to logger
+// (text on stderr if nil)
+func NewSlowRequestLogger(threshold time.Duration, logger *Logger) *SlowRequestLogger {
+	if logger == nil {
+		logger = NewLogger(LogFormatText, nil)
+	}
+	return &SlowRequestLogger{threshold: threshold, logger: logger}
+}
+
+// UnaryServerInterceptor times each unary call and logs the slow ones
+func (l *SlowRequestLogger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		if elapsed := time.Since(start); elapsed > l.threshold {
+			fields := Fields{RPC: info.FullMethod, Latency: elapsed, Err: err}
+			if r, ok := req.(interface{ GetKey() string }); ok {
+				fields.KeySize = len(r.GetKey())
+			}
+			if r, ok := req.(interface{ GetValue() []byte }); ok {
+				fields.ValueSize = len(r.GetValue())
+			}
+			l.logger.Warn(fields, "🐢 SLOW %s: %v (threshold %v, key_size=%d, value_size=%d)",
+				info.FullMethod, elapsed.Round(time.Microsecond), l.threshold, fields.KeySize, fields.ValueSize)
+		}
+		return resp, err
+	}
+}
+
+// ServerOptions installs the slow request logger's interceptor on a server
+func (l *SlowRequestLogger) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.ChainUnaryInterceptor(l.UnaryServerInterceptor())}
+}
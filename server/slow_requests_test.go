@@ -0,0 +1,55 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"kvstore/proto"
+
+	"google.golang.org/grpc"
+)
+
+func TestSlowRequestLogger(t *testing.T) {
+	var out bytes.Buffer
+	interceptor := NewSlowRequestLogger(20*time.Millisecond, NewLogger(LogFormatJSON, &out)).UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/kvstore.KVStore/Put"}
+	req := &proto.PutRequest{Key: "key", Value: []byte("value")}
+
+	// A fast call is not logged
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &proto.PutResponse{Success: true}, nil
+	}
+	if _, err := interceptor(context.Background(), req, info, handler); err != nil {
+		t.Fatalf("Call failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("Expected no log for a fast call, got %s", out.String())
+	}
+
+	// A slow one is, with its method, sizes and latency
+	slow := func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(50 * time.Millisecond)
+		return &proto.PutResponse{Success: true}, nil
+	}
+	resp, err := interceptor(context.Background(), req, info, slow)
+	if err != nil || !resp.(*proto.PutResponse).Success {
+		t.Fatalf("Expected the handler's response, got %v (err: %v)", resp, err)
+	}
+
+	var event map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &event); err != nil {
+		t.Fatalf("Expected one JSON event, got %q: %v", out.String(), err)
+	}
+	if event["level"] != "WARN" || event["rpc"] != info.FullMethod {
+		t.Errorf("Expected a WARN event for %s, got %v", info.FullMethod, event)
+	}
+	if event["key_size"] != float64(3) || event["value_size"] != float64(5) {
+		t.Errorf("Expected key_size 3 and value_size 5, got %v", event)
+	}
+	if latency, _ := event["latency_ms"].(float64); latency < 50 {
+		t.Errorf("Expected latency_ms of at least 50, got %v", event["latency_ms"])
+	}
+}
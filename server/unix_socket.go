@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"time"
+)
+
+// ListenUnix listens on a Unix domain socket at path, for clients on the
+// same host (e.g. a sidecar) that would rather skip TCP. A socket file left
+// behind by a server that crashed is removed first; one that a running
+// server still answers on is an error, as is a path that isn't a socket.
+// Closing the listener (as grpc.Server's Stop and GracefulStop do) removes
+// the file again.
+func ListenUnix(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to check socket %s: %w", path, err)
+	case info.Mode()&os.ModeSocket == 0:
+		return nil, fmt.Errorf("%s exists and is not a socket", path)
+	default:
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is in use by another server", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+		log.Printf("🧹 Removed stale socket %s", path)
+	}
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListenUnix_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kv.sock")
+
+	// A crashed server leaves its socket file behind
+	crashed, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	crashed.(*net.UnixListener).SetUnlinkOnClose(false)
+	crashed.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected a stale socket file, got %v", err)
+	}
+
+	lis, err := ListenUnix(path)
+	if err != nil {
+		t.Fatalf("Expected the stale socket to be replaced, got %v", err)
+	}
+	defer lis.Close()
+
+	// A live one is left alone
+	if _, err := ListenUnix(path); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("Expected a socket in use to be refused, got %v", err)
+	}
+
+	// So is anything that isn't a socket
+	file := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(file, []byte("precious"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenUnix(file); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("Expected a regular file to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "precious" {
+		t.Errorf("Regular file was touched: %q", data)
+	}
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// backupManifestName is the first file of a backup archive
+const backupManifestName = "MANIFEST.json"
+
+// BackupManifest describes the SSTables in a backup archive
+type BackupManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"created_at"`
+	Tables    []BackupTable `json:"tables"` // Newest first, as the store orders them
+}
+
+// BackupTable is one SSTable in a backup archive
+type BackupTable struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// ErrBadBackup is returned by RestoreBackup for an archive it can't restore
+var ErrBadBackup = errors.New("invalid backup archive")
+
+// Backup writes a consistent copy of the store to w as a tar archive: a
+// manifest, then every SSTable. The MemTable is flushed first, so the
+// SSTables hold every write acknowledged before the call; the WAL is not
+// needed and is left out. Writes made during the backup are not in it.
+//
+// SSTables never change once written, so the backup only has to keep the
+// files it lists from being deleted: compactions wait until it is done.
+// Restore the archive with RestoreBackup.
+func (s *LSMStore) Backup(w io.Writer) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	// Pin the SSTable set: only compaction deletes SSTables
+	if s.compactionMgr != nil {
+		s.compactionMgr.compactMu.Lock()
+		defer s.compactionMgr.compactMu.Unlock()
+	}
+
+	if err := s.Flush(); err != nil {
+		return fmt.Errorf("failed to flush before backup: %w", err)
+	}
+
+	s.mu.RLock()
+	paths := make([]string, len(s.sstables))
+	for i, sst := range s.sstables {
+		paths[i] = sst.filePath
+	}
+	s.mu.RUnlock()
+
+	manifest := BackupManifest{Version: 1, CreatedAt: time.Now().UTC()}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat SSTable: %w", err)
+		}
+		manifest.Tables = append(manifest.Tables, BackupTable{Name: filepath.Base(path), Size: info.Size()})
+	}
+
+	tw := tar.NewWriter(w)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    backupManifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: manifest.CreatedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	for i, path := range paths {
+		if err := backupFile(tw, path, manifest.Tables[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish backup archive: %w", err)
+	}
+	return nil
+}
+
+// backupFile copies one SSTable into the archive
+func backupFile(tw *tar.Writer, path string, table BackupTable) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open SSTable for backup: %w", err)
+	}
+	defer file.Close()
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    table.Name,
+		Mode:    0644,
+		Size:    table.Size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", table.Name, err)
+	}
+	if _, err := io.CopyN(tw, file, table.Size); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// RestoreBackup rebuilds a data directory from an archive written by
+// Backup. dataDir must not hold a store already: it is created if missing,
+// and must otherwise be empty. Every table the manifest lists must be in
+// the archive with its recorded size, or nothing is left behind and the
+// error wraps ErrBadBackup. Open the store with NewLSMStore afterwards.
+func RestoreBackup(dataDir string, r io.Reader) (err error) {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	existing, err := os.ReadDir(dataDir)
+	if err != nil {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("data directory %s is not empty", dataDir)
+	}
+
+	// Don't leave a partial store behind
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+	}()
+
+	tr := tar.NewReader(r)
+	header, err := tr.Next()
+	if err != nil || header.Name != backupManifestName {
+		return fmt.Errorf("%w: missing manifest", ErrBadBackup)
+	}
+	var manifest BackupManifest
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return fmt.Errorf("%w: manifest: %v", ErrBadBackup, err)
+	}
+	if manifest.Version != 1 {
+		return fmt.Errorf("%w: unsupported manifest version %d", ErrBadBackup, manifest.Version)
+	}
+
+	expected := make(map[string]int64, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		if matched, _ := filepath.Match("sstable_*.db", table.Name); !matched || filepath.Base(table.Name) != table.Name {
+			return fmt.Errorf("%w: unexpected table name %q", ErrBadBackup, table.Name)
+		}
+		expected[table.Name] = table.Size
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrBadBackup, err)
+		}
+		size, ok := expected[header.Name]
+		if !ok {
+			return fmt.Errorf("%w: %q is not in the manifest", ErrBadBackup, header.Name)
+		}
+		if header.Size != size {
+			return fmt.Errorf("%w: %s has %d bytes, the manifest says %d", ErrBadBackup, header.Name, header.Size, size)
+		}
+
+		path := filepath.Join(dataDir, header.Name)
+		written = append(written, path)
+		if err := restoreFile(path, tr, size); err != nil {
+			return err
+		}
+		delete(expected, header.Name)
+	}
+
+	for name := range expected {
+		return fmt.Errorf("%w: %s is missing", ErrBadBackup, name)
+	}
+
+	// The archive lists the tables in the store's order
+	paths := make([]string, len(manifest.Tables))
+	for i, table := range manifest.Tables {
+		paths[i] = filepath.Join(dataDir, table.Name)
+	}
+	return writeManifest(dataDir, paths)
+}
+
+// restoreFile writes one SSTable from the archive and syncs it
+func restoreFile(path string, r io.Reader, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+	}
+	defer file.Close()
+
+	if _, err := io.CopyN(file, r, size); err != nil {
+		return fmt.Errorf("%w: %s is truncated: %v", ErrBadBackup, filepath.Base(path), err)
+	}
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLSMStore_BackupRestore(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Two SSTables, a delete, and writes still in the MemTable
+	want := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		key, value := fmt.Sprintf("key%03d", i), fmt.Sprintf("value%d", i)
+		if err := store.Put(key, []byte(value)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		want[key] = value
+		if i == 100 || i == 200 {
+			if err := store.Flush(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+		}
+	}
+	if err := store.Delete("key050"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	delete(want, "key050")
+
+	var archive bytes.Buffer
+	if err := store.Backup(&archive); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Writes after the backup are not in it
+	if err := store.Put("late", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	restoreDir := filepath.Join(t.TempDir(), "restored")
+	if err := RestoreBackup(restoreDir, bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	restored, err := NewLSMStore(restoreDir)
+	if err != nil {
+		t.Fatalf("Failed to open restored store: %v", err)
+	}
+	defer restored.Close()
+
+	for key, value := range want {
+		got, err := restored.Get(key)
+		if err != nil || string(got) != value {
+			t.Fatalf("Get(%s) = %q, %v; want %q", key, got, err, value)
+		}
+	}
+	for _, key := range []string{"key050", "late"} {
+		if _, err := restored.Get(key); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Expected %s not found in the restored store, got %v", key, err)
+		}
+	}
+
+	// A directory that already holds data is refused
+	if err := RestoreBackup(restoreDir, bytes.NewReader(archive.Bytes())); err == nil {
+		t.Error("Expected RestoreBackup into a non-empty directory to fail")
+	}
+
+	// A truncated archive leaves nothing behind
+	truncatedDir := t.TempDir()
+	err = RestoreBackup(truncatedDir, bytes.NewReader(archive.Bytes()[:archive.Len()/2]))
+	if !errors.Is(err, ErrBadBackup) {
+		t.Fatalf("Expected ErrBadBackup for a truncated archive, got %v", err)
+	}
+	if entries, _ := os.ReadDir(truncatedDir); len(entries) != 0 {
+		t.Errorf("Expected the failed restore cleaned up, found %d files", len(entries))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"hash/fnv"
 	"math"
+	"math/bits"
 )
 
 // BloomFilter is a space-efficient probabilistic data structure
@@ -67,8 +68,18 @@ func (bf *BloomFilter) MayContain(key []byte) bool {
 // getHashes generates k hash values for a key using double hashing
 // h(i) = hash1(x) + i*hash2(x)
 func (bf *BloomFilter) getHashes(key []byte) []uint32 {
-	hash1 := hashFNV32(key)
-	hash2 := hashFNV32a(key)
+	return bf.deriveHashes(hashFNV32(key), hashFNV32a(key))
+}
+
+// deriveHashes expands two base hashes into k hash values.
+// A zero hash2 would collapse every h(i) onto hash1, leaving a single
+// effective hash function for that key, so it is replaced by a non-zero
+// value mixed from hash1. Keys with a non-zero hash2 hash exactly as
+// before, so filters already written to SSTables stay valid.
+func (bf *BloomFilter) deriveHashes(hash1, hash2 uint32) []uint32 {
+	if hash2 == 0 {
+		hash2 = mixHash32(hash1) | 1
+	}
 
 	hashes := make([]uint32, bf.numHashes)
 	for i := uint32(0); i < bf.numHashes; i++ {
@@ -77,6 +88,17 @@ func (bf *BloomFilter) getHashes(key []byte) []uint32 {
 	return hashes
 }
 
+// mixHash32 is the murmur3 32-bit finalizer, used to derive an independent
+// looking value from an existing hash
+func mixHash32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
 // hashFNV32 computes FNV-1 32-bit hash
 func hashFNV32(data []byte) uint32 {
 	h := fnv.New32()
@@ -135,23 +157,29 @@ func optimalHashFunctions(m, n int) int {
 
 // Stats returns bloom filter statistics
 func (bf *BloomFilter) Stats() map[string]interface{} {
-	setBits := 0
-	for _, b := range bf.bits {
-		for i := 0; i < 8; i++ {
-			if (b & (1 << i)) != 0 {
-				setBits++
-			}
-		}
-	}
-
-	fillRatio := float64(setBits) / float64(bf.size)
+	setBits := bf.bitsSet()
 
 	return map[string]interface{}{
 		"size_bits":    bf.size,
 		"size_bytes":   len(bf.bits),
 		"num_hashes":   bf.numHashes,
 		"bits_set":     setBits,
-		"fill_ratio":   fillRatio,
-		"expected_fpr": math.Pow(fillRatio, float64(bf.numHashes)), // Approximate false positive rate
+		"fill_ratio":   float64(setBits) / float64(bf.size),
+		"expected_fpr": bf.estimatedFPR(setBits),
+	}
+}
+
+// bitsSet counts the bits set in the filter
+func (bf *BloomFilter) bitsSet() int {
+	setBits := 0
+	for _, b := range bf.bits {
+		setBits += bits.OnesCount8(b)
 	}
+	return setBits
+}
+
+// estimatedFPR approximates the false positive rate from the bits set: a
+// key absent from the set passes only if all its hashes land on set bits
+func (bf *BloomFilter) estimatedFPR(setBits int) float64 {
+	return math.Pow(float64(setBits)/float64(bf.size), float64(bf.numHashes))
 }
@@ -71,6 +71,67 @@ func TestBloomFilter_FalsePositiveRate(t *testing.T) {
 	}
 }
 
+func TestBloomFilter_ZeroSecondHash(t *testing.T) {
+	bf := NewBloomFilter(1000, 0.01)
+
+	// FNV-1a is zero for roughly one key in 2^32, so drive the
+	// double hashing directly with hash2 == 0
+	for _, hash1 := range []uint32{0, 1, 12345, 0xdeadbeef} {
+		positions := make(map[uint32]bool)
+		for _, hash := range bf.deriveHashes(hash1, 0) {
+			positions[hash%bf.size] = true
+		}
+
+		if len(positions) < int(bf.numHashes)-1 {
+			t.Errorf("hash1=%d: expected ~%d distinct bit positions, got %d",
+				hash1, bf.numHashes, len(positions))
+		}
+	}
+
+	// Non-zero hash2 must hash exactly as before so persisted filters stay valid
+	hashes := bf.deriveHashes(100, 7)
+	for i, hash := range hashes {
+		if hash != 100+uint32(i)*7 {
+			t.Fatalf("h(%d): expected %d, got %d", i, 100+uint32(i)*7, hash)
+		}
+	}
+}
+
+func TestBloomFilter_FalsePositiveRateAcrossKeyShapes(t *testing.T) {
+	keyShapes := map[string]func(i int) []byte{
+		"sequential": func(i int) []byte { return []byte(fmt.Sprintf("key_%d", i)) },
+		"prefixed":   func(i int) []byte { return []byte(fmt.Sprintf("user:%08d:profile", i)) },
+		"binary": func(i int) []byte {
+			return []byte{byte(i), byte(i >> 8), byte(i >> 16), byte(i >> 24)}
+		},
+	}
+
+	for _, numElements := range []int{100, 1000, 10000} {
+		for name, makeKey := range keyShapes {
+			targetFPR := 0.01
+			bf := NewBloomFilter(numElements, targetFPR)
+
+			for i := 0; i < numElements; i++ {
+				bf.Add(makeKey(i))
+			}
+
+			testSize := 20000
+			falsePositives := 0
+			for i := numElements; i < numElements+testSize; i++ {
+				if bf.MayContain(makeKey(i)) {
+					falsePositives++
+				}
+			}
+
+			actualFPR := float64(falsePositives) / float64(testSize)
+			if actualFPR > targetFPR*3 {
+				t.Errorf("%s/%d: false positive rate spiked to %.2f%% (target: %.2f%%)",
+					name, numElements, actualFPR*100, targetFPR*100)
+			}
+		}
+	}
+}
+
 func TestBloomFilter_Serialization(t *testing.T) {
 	// Create and populate bloom filter
 	bf1 := NewBloomFilter(1000, 0.01)
@@ -130,6 +191,70 @@ func TestBloomFilter_Stats(t *testing.T) {
 	t.Logf("  Expected FPR: %.2f%%", stats["expected_fpr"].(float64)*100)
 }
 
+// Test: every read path counts its bloom filter probes toward the store's
+// totals, which BloomStats and Stats both report
+func TestLSMStore_BloomStatsCountsEveryReadPath(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Two tables covering the same key range, so absent keys between them
+	// get past the key range check of both
+	for table := 0; table < 2; table++ {
+		for i := table; i < 200; i += 2 {
+			if err := store.Put(fmt.Sprintf("key%03d", i), []byte("value")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := store.flushIfOlderThan(0); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if err := store.flushQueued(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	before := store.BloomStats()
+	if before.Tables != 2 || before.Filtered != 2 {
+		t.Fatalf("Expected 2 filtered tables, got %+v", before)
+	}
+	if before.FillRatio <= 0 || before.FillRatio >= 1 || before.EstimatedFPR >= 0.05 {
+		t.Errorf("Implausible fill ratio or FPR: %+v", before)
+	}
+
+	const absent = 50
+	for i := 0; i < absent; i++ {
+		key := fmt.Sprintf("key%03dx", 2+i*2)
+		if _, err := store.Get(key); err != ErrKeyNotFound {
+			t.Fatalf("Get(%s): expected ErrKeyNotFound, got %v", key, err)
+		}
+		if _, _, err := store.GetWithTimestamp(key); err != ErrKeyNotFound {
+			t.Fatalf("GetWithTimestamp(%s): expected ErrKeyNotFound, got %v", key, err)
+		}
+		if exists, err := store.Exists(key); err != nil || exists {
+			t.Fatalf("Exists(%s) = %v, %v", key, exists, err)
+		}
+	}
+
+	// Each path probes both tables once per key
+	after := store.BloomStats()
+	hits, misses := after.Hits-before.Hits, after.Misses-before.Misses
+	if hits+misses != 3*2*absent {
+		t.Errorf("Expected %d probes, got %d hits + %d misses", 3*2*absent, hits, misses)
+	}
+	if hits < 3*2*absent*9/10 {
+		t.Errorf("Expected nearly every probe of an absent key to skip the read, got %d hits", hits)
+	}
+
+	stats := store.Stats()
+	if stats["bloom_filter_hits"].(int64) != after.Hits || stats["bloom_filter_misses"].(int64) != after.Misses {
+		t.Errorf("Stats (%v/%v) and BloomStats (%d/%d) disagree",
+			stats["bloom_filter_hits"], stats["bloom_filter_misses"], after.Hits, after.Misses)
+	}
+}
+
 func BenchmarkBloomFilter_Add(b *testing.B) {
 	bf := NewBloomFilter(1000000, 0.01)
 	key := []byte("benchmark_key")
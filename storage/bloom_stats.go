@@ -0,0 +1,85 @@
+package storage
+
+import "sync/atomic"
+
+// bloomCounters tallies a store's bloom filter probes. Its SSTables all
+// share one, so Get, Exists and point scans add to the same totals.
+type bloomCounters struct {
+	hits   atomic.Int64 // Probes that ruled the key out, skipping a disk read
+	misses atomic.Int64 // Probes that sent the read on to the index
+}
+
+// mayContain probes the bloom filter, counting the outcome for the owning
+// store. A table without a filter may contain any key and counts nothing.
+func (s *SSTable) mayContain(key []byte) bool {
+	if s.bloomFilter == nil {
+		return true
+	}
+	ok := s.bloomFilter.MayContain(key)
+	if s.bloomCounts != nil {
+		if ok {
+			s.bloomCounts.misses.Add(1)
+		} else {
+			s.bloomCounts.hits.Add(1)
+		}
+	}
+	return ok
+}
+
+// BloomStats aggregates the bloom filters of every SSTable in a store
+type BloomStats struct {
+	Tables       int     // SSTables in the store
+	Filtered     int     // SSTables that have a bloom filter
+	SizeBytes    int     // Memory held by the filters
+	FillRatio    float64 // Bits set over all filters' bits
+	EstimatedFPR float64 // Mean estimated false positive rate of one table's filter
+	Hits         int64   // Probes that skipped a disk read (as in Stats' bloom_filter_hits)
+	Misses       int64   // Probes that went on to the index (bloom_filter_misses)
+}
+
+// HitRate is the share of probes that skipped a disk read (0 with none)
+func (b BloomStats) HitRate() float64 {
+	if total := b.Hits + b.Misses; total > 0 {
+		return float64(b.Hits) / float64(total)
+	}
+	return 0
+}
+
+// BloomStats aggregates the store's bloom filters: how full they are, the
+// false positive rate that implies, and how often they saved a read. A
+// lookup for an absent key probes every table whose key range covers it, so
+// it reaches disk about EstimatedFPR times per table probed.
+func (s *LSMStore) BloomStats() BloomStats {
+	s.mu.RLock()
+	sstables := make([]*SSTable, len(s.sstables))
+	copy(sstables, s.sstables)
+	s.mu.RUnlock()
+
+	stats := BloomStats{
+		Tables: len(sstables),
+		Hits:   s.bloom.hits.Load(),
+		Misses: s.bloom.misses.Load(),
+	}
+
+	var bitsSet, bitsTotal int
+	var fprSum float64
+	for _, sst := range sstables {
+		bf := sst.bloomFilter
+		if bf == nil {
+			continue
+		}
+		set := bf.bitsSet()
+		stats.Filtered++
+		stats.SizeBytes += len(bf.bits)
+		bitsSet += set
+		bitsTotal += int(bf.size)
+		fprSum += bf.estimatedFPR(set)
+	}
+	if bitsTotal > 0 {
+		stats.FillRatio = float64(bitsSet) / float64(bitsTotal)
+	}
+	if stats.Filtered > 0 {
+		stats.EstimatedFPR = fprSum / float64(stats.Filtered)
+	}
+	return stats
+}
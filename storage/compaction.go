@@ -2,41 +2,139 @@ package storage
 
 import (
 	"bytes"
+	"container/heap"
+	"encoding/json"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	// compactionStatsFile holds lifetime compaction counters in the data dir
+	compactionStatsFile = "compaction_stats.json"
+
+	// DefaultTombstoneTTL is how long compaction keeps a tombstone before
+	// purging it. It must outlive hinted handoff (24h) and read repair, so a
+	// replica that missed a delete learns about it before the tombstone is
+	// gone; purging earlier could let that replica resurrect the key.
+	DefaultTombstoneTTL = 48 * time.Hour
+
+	// compactionTableCount is how many SSTables trigger a compaction
+	compactionTableCount = 4
+
+	// DefaultTombstoneCompactionRatio is the share of purgeable tombstones
+	// among all SSTable entries that triggers a compaction before there are
+	// enough SSTables for the count-based trigger
+	DefaultTombstoneCompactionRatio = 0.3
+)
+
 // CompactionManager handles background compaction of SSTables
 type CompactionManager struct {
 	store          *LSMStore
 	stopCh         chan struct{}
 	wg             sync.WaitGroup
 	mu             sync.Mutex
+	compactMu      sync.Mutex // Serializes compactions (background loop and ForceCompact)
 	running        bool
 	compactionRate time.Duration
+	tombstoneTTL   atomic.Int64  // time.Duration; tombstones younger than this survive compaction
+	tombstoneRatio atomic.Uint64 // math.Float64bits of the tombstone trigger; 0 disables it
 	stats          CompactionStats
 }
 
-// CompactionStats tracks compaction metrics
+// CompactionStats tracks compaction metrics.
+// The counters are persisted to the data dir so they survive restarts.
 type CompactionStats struct {
-	TotalCompactions    int64
-	TotalBytesReclaimed int64
-	TotalKeysRemoved    int64
-	LastCompactionTime  time.Time
-	mu                  sync.RWMutex
+	TotalCompactions    int64        `json:"total_compactions"`
+	TotalBytesReclaimed int64        `json:"total_bytes_reclaimed"`
+	TotalKeysRemoved    int64        `json:"total_keys_removed"`
+	LastCompactionTime  time.Time    `json:"last_compaction_time"`
+	mu                  sync.RWMutex `json:"-"`
 }
 
-// NewCompactionManager creates a new compaction manager
+// NewCompactionManager creates a new compaction manager and loads the
+// persisted compaction statistics, if any
 func NewCompactionManager(store *LSMStore) *CompactionManager {
-	return &CompactionManager{
+	cm := &CompactionManager{
 		store:          store,
 		stopCh:         make(chan struct{}),
 		compactionRate: 30 * time.Second, // Run compaction every 30 seconds
 		stats:          CompactionStats{},
 	}
+
+	cm.tombstoneTTL.Store(int64(DefaultTombstoneTTL))
+	cm.tombstoneRatio.Store(math.Float64bits(DefaultTombstoneCompactionRatio))
+
+	if err := cm.loadStats(); err != nil {
+		log.Printf("⚠️  Failed to load compaction stats, starting from zero: %v", err)
+	}
+
+	return cm
+}
+
+// loadStats restores the compaction counters from the stats file
+func (cm *CompactionManager) loadStats() error {
+	data, err := os.ReadFile(filepath.Join(cm.store.dataDir, compactionStatsFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Decode into a separate value so a corrupt file leaves the stats zeroed
+	var loaded CompactionStats
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("corrupt stats file: %w", err)
+	}
+
+	cm.stats.mu.Lock()
+	defer cm.stats.mu.Unlock()
+
+	cm.stats.TotalCompactions = loaded.TotalCompactions
+	cm.stats.TotalBytesReclaimed = loaded.TotalBytesReclaimed
+	cm.stats.TotalKeysRemoved = loaded.TotalKeysRemoved
+	cm.stats.LastCompactionTime = loaded.LastCompactionTime
+	return nil
+}
+
+// saveStats writes the compaction counters to the stats file.
+// The file is written to a temp file and renamed so a crash never leaves a
+// partially written stats file behind.
+func (cm *CompactionManager) saveStats() error {
+	cm.stats.mu.RLock()
+	data, err := json.MarshalIndent(&cm.stats, "", "  ")
+	cm.stats.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(cm.store.dataDir, compactionStatsFile)
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
 }
 
 // Start begins the background compaction process
@@ -90,16 +188,28 @@ func (cm *CompactionManager) compactionLoop() {
 
 // maybeCompact checks if compaction is needed and performs it
 func (cm *CompactionManager) maybeCompact() error {
+	cm.compactMu.Lock()
+	defer cm.compactMu.Unlock()
+
 	cm.store.mu.RLock()
-	numSSTables := len(cm.store.sstables)
+	sstables := make([]*SSTable, len(cm.store.sstables))
+	copy(sstables, cm.store.sstables)
 	cm.store.mu.RUnlock()
-
-	// Trigger compaction if we have more than 4 SSTables
-	if numSSTables <= 4 {
+	numSSTables := len(sstables)
+
+	// Trigger compaction if we have more than 4 SSTables, or earlier if
+	// enough of what they hold is deletes that compaction can drop
+	ratio := cm.purgeableTombstoneRatio(sstables)
+	threshold := math.Float64frombits(cm.tombstoneRatio.Load())
+	switch {
+	case numSSTables > compactionTableCount:
+		log.Printf("🔄 Starting compaction (%d SSTables)", numSSTables)
+	case threshold > 0 && ratio >= threshold:
+		log.Printf("🔄 Starting compaction (%d SSTables, %.0f%% purgeable tombstones)", numSSTables, ratio*100)
+	default:
 		return nil
 	}
 
-	log.Printf("🔄 Starting compaction (%d SSTables)", numSSTables)
 	startTime := time.Now()
 
 	if err := cm.compact(); err != nil {
@@ -109,16 +219,11 @@ func (cm *CompactionManager) maybeCompact() error {
 	duration := time.Since(startTime)
 	log.Printf("✅ Compaction completed in %v", duration)
 
-	cm.stats.mu.Lock()
-	cm.stats.TotalCompactions++
-	cm.stats.LastCompactionTime = time.Now()
-	cm.stats.mu.Unlock()
-
 	return nil
 }
 
-// compact performs the actual compaction
-func (cm *CompactionManager) compact() error {
+// compact performs the actual compaction (must be called with compactMu held)
+func (cm *CompactionManager) compact() (err error) {
 	cm.store.mu.Lock()
 
 	// Select SSTables to compact (all of them in simple size-tiered compaction)
@@ -128,6 +233,16 @@ func (cm *CompactionManager) compact() error {
 		return nil
 	}
 
+	start := time.Now()
+	defer func() {
+		result := "ok"
+		if err != nil {
+			result = "error"
+		}
+		cm.store.metrics.IncCounter(MetricCompactions, "result", result)
+		cm.store.metrics.ObserveLatency(MetricCompactionLatency, time.Since(start))
+	}()
+
 	// Create copies of SSTable references
 	compactTables := make([]*SSTable, len(sstablesToCompact))
 	copy(compactTables, sstablesToCompact)
@@ -138,39 +253,63 @@ func (cm *CompactionManager) compact() error {
 
 	cm.store.mu.Unlock()
 
-	// Perform merge (without holding locks for I/O)
-	mergedEntries, stats, err := cm.mergeSSTables(compactTables)
+	// Stream the merge into the new SSTable (without holding locks for I/O)
+	writer, err := NewSSTableWriter(cm.store.sstableDir, newTableID)
 	if err != nil {
-		return fmt.Errorf("failed to merge SSTables: %w", err)
+		return fmt.Errorf("failed to create new SSTable: %w", diskWriteError(err))
 	}
-
-	// Write merged data to new SSTable
-	writer, err := NewSSTableWriter(cm.store.dataDir, newTableID)
-	if err != nil {
-		return fmt.Errorf("failed to create new SSTable: %w", err)
+	if cm.store.sstableOutput != nil {
+		writer.writer.Reset(cm.store.sstableOutput(writer.file))
 	}
 
-	for _, entry := range mergedEntries {
-		if err := writer.Write(entry.Key, entry.Value); err != nil {
-			return fmt.Errorf("failed to write entry: %w", err)
+	stats, err := cm.mergeSSTables(compactTables, func(entry Entry) error {
+		if err := writer.writeRecord(entry.Key, entry.Value, entry.Timestamp, entry.Flags); err != nil {
+			return fmt.Errorf("failed to write entry: %w", diskWriteError(err))
 		}
+		return nil
+	})
+	if err != nil {
+		writer.abort()
+		return fmt.Errorf("failed to merge SSTables: %w", err)
 	}
 
+	// As with a flush, a failed write leaves no partial table behind and
+	// the tables being compacted in place
 	if err := writer.Finalize(); err != nil {
-		return fmt.Errorf("failed to finalize SSTable: %w", err)
+		writer.abort()
+		return fmt.Errorf("failed to finalize SSTable: %w", diskWriteError(err))
 	}
 
 	// Open the new compacted SSTable
 	newSSTable, err := OpenSSTable(writer.filePath)
 	if err != nil {
+		os.Remove(writer.filePath)
 		return fmt.Errorf("failed to open new SSTable: %w", err)
 	}
-
-	// Update store: replace old SSTables with new one
-	cm.store.mu.Lock()
-
-	// Remove old SSTables from the list
-	cm.store.sstables = []*SSTable{newSSTable}
+	cm.store.adoptSSTable(newSSTable)
+
+	// Update store: replace old SSTables with new one, keeping any flushed
+	// during the merge. The old tables stay on disk until the manifest no
+	// longer lists them.
+	compacted := make(map[*SSTable]bool, len(compactTables))
+	for _, sst := range compactTables {
+		compacted[sst] = true
+	}
+	numSSTables := 0
+	if err := cm.store.publishSSTables(func(current []*SSTable) []*SSTable {
+		remaining := make([]*SSTable, 0, len(current))
+		for _, sst := range current {
+			if !compacted[sst] {
+				remaining = append(remaining, sst)
+			}
+		}
+		numSSTables = len(remaining) + 1
+		return append(remaining, newSSTable)
+	}); err != nil {
+		os.Remove(newSSTable.FilePath())
+		return fmt.Errorf("failed to record compaction in the manifest: %w", diskWriteError(err))
+	}
+	cm.store.metrics.SetGauge(MetricSSTables, float64(numSSTables))
 
 	// Get file paths of old SSTables for deletion
 	oldFiles := make([]string, len(compactTables))
@@ -178,8 +317,6 @@ func (cm *CompactionManager) compact() error {
 		oldFiles[i] = sst.FilePath()
 	}
 
-	cm.store.mu.Unlock()
-
 	// Delete old SSTable files
 	for _, filePath := range oldFiles {
 		if err := os.Remove(filePath); err != nil {
@@ -189,10 +326,16 @@ func (cm *CompactionManager) compact() error {
 
 	// Update stats
 	cm.stats.mu.Lock()
+	cm.stats.TotalCompactions++
 	cm.stats.TotalKeysRemoved += stats.KeysRemoved
 	cm.stats.TotalBytesReclaimed += stats.BytesReclaimed
+	cm.stats.LastCompactionTime = time.Now()
 	cm.stats.mu.Unlock()
 
+	if err := cm.saveStats(); err != nil {
+		log.Printf("⚠️  Failed to persist compaction stats: %v", err)
+	}
+
 	log.Printf("📊 Compaction stats: %d keys removed, %d bytes reclaimed",
 		stats.KeysRemoved, stats.BytesReclaimed)
 
@@ -205,90 +348,111 @@ type MergeStats struct {
 	BytesReclaimed int64
 }
 
-// mergeSSTables merges multiple SSTables into a single sorted list
-func (cm *CompactionManager) mergeSSTables(sstables []*SSTable) ([]Entry, *MergeStats, error) {
-	// Collect all entries from all SSTables
-	type keyEntry struct {
-		key      string
-		value    []byte
-		tableIdx int // Which SSTable this came from (lower = newer)
-	}
+// SetTombstoneTTL sets how old a tombstone must be before compaction purges
+// it; younger tombstones are carried forward. 0 purges them immediately.
+func (cm *CompactionManager) SetTombstoneTTL(ttl time.Duration) {
+	cm.tombstoneTTL.Store(int64(ttl))
+}
 
-	allEntries := make(map[string]*keyEntry)
-	totalOriginalSize := int64(0)
+// SetTombstoneCompactionRatio sets the share of purgeable tombstones that
+// triggers an early compaction (see DefaultTombstoneCompactionRatio);
+// 0 disables the tombstone trigger, leaving only the table count.
+func (cm *CompactionManager) SetTombstoneCompactionRatio(ratio float64) {
+	cm.tombstoneRatio.Store(math.Float64bits(ratio))
+}
 
-	// Read all entries from all SSTables
-	for tableIdx, sst := range sstables {
-		for _, indexEntry := range sst.index {
-			key := string(indexEntry.Key)
-			value, found, err := sst.Get(indexEntry.Key)
-			if err != nil {
-				return nil, nil, fmt.Errorf("failed to read from SSTable: %w", err)
-			}
-			if !found {
-				continue
-			}
+// purgeableTombstoneRatio returns the share of entries across sstables that
+// are tombstones compaction would drop. A table counts only once all its
+// tombstones are past the TTL: tombstones compaction must carry forward
+// would otherwise keep the ratio high and retrigger it on every check.
+func (cm *CompactionManager) purgeableTombstoneRatio(sstables []*SSTable) float64 {
+	purgeBefore := time.Now().Add(-time.Duration(cm.tombstoneTTL.Load())).UnixNano()
+
+	var entries, tombstones int
+	for _, sst := range sstables {
+		entries += sst.NumEntries()
+		if sst.newestTombstone <= purgeBefore {
+			tombstones += sst.NumTombstones()
+		}
+	}
 
-			totalOriginalSize += int64(len(indexEntry.Key) + len(value))
+	if entries == 0 {
+		return 0
+	}
+	return float64(tombstones) / float64(entries)
+}
 
-			// Keep the newest version (lower tableIdx = newer)
-			if existing, exists := allEntries[key]; !exists || tableIdx < existing.tableIdx {
-				allEntries[key] = &keyEntry{
-					key:      key,
-					value:    value,
-					tableIdx: tableIdx,
-				}
-			}
+// mergeSSTables merges SSTables (ordered newest to oldest) with a k-way
+// merge over per-table iterators, passing each surviving entry to emit in
+// key order. Of the versions of a key, the one with the latest timestamp
+// survives; on a tie (or for tables written before timestamps) the newest
+// table's wins. Only one entry per table is held in memory at a time, so
+// memory grows with the number of tables rather than the data size.
+func (cm *CompactionManager) mergeSSTables(sstables []*SSTable, emit func(entry Entry) error) (*MergeStats, error) {
+	h := make(mergeHeap, 0, len(sstables))
+	defer func() {
+		for _, c := range h {
+			c.src.Close()
 		}
-	}
+	}()
 
-	// Filter out tombstones and convert to sorted list
-	var result []Entry
-	tombstone := []byte("__TOMBSTONE__")
-	keysRemoved := int64(0)
+	for tableIdx, sst := range sstables {
+		it, err := sst.newIterator()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open SSTable: %w", err)
+		}
 
-	for _, entry := range allEntries {
-		// Skip tombstones (deleted keys)
-		if bytes.Equal(entry.value, tombstone) {
-			keysRemoved++
+		entry, ok, err := it.Next()
+		if err != nil || !ok {
+			it.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read from SSTable: %w", err)
+			}
 			continue
 		}
-
-		result = append(result, Entry{
-			Key:   []byte(entry.key),
-			Value: entry.value,
-		})
+		h = append(h, &mergeCursor{src: it, entry: entry, age: tableIdx})
 	}
+	heap.Init(&h)
 
-	// Sort by key
-	sortEntries(result)
+	purgeBefore := time.Now().Add(-time.Duration(cm.tombstoneTTL.Load())).UnixNano()
+	stats := &MergeStats{}
 
-	// Calculate bytes reclaimed
-	newSize := int64(0)
-	for _, entry := range result {
-		newSize += int64(len(entry.Key) + len(entry.Value))
-	}
-	bytesReclaimed := totalOriginalSize - newSize
-
-	stats := &MergeStats{
-		KeysRemoved:    keysRemoved,
-		BytesReclaimed: bytesReclaimed,
-	}
+	for h.Len() > 0 {
+		// The top of the heap is the newest version of the smallest key
+		newest := h[0].entry
 
-	return result, stats, nil
-}
+		// Consume every version of this key
+		for h.Len() > 0 && bytes.Equal(h[0].entry.Key, newest.Key) {
+			c := h[0]
+			stats.BytesReclaimed += int64(len(c.entry.Key) + len(c.entry.Value))
 
-// sortEntries sorts entries by key
-func sortEntries(entries []Entry) {
-	// Simple bubble sort for now (could use sort.Slice for better performance)
-	n := len(entries)
-	for i := 0; i < n-1; i++ {
-		for j := 0; j < n-i-1; j++ {
-			if bytes.Compare(entries[j].Key, entries[j+1].Key) > 0 {
-				entries[j], entries[j+1] = entries[j+1], entries[j]
+			entry, ok, err := c.src.Next()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read from SSTable: %w", err)
+			}
+			if ok {
+				c.entry = entry
+				heap.Fix(&h, 0)
+			} else {
+				c.src.Close()
+				heap.Pop(&h)
 			}
 		}
+
+		// Tombstones within the grace period are kept so the delete still
+		// shadows older versions on lagging replicas
+		if newest.Op == OpDelete && newest.Timestamp <= purgeBefore {
+			stats.KeysRemoved++
+			continue
+		}
+
+		if err := emit(newest); err != nil {
+			return nil, err
+		}
+		stats.BytesReclaimed -= int64(len(newest.Key) + len(newest.Value))
 	}
+
+	return stats, nil
 }
 
 // GetStats returns compaction statistics
@@ -304,8 +468,16 @@ func (cm *CompactionManager) GetStats() map[string]interface{} {
 	}
 }
 
-// ForceCompact triggers an immediate compaction (useful for testing)
+// ForceCompact triggers an immediate compaction (useful for testing).
+// If a background compaction is in progress, it blocks until that finishes.
 func (cm *CompactionManager) ForceCompact() error {
+	if cm.store.readOnly {
+		return ErrReadOnly
+	}
+
+	cm.compactMu.Lock()
+	defer cm.compactMu.Unlock()
+
 	log.Println("🔄 Forcing compaction...")
 	return cm.compact()
 }
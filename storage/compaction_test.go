@@ -1,7 +1,16 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -78,6 +87,7 @@ func TestCompaction_TombstoneRemoval(t *testing.T) {
 		t.Fatalf("Failed to create store: %v", err)
 	}
 	defer store.Close()
+	store.SetTombstoneTTL(0)
 
 	// Write and delete many keys to create tombstones
 	numKeys := 1000
@@ -96,10 +106,8 @@ func TestCompaction_TombstoneRemoval(t *testing.T) {
 		}
 	}
 
-	// Force flush to create SSTables with tombstones
-	if err := store.maybeFlush(); err != nil {
-		t.Fatalf("Flush failed: %v", err)
-	}
+	// Flush to create an SSTable with tombstones
+	flushMemTableForTest(t, store)
 
 	// Write more data to create another SSTable
 	for i := numKeys; i < numKeys*2; i++ {
@@ -109,9 +117,7 @@ func TestCompaction_TombstoneRemoval(t *testing.T) {
 		}
 	}
 
-	if err := store.maybeFlush(); err != nil {
-		t.Fatalf("Flush failed: %v", err)
-	}
+	flushMemTableForTest(t, store)
 
 	// Get initial stats
 	statsBefore := store.compactionMgr.GetStats()
@@ -200,6 +206,488 @@ func TestCompaction_AutomaticTrigger(t *testing.T) {
 	}
 }
 
+func TestCompaction_ConcurrentForceAndBackground(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Create 6 small SSTables so the background check wants to compact
+	for table := 0; table < 6; table++ {
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key_%d_%d", table, i)
+			if err := store.Put(key, []byte("value")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		flushMemTableForTest(t, store)
+	}
+
+	// Run manual and background compactions at the same time
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- store.compactionMgr.ForceCompact()
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- store.compactionMgr.maybeCompact()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent compaction failed: %v", err)
+		}
+	}
+
+	// Every SSTable the store knows about must still exist on disk, and
+	// no stale files should be left behind
+	files, _ := filepath.Glob(filepath.Join(tmpDir, "sstable_*.db"))
+	store.mu.RLock()
+	numSSTables := len(store.sstables)
+	store.mu.RUnlock()
+	if len(files) != numSSTables {
+		t.Errorf("Expected %d SSTable files on disk, found %d", numSSTables, len(files))
+	}
+
+	for table := 0; table < 6; table++ {
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("key_%d_%d", table, i)
+			if _, err := store.Get(key); err != nil {
+				t.Fatalf("Key %s lost after concurrent compaction: %v", key, err)
+			}
+		}
+	}
+}
+
+func TestCompaction_StatsPersistAcrossRestart(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store.SetTombstoneTTL(0)
+
+	for i := 0; i < 100; i++ {
+		if err := store.Put(fmt.Sprintf("key_%d", i), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	flushMemTableForTest(t, store)
+
+	for i := 0; i < 10; i++ {
+		if err := store.Delete(fmt.Sprintf("key_%d", i)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	}
+	flushMemTableForTest(t, store)
+
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+
+	statsBefore := store.compactionMgr.GetStats()
+	if statsBefore["total_compactions"].(int64) != 1 {
+		t.Fatalf("Expected 1 compaction, got %v", statsBefore["total_compactions"])
+	}
+	if statsBefore["total_keys_removed"].(int64) == 0 {
+		t.Fatal("Expected tombstones to be removed")
+	}
+	store.Close()
+
+	// "Restart" the store
+	store2, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	statsAfter := store2.compactionMgr.GetStats()
+	for _, key := range []string{"total_compactions", "total_bytes_reclaimed", "total_keys_removed", "last_compaction"} {
+		if statsAfter[key] != statsBefore[key] {
+			t.Errorf("%s: expected %v after restart, got %v", key, statsBefore[key], statsAfter[key])
+		}
+	}
+}
+
+func TestCompaction_CorruptStatsFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(tmpDir, compactionStatsFile), []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write stats file: %v", err)
+	}
+
+	// A corrupt stats file must not prevent the store from opening
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if n := store.compactionMgr.GetStats()["total_compactions"].(int64); n != 0 {
+		t.Errorf("Expected counters to start from zero, got %d compactions", n)
+	}
+}
+
+func TestCompaction_TombstoneGracePeriod(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+	store.SetTombstoneTTL(time.Hour)
+
+	for _, key := range []string{"fresh", "live"} {
+		if err := store.Put(key, []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	// Versions are ordered by timestamp, so the write the backdated delete
+	// below shadows must be older still
+	store.mu.Lock()
+	store.memTable.Put([]byte("old"), []byte("value"), time.Now().Add(-3*time.Hour).UnixNano())
+	store.mu.Unlock()
+	flushMemTableForTest(t, store)
+
+	// One delete happens now, the other is backdated past the grace period
+	if err := store.Delete("fresh"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	store.mu.Lock()
+	store.memTable.Delete([]byte("old"), time.Now().Add(-2*time.Hour).UnixNano())
+	store.mu.Unlock()
+	flushMemTableForTest(t, store)
+
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+
+	if n := store.compactionMgr.GetStats()["total_keys_removed"].(int64); n != 1 {
+		t.Errorf("Expected only the old tombstone to be purged, got %d removed", n)
+	}
+
+	entries, err := store.sstables[0].Range(nil, nil, false)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	found := make(map[string]Entry)
+	for _, entry := range entries {
+		found[string(entry.Key)] = entry
+	}
+	if found["fresh"].Op != OpDelete {
+		t.Errorf("Expected the fresh tombstone to be carried forward, got %+v", found["fresh"])
+	}
+	if _, ok := found["old"]; ok {
+		t.Error("Expected the old tombstone to be purged")
+	}
+
+	for _, key := range []string{"fresh", "old"} {
+		if _, err := store.Get(key); err != ErrKeyNotFound {
+			t.Errorf("Deleted key %s visible after compaction: %v", key, err)
+		}
+	}
+	if _, err := store.Get("live"); err != nil {
+		t.Errorf("Live key lost in compaction: %v", err)
+	}
+}
+
+func TestCompaction_TombstoneRatioTrigger(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	compactions := func() int64 {
+		return store.compactionMgr.GetStats()["total_compactions"].(int64)
+	}
+	dataSize := func() int64 {
+		var total int64
+		for _, sst := range store.sstables {
+			info, err := os.Stat(sst.FilePath())
+			if err != nil {
+				t.Fatal(err)
+			}
+			total += info.Size()
+		}
+		return total
+	}
+
+	const numKeys = 1000
+	value := make([]byte, 100)
+	for i := 0; i < numKeys; i++ {
+		if err := store.Put(fmt.Sprintf("key_%04d", i), value); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	flushMemTableForTest(t, store)
+
+	// Delete 90% of the keys into a second SSTable
+	for i := 0; i < numKeys*9/10; i++ {
+		if err := store.Delete(fmt.Sprintf("key_%04d", i)); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+	}
+	flushMemTableForTest(t, store)
+
+	if n := store.sstables[0].NumTombstones(); n != numKeys*9/10 {
+		t.Fatalf("Expected %d tombstones in the footer, got %d", numKeys*9/10, n)
+	}
+
+	// Two SSTables are well below the count trigger, and tombstones inside
+	// the grace period can't be dropped, so nothing happens yet
+	if err := store.compactionMgr.maybeCompact(); err != nil {
+		t.Fatalf("maybeCompact failed: %v", err)
+	}
+	if n := compactions(); n != 0 {
+		t.Fatalf("Expected no compaction with young tombstones, got %d", n)
+	}
+
+	// Once the tombstones are purgeable the ratio triggers a compaction
+	store.SetTombstoneTTL(0)
+	sizeBefore := dataSize()
+	if err := store.compactionMgr.maybeCompact(); err != nil {
+		t.Fatalf("maybeCompact failed: %v", err)
+	}
+	if n := compactions(); n != 1 {
+		t.Fatalf("Expected a tombstone-driven compaction, got %d", n)
+	}
+
+	if n := len(store.sstables); n != 1 {
+		t.Errorf("Expected 1 SSTable after compaction, got %d", n)
+	}
+	if removed := store.compactionMgr.GetStats()["total_keys_removed"].(int64); removed != numKeys*9/10 {
+		t.Errorf("Expected %d keys removed, got %d", numKeys*9/10, removed)
+	}
+	if sizeAfter := dataSize(); sizeAfter >= sizeBefore/2 {
+		t.Errorf("Expected space to be reclaimed: %d bytes before, %d after", sizeBefore, sizeAfter)
+	}
+	if _, err := store.Get("key_0000"); err != ErrKeyNotFound {
+		t.Errorf("Expected deleted key to stay deleted, got %v", err)
+	}
+	if _, err := store.Get(fmt.Sprintf("key_%04d", numKeys-1)); err != nil {
+		t.Errorf("Get of a surviving key failed: %v", err)
+	}
+
+	// The compacted table has no tombstones left, so it doesn't retrigger
+	if err := store.compactionMgr.maybeCompact(); err != nil {
+		t.Fatalf("maybeCompact failed: %v", err)
+	}
+	if n := compactions(); n != 1 {
+		t.Errorf("Expected no further compaction, got %d", n)
+	}
+}
+
+func TestCompaction_MergeNewestWinsInKeyOrder(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Three overlapping generations: each overwrites every third key of the last
+	for gen := 0; gen < 3; gen++ {
+		for i := gen; i < 300; i += gen + 1 {
+			if err := store.Put(fmt.Sprintf("key_%03d", i), []byte(fmt.Sprintf("gen%d", gen))); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		flushMemTableForTest(t, store)
+	}
+
+	expected := make(map[string]string)
+	for gen := 0; gen < 3; gen++ {
+		for i := gen; i < 300; i += gen + 1 {
+			expected[fmt.Sprintf("key_%03d", i)] = fmt.Sprintf("gen%d", gen)
+		}
+	}
+
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+	if len(store.sstables) != 1 {
+		t.Fatalf("Expected 1 SSTable after compaction, got %d", len(store.sstables))
+	}
+
+	entries, err := store.sstables[0].Range(nil, nil, false)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, entry := range entries {
+		if i > 0 && bytes.Compare(entries[i-1].Key, entry.Key) >= 0 {
+			t.Fatalf("Entries out of order: %s before %s", entries[i-1].Key, entry.Key)
+		}
+		if want := expected[string(entry.Key)]; string(entry.Value) != want {
+			t.Errorf("%s: expected %s, got %s", entry.Key, want, entry.Value)
+		}
+	}
+}
+
+func TestCompaction_MergeResolvesByTimestamp(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// writeTable writes one SSTable holding key -> value at timestamp
+	writeTable := func(value string, timestamp int64) *SSTable {
+		t.Helper()
+
+		store.mu.Lock()
+		tableID := store.nextTableID
+		store.nextTableID++
+		store.mu.Unlock()
+
+		writer, err := NewSSTableWriter(store.sstableDir, tableID)
+		if err != nil {
+			t.Fatalf("Failed to create writer: %v", err)
+		}
+		if err := writer.Write([]byte("key"), []byte(value), timestamp); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		if err := writer.Finalize(); err != nil {
+			t.Fatalf("Finalize failed: %v", err)
+		}
+		sst, err := OpenSSTable(writer.filePath)
+		if err != nil {
+			t.Fatalf("OpenSSTable failed: %v", err)
+		}
+		return sst
+	}
+
+	// The table in the newer position holds the older write, as a table
+	// reordered by an earlier compaction could
+	older := writeTable("old", 100)
+	newer := writeTable("new", 200)
+	store.mu.Lock()
+	store.sstables = []*SSTable{older, newer}
+	store.mu.Unlock()
+
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+
+	entries, err := store.sstables[0].Range(nil, nil, false)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	if len(entries) != 1 || string(entries[0].Value) != "new" || entries[0].Timestamp != 200 {
+		t.Fatalf("Expected the write at 200 to win, got %+v", entries)
+	}
+	if value, err := store.Get("key"); err != nil || string(value) != "new" {
+		t.Errorf("Get = %q, %v; expected new", value, err)
+	}
+}
+
+// flushMemTableForTest flushes the current MemTable regardless of its size
+func flushMemTableForTest(t *testing.T, store *LSMStore) {
+	t.Helper()
+
+	store.mu.Lock()
+	memTable := store.memTable
+	store.memTable = NewMemTable()
+	tableID := store.nextTableID
+	store.nextTableID++
+	store.mu.Unlock()
+
+	if err := store.flushToDisk(memTable, tableID); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.wal.Reset(); err != nil {
+		t.Fatalf("WAL reset failed: %v", err)
+	}
+}
+
+// BenchmarkCompaction_MergeMemory reports the peak heap while merging
+// 8 tables of 1KB values (~40MB). "streaming" discards entries as they are
+// emitted, like the SSTable writer; "materialized" collects them the way the
+// merge did before it was streamed.
+func BenchmarkCompaction_MergeMemory(b *testing.B) {
+	store, err := NewLSMStore(b.TempDir())
+	if err != nil {
+		b.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	value := make([]byte, 1024)
+	for table := 0; table < 8; table++ {
+		for i := 0; i < 5000; i++ {
+			store.Put(fmt.Sprintf("key_%d_%05d", table, i), value)
+		}
+		store.mu.Lock()
+		memTable := store.memTable
+		store.memTable = NewMemTable()
+		tableID := store.nextTableID
+		store.nextTableID++
+		store.mu.Unlock()
+		if err := store.flushToDisk(memTable, tableID); err != nil {
+			b.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	// newEmit returns a fresh sink for each merge
+	run := func(b *testing.B, newEmit func() func(entry Entry) error) {
+		var peak atomic.Uint64
+		for i := 0; i < b.N; i++ {
+			runtime.GC()
+			var base runtime.MemStats
+			runtime.ReadMemStats(&base)
+
+			done := make(chan struct{})
+			go func() {
+				var m runtime.MemStats
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+					runtime.ReadMemStats(&m)
+					if m.HeapAlloc > base.HeapAlloc && m.HeapAlloc-base.HeapAlloc > peak.Load() {
+						peak.Store(m.HeapAlloc - base.HeapAlloc)
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}()
+
+			if _, err := store.compactionMgr.mergeSSTables(store.sstables, newEmit()); err != nil {
+				b.Fatalf("Merge failed: %v", err)
+			}
+			close(done)
+		}
+		b.ReportMetric(float64(peak.Load())/(1024*1024), "peak-heap-MB")
+	}
+
+	b.Run("streaming", func(b *testing.B) {
+		run(b, func() func(entry Entry) error {
+			return func(entry Entry) error { return nil }
+		})
+	})
+	b.Run("materialized", func(b *testing.B) {
+		run(b, func() func(entry Entry) error {
+			var entries []Entry
+			return func(entry Entry) error {
+				entries = append(entries, entry)
+				return nil
+			}
+		})
+	})
+}
+
 func BenchmarkCompaction(b *testing.B) {
 	tmpDir := b.TempDir()
 
@@ -213,17 +701,74 @@ func BenchmarkCompaction(b *testing.B) {
 	}
 
 	// Force creation of multiple SSTables
-	store.maybeFlush()
+	store.Flush()
 
 	for i := 10000; i < 20000; i++ {
 		key := fmt.Sprintf("key_%d", i)
 		store.Put(key, []byte("value"))
 	}
 
-	store.maybeFlush()
+	store.Flush()
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		store.compactionMgr.ForceCompact()
 	}
 }
+
+func TestCompaction_DiskFullKeepsTables(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for table := 0; table < 2; table++ {
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("key%d", i)
+			if err := store.Put(key, []byte(fmt.Sprintf("value%d_%d", i, table))); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := store.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+	before, _ := filepath.Glob(filepath.Join(dir, "sstable_*.db"))
+	if len(before) != 2 {
+		t.Fatalf("Expected 2 SSTables, found %v", before)
+	}
+
+	// The merged table fits in the write buffer, so the disk fills up when
+	// it is finalized
+	store.sstableOutput = func(w io.Writer) io.Writer {
+		return &failingWriter{w: w, limit: 0, err: syscall.ENOSPC}
+	}
+	err = store.compactionMgr.ForceCompact()
+	if !errors.Is(err, ErrDiskFull) || !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("Expected ErrDiskFull wrapping ENOSPC, got %v", err)
+	}
+
+	if after, _ := filepath.Glob(filepath.Join(dir, "sstable_*.db")); len(after) != len(before) {
+		t.Errorf("Expected the partial SSTable removed, had %v, now %v", before, after)
+	}
+	if stats := store.Stats(); stats["num_sstables"] != 2 {
+		t.Errorf("Expected both SSTables kept, got %v", stats["num_sstables"])
+	}
+	if value, err := store.Get("key3"); err != nil || string(value) != "value3_1" {
+		t.Errorf("Get(key3) after failed compaction = %q, %v", value, err)
+	}
+
+	// Space is freed: compaction goes through
+	store.sstableOutput = nil
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Retried compaction failed: %v", err)
+	}
+	if stats := store.Stats(); stats["num_sstables"] != 1 {
+		t.Errorf("Expected one SSTable after compaction, got %v", stats["num_sstables"])
+	}
+	if value, err := store.Get("key3"); err != nil || string(value) != "value3_1" {
+		t.Errorf("Get(key3) after compaction = %q, %v", value, err)
+	}
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// hotKeySampleEvery is how many reads and writes go by per one counted,
+	// so tracking costs one atomic add on most operations
+	hotKeySampleEvery = 8
+
+	// hotKeyCapacity bounds the keys tracked per window. Once full, a new
+	// key replaces the least counted one and inherits its count (the
+	// Space-Saving algorithm), so a key that is truly hot can't be crowded
+	// out, at the price of overestimating newcomers.
+	hotKeyCapacity = 256
+
+	// hotKeyWindow is how long each window of key counts lasts
+	hotKeyWindow = 10 * time.Second
+
+	// hotKeysLogged caps the keys logged for one window
+	hotKeysLogged = 5
+)
+
+// KeyCount is a key and about how many reads and writes it has had
+type KeyCount struct {
+	Key   string
+	Count int64 // Estimated from samples, so a multiple of the sample rate
+}
+
+// hotKeyTracker samples the keys a store reads and writes and keeps
+// approximate counts of the busiest in two windows: the one filling now and
+// the last complete one, so HotKeys always covers at least one full window.
+// Windows roll over on the first sample after they end.
+type hotKeyTracker struct {
+	ops atomic.Uint64 // Operations seen; every hotKeySampleEvery-th is counted
+
+	mu          sync.Mutex
+	current     map[string]int64 // key -> sampled count this window
+	previous    map[string]int64 // key -> sampled count last window
+	windowStart time.Time
+	threshold   float64 // ops/s above which a window's hot keys are logged (0: never)
+}
+
+// record notes one read or write of key
+func (t *hotKeyTracker) record(key string) {
+	if t.ops.Add(1)%hotKeySampleEvery != 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotateLocked(time.Now())
+	if _, ok := t.current[key]; ok || len(t.current) < hotKeyCapacity {
+		t.current[key]++
+		return
+	}
+
+	// Full: take over the least counted key's slot
+	minKey, minCount := "", int64(-1)
+	for k, c := range t.current {
+		if minCount < 0 || c < minCount {
+			minKey, minCount = k, c
+		}
+	}
+	delete(t.current, minKey)
+	t.current[key] = minCount + 1
+}
+
+// rotateLocked starts a new window if the current one has ended, logging
+// its hot keys if any went over the threshold (must be called with mu held)
+func (t *hotKeyTracker) rotateLocked(now time.Time) {
+	if t.current == nil {
+		t.current = make(map[string]int64)
+		t.windowStart = now
+		return
+	}
+	elapsed := now.Sub(t.windowStart)
+	if elapsed < hotKeyWindow {
+		return
+	}
+
+	if t.threshold > 0 {
+		hot := topKeyCounts(t.current, hotKeysLogged)
+		if len(hot) > 0 && float64(hot[0].Count)/elapsed.Seconds() > t.threshold {
+			log.Printf("🔥 Hot keys over %g ops/s in the last %v:", t.threshold, elapsed.Round(time.Second))
+			for _, kc := range hot {
+				log.Printf("   %q: ~%.0f ops/s", kc.Key, float64(kc.Count)/elapsed.Seconds())
+			}
+		}
+	}
+
+	// A window with no samples after it is no longer recent
+	t.previous = t.current
+	if elapsed >= 2*hotKeyWindow {
+		t.previous = nil
+	}
+	t.current = make(map[string]int64, len(t.previous))
+	t.windowStart = now
+}
+
+// top returns the n busiest keys of the last two windows, busiest first
+func (t *hotKeyTracker) top(n int) []KeyCount {
+	t.mu.Lock()
+	t.rotateLocked(time.Now())
+	merged := make(map[string]int64, len(t.current)+len(t.previous))
+	for k, c := range t.previous {
+		merged[k] += c
+	}
+	for k, c := range t.current {
+		merged[k] += c
+	}
+	t.mu.Unlock()
+
+	return topKeyCounts(merged, n)
+}
+
+// topKeyCounts turns sampled counts into the n largest estimates, ties
+// broken by key so the order is stable
+func topKeyCounts(counts map[string]int64, n int) []KeyCount {
+	keys := make([]KeyCount, 0, len(counts))
+	for k, c := range counts {
+		keys = append(keys, KeyCount{Key: k, Count: c * hotKeySampleEvery})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n < len(keys) {
+		keys = keys[:max(n, 0)]
+	}
+	return keys
+}
+
+// HotKeys returns the n most read and written keys of the last 10-20
+// seconds, busiest first. Counts are estimates: only one operation in
+// hotKeySampleEvery is counted, and only the busiest few hundred keys are
+// tracked at all. Namespaced keys appear with their namespace prefix.
+func (s *LSMStore) HotKeys(n int) []KeyCount {
+	return s.hotKeys.top(n)
+}
+
+// SetHotKeyThreshold logs the busiest keys whenever a key averaged more
+// than opsPerSecond reads and writes over a 10-second window, to spot a key
+// hot enough to bottleneck the replicas holding it. 0 turns logging off;
+// keys are tracked for HotKeys either way.
+func (s *LSMStore) SetHotKeyThreshold(opsPerSecond float64) {
+	s.hotKeys.mu.Lock()
+	defer s.hotKeys.mu.Unlock()
+	s.hotKeys.threshold = opsPerSecond
+}
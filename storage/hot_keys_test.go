@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLSMStore_HotKeys(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// One celebrity key among many quiet ones
+	for i := 0; i < 300; i++ {
+		if err := store.Put(fmt.Sprintf("user:%d", i), []byte("profile")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	for i := 0; i < 2000; i++ {
+		if i%5 == 0 {
+			if err := store.Put("user:celebrity", []byte("profile")); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+			continue
+		}
+		if _, err := store.Get("user:celebrity"); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+
+	hot := store.HotKeys(3)
+	if len(hot) != 3 {
+		t.Fatalf("Expected 3 hot keys, got %v", hot)
+	}
+	if hot[0].Key != "user:celebrity" {
+		t.Fatalf("Expected user:celebrity to be the hottest key, got %v", hot)
+	}
+	// Sampling one in eight puts the estimate within a sample of the truth
+	if hot[0].Count < 2000-hotKeySampleEvery || hot[0].Count > 2000+hotKeySampleEvery {
+		t.Errorf("Expected about 2000 operations on user:celebrity, got %d", hot[0].Count)
+	}
+	if hot[1].Count > hot[0].Count/10 {
+		t.Errorf("Quiet key counted like a hot one: %v", hot)
+	}
+
+	// Counts age out once two windows pass without samples
+	store.hotKeys.mu.Lock()
+	store.hotKeys.windowStart = store.hotKeys.windowStart.Add(-2 * hotKeyWindow)
+	store.hotKeys.mu.Unlock()
+	if hot := store.HotKeys(3); len(hot) != 0 {
+		t.Errorf("Expected no hot keys after %v of quiet, got %v", 2*hotKeyWindow, hot)
+	}
+}
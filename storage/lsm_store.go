@@ -1,61 +1,307 @@
 package storage
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
+
+	"kvstore/tracing"
 )
 
 const (
 	// MemTableSizeThreshold is the size limit before flushing to disk (64MB)
 	MemTableSizeThreshold = 64 * 1024 * 1024
+
+	// MaxImmutableMemTables is how many full MemTables may wait to be flushed
+	// before writes block. While one is being written to an SSTable, writers
+	// keep going into a fresh MemTable; once this many are queued, the disk is
+	// not keeping up and writers wait for the flusher instead of growing
+	// memory without bound (about (MaxImmutableMemTables+1) *
+	// MemTableSizeThreshold at most).
+	MaxImmutableMemTables = 4
+
+	// flushRetryDelay is how long the flusher waits after a failed flush
+	// before trying again; the MemTable stays queued and in the WAL meanwhile
+	flushRetryDelay = time.Second
+
+	// DefaultMaxMemTableAge is how long writes may sit in a non-empty MemTable
+	// (and the WAL) before it is flushed even below the size threshold
+	DefaultMaxMemTableAge = 10 * time.Minute
+
+	// MaxValueSize is the largest single value the store accepts (32MB).
+	// Values are kept whole in the WAL, MemTable and SSTables, so a value
+	// must fit in memory; larger blobs should be split across keys.
+	MaxValueSize = 32 * 1024 * 1024
 )
 
 var (
-	ErrKeyNotFound = errors.New("key not found")
+	ErrKeyNotFound   = errors.New("key not found")
+	ErrValueTooLarge = errors.New("value exceeds maximum size")
+
+	// ErrStoreBusy is returned in non-blocking write mode when a flush is
+	// running and the MemTable is over its ceiling. Nothing was written;
+	// the caller should back off and retry.
+	ErrStoreBusy = errors.New("store is busy flushing, retry later")
+
+	// ErrReadOnly is returned for writes and compactions on a store opened
+	// with OpenReadOnly
+	ErrReadOnly = errors.New("store is opened read-only")
+
+	// ErrRangeOutOfBounds is returned by GetRange for a byte range that does
+	// not lie within the value
+	ErrRangeOutOfBounds = errors.New("byte range out of bounds")
+
+	// ErrDiskFull is returned when the WAL or an SSTable can't be written
+	// because its filesystem is out of space or over quota. A failed WAL
+	// write or rotation leaves the WAL as it was, a MemTable whose flush
+	// failed stays readable and queued, and a failed compaction keeps the
+	// tables it was merging, so writes succeed again once space is freed.
+	ErrDiskFull = errors.New("disk full")
+
+	// ErrWriteFailed is returned when the WAL or an SSTable can't be written
+	// for any other reason, such as a read-only filesystem or an I/O error.
+	// The store is left as it was before the write, as with ErrDiskFull.
+	ErrWriteFailed = errors.New("write to disk failed")
 )
 
-// LSMStore is a Log-Structured Merge-Tree based key-value store
+// diskWriteError classifies an error writing the WAL or an SSTable as
+// ErrDiskFull or ErrWriteFailed, keeping the OS error in the chain
+func diskWriteError(err error) error {
+	switch {
+	case err == nil, errors.Is(err, ErrDiskFull), errors.Is(err, ErrWriteFailed), errors.Is(err, ErrReadOnly):
+		return err
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return fmt.Errorf("%w: %w", ErrDiskFull, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrWriteFailed, err)
+	}
+}
+
+// BatchOp is a single Put or Delete inside a WriteBatch
+type BatchOp struct {
+	Op        OpType // OpPut or OpDelete
+	Namespace string // "" for the default namespace (see NamespacedKey)
+	Key       string
+	Value     []byte // Ignored for OpDelete
+}
+
+// immutableMemTable is a full MemTable waiting to be flushed, with the
+// rotated WAL segments that hold its writes until its SSTable exists
+type immutableMemTable struct {
+	table    *MemTable
+	segments []int
+}
+
+// LSMStore is a Log-Structured Merge-Tree based key-value store.
+// Keys are arbitrary byte strings (a Go string may hold any bytes, including
+// NUL) and are ordered bytewise, as by bytes.Compare, in every layer.
 type LSMStore struct {
-	memTable       *MemTable
-	immutableTable *MemTable  // MemTable being flushed
-	sstables       []*SSTable // Sorted by newest to oldest
-	wal            *WAL
-	dataDir        string
-	nextTableID    int
-	mu             sync.RWMutex
-	flushMu        sync.Mutex
-	compactionMgr  *CompactionManager // Compaction manager
-	
-	// Stats for bloom filters
-	bloomFilterHits   int64
-	bloomFilterMisses int64
-	statsMu           sync.RWMutex
+	memTable      *MemTable
+	immutables    []*immutableMemTable // Waiting to be flushed, oldest first
+	sstables      []*SSTable           // Sorted by newest to oldest, as in the manifest
+	manifestMu    sync.Mutex           // Held while the table list and manifest change (see publishSSTables)
+	wal           *WAL
+	dataDir       string
+	sstableDir    string // SSTables; dataDir unless StoreOptions.SSTableDir is set
+	nextTableID   int
+	mu            sync.RWMutex
+	compactionMgr *CompactionManager // Compaction manager
+	readOnly      bool               // Opened with OpenReadOnly
+
+	// WAL segments. Writers hold rotateMu shared from their WAL append to
+	// their MemTable update, so a rotation never splits a write between the
+	// segment and the next MemTable.
+	rotateMu      sync.RWMutex
+	memSegments   []int // Recovered segments whose writes are in memTable
+	nextSegmentID int
+
+	// Background flushing (see flushLoop)
+	flushMu           sync.Mutex // Held while the queue is written to SSTables
+	flushCond         *sync.Cond // On mu; signalled when the queue shrinks
+	flushCh           chan struct{}
+	flushStopCh       chan struct{}
+	flushStop         sync.Once
+	flushWg           sync.WaitGroup
+	flushStopped      bool   // No flusher left to wait for (guarded by mu)
+	maxImmutables     int    // MaxImmutableMemTables, lowered by tests
+	memTableThreshold int64  // MemTableSizeThreshold, lowered by tests
+	beforeFlush       func() // Test hook run before each SSTable is written
+
+	sstableOutput func(io.Writer) io.Writer // Test hook wrapping each flushed or compacted SSTable's file
+
+	// Time-based flushing (see SetMaxMemTableAge)
+	maxMemTableAge atomic.Int64 // time.Duration; 0 disables
+	ageFlushStopCh chan struct{}
+	ageFlushStop   sync.Once
+	ageFlushWg     sync.WaitGroup
+
+	// Background WAL fsync (see SetWALSyncInterval)
+	walSyncMu   sync.Mutex
+	walSyncStop chan struct{} // nil while background sync is off
+	walSyncWg   sync.WaitGroup
+
+	// Non-blocking writes (see SetNonBlockingWrites)
+	busyCeiling atomic.Int64 // MemTable bytes; 0 means writes block on flushes
+	flushing    atomic.Bool  // A MemTable is being written to disk
+
+	// Stats for bloom filters (atomic so Stats never contends with reads/writes);
+	// every SSTable of the store counts its probes here
+	bloom         bloomCounters
+	keyRangeSkips atomic.Int64 // SSTables skipped as the key is outside their range
+
+	// Sampled per-key operation counts (see HotKeys)
+	hotKeys hotKeyTracker
+
+	// Bloom filter over every SSTable's keys (nil unless StoreOptions.StoreBloom)
+	storeBloom *storeBloom
+
+	// Reads skip record checksums (see SetVerifyChecksumsOnRead)
+	skipChecksums atomic.Bool
+
+	// Change-data-capture subscribers (see Watch)
+	watch watchHub
+
+	// Instrumentation (see StoreOptions.Metrics)
+	metrics Metrics
+}
+
+// StoreOptions places a store's files on separate disks. An empty directory
+// defaults to the data directory, which keeps everything else (compaction
+// stats).
+type StoreOptions struct {
+	WALDir     string // WAL and its segments, e.g. on a fast NVMe drive
+	SSTableDir string // SSTables, e.g. on bulk storage
+
+	// StoreBloom keeps one bloom filter over the keys of every SSTable, so
+	// most lookups of absent keys skip the SSTables instead of probing each
+	// table's filter. It holds about 2.4 bytes of memory per key, and
+	// opening the store reads every SSTable's index to build it.
+	StoreBloom bool
+
+	// Metrics receives the store's, its compactions' and its WAL's
+	// counters, latencies and gauges (nil discards them)
+	Metrics Metrics
 }
 
 // NewLSMStore creates a new LSM-based store
 func NewLSMStore(dataDir string) (*LSMStore, error) {
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	return NewLSMStoreWithOptions(dataDir, StoreOptions{})
+}
+
+// NewLSMStoreWithOptions creates a new LSM-based store with its WAL and
+// SSTables placed by opts. A store must be reopened with the same layout to
+// find its data.
+func NewLSMStoreWithOptions(dataDir string, opts StoreOptions) (*LSMStore, error) {
+	walDir, sstableDir := opts.WALDir, opts.SSTableDir
+	if walDir == "" {
+		walDir = dataDir
+	}
+	if sstableDir == "" {
+		sstableDir = dataDir
 	}
 
-	wal, err := NewWAL(dataDir)
+	for _, dir := range []string{dataDir, sstableDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+	}
+
+	wal, err := NewWAL(walDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create WAL: %w", err)
 	}
 
+	store, err := openStore(dataDir, sstableDir, wal)
+	if err != nil {
+		wal.Close()
+		return nil, err
+	}
+	removeOrphanSSTables(sstableDir)
+	if opts.Metrics != nil {
+		store.metrics = opts.Metrics
+		wal.metrics = opts.Metrics
+	}
+	if opts.StoreBloom {
+		if err := store.enableStoreBloom(); err != nil {
+			wal.Close()
+			return nil, err
+		}
+	}
+
+	// Initialize and start compaction manager
+	store.compactionMgr = NewCompactionManager(store)
+	store.compactionMgr.Start()
+
+	store.flushWg.Add(1)
+	go store.flushLoop()
+
+	store.ageFlushWg.Add(1)
+	go store.ageFlushLoop()
+
+	return store, nil
+}
+
+// OpenReadOnly opens an existing data directory without modifying it, for
+// tooling that inspects production data. SSTables are loaded and the WAL is
+// replayed into memory, so unflushed writes are visible to Get and Scan, but
+// no file is created, truncated, flushed or compacted. Writes and
+// ForceCompact fail with ErrReadOnly.
+func OpenReadOnly(dataDir string) (*LSMStore, error) {
+	info, err := os.Stat(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("data directory %s is not a directory", dataDir)
+	}
+
+	wal, err := OpenWALReadOnly(dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+
+	store, err := openStore(dataDir, dataDir, wal)
+	if err != nil {
+		wal.Close()
+		return nil, err
+	}
+	store.readOnly = true
+
+	// Only for its stats; the compaction loop is never started
+	store.compactionMgr = NewCompactionManager(store)
+
+	return store, nil
+}
+
+// openStore creates a store over wal and restores its state from the
+// SSTables in sstableDir and the WAL. The caller starts the background work.
+func openStore(dataDir, sstableDir string, wal *WAL) (*LSMStore, error) {
 	store := &LSMStore{
-		memTable:    NewMemTable(),
-		dataDir:     dataDir,
-		sstables:    make([]*SSTable, 0),
-		wal:         wal,
-		nextTableID: 0,
+		memTable:       NewMemTable(),
+		dataDir:        dataDir,
+		sstableDir:     sstableDir,
+		sstables:       make([]*SSTable, 0),
+		wal:            wal,
+		nextTableID:    0,
+		ageFlushStopCh: make(chan struct{}),
+
+		flushCh:           make(chan struct{}, 1),
+		flushStopCh:       make(chan struct{}),
+		maxImmutables:     MaxImmutableMemTables,
+		memTableThreshold: MemTableSizeThreshold,
+
+		metrics: noopMetrics{},
 	}
+	store.flushCond = sync.NewCond(&store.mu)
+	store.maxMemTableAge.Store(int64(DefaultMaxMemTableAge))
 
 	// Load existing SSTables
 	if err := store.loadSSTables(); err != nil {
@@ -67,35 +313,194 @@ func NewLSMStore(dataDir string) (*LSMStore, error) {
 		return nil, fmt.Errorf("failed to recover from WAL: %w", err)
 	}
 
-	// Initialize and start compaction manager
-	store.compactionMgr = NewCompactionManager(store)
-	store.compactionMgr.Start()
-
 	return store, nil
 }
 
-// Put stores a key-value pair
+// SetTombstoneTTL sets how long deleted keys' tombstones are kept by
+// compaction (see DefaultTombstoneTTL)
+func (s *LSMStore) SetTombstoneTTL(ttl time.Duration) {
+	s.compactionMgr.SetTombstoneTTL(ttl)
+}
+
+// SetTombstoneCompactionRatio sets the share of purgeable tombstones that
+// triggers an early compaction; 0 disables it
+func (s *LSMStore) SetTombstoneCompactionRatio(ratio float64) {
+	s.compactionMgr.SetTombstoneCompactionRatio(ratio)
+}
+
+// SetMaxMemTableAge sets how long the oldest write may sit in the MemTable
+// before a background flush; 0 disables time-based flushing
+func (s *LSMStore) SetMaxMemTableAge(age time.Duration) {
+	s.maxMemTableAge.Store(int64(age))
+}
+
+// SetNonBlockingWrites makes writes shed load instead of waiting on flushes.
+// While a flush is running, a write that would need to flush again no longer
+// queues behind it, and once the MemTable holds ceiling bytes or more, writes
+// fail with ErrStoreBusy until the flush completes. A ceiling of 0 restores
+// the default, where writes block until the flush is done.
+func (s *LSMStore) SetNonBlockingWrites(ceiling int64) {
+	s.busyCeiling.Store(ceiling)
+}
+
+// Sync makes every write acknowledged before the call durable: the WAL is
+// flushed and fsynced, so those writes survive an OS crash or power loss,
+// not just a crash of this process. Use it as a barrier before declaring a
+// checkpoint.
+func (s *LSMStore) Sync() error {
+	if s.readOnly {
+		return nil // Nothing was written
+	}
+	if err := s.wal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+	return nil
+}
+
+// Flush writes the MemTable to a new SSTable now, whatever its size, and
+// returns once it and any MemTables already queued are on disk and their
+// WAL segments are deleted. An empty MemTable is left alone. Use it before
+// a backup, or to get a deterministic layout in tests.
+func (s *LSMStore) Flush() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	// Drain the queue first, so the swap below never waits for room
+	if err := s.flushQueued(); err != nil {
+		return fmt.Errorf("failed to flush MemTable: %w", err)
+	}
+	if err := s.flushIf(func(m *MemTable) bool { return m.Len() > 0 }); err != nil {
+		return fmt.Errorf("failed to flush MemTable: %w", err)
+	}
+	if err := s.flushQueued(); err != nil {
+		return fmt.Errorf("failed to flush MemTable: %w", err)
+	}
+	return nil
+}
+
+// SetWALSyncInterval fsyncs the WAL in the background every interval;
+// 0 (the default) turns it off. The durability of an acknowledged write is:
+//
+//   - Default: the write is in the OS page cache when Put returns. It
+//     survives the process crashing, but may be lost if the machine crashes
+//     or loses power before the OS writes it back.
+//   - With an interval: as above, but at most interval worth of writes can
+//     be lost to a machine crash, at the cost of one fsync per interval.
+//   - After Sync returns: the write is on stable storage.
+func (s *LSMStore) SetWALSyncInterval(interval time.Duration) {
+	if s.readOnly {
+		return
+	}
+
+	s.walSyncMu.Lock()
+	defer s.walSyncMu.Unlock()
+
+	if s.walSyncStop != nil {
+		close(s.walSyncStop)
+		s.walSyncWg.Wait()
+		s.walSyncStop = nil
+	}
+
+	if interval > 0 {
+		s.walSyncStop = make(chan struct{})
+		s.walSyncWg.Add(1)
+		go s.walSyncLoop(interval, s.walSyncStop)
+	}
+}
+
+// walSyncLoop fsyncs the WAL every interval until stop is closed
+func (s *LSMStore) walSyncLoop(interval time.Duration, stop chan struct{}) {
+	defer s.walSyncWg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.wal.Sync(); err != nil {
+			log.Printf("⚠️  Background WAL sync failed: %v", err)
+		}
+	}
+}
+
+// checkBusy returns ErrStoreBusy if writes should be shed right now
+func (s *LSMStore) checkBusy() error {
+	ceiling := s.busyCeiling.Load()
+	if ceiling <= 0 || !s.flushing.Load() {
+		return nil
+	}
+
+	s.mu.RLock()
+	size := s.memTable.Size()
+	s.mu.RUnlock()
+
+	if size >= ceiling {
+		return ErrStoreBusy
+	}
+	return nil
+}
+
+// Put stores a key-value pair. An empty or nil value is a real value, not a
+// delete: Get finds it and returns an empty, non-nil slice. Only Delete
+// makes a key not found.
 func (s *LSMStore) Put(key string, value []byte) error {
+	return s.PutWithTimestamp(key, value, time.Now().UnixNano())
+}
+
+// PutWithTimestamp is Put with the write timestamp chosen by the caller,
+// for a replica applying a write its coordinator already timestamped. Reads
+// and merges resolve a key by timestamp, so a write older than the key's
+// current version is logged but never read back; the MemTable drops it
+// outright, and watchers are not told about it.
+func (s *LSMStore) PutWithTimestamp(key string, value []byte, timestamp int64) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if len(value) > MaxValueSize {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(value), MaxValueSize)
+	}
+	if err := s.checkBusy(); err != nil {
+		return err
+	}
+	s.hotKeys.record(key)
+	start := time.Now()
+
 	// Write to WAL first (durability)
 	entry := Entry{
-		Timestamp: time.Now().UnixNano(),
+		Timestamp: timestamp,
 		Op:        OpPut,
 		Key:       []byte(key),
 		Value:     value,
 	}
 
+	s.rotateMu.RLock()
 	if err := s.wal.Write(entry); err != nil {
+		s.rotateMu.RUnlock()
 		return fmt.Errorf("failed to write to WAL: %w", err)
 	}
 
 	// Write to MemTable
 	s.mu.Lock()
-	s.memTable.Put([]byte(key), value)
+	applied := s.memTable.Put([]byte(key), value, entry.Timestamp)
 	memSize := s.memTable.Size()
+	if applied {
+		s.watch.publish(WatchEvent{Op: OpPut, Key: key, Value: value, Timestamp: entry.Timestamp})
+	}
 	s.mu.Unlock()
+	s.rotateMu.RUnlock()
+
+	s.metrics.IncCounter(MetricPuts)
+	s.metrics.ObserveLatency(MetricPutLatency, time.Since(start))
+	s.metrics.SetGauge(MetricMemTableBytes, float64(memSize))
 
 	// Check if MemTable is full
-	if memSize >= MemTableSizeThreshold {
+	if memSize >= s.memTableThreshold {
 		if err := s.maybeFlush(); err != nil {
 			return fmt.Errorf("failed to flush MemTable: %w", err)
 		}
@@ -104,66 +509,302 @@ func (s *LSMStore) Put(key string, value []byte) error {
 	return nil
 }
 
-// Get retrieves a value by key
-func (s *LSMStore) Get(key string) ([]byte, error) {
+// PutIfAbsent stores a key-value pair only if the key has no live value,
+// and reports whether it did. The lookup and the write happen under the
+// store lock, so of several concurrent callers for the same key exactly one
+// succeeds. Unlike Get, the lookup holds the lock while it reads SSTables,
+// so it briefly stalls other writers.
+func (s *LSMStore) PutIfAbsent(key string, value []byte) (bool, error) {
+	if s.readOnly {
+		return false, ErrReadOnly
+	}
+	if len(value) > MaxValueSize {
+		return false, fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(value), MaxValueSize)
+	}
+	if err := s.checkBusy(); err != nil {
+		return false, err
+	}
+	s.hotKeys.record(key)
+	start := time.Now()
+
 	keyBytes := []byte(key)
 
-	s.mu.RLock()
-	
-	// Check MemTable first
-	if value, found := s.memTable.Get(keyBytes); found {
-		s.mu.RUnlock()
-		return value, nil
+	s.rotateMu.RLock()
+	s.mu.Lock()
+
+	current, found, err := s.getLocked(keyBytes)
+	if err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return false, err
+	}
+	if found {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return false, nil
+	}
+
+	// Written after the key's tombstone, if it has one, even if the delete
+	// was replayed with a timestamp ahead of this node's clock
+	return s.putAndUnlock(key, value, max(time.Now().UnixNano(), current.Timestamp+1), start)
+}
+
+// WriteBatch applies several Puts and Deletes atomically.
+// The batch is logged as one WAL record and applied to the MemTable under a
+// single lock acquisition, so neither readers nor recovery ever see part of it.
+func (s *LSMStore) WriteBatch(ops []BatchOp) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if len(ops) == 0 {
+		return nil
 	}
 
-	// Check immutable MemTable (if being flushed)
-	if s.immutableTable != nil {
-		if value, found := s.immutableTable.Get(keyBytes); found {
-			s.mu.RUnlock()
-			return value, nil
+	timestamp := time.Now().UnixNano()
+	entries := make([]Entry, len(ops))
+	for i, op := range ops {
+		if op.Op != OpPut && op.Op != OpDelete {
+			return fmt.Errorf("invalid op type %d for key %q", op.Op, op.Key)
+		}
+		if op.Op == OpPut && len(op.Value) > MaxValueSize {
+			return fmt.Errorf("%w: key %q has %d bytes (max %d)", ErrValueTooLarge, op.Key, len(op.Value), MaxValueSize)
+		}
+
+		key, err := NamespacedKey(op.Namespace, op.Key)
+		if err != nil {
+			return err
+		}
+
+		entries[i] = Entry{
+			Timestamp: timestamp,
+			Op:        op.Op,
+			Key:       []byte(key),
+		}
+		if op.Op == OpPut {
+			entries[i].Value = op.Value
 		}
 	}
 
-	// Check SSTables (newest to oldest)
-	sstables := make([]*SSTable, len(s.sstables))
-	copy(sstables, s.sstables)
-	s.mu.RUnlock()
+	if err := s.checkBusy(); err != nil {
+		return err
+	}
+	start := time.Now()
 
-	for _, sst := range sstables {
-		// Track bloom filter effectiveness
-		if sst.HasBloomFilter() {
-			if !sst.bloomFilter.MayContain(keyBytes) {
-				// Bloom filter says definitely not present
-				s.statsMu.Lock()
-				s.bloomFilterHits++
-				s.statsMu.Unlock()
-				continue // Skip this SSTable
-			} else {
-				// Bloom filter says might be present
-				s.statsMu.Lock()
-				s.bloomFilterMisses++
-				s.statsMu.Unlock()
-			}
+	s.rotateMu.RLock()
+	if err := s.wal.WriteBatch(entries); err != nil {
+		s.rotateMu.RUnlock()
+		return fmt.Errorf("failed to write batch to WAL: %w", err)
+	}
+
+	// Apply the whole batch under one lock
+	events := make([]WatchEvent, len(entries))
+	s.mu.Lock()
+	for i, entry := range entries {
+		if entry.Op == OpPut {
+			s.memTable.Put(entry.Key, entry.Value, entry.Timestamp)
+		} else {
+			s.memTable.Delete(entry.Key, entry.Timestamp)
 		}
+		events[i] = WatchEvent{Op: entry.Op, Key: string(entry.Key), Value: entry.Value, Timestamp: entry.Timestamp}
+	}
+	memSize := s.memTable.Size()
+	s.watch.publish(events...)
+	s.mu.Unlock()
+	s.rotateMu.RUnlock()
 
-		value, found, err := sst.Get(keyBytes)
-		if err != nil {
-			return nil, fmt.Errorf("error reading SSTable: %w", err)
+	// Each op counts as a Put or Delete; the batch is one write latency
+	for _, entry := range entries {
+		if entry.Op == OpPut {
+			s.metrics.IncCounter(MetricPuts)
+		} else {
+			s.metrics.IncCounter(MetricDeletes)
 		}
-		if found {
-			// Check for tombstone
-			if bytes.Equal(value, []byte("__TOMBSTONE__")) {
-				return nil, ErrKeyNotFound
-			}
-			return value, nil
+	}
+	s.metrics.ObserveLatency(MetricPutLatency, time.Since(start))
+	s.metrics.SetGauge(MetricMemTableBytes, float64(memSize))
+
+	// Check if MemTable is full
+	if memSize >= s.memTableThreshold {
+		if err := s.maybeFlush(); err != nil {
+			return fmt.Errorf("failed to flush MemTable: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get retrieves a value by key
+func (s *LSMStore) Get(key string) ([]byte, error) {
+	value, _, err := s.GetWithTimestamp(key)
+	return value, err
+}
+
+// GetWithTimestamp is Get that also returns the timestamp the value was
+// written at
+func (s *LSMStore) GetWithTimestamp(key string) ([]byte, int64, error) {
+	return s.GetWithTimestampContext(context.Background(), key)
+}
+
+// GetWithTimestampContext is GetWithTimestamp traced as a child of the span
+// in ctx, with a span for each MemTable or SSTable read (see tracing)
+func (s *LSMStore) GetWithTimestampContext(ctx context.Context, key string) ([]byte, int64, error) {
+	ctx, span := tracing.Start(ctx, "storage.Get")
+	defer span.End()
+	s.hotKeys.record(key)
+	start := time.Now()
+	defer func() { s.metrics.ObserveLatency(MetricGetLatency, time.Since(start)) }()
+
+	keyBytes := []byte(key)
+
+	// A point range: stops at the newest layer holding the key
+	it := s.newMergeIterator(ctx, MergeOptions{
+		Start:             keyBytes,
+		End:               append(keyBytes[:len(keyBytes):len(keyBytes)], 0),
+		IncludeTombstones: true,
+	})
+	defer it.Close()
+
+	entry, found := it.Next()
+	if err := it.Err(); err != nil {
+		s.metrics.IncCounter(MetricGets, "result", "error")
+		return nil, 0, fmt.Errorf("error reading SSTable: %w", err)
+	}
+	if !found || entry.Op == OpDelete {
+		s.metrics.IncCounter(MetricGets, "result", "not_found")
+		return nil, 0, ErrKeyNotFound
+	}
+	s.metrics.IncCounter(MetricGets, "result", "found")
+	if entry.Value == nil {
+		entry.Value = []byte{} // Stored empty, which nil would blur with absent
+	}
+	return entry.Value, entry.Timestamp, nil
+}
+
+// GetRange returns length bytes of key's value starting at offset. The
+// range must lie within the value; ErrRangeOutOfBounds is returned otherwise.
+//
+// The whole value is still read and then sliced: records are stored whole
+// in the MemTable and SSTables. A block-based record format would let the
+// SSTable path pread only the blocks covering the range instead.
+func (s *LSMStore) GetRange(key string, offset, length int) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, fmt.Errorf("%w: offset %d, length %d", ErrRangeOutOfBounds, offset, length)
+	}
+
+	value, err := s.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if offset > len(value) || length > len(value)-offset {
+		return nil, fmt.Errorf("%w: [%d, %d) of a %d-byte value", ErrRangeOutOfBounds, offset, offset+length, len(value))
+	}
+	return value[offset : offset+length], nil
+}
+
+// Exists reports whether key has a live value. It gives the same answer
+// as Get without copying the value out: it is a keys-only point lookup, so
+// SSTable values are only read when they may be tombstones.
+func (s *LSMStore) Exists(key string) (bool, error) {
+	s.hotKeys.record(key)
+	keyBytes := []byte(key)
+
+	it := s.newMergeIterator(context.Background(), MergeOptions{
+		Start:    keyBytes,
+		End:      append(keyBytes[:len(keyBytes):len(keyBytes)], 0),
+		KeysOnly: true,
+	})
+	defer it.Close()
+
+	_, found := it.Next()
+	if err := it.Err(); err != nil {
+		return false, fmt.Errorf("error reading SSTable: %w", err)
+	}
+	return found, nil
+}
+
+// getLocked returns key's live entry as Get resolves it, the newest version
+// across every layer (must be called with the lock held). A deleted key is
+// not found, but its tombstone is returned, so a write after it can be
+// timestamped past the delete.
+func (s *LSMStore) getLocked(key []byte) (Entry, bool, error) {
+	it := s.mergeIteratorLocked(context.Background(), MergeOptions{
+		Start:             key,
+		End:               append(key[:len(key):len(key)], 0),
+		IncludeTombstones: true,
+	})
+	defer it.Close()
+	it.Seek(key)
+
+	entry, found := it.Next()
+	if err := it.Err(); err != nil {
+		return Entry{}, false, fmt.Errorf("error reading SSTable: %w", err)
+	}
+	return entry, found && entry.Op != OpDelete, nil
+}
+
+// Scan returns all live key-value pairs with start <= key < end in sorted
+// order. An empty start or end leaves that side of the range open. It
+// covers the whole keyspace, namespaced keys included; ScanNS("") covers
+// only the default namespace.
+func (s *LSMStore) Scan(start, end string) ([]Entry, error) {
+	return s.scan(start, end, false, 0, nil)
+}
+
+// ScanKeys returns all live keys with start <= key < end in sorted order.
+// It is served from the MemTable and the SSTable indexes without reading
+// values from disk.
+func (s *LSMStore) ScanKeys(start, end string) ([]string, error) {
+	entries, err := s.scan(start, end, true, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = string(entry.Key)
+	}
+	return keys, nil
+}
+
+// scan merges the MemTables and SSTables over [start, end), keeping the
+// newest version of each key and dropping tombstones, and the keys skip
+// reports if skip is set. It stops after limit entries unless limit is 0.
+func (s *LSMStore) scan(start, end string, keysOnly bool, limit int, skip func(key []byte) bool) ([]Entry, error) {
+	it := s.NewMergeIterator(MergeOptions{
+		Start:    []byte(start),
+		End:      []byte(end),
+		KeysOnly: keysOnly,
+	})
+	defer it.Close()
+
+	var result []Entry
+	for limit <= 0 || len(result) < limit {
+		entry, ok := it.Next()
+		if !ok {
+			break
+		}
+		if skip != nil && skip(entry.Key) {
+			continue
 		}
+		result = append(result, entry)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning SSTable: %w", err)
 	}
 
-	return nil, ErrKeyNotFound
+	return result, nil
 }
 
 // Delete removes a key-value pair
 func (s *LSMStore) Delete(key string) error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	if err := s.checkBusy(); err != nil {
+		return err
+	}
+	s.hotKeys.record(key)
+
 	// Write to WAL
 	entry := Entry{
 		Timestamp: time.Now().UnixNano(),
@@ -172,18 +813,25 @@ func (s *LSMStore) Delete(key string) error {
 		Value:     nil,
 	}
 
+	s.rotateMu.RLock()
 	if err := s.wal.Write(entry); err != nil {
+		s.rotateMu.RUnlock()
 		return fmt.Errorf("failed to write delete to WAL: %w", err)
 	}
 
 	// Write tombstone to MemTable
 	s.mu.Lock()
-	s.memTable.Delete([]byte(key))
+	s.memTable.Delete([]byte(key), entry.Timestamp)
 	memSize := s.memTable.Size()
+	s.watch.publish(WatchEvent{Op: OpDelete, Key: key, Timestamp: entry.Timestamp})
 	s.mu.Unlock()
+	s.rotateMu.RUnlock()
+
+	s.metrics.IncCounter(MetricDeletes)
+	s.metrics.SetGauge(MetricMemTableBytes, float64(memSize))
 
 	// Check if MemTable is full
-	if memSize >= MemTableSizeThreshold {
+	if memSize >= s.memTableThreshold {
 		if err := s.maybeFlush(); err != nil {
 			return fmt.Errorf("failed to flush MemTable: %w", err)
 		}
@@ -192,106 +840,333 @@ func (s *LSMStore) Delete(key string) error {
 	return nil
 }
 
-// maybeFlush flushes MemTable to disk if needed
-func (s *LSMStore) maybeFlush() error {
-	s.flushMu.Lock()
-	defer s.flushMu.Unlock()
+// DeleteWithResult is Delete that also reports whether the key had a live
+// value before it was deleted. The check goes through the read path under
+// the write lock, so it is exact but costs a lookup; Delete skips it. A
+// tombstone is written either way.
+func (s *LSMStore) DeleteWithResult(key string) (bool, error) {
+	if s.readOnly {
+		return false, ErrReadOnly
+	}
+	if err := s.checkBusy(); err != nil {
+		return false, err
+	}
+	s.hotKeys.record(key)
+
+	keyBytes := []byte(key)
 
+	s.rotateMu.RLock()
 	s.mu.Lock()
-	
-	// Double-check size after acquiring lock
-	if s.memTable.Size() < MemTableSizeThreshold {
+
+	existed, err := s.existsLocked(keyBytes)
+	if err != nil {
 		s.mu.Unlock()
-		return nil
+		s.rotateMu.RUnlock()
+		return false, err
 	}
 
-	// Move current MemTable to immutable
-	s.immutableTable = s.memTable
-	s.memTable = NewMemTable()
-	
-	tableToFlush := s.immutableTable
-	tableID := s.nextTableID
-	s.nextTableID++
-	
+	entry := Entry{
+		Timestamp: time.Now().UnixNano(),
+		Op:        OpDelete,
+		Key:       keyBytes,
+		Value:     nil,
+	}
+	if err := s.wal.Write(entry); err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return false, fmt.Errorf("failed to write delete to WAL: %w", err)
+	}
+
+	s.memTable.Delete(keyBytes, entry.Timestamp)
+	memSize := s.memTable.Size()
+	s.watch.publish(WatchEvent{Op: OpDelete, Key: key, Timestamp: entry.Timestamp})
 	s.mu.Unlock()
+	s.rotateMu.RUnlock()
 
-	// Flush to disk (no locks held during I/O)
-	if err := s.flushToDisk(tableToFlush, tableID); err != nil {
-		return err
+	s.metrics.IncCounter(MetricDeletes)
+	s.metrics.SetGauge(MetricMemTableBytes, float64(memSize))
+
+	// Check if MemTable is full
+	if memSize >= s.memTableThreshold {
+		if err := s.maybeFlush(); err != nil {
+			return existed, fmt.Errorf("failed to flush MemTable: %w", err)
+		}
 	}
 
-	// Clear immutable table and reset WAL
+	return existed, nil
+}
+
+// existsLocked reports whether key has a live value (must be called with
+// the lock held)
+func (s *LSMStore) existsLocked(key []byte) (bool, error) {
+	_, found, err := s.getLocked(key)
+	return found, err
+}
+
+// maybeFlush queues the MemTable for flushing if it is over the size threshold
+func (s *LSMStore) maybeFlush() error {
+	return s.flushIf(func(m *MemTable) bool {
+		return m.Size() >= s.memTableThreshold
+	})
+}
+
+// flushIfOlderThan flushes the MemTable if its first write is at least maxAge old
+func (s *LSMStore) flushIfOlderThan(maxAge time.Duration) error {
+	return s.flushIf(func(m *MemTable) bool {
+		return m.Len() > 0 && m.Age() >= maxAge
+	})
+}
+
+// flushIf swaps out the MemTable if shouldFlush holds for it: the MemTable
+// joins the flush queue, the WAL is rotated into a segment that holds its
+// writes, and the background flusher is woken to write the SSTable.
+// The size-triggered and time-triggered flushes both go through here, and
+// the condition is checked under the store lock so a MemTable that was just
+// swapped out is never queued twice.
+//
+// If the queue is full, the caller blocks until the flusher makes room,
+// which slows writers down to the speed of the disk. In non-blocking write
+// mode it returns instead and leaves the MemTable in place; writes are shed
+// with ErrStoreBusy once it reaches the ceiling.
+func (s *LSMStore) flushIf(shouldFlush func(*MemTable) bool) error {
+	// Excludes writers until the MemTable and WAL are swapped together
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
 	s.mu.Lock()
-	s.immutableTable = nil
+	for len(s.immutables) >= s.maxImmutables && !s.flushStopped {
+		if !shouldFlush(s.memTable) || s.busyCeiling.Load() > 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		s.flushCond.Wait()
+	}
+	if !shouldFlush(s.memTable) {
+		s.mu.Unlock()
+		return nil
+	}
+	segmentID := s.nextSegmentID
+	s.nextSegmentID++
 	s.mu.Unlock()
 
-	if err := s.wal.Reset(); err != nil {
-		return fmt.Errorf("failed to reset WAL: %w", err)
+	// Writers are held off by rotateMu, so the MemTable can't change while
+	// the WAL is rotated without the store lock (readers keep going)
+	if err := s.wal.Rotate(segmentID); err != nil {
+		return fmt.Errorf("failed to rotate WAL: %w", diskWriteError(err))
 	}
 
+	s.mu.Lock()
+	s.immutables = append(s.immutables, &immutableMemTable{
+		table:    s.memTable,
+		segments: append(s.memSegments, segmentID),
+	})
+	s.memTable = NewMemTable()
+	s.memSegments = nil
+	s.mu.Unlock()
+
+	select {
+	case s.flushCh <- struct{}{}:
+	default: // The flusher is already due to run
+	}
 	return nil
 }
 
-// flushToDisk writes MemTable entries to a new SSTable
+// flushLoop writes queued MemTables to SSTables in the background until
+// Close, which waits for it to drain the queue
+func (s *LSMStore) flushLoop() {
+	defer s.flushWg.Done()
+
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-s.flushCh:
+		case <-retry:
+		case <-s.flushStopCh:
+			if err := s.flushQueued(); err != nil {
+				log.Printf("⚠️  MemTable flush on close failed, its writes stay in the WAL: %v", err)
+			}
+			return
+		}
+
+		retry = nil
+		if err := s.flushQueued(); err != nil {
+			log.Printf("⚠️  MemTable flush failed, retrying in %v: %v", flushRetryDelay, err)
+			retry = time.After(flushRetryDelay)
+		}
+	}
+}
+
+// flushQueued writes the queued MemTables to SSTables, oldest first, so the
+// SSTables are created in write order. Each stays readable in the queue
+// until its SSTable is in place; then its WAL segments are deleted.
+func (s *LSMStore) flushQueued() error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	for {
+		s.mu.Lock()
+		if len(s.immutables) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		oldest := s.immutables[0]
+		tableID := s.nextTableID
+		s.nextTableID++
+		s.mu.Unlock()
+
+		s.flushing.Store(true)
+		if s.beforeFlush != nil {
+			s.beforeFlush()
+		}
+		// Flush to disk (no locks held during I/O)
+		start := time.Now()
+		err := s.flushToDisk(oldest.table, tableID)
+		s.flushing.Store(false)
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.immutables = s.immutables[1:]
+		numSSTables := len(s.sstables)
+		s.flushCond.Broadcast()
+		s.mu.Unlock()
+
+		s.metrics.IncCounter(MetricFlushes)
+		s.metrics.ObserveLatency(MetricFlushLatency, time.Since(start))
+		s.metrics.SetGauge(MetricSSTables, float64(numSSTables))
+
+		for _, segmentID := range oldest.segments {
+			if err := s.wal.RemoveSegment(segmentID); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+	}
+}
+
+// ageFlushLoop periodically flushes a MemTable that has been holding writes
+// for longer than the configured maximum age
+func (s *LSMStore) ageFlushLoop() {
+	defer s.ageFlushWg.Done()
+
+	for {
+		maxAge := time.Duration(s.maxMemTableAge.Load())
+
+		// Check often enough to flush close to the deadline
+		interval := maxAge / 10
+		if maxAge <= 0 || interval > time.Minute {
+			interval = time.Minute
+		} else if interval < 10*time.Millisecond {
+			interval = 10 * time.Millisecond
+		}
+
+		select {
+		case <-s.ageFlushStopCh:
+			return
+		case <-time.After(interval):
+		}
+
+		if maxAge <= 0 {
+			continue
+		}
+		if err := s.flushIfOlderThan(maxAge); err != nil {
+			log.Printf("⚠️  Time-based MemTable flush failed: %v", err)
+		}
+	}
+}
+
+// flushToDisk writes MemTable entries to a new SSTable. If the write
+// fails, the partial file is removed and the error is ErrDiskFull or
+// ErrWriteFailed; the caller keeps the MemTable queued to try again.
 func (s *LSMStore) flushToDisk(memTable *MemTable, tableID int) error {
-	writer, err := NewSSTableWriter(s.dataDir, tableID)
+	writer, err := NewSSTableWriter(s.sstableDir, tableID)
 	if err != nil {
-		return err
+		return diskWriteError(err)
+	}
+	if s.sstableOutput != nil {
+		writer.writer.Reset(s.sstableOutput(writer.file))
 	}
 
-	// Get all entries in sorted order
-	entries := memTable.Iterator()
-
-	// Write to SSTable
-	for _, entry := range entries {
-		if err := writer.Write(entry.Key, entry.Value); err != nil {
-			return fmt.Errorf("failed to write entry to SSTable: %w", err)
+	// Stream the entries in sorted order, keeping only the keys the store
+	// bloom filter needs
+	var keys [][]byte
+	it := memTable.NewIterator()
+	for it.Next() {
+		var err error
+		if it.Deleted() {
+			err = writer.WriteDelete(it.Key(), it.Timestamp())
+		} else {
+			err = writer.Write(it.Key(), it.Value(), it.Timestamp())
+		}
+		if err != nil {
+			writer.abort()
+			return fmt.Errorf("failed to write entry to SSTable: %w", diskWriteError(err))
+		}
+		if s.storeBloom != nil {
+			keys = append(keys, it.Key())
 		}
 	}
 
 	// Finalize the SSTable
 	if err := writer.Finalize(); err != nil {
-		return fmt.Errorf("failed to finalize SSTable: %w", err)
+		writer.abort()
+		return fmt.Errorf("failed to finalize SSTable: %w", diskWriteError(err))
 	}
 
 	// Open the new SSTable and add to list
 	sst, err := OpenSSTable(writer.filePath)
 	if err != nil {
+		os.Remove(writer.filePath)
 		return fmt.Errorf("failed to open new SSTable: %w", err)
 	}
+	s.adoptSSTable(sst)
 
-	s.mu.Lock()
-	// Add to front (newest)
-	s.sstables = append([]*SSTable{sst}, s.sstables...)
-	s.mu.Unlock()
+	// Lookups may only skip the SSTables once the new keys are in the filter
+	if err := s.addToStoreBloom(keys); err != nil {
+		return err
+	}
+
+	// Add to front (newest). Until the manifest lists it the table is an
+	// orphan, and its writes are still in the WAL.
+	if err := s.publishSSTables(func(current []*SSTable) []*SSTable {
+		return append([]*SSTable{sst}, current...)
+	}); err != nil {
+		os.Remove(sst.filePath)
+		return fmt.Errorf("failed to add SSTable to the manifest: %w", diskWriteError(err))
+	}
 
 	return nil
 }
 
-// loadSSTables loads existing SSTables from disk
+// adoptSSTable ties a table to the store's bloom filter counters and
+// checksum setting before it is published
+func (s *LSMStore) adoptSSTable(sst *SSTable) {
+	sst.bloomCounts = &s.bloom
+	sst.skipChecksums = &s.skipChecksums
+}
+
+// loadSSTables loads the SSTables the manifest lists, in its order (see
+// listSSTables). Orphaned table files are left for NewLSMStore to delete,
+// but their IDs are not reused.
 func (s *LSMStore) loadSSTables() error {
-	pattern := filepath.Join(s.dataDir, "sstable_*.db")
-	files, err := filepath.Glob(pattern)
+	files, orphans, err := listSSTables(s.sstableDir)
 	if err != nil {
 		return err
 	}
 
-	// Sort files by name (which includes ID) to get newest first
-	sort.Slice(files, func(i, j int) bool {
-		return files[i] > files[j]
-	})
-
+	// Indexes are read on first use, so startup only touches the footers
+	// and bloom filters
 	for _, file := range files {
-		sst, err := OpenSSTable(file)
+		sst, err := OpenSSTableMeta(file)
 		if err != nil {
 			return fmt.Errorf("failed to open SSTable %s: %w", file, err)
 		}
+		s.adoptSSTable(sst)
 		s.sstables = append(s.sstables, sst)
+	}
 
-		// Update nextTableID
-		var id int
-		fmt.Sscanf(filepath.Base(file), "sstable_%d.db", &id)
-		if id >= s.nextTableID {
+	for _, file := range append(files, orphans...) {
+		if id, ok := parseSSTableID(filepath.Base(file)); ok && id >= s.nextTableID {
 			s.nextTableID = id + 1
 		}
 	}
@@ -299,23 +1174,47 @@ func (s *LSMStore) loadSSTables() error {
 	return nil
 }
 
-// recover replays WAL entries to restore state
+// recover replays WAL entries to restore state: first the segments of
+// MemTables that were queued but not flushed, oldest first, then the live
+// WAL. The segments are kept until the recovered MemTable is flushed.
 func (s *LSMStore) recover() error {
+	segments, err := s.wal.Segments()
+	if err != nil {
+		return fmt.Errorf("failed to list WAL segments: %w", err)
+	}
+
+	for _, segmentID := range segments {
+		entries, err := s.wal.ReadSegment(segmentID)
+		if err != nil {
+			return fmt.Errorf("failed to read WAL segment %d: %w", segmentID, err)
+		}
+		s.replay(entries)
+
+		s.memSegments = append(s.memSegments, segmentID)
+		if segmentID >= s.nextSegmentID {
+			s.nextSegmentID = segmentID + 1
+		}
+	}
+
 	entries, err := s.wal.ReadAll()
 	if err != nil {
 		return fmt.Errorf("failed to read WAL: %w", err)
 	}
+	s.replay(entries)
+
+	return nil
+}
 
+// replay applies WAL entries to the MemTable
+func (s *LSMStore) replay(entries []Entry) {
 	for _, entry := range entries {
 		switch entry.Op {
 		case OpPut:
-			s.memTable.Put(entry.Key, entry.Value)
+			s.memTable.Put(entry.Key, entry.Value, entry.Timestamp)
 		case OpDelete:
-			s.memTable.Delete(entry.Key)
+			s.memTable.Delete(entry.Key, entry.Timestamp)
 		}
 	}
-
-	return nil
 }
 
 // Close closes the store
@@ -325,33 +1224,116 @@ func (s *LSMStore) Close() error {
 		s.compactionMgr.Stop()
 	}
 
-	// Flush any remaining data
-	if s.memTable.Size() > 0 {
+	// Stop time-based flushing
+	s.ageFlushStop.Do(func() { close(s.ageFlushStopCh) })
+	s.ageFlushWg.Wait()
+
+	// Stop background WAL syncs
+	s.SetWALSyncInterval(0)
+
+	// End every watch
+	s.watch.closeAll()
+
+	// Flush any remaining data (a read-only store keeps it in the WAL)
+	if s.memTable.Size() > 0 && !s.readOnly {
 		if err := s.maybeFlush(); err != nil {
 			return err
 		}
 	}
 
+	// Let the flusher drain the queue, then release writers still waiting
+	// for room in it
+	s.flushStop.Do(func() { close(s.flushStopCh) })
+	s.flushWg.Wait()
+	s.mu.Lock()
+	s.flushStopped = true
+	s.flushCond.Broadcast()
+	s.mu.Unlock()
+
 	return s.wal.Close()
 }
 
+// Truncate deletes every key: the MemTables (including any waiting to be
+// flushed) are dropped, every SSTable file and WAL segment is removed, the
+// WAL is emptied and table numbering starts again from 0. The store stays
+// open and usable. SSTables hold no open file handles between reads, so
+// their files can be removed directly.
+//
+// It waits for a running compaction or flush to finish and holds off new
+// ones, and writes, until it is done. Watchers are not told. A crash part
+// way through can leave some of the old data behind; call Truncate again.
+func (s *LSMStore) Truncate() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+
+	// Same order as compaction (compactMu, then mu), flushIf (rotateMu, then
+	// mu) and the flusher (flushMu, then mu)
+	if s.compactionMgr != nil {
+		s.compactionMgr.compactMu.Lock()
+		defer s.compactionMgr.compactMu.Unlock()
+	}
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Flushes and compactions are held off, so nothing else is publishing
+	if err := writeManifest(s.sstableDir, nil); err != nil {
+		return err
+	}
+	for _, sst := range s.sstables {
+		if err := os.Remove(sst.filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove SSTable: %w", err)
+		}
+	}
+	s.sstables = nil
+
+	segments, err := s.wal.Segments()
+	if err != nil {
+		return fmt.Errorf("failed to list WAL segments: %w", err)
+	}
+	for _, segmentID := range segments {
+		if err := s.wal.RemoveSegment(segmentID); err != nil {
+			return err
+		}
+	}
+	if err := s.wal.Reset(); err != nil {
+		return fmt.Errorf("failed to reset WAL: %w", err)
+	}
+
+	s.memTable = NewMemTable()
+	s.memSegments = nil
+	s.immutables = nil
+	s.nextTableID = 0
+	s.nextSegmentID = 0
+	s.flushCond.Broadcast()
+
+	log.Printf("🧹 Store truncated")
+	return nil
+}
+
 // Stats returns storage statistics
+// The store lock is only held long enough to read the SSTable count and the
+// MemTable pointer; all other counters are atomics, so Stats never blocks Puts.
 func (s *LSMStore) Stats() map[string]interface{} {
 	s.mu.RLock()
 	numSSTables := len(s.sstables)
-	memTableSize := s.memTable.Size()
+	numImmutables := len(s.immutables)
+	memTable := s.memTable
 	s.mu.RUnlock()
 
-	s.statsMu.RLock()
-	bloomHits := s.bloomFilterHits
-	bloomMisses := s.bloomFilterMisses
-	s.statsMu.RUnlock()
-
 	stats := map[string]interface{}{
-		"memtable_size":        memTableSize,
-		"num_sstables":         numSSTables,
-		"bloom_filter_hits":    bloomHits,
-		"bloom_filter_misses":  bloomMisses,
+		"memtable_size":       memTable.Size(),
+		"memtable_entries":    memTable.Len(),
+		"num_sstables":        numSSTables,
+		"immutable_memtables": numImmutables,
+		"bloom_filter_hits":   s.bloom.hits.Load(),
+		"bloom_filter_misses": s.bloom.misses.Load(),
+		"key_range_skips":     s.keyRangeSkips.Load(),
+		"store_bloom_skips":   s.storeBloomSkips(),
 	}
 
 	// Add compaction stats if available
@@ -368,4 +1350,4 @@ func (s *LSMStore) Stats() map[string]interface{} {
 // CompactionManager returns the compaction manager (for manual compaction)
 func (s *LSMStore) CompactionManager() *CompactionManager {
 	return s.compactionMgr
-}
\ No newline at end of file
+}
@@ -1,8 +1,20 @@
 package storage
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
+
+	"kvstore/tracing"
 )
 
 func TestLSMStore_BasicOperations(t *testing.T) {
@@ -65,6 +77,11 @@ func TestLSMStore_MemTableFlush(t *testing.T) {
 		}
 	}
 
+	// The full MemTables are flushed in the background
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
 	// Check stats - should have created SSTables
 	stats := store.Stats()
 	numSSTables := stats["num_sstables"].(int)
@@ -132,187 +149,2119 @@ func TestLSMStore_ReadFromSSTable(t *testing.T) {
 	}
 }
 
-func TestLSMStore_CrashRecovery(t *testing.T) {
+func TestLSMStore_Scan(t *testing.T) {
 	tmpDir := t.TempDir()
 
-	store1, err := NewLSMStore(tmpDir)
+	store, err := NewLSMStore(tmpDir)
 	if err != nil {
-		t.Fatalf("Failed to create store: %v", err)
+		t.Fatalf("Failed to create LSM store: %v", err)
 	}
+	defer store.Close()
 
-	// Write some data
-	for i := 0; i < 100; i++ {
-		key := fmt.Sprintf("key_%d", i)
-		value := fmt.Sprintf("value_%d", i)
-		if err := store1.Put(key, []byte(value)); err != nil {
+	// Older versions go to an SSTable
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		if err := store.Put(k, []byte("old_"+k)); err != nil {
 			t.Fatalf("Put failed: %v", err)
 		}
 	}
+	flushMemTableForTest(t, store)
 
-	// Close without explicit flush (data in MemTable + WAL)
-	store1.Close()
+	// Newer versions and a delete stay in the MemTable
+	if err := store.Put("b", []byte("new_b")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("c"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Put("bb", []byte("new_bb")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
 
-	// Reopen - should recover from WAL
-	store2, err := NewLSMStore(tmpDir)
+	entries, err := store.Scan("b", "e")
 	if err != nil {
-		t.Fatalf("Failed to reopen store: %v", err)
+		t.Fatalf("Scan failed: %v", err)
 	}
-	defer store2.Close()
-
-	// Verify all data recovered
-	for i := 0; i < 100; i++ {
-		key := fmt.Sprintf("key_%d", i)
-		expectedValue := fmt.Sprintf("value_%d", i)
 
-		value, err := store2.Get(key)
-		if err != nil {
-			t.Fatalf("Get failed after recovery for key %s: %v", key, err)
+	expected := []struct{ key, value string }{
+		{"b", "new_b"},
+		{"bb", "new_bb"},
+		{"d", "old_d"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i, e := range expected {
+		if string(entries[i].Key) != e.key || string(entries[i].Value) != e.value {
+			t.Errorf("Entry %d: expected %s=%s, got %s=%s", i, e.key, e.value, entries[i].Key, entries[i].Value)
 		}
-		if string(value) != expectedValue {
-			t.Errorf("Key %s: expected %s, got %s", key, expectedValue, value)
+	}
+
+	// Keys-only scan over the full range
+	keys, err := store.ScanKeys("", "")
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+
+	expectedKeys := []string{"a", "b", "bb", "d", "e"}
+	if len(keys) != len(expectedKeys) {
+		t.Fatalf("Expected keys %v, got %v", expectedKeys, keys)
+	}
+	for i, k := range expectedKeys {
+		if keys[i] != k {
+			t.Errorf("Key %d: expected %s, got %s", i, k, keys[i])
 		}
 	}
 }
 
-func TestMemTable_SkipList(t *testing.T) {
-	mem := NewMemTable()
+func TestSSTable_RangeKeysOnly(t *testing.T) {
+	tmpDir := t.TempDir()
 
-	// Insert in random order
-	testData := map[string]string{
-		"zebra":  "stripes",
-		"apple":  "red",
-		"mango":  "yellow",
-		"banana": "yellow",
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
 	}
+	defer store.Close()
 
-	for k, v := range testData {
-		mem.Put([]byte(k), []byte(v))
+	store.Put("alive", []byte("value"))
+	store.Delete("dead")
+	flushMemTableForTest(t, store)
+
+	entries, err := store.sstables[0].Range(nil, nil, true)
+	if err != nil {
+		t.Fatalf("Range failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
 	}
 
-	// Get all entries - should be sorted
-	entries := mem.Iterator()
+	// Live values are not read, tombstones are still recognisable
+	if string(entries[0].Key) != "alive" || entries[0].Value != nil {
+		t.Errorf("Expected alive with nil value, got %s=%q", entries[0].Key, entries[0].Value)
+	}
+	if string(entries[1].Key) != "dead" || entries[1].Op != OpDelete {
+		t.Errorf("Expected dead tombstone, got %s=%q", entries[1].Key, entries[1].Value)
+	}
+}
 
-	if len(entries) != len(testData) {
-		t.Errorf("Expected %d entries, got %d", len(testData), len(entries))
+func TestLSMStore_BinaryKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
 	}
+	defer store.Close()
 
-	// Verify sorted order
-	prevKey := ""
-	for _, entry := range entries {
-		key := string(entry.Key)
-		if prevKey != "" && key < prevKey {
-			t.Errorf("Entries not in sorted order: %s came after %s", key, prevKey)
+	keys := []string{
+		"a",
+		"a\x00",
+		"a\x00b",
+		"\x00",
+		"\x00\x00",
+		"\xff\xfe",
+		"\xff",
+		"\x80abc",
+		"z\x01",
+	}
+
+	// Half the keys end up in an SSTable, the rest stay in the MemTable
+	for i, k := range keys {
+		if err := store.Put(k, []byte(fmt.Sprintf("value_%d", i))); err != nil {
+			t.Fatalf("Put failed for %q: %v", k, err)
+		}
+		if i == len(keys)/2 {
+			flushMemTableForTest(t, store)
 		}
-		prevKey = key
 	}
 
-	t.Logf("Sorted order: ")
-	for _, entry := range entries {
-		t.Logf("  %s: %s", entry.Key, entry.Value)
+	verify := func(stage string) {
+		for i, k := range keys {
+			value, err := store.Get(k)
+			if err != nil {
+				t.Fatalf("%s: Get failed for %q: %v", stage, k, err)
+			}
+			if string(value) != fmt.Sprintf("value_%d", i) {
+				t.Errorf("%s: key %q: expected value_%d, got %s", stage, k, i, value)
+			}
+		}
+
+		scanned, err := store.ScanKeys("", "")
+		if err != nil {
+			t.Fatalf("%s: ScanKeys failed: %v", stage, err)
+		}
+		if len(scanned) != len(keys) {
+			t.Fatalf("%s: expected %d keys, got %d", stage, len(keys), len(scanned))
+		}
+		for i := 1; i < len(scanned); i++ {
+			if bytes.Compare([]byte(scanned[i-1]), []byte(scanned[i])) >= 0 {
+				t.Errorf("%s: keys out of order: %q before %q", stage, scanned[i-1], scanned[i])
+			}
+		}
+
+		// Scan bounds are bytewise too
+		bounded, err := store.ScanKeys("a\x00", "\x80")
+		if err != nil {
+			t.Fatalf("%s: ScanKeys failed: %v", stage, err)
+		}
+		expected := []string{"a\x00", "a\x00b", "z\x01"}
+		if fmt.Sprint(bounded) != fmt.Sprint(expected) {
+			t.Errorf("%s: expected %q, got %q", stage, expected, bounded)
+		}
+	}
+
+	verify("before compaction")
+
+	flushMemTableForTest(t, store)
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
 	}
+
+	verify("after compaction")
 }
 
-func BenchmarkLSMStore_Put(b *testing.B) {
-	tmpDir := b.TempDir()
-	store, _ := NewLSMStore(tmpDir)
+func TestLSMStore_TimeBasedFlush(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
 	defer store.Close()
 
-	value := []byte("benchmark_value_with_reasonable_length")
+	store.SetMaxMemTableAge(100 * time.Millisecond)
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		key := fmt.Sprintf("key_%d", i)
-		store.Put(key, value)
+	for i := 0; i < 5; i++ {
+		if err := store.Put(fmt.Sprintf("key_%d", i), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	// Far below the size threshold, so only the age can trigger the flush
+	deadline := time.Now().Add(2 * time.Second)
+	for store.Stats()["num_sstables"].(int) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("MemTable was not flushed after exceeding its maximum age")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if entries := store.Stats()["memtable_entries"].(int64); entries != 0 {
+		t.Errorf("Expected an empty MemTable after the flush, got %d entries", entries)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := store.Get(fmt.Sprintf("key_%d", i)); err != nil {
+			t.Errorf("Get after time-based flush failed: %v", err)
+		}
+	}
+
+	// An empty MemTable is never flushed
+	time.Sleep(300 * time.Millisecond)
+	if n := store.Stats()["num_sstables"].(int); n != 1 {
+		t.Errorf("Expected 1 SSTable, got %d", n)
 	}
 }
 
-func BenchmarkLSMStore_Get(b *testing.B) {
-	tmpDir := b.TempDir()
-	store, _ := NewLSMStore(tmpDir)
+func TestLSMStore_Flush(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
 	defer store.Close()
 
-	// Prepopulate
-	value := []byte("benchmark_value")
-	for i := 0; i < 10000; i++ {
-		key := fmt.Sprintf("key_%d", i)
-		store.Put(key, value)
+	for i := 0; i < 5; i++ {
+		if err := store.Put(fmt.Sprintf("key_%d", i), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
 	}
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		key := fmt.Sprintf("key_%d", i%10000)
-		store.Get(key)
+	before := store.Stats()["num_sstables"].(int)
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	stats := store.Stats()
+	if n := stats["num_sstables"].(int); n != before+1 {
+		t.Errorf("Expected %d SSTables after Flush, got %d", before+1, n)
+	}
+	if entries := stats["memtable_entries"].(int64); entries != 0 {
+		t.Errorf("Expected an empty MemTable after Flush, got %d entries", entries)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := store.Get(fmt.Sprintf("key_%d", i)); err != nil {
+			t.Errorf("Get after Flush failed: %v", err)
+		}
 	}
-}
 
-func TestSSTable_WithBloomFilter(t *testing.T) {
-	tmpDir := t.TempDir()
+	// Nothing is left for the WAL to replay
+	if segments, _ := filepath.Glob(filepath.Join(dir, "wal_*.log")); len(segments) != 0 {
+		t.Errorf("Expected the WAL segments deleted after Flush, got %v", segments)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "wal.log")); err != nil || info.Size() != 0 {
+		t.Errorf("Expected an empty WAL after Flush, got %v", err)
+	}
 
-	// Create an SSTable with bloom filter
-	writer, err := NewSSTableWriter(tmpDir, 999)
+	// An empty MemTable makes no SSTable
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Second Flush failed: %v", err)
+	}
+	if n := store.Stats()["num_sstables"].(int); n != before+1 {
+		t.Errorf("Expected Flush of an empty MemTable to add no SSTable, got %d", n)
+	}
+}
+
+func TestLSMStore_NonBlockingWritesBusy(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
 	if err != nil {
-		t.Fatalf("Failed to create SSTable writer: %v", err)
+		t.Fatalf("Failed to create LSM store: %v", err)
 	}
+	defer store.Close()
 
-	// Write some test data
-	testData := map[string]string{
-		"apple":      "red",
-		"banana":     "yellow",
-		"cherry":     "red",
-		"date":       "brown",
-		"elderberry": "purple",
+	// Simulate a long-running flush
+	store.flushMu.Lock()
+	store.flushing.Store(true)
+
+	// By default writes are accepted (and would block on the next flush)
+	if err := store.Put("a", make([]byte, 2048)); err != nil {
+		t.Fatalf("Put in blocking mode failed: %v", err)
 	}
 
-	// Write in sorted order
-	keys := []string{"apple", "banana", "cherry", "date", "elderberry"}
-	for _, k := range keys {
-		if err := writer.Write([]byte(k), []byte(testData[k])); err != nil {
-			t.Fatalf("Write failed: %v", err)
+	store.SetNonBlockingWrites(1024)
+
+	done := make(chan error, 1)
+	go func() { done <- store.Put("b", []byte("v")) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrStoreBusy) {
+			t.Errorf("Expected ErrStoreBusy over the ceiling, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Put blocked instead of returning ErrStoreBusy")
+	}
+	if err := store.Delete("a"); !errors.Is(err, ErrStoreBusy) {
+		t.Errorf("Expected ErrStoreBusy from Delete, got %v", err)
+	}
+	if err := store.WriteBatch([]BatchOp{{Op: OpPut, Key: "c", Value: []byte("v")}}); !errors.Is(err, ErrStoreBusy) {
+		t.Errorf("Expected ErrStoreBusy from WriteBatch, got %v", err)
+	}
+	if _, err := store.Get("b"); err != ErrKeyNotFound {
+		t.Errorf("Rejected write should not be applied, got %v", err)
+	}
+
+	// A write that would trigger another flush does not queue behind this one
+	go func() { done <- store.maybeFlush() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("maybeFlush failed: %v", err)
 		}
+	case <-time.After(time.Second):
+		t.Fatal("maybeFlush waited for the running flush")
 	}
 
-	if err := writer.Finalize(); err != nil {
-		t.Fatalf("Finalize failed: %v", err)
+	// Once the flush completes, writes go through again
+	store.flushing.Store(false)
+	store.flushMu.Unlock()
+	if err := store.Put("b", []byte("v")); err != nil {
+		t.Errorf("Put after flush completed failed: %v", err)
+	}
+}
+
+// memTableBytesForTest returns the size of the MemTable plus the queue
+func memTableBytesForTest(store *LSMStore) (int64, int) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	total := store.memTable.Size()
+	for _, imm := range store.immutables {
+		total += imm.table.Size()
 	}
+	return total, len(store.immutables)
+}
 
-	// Open the SSTable
-	sst, err := OpenSSTable(writer.filePath)
+func TestLSMStore_FlushBackpressure(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
 	if err != nil {
-		t.Fatalf("Failed to open SSTable: %v", err)
+		t.Fatalf("Failed to create LSM store: %v", err)
 	}
+	defer store.Close()
 
-	// Check bloom filter exists
-	if !sst.HasBloomFilter() {
-		t.Error("SSTable should have a bloom filter")
+	const threshold = 4096
+	store.memTableThreshold = threshold
+	store.maxImmutables = 2
+
+	// A disk that doesn't finish any SSTable until released
+	release := make(chan struct{})
+	store.beforeFlush = func() { <-release }
+
+	const numKeys = 200
+	value := make([]byte, 512)
+	var lastHot atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < numKeys; i++ {
+			if err := store.Put(fmt.Sprintf("key_%03d", i), value); err != nil {
+				done <- err
+				return
+			}
+			if err := store.Put("hot", []byte(fmt.Sprint(i))); err != nil {
+				done <- err
+				return
+			}
+			lastHot.Store(int64(i))
+		}
+		done <- nil
+	}()
+
+	// The writer fills the queue and then has to wait for the disk
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, queued := memTableBytesForTest(store); queued == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Queue never filled up")
+		}
+		time.Sleep(time.Millisecond)
 	}
+	time.Sleep(100 * time.Millisecond)
 
-	// Test that existing keys are found
-	for k, expectedValue := range testData {
-		value, found, err := sst.Get([]byte(k))
+	select {
+	case err := <-done:
+		t.Fatalf("Writer finished while the disk was stalled: %v", err)
+	default:
+	}
+
+	// Memory is bounded by the queue plus the active MemTable (each may
+	// overshoot the threshold by one write)
+	size, queued := memTableBytesForTest(store)
+	if limit := int64(3 * (threshold + 1024)); size > limit {
+		t.Errorf("Expected at most %d bytes in memory, got %d", limit, size)
+	}
+	if queued != 2 {
+		t.Errorf("Expected 2 queued MemTables, got %d", queued)
+	}
+
+	// Queued MemTables are readable, newest version first
+	if _, err := store.Get("key_000"); err != nil {
+		t.Errorf("Get from a queued MemTable failed: %v", err)
+	}
+	got, err := store.Get("hot")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := fmt.Sprint(lastHot.Load()); string(got) != want {
+		t.Errorf("Expected newest value %s, got %s", want, got)
+	}
+
+	close(release)
+	select {
+	case err := <-done:
 		if err != nil {
-			t.Fatalf("Get failed for key %s: %v", k, err)
+			t.Fatalf("Put failed: %v", err)
 		}
-		if !found {
-			t.Errorf("Key %s not found (should exist)", k)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writer still blocked after the disk caught up")
+	}
+
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if n := store.Stats()["num_sstables"].(int); n == 0 {
+		t.Error("Expected SSTables after the disk caught up")
+	}
+	for i := 0; i < numKeys; i++ {
+		if _, err := store.Get(fmt.Sprintf("key_%03d", i)); err != nil {
+			t.Errorf("Get key_%03d failed: %v", i, err)
 		}
-		if string(value) != expectedValue {
-			t.Errorf("Key %s: expected %s, got %s", k, expectedValue, value)
+	}
+	if got, err := store.Get("hot"); err != nil || string(got) != fmt.Sprint(numKeys-1) {
+		t.Errorf("Expected hot=%d, got %s (%v)", numKeys-1, got, err)
+	}
+
+	// Flushed MemTables' WAL segments are deleted
+	if segments, err := store.wal.Segments(); err != nil || len(segments) != 0 {
+		t.Errorf("Expected no WAL segments, got %v (%v)", segments, err)
+	}
+}
+
+func TestLSMStore_RecoverQueuedMemTables(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store1, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store1.memTableThreshold = 1024
+	release := make(chan struct{})
+	store1.beforeFlush = func() { <-release }
+	defer func() {
+		close(release)
+		store1.Close()
+	}()
+
+	for i := 0; i < 12; i++ {
+		if err := store1.Put(fmt.Sprintf("key_%02d", i), make([]byte, 256)); err != nil {
+			t.Fatalf("Put failed: %v", err)
 		}
 	}
+	if err := store1.Delete("key_00"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store1.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, queued := memTableBytesForTest(store1); queued == 0 {
+		t.Fatal("Expected queued MemTables")
+	}
 
-	// Test that non-existent keys return false (bloom filter should help)
-	nonExistent := []string{"fig", "grape", "kiwi", "lemon", "mango"}
-	for _, k := range nonExistent {
-		value, found, err := sst.Get([]byte(k))
+	// Crash with MemTables still waiting for the disk
+	crashDir := t.TempDir()
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(tmpDir, file.Name()))
 		if err != nil {
-			t.Fatalf("Get failed for key %s: %v", k, err)
+			t.Fatal(err)
 		}
-		if found {
-			t.Errorf("Key %s found but shouldn't exist (got value: %s)", k, value)
+		if err := os.WriteFile(filepath.Join(crashDir, file.Name()), data, 0644); err != nil {
+			t.Fatal(err)
 		}
 	}
 
-	// Print bloom filter stats
+	store2, err := NewLSMStore(crashDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	if _, err := store2.Get("key_00"); err != ErrKeyNotFound {
+		t.Errorf("Expected deleted key to stay deleted, got %v", err)
+	}
+	for i := 1; i < 12; i++ {
+		if _, err := store2.Get(fmt.Sprintf("key_%02d", i)); err != nil {
+			t.Errorf("Get key_%02d after crash failed: %v", i, err)
+		}
+	}
+}
+
+func TestLSMStore_CrashRecovery(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store1, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Write some data
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		value := fmt.Sprintf("value_%d", i)
+		if err := store1.Put(key, []byte(value)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	// Close without explicit flush (data in MemTable + WAL)
+	store1.Close()
+
+	// Reopen - should recover from WAL
+	store2, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	// Verify all data recovered
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		expectedValue := fmt.Sprintf("value_%d", i)
+
+		value, err := store2.Get(key)
+		if err != nil {
+			t.Fatalf("Get failed after recovery for key %s: %v", key, err)
+		}
+		if string(value) != expectedValue {
+			t.Errorf("Key %s: expected %s, got %s", key, expectedValue, value)
+		}
+	}
+}
+
+func TestLSMStore_SplitDirectories(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	opts := StoreOptions{
+		WALDir:     filepath.Join(root, "nvme", "wal"),
+		SSTableDir: filepath.Join(root, "bulk", "sstables"),
+	}
+
+	store1, err := NewLSMStoreWithOptions(dataDir, opts)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	store1.memTableThreshold = 1024
+	defer store1.Close()
+
+	// Enough to flush some SSTables, with the rest left in the WAL
+	for i := 0; i < 20; i++ {
+		if err := store1.Put(fmt.Sprintf("key_%02d", i), make([]byte, 256)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store1.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store1.Put("unflushed", []byte("in the WAL")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store1.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	glob := func(dir, pattern string) []string {
+		t.Helper()
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return files
+	}
+	if len(glob(opts.SSTableDir, "sstable_*.db")) == 0 {
+		t.Error("Expected SSTables in the SSTable directory")
+	}
+	if len(glob(opts.WALDir, "wal.log")) != 1 {
+		t.Error("Expected wal.log in the WAL directory")
+	}
+	if files := glob(dataDir, "*.*"); len(files) != 0 {
+		t.Errorf("Expected no WAL or SSTables in the data directory, found %v", files)
+	}
+	if len(glob(opts.SSTableDir, "wal*")) != 0 || len(glob(opts.WALDir, "sstable_*")) != 0 {
+		t.Error("Files landed in the wrong directory")
+	}
+
+	// Crash: copy the three directories as they are and recover from them
+	crashRoot := t.TempDir()
+	crashOpts := StoreOptions{
+		WALDir:     filepath.Join(crashRoot, "wal"),
+		SSTableDir: filepath.Join(crashRoot, "sstables"),
+	}
+	for from, to := range map[string]string{
+		dataDir:         filepath.Join(crashRoot, "data"),
+		opts.WALDir:     crashOpts.WALDir,
+		opts.SSTableDir: crashOpts.SSTableDir,
+	} {
+		if err := os.CopyFS(to, os.DirFS(from)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store2, err := NewLSMStoreWithOptions(filepath.Join(crashRoot, "data"), crashOpts)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	for i := 0; i < 20; i++ {
+		if _, err := store2.Get(fmt.Sprintf("key_%02d", i)); err != nil {
+			t.Errorf("Get key_%02d after recovery failed: %v", i, err)
+		}
+	}
+	if value, err := store2.Get("unflushed"); err != nil || string(value) != "in the WAL" {
+		t.Errorf("Expected the unflushed write recovered from the WAL, got %q, %v", value, err)
+	}
+}
+
+func TestLSMStore_SyncSurvivesCrash(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store1, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store1.Close()
+	store1.SetWALSyncInterval(10 * time.Millisecond)
+
+	if err := store1.Put("checkpoint", []byte("v1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store1.Sync(); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// Snapshot the directory as a crash would leave it: no Close, no flush
+	crashDir := t.TempDir()
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(tmpDir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(crashDir, file.Name()), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store2, err := NewLSMStore(crashDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	value, err := store2.Get("checkpoint")
+	if err != nil {
+		t.Fatalf("Get after crash failed: %v", err)
+	}
+	if string(value) != "v1" {
+		t.Errorf("Expected v1, got %s", value)
+	}
+}
+
+func TestLSMStore_WriteBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("stale", []byte("old")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	err = store.WriteBatch([]BatchOp{
+		{Op: OpPut, Key: "a", Value: []byte("1")},
+		{Op: OpPut, Key: "b", Value: []byte("2")},
+		{Op: OpDelete, Key: "stale"},
+	})
+	if err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	for k, v := range map[string]string{"a": "1", "b": "2"} {
+		value, err := store.Get(k)
+		if err != nil || string(value) != v {
+			t.Errorf("Key %s: expected %s, got %s (err: %v)", k, v, value, err)
+		}
+	}
+	if _, err := store.Get("stale"); err != ErrKeyNotFound {
+		t.Errorf("Expected stale to be deleted, got %v", err)
+	}
+
+	// An invalid op rejects the whole batch
+	err = store.WriteBatch([]BatchOp{
+		{Op: OpPut, Key: "c", Value: []byte("3")},
+		{Op: OpType(99), Key: "d"},
+	})
+	if err == nil {
+		t.Fatal("Expected error for invalid op type")
+	}
+	if _, err := store.Get("c"); err != ErrKeyNotFound {
+		t.Errorf("Expected no part of a rejected batch to be applied, got %v", err)
+	}
+}
+
+func TestLSMStore_WriteBatchRecovery(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store1, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store1.Put("before", []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	var ops []BatchOp
+	for i := 0; i < 50; i++ {
+		ops = append(ops, BatchOp{Op: OpPut, Key: fmt.Sprintf("batch_%d", i), Value: []byte(fmt.Sprintf("value_%d", i))})
+	}
+	ops = append(ops, BatchOp{Op: OpDelete, Key: "before"})
+
+	if err := store1.WriteBatch(ops); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	// Crash right after the batch record: nothing is flushed to SSTables
+	store1.compactionMgr.Stop()
+	store1.wal.Close()
+
+	store2, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	for i := 0; i < 50; i++ {
+		value, err := store2.Get(fmt.Sprintf("batch_%d", i))
+		if err != nil {
+			t.Fatalf("Get failed after recovery for batch_%d: %v", i, err)
+		}
+		if string(value) != fmt.Sprintf("value_%d", i) {
+			t.Errorf("batch_%d: expected value_%d, got %s", i, i, value)
+		}
+	}
+	if _, err := store2.Get("before"); err != ErrKeyNotFound {
+		t.Errorf("Expected delete from batch to be replayed, got %v", err)
+	}
+}
+
+func TestLSMStore_TornWriteBatchDiscarded(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	store1, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store1.Put("before", []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	err = store1.WriteBatch([]BatchOp{
+		{Op: OpPut, Key: "a", Value: []byte("1")},
+		{Op: OpPut, Key: "b", Value: []byte("2")},
+	})
+	if err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	store1.compactionMgr.Stop()
+	store1.wal.Close()
+
+	// Cut the batch record in half, as if the crash happened mid-write
+	walPath := filepath.Join(tmpDir, "wal.log")
+	info, err := os.Stat(walPath)
+	if err != nil {
+		t.Fatalf("Failed to stat WAL: %v", err)
+	}
+	if err := os.Truncate(walPath, info.Size()-8); err != nil {
+		t.Fatalf("Failed to truncate WAL: %v", err)
+	}
+
+	store2, err := NewLSMStore(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store2.Close()
+
+	if value, err := store2.Get("before"); err != nil || string(value) != "x" {
+		t.Errorf("Expected record before the batch to survive, got %s (err: %v)", value, err)
+	}
+	for _, k := range []string{"a", "b"} {
+		if _, err := store2.Get(k); err != ErrKeyNotFound {
+			t.Errorf("Expected torn batch to be discarded, but %s is present (err: %v)", k, err)
+		}
+	}
+}
+
+func TestLSMStore_Exists(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"flushed", "flushed_deleted", "deleted_later"} {
+		if err := store.Put(key, []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.Delete("flushed_deleted"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	flushMemTableForTest(t, store)
+
+	if err := store.Put("in_memtable", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// A value whose length matches a legacy tombstone's must not be mistaken for one
+	if err := store.Put("tombstone_sized", make([]byte, len(legacyTombstoneMarker))); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("deleted_later"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	flushMemTableForTest(t, store)
+	if err := store.Put("in_memtable_2", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("in_memtable_2"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	tests := map[string]bool{
+		"flushed":         true,
+		"in_memtable":     true,
+		"tombstone_sized": true,
+		"missing":         false,
+		"flushed_deleted": false, // Tombstone in the same SSTable
+		"deleted_later":   false, // Tombstone in a newer SSTable
+		"in_memtable_2":   false, // Tombstone in the MemTable
+	}
+	for key, want := range tests {
+		got, err := store.Exists(key)
+		if err != nil {
+			t.Errorf("Exists(%q) failed: %v", key, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Exists(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestLSMStore_EmptyValueIsNotADelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+
+	expectEmpty := func(stage string) {
+		t.Helper()
+		value, err := store.Get("empty")
+		if err != nil {
+			t.Fatalf("%s: expected the empty value found, got %v", stage, err)
+		}
+		if value == nil || len(value) != 0 {
+			t.Errorf("%s: expected a non-nil empty value, got %q", stage, value)
+		}
+		if exists, err := store.Exists("empty"); err != nil || !exists {
+			t.Errorf("%s: Exists = %v, %v", stage, exists, err)
+		}
+		entries, err := store.Scan("", "")
+		if err != nil || len(entries) != 1 || string(entries[0].Key) != "empty" || len(entries[0].Value) != 0 {
+			t.Errorf("%s: expected Scan to return the empty value, got %+v, %v", stage, entries, err)
+		}
+	}
+
+	if err := store.Put("empty", nil); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	expectEmpty("MemTable")
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expectEmpty("SSTable")
+
+	if err := store.Put("other", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CompactionManager().ForceCompact(); err != nil {
+		t.Fatalf("ForceCompact failed: %v", err)
+	}
+	expectEmpty("compacted")
+
+	if err := store.Put("empty", []byte{}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	store.Close()
+	if store, err = NewLSMStore(dir); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer func() { store.Close() }()
+	expectEmpty("WAL replay")
+
+	if err := store.Delete("empty"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("empty"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound after Delete, got %v", err)
+	}
+	if exists, _ := store.Exists("empty"); exists {
+		t.Error("Expected the deleted key not to exist")
+	}
+}
+
+func TestLSMStore_TombstoneMarkerIsAValue(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+
+	// Deletes are marked out of band, so the old marker value, alone or
+	// followed by a timestamp, is stored like any other
+	values := map[string][]byte{
+		"marker":     []byte("__TOMBSTONE__"),
+		"stamped":    newLegacyTombstone(time.Now().UnixNano()),
+		"zz_deleted": []byte("value"),
+	}
+	expectValues := func(stage string) {
+		t.Helper()
+		for key, want := range values {
+			if key == "zz_deleted" {
+				continue
+			}
+			value, err := store.Get(key)
+			if err != nil || !bytes.Equal(value, want) {
+				t.Errorf("%s: Get(%q) = %q, %v, want %q", stage, key, value, err, want)
+			}
+			if exists, err := store.Exists(key); err != nil || !exists {
+				t.Errorf("%s: Exists(%q) = %v, %v", stage, key, exists, err)
+			}
+		}
+		if _, err := store.Get("zz_deleted"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("%s: expected ErrKeyNotFound for the deleted key, got %v", stage, err)
+		}
+		entries, err := store.Scan("", "")
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("%s: expected Scan to return the 2 values, got %+v, %v", stage, entries, err)
+		}
+		for _, entry := range entries {
+			if !bytes.Equal(entry.Value, values[string(entry.Key)]) {
+				t.Errorf("%s: Scan returned %s=%q, want %q", stage, entry.Key, entry.Value, values[string(entry.Key)])
+			}
+		}
+	}
+
+	for key, value := range values {
+		if err := store.Put(key, value); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.Delete("zz_deleted"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	expectValues("MemTable")
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	expectValues("SSTable")
+
+	if err := store.CompactionManager().ForceCompact(); err != nil {
+		t.Fatalf("ForceCompact failed: %v", err)
+	}
+	expectValues("compacted")
+
+	if err := store.Put("marker", values["marker"]); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	store.Close()
+	if store, err = NewLSMStore(dir); err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer func() { store.Close() }()
+	expectValues("WAL replay")
+}
+
+func TestLSMStore_PutIfAbsent(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	if written, err := store.PutIfAbsent("config", []byte("v1")); err != nil || !written {
+		t.Fatalf("Expected first PutIfAbsent to write, got %v, %v", written, err)
+	}
+	if written, err := store.PutIfAbsent("config", []byte("v2")); err != nil || written {
+		t.Fatalf("Expected PutIfAbsent on an existing key to do nothing, got %v, %v", written, err)
+	}
+	if value, _ := store.Get("config"); string(value) != "v1" {
+		t.Errorf("Expected v1, got %s", value)
+	}
+
+	// A key that only exists in an SSTable is present too
+	flushMemTableForTest(t, store)
+	if written, err := store.PutIfAbsent("config", []byte("v3")); err != nil || written {
+		t.Errorf("Expected PutIfAbsent on a flushed key to do nothing, got %v, %v", written, err)
+	}
+
+	// A deleted key is absent, even though an older value is in an SSTable
+	if err := store.Delete("config"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get("config"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound after Delete, got %v", err)
+	}
+	if written, err := store.PutIfAbsent("config", []byte("v4")); err != nil || !written {
+		t.Errorf("Expected PutIfAbsent after Delete to write, got %v, %v", written, err)
+	}
+}
+
+// spanRecorder keeps every span it is given
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+func (r *spanRecorder) ExportSpan(span *tracing.Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+// trace returns the spans of one trace and clears the recorder
+func (r *spanRecorder) trace(traceID tracing.TraceID) []*tracing.Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var spans []*tracing.Span
+	for _, span := range r.spans {
+		if span.TraceID == traceID {
+			spans = append(spans, span)
+		}
+	}
+	r.spans = nil
+	return spans
+}
+
+func TestLSMStore_GetTracesLayers(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("flushed", []byte("on disk")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.flushIfOlderThan(0); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.Put("fresh", []byte("in memory")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	recorder := &spanRecorder{}
+	tracing.SetExporter(recorder)
+	defer tracing.SetExporter(nil)
+
+	// getTraced returns the layer spans of a Get, checking they hang off
+	// the storage.Get span under the caller's
+	getTraced := func(key string) map[string]*tracing.Span {
+		t.Helper()
+		ctx, root := tracing.Start(context.Background(), "request")
+		if _, _, err := store.GetWithTimestampContext(ctx, key); err != nil {
+			t.Fatalf("Get(%s) failed: %v", key, err)
+		}
+		root.End()
+
+		layers := make(map[string]*tracing.Span)
+		var get *tracing.Span
+		spans := recorder.trace(root.TraceID)
+		for _, span := range spans {
+			switch span.Name {
+			case "storage.Get":
+				get = span
+			case "storage.MemTable", "storage.SSTable":
+				layers[span.Name] = span
+			}
+		}
+		if get == nil || get.ParentID != root.SpanID {
+			t.Fatalf("Expected a storage.Get span under the caller's, got %d spans", len(spans))
+		}
+		for _, span := range layers {
+			if span.ParentID != get.SpanID {
+				t.Errorf("%s span is not under storage.Get", span.Name)
+			}
+		}
+		return layers
+	}
+
+	layers := getTraced("fresh")
+	if span := layers["storage.MemTable"]; span == nil || span.Attributes()["found"] != "true" {
+		t.Error("Expected the MemTable span to find the fresh key")
+	}
+	if layers["storage.SSTable"] != nil {
+		t.Error("Expected no SSTable read for a key found in the MemTable")
+	}
+
+	layers = getTraced("flushed")
+	if span := layers["storage.MemTable"]; span == nil || span.Attributes()["found"] != "false" {
+		t.Error("Expected the MemTable span to miss the flushed key")
+	}
+	span := layers["storage.SSTable"]
+	if span == nil || span.Attributes()["found"] != "true" || span.Attributes()["sstable"] == "" {
+		t.Fatal("Expected an SSTable span naming the table that held the key")
+	}
+}
+
+func TestLSMStore_GetRange(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("blob", []byte("0123456789")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	tests := []struct {
+		offset, length int
+		want           string
+	}{
+		{0, 4, "0123"},
+		{6, 4, "6789"},
+		{3, 0, ""},
+		{10, 0, ""}, // An empty range at the very end is in bounds
+	}
+	for _, tt := range tests {
+		value, err := store.GetRange("blob", tt.offset, tt.length)
+		if err != nil {
+			t.Errorf("GetRange(%d, %d) failed: %v", tt.offset, tt.length, err)
+			continue
+		}
+		if string(value) != tt.want {
+			t.Errorf("GetRange(%d, %d) = %q, want %q", tt.offset, tt.length, value, tt.want)
+		}
+	}
+
+	outOfBounds := []struct {
+		name           string
+		offset, length int
+	}{
+		{"offset past end", 11, 0},
+		{"length exceeds value", 0, 11},
+		{"range runs past end", 8, 3},
+		{"negative offset", -1, 2},
+		{"negative length", 2, -1},
+	}
+	for _, tt := range outOfBounds {
+		if _, err := store.GetRange("blob", tt.offset, tt.length); !errors.Is(err, ErrRangeOutOfBounds) {
+			t.Errorf("%s: expected ErrRangeOutOfBounds, got %v", tt.name, err)
+		}
+	}
+
+	if _, err := store.GetRange("missing", 0, 1); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for a missing key, got %v", err)
+	}
+}
+
+func TestLSMStore_DeleteWithResult(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	deleteWithResult := func(key string, want bool) {
+		t.Helper()
+		existed, err := store.DeleteWithResult(key)
+		if err != nil {
+			t.Fatalf("DeleteWithResult(%q) failed: %v", key, err)
+		}
+		if existed != want {
+			t.Errorf("DeleteWithResult(%q) = %v, want %v", key, existed, want)
+		}
+	}
+
+	for _, key := range []string{"in_memtable", "in_sstable", "deleted_in_sstable"} {
+		if err := store.Put(key, []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	deleteWithResult("in_memtable", true)
+	deleteWithResult("in_memtable", false) // Tombstone in the MemTable
+	deleteWithResult("missing", false)
+
+	if err := store.Delete("deleted_in_sstable"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	flushMemTableForTest(t, store)
+
+	deleteWithResult("in_sstable", true)
+	deleteWithResult("deleted_in_sstable", false) // Tombstone in an SSTable
+	deleteWithResult("in_memtable", false)        // Tombstone flushed too
+
+	// The tombstone written by DeleteWithResult shadows the flushed value
+	flushMemTableForTest(t, store)
+	if _, err := store.Get("in_sstable"); err != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound after DeleteWithResult, got %v", err)
+	}
+	deleteWithResult("in_sstable", false)
+}
+
+// Test: a conditional write over a delete stamped ahead of this node's
+// clock, as a replayed replica write can be, is not shadowed by it
+func TestLSMStore_PutIfAbsentAfterNewerTombstone(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	future := time.Now().Add(time.Hour).UnixNano()
+	for _, key := range []string{"memtable", "sstable", "updated"} {
+		store.mu.Lock()
+		store.memTable.Delete([]byte(key), future)
+		store.mu.Unlock()
+	}
+	flushMemTableForTest(t, store)
+	store.mu.Lock()
+	store.memTable.Delete([]byte("memtable"), future+1)
+	store.mu.Unlock()
+
+	for _, key := range []string{"memtable", "sstable"} {
+		written, err := store.PutIfAbsent(key, []byte("value"))
+		if err != nil || !written {
+			t.Fatalf("PutIfAbsent(%s) = %v, %v", key, written, err)
+		}
+		if value, err := store.Get(key); err != nil || string(value) != "value" {
+			t.Errorf("Expected PutIfAbsent(%s) to be read back, got %q, %v", key, value, err)
+		}
+	}
+
+	if _, err := store.Update("updated", func([]byte, bool) ([]byte, error) { return []byte("value"), nil }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if value, err := store.Get("updated"); err != nil || string(value) != "value" {
+		t.Errorf("Expected the Update to be read back, got %q, %v", value, err)
+	}
+}
+
+func TestLSMStore_PutIfAbsentConcurrent(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	const callers = 50
+	var wg sync.WaitGroup
+	var winners atomic.Int32
+	winner := make(chan string, callers)
+	start := make(chan struct{})
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			value := fmt.Sprintf("caller_%d", i)
+			written, err := store.PutIfAbsent("leader", []byte(value))
+			if err != nil {
+				t.Errorf("PutIfAbsent failed: %v", err)
+				return
+			}
+			if written {
+				winners.Add(1)
+				winner <- value
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if n := winners.Load(); n != 1 {
+		t.Fatalf("Expected exactly 1 caller to write, got %d", n)
+	}
+	value, err := store.Get("leader")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if want := <-winner; string(value) != want {
+		t.Errorf("Expected the winner's value %s, got %s", want, value)
+	}
+}
+
+func TestLSMStore_UpdateConcurrent(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// Each caller appends one byte; a lost update would drop one
+	appendOne := func(current []byte, found bool) ([]byte, error) {
+		return append(append([]byte(nil), current...), 'x'), nil
+	}
+
+	const callers = 50
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Update("log", appendOne); err != nil {
+				t.Errorf("Update failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := store.Get("log")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(value) != callers {
+		t.Errorf("Expected %d bytes, got %d", callers, len(value))
+	}
+
+	// A failing update leaves the value alone, and a deleted key isn't found
+	if _, err := store.Update("log", func([]byte, bool) ([]byte, error) { return nil, errors.New("no") }); err == nil {
+		t.Error("Expected the update's error")
+	}
+	if err := store.Delete("log"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Update("log", func(current []byte, found bool) ([]byte, error) {
+		if found {
+			t.Errorf("Deleted key found with %q", current)
+		}
+		return []byte("fresh"), nil
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+}
+
+func TestLSMStore_PutIfVersion(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("key", []byte("v1")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	_, version, err := store.GetWithTimestamp("key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	// The version is checked from an SSTable as well as the MemTable
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	newVersion, err := store.PutIfVersion("key", []byte("v2"), version)
+	if err != nil || newVersion <= version {
+		t.Fatalf("Expected a newer version than %d, got %d (err: %v)", version, newVersion, err)
+	}
+
+	// The version read before the write is now stale
+	if _, err := store.PutIfVersion("key", []byte("v3"), version); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("Expected ErrVersionMismatch, got %v", err)
+	}
+	value, current, err := store.GetWithTimestamp("key")
+	if err != nil || string(value) != "v2" || current != newVersion {
+		t.Errorf("Expected v2 at version %d, got %q at %d (err: %v)", newVersion, value, current, err)
+	}
+
+	// A deleted key has no version
+	if err := store.Delete("key"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.PutIfVersion("key", []byte("v4"), newVersion); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected ErrVersionMismatch for a deleted key, got %v", err)
+	}
+}
+
+func TestLSMStore_StaleTimestampWrite(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// Get, Exists and Scan must all pick the version with the higher
+	// timestamp, whichever layer holds it
+	check := func(stage string) {
+		t.Helper()
+		value, timestamp, err := store.GetWithTimestamp("k")
+		if err != nil || string(value) != "new" || timestamp != 200 {
+			t.Errorf("%s: expected Get to return new at 200, got %q at %d (err: %v)", stage, value, timestamp, err)
+		}
+		if exists, err := store.Exists("k"); err != nil || !exists {
+			t.Errorf("%s: expected k to exist, got %v (err: %v)", stage, exists, err)
+		}
+		entries, err := store.Scan("", "")
+		if err != nil || len(entries) != 1 || string(entries[0].Value) != "new" {
+			t.Errorf("%s: expected Scan to return new, got %v (err: %v)", stage, entries, err)
+		}
+	}
+
+	if err := store.PutWithTimestamp("k", []byte("new"), 200); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.PutWithTimestamp("k", []byte("old"), 100); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	check("both in the MemTable")
+
+	// The older write lands in a newer layer than the version that beats it
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.PutWithTimestamp("k", []byte("old"), 100); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	check("old write in the MemTable")
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	check("old write in a newer SSTable")
+
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+	check("after compaction")
+
+	// Update reads the same version, and writes after it
+	if err := store.PutWithTimestamp("k", []byte("old"), 100); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if _, err := store.Update("k", func(current []byte, found bool) ([]byte, error) {
+		if !found || string(current) != "new" {
+			t.Errorf("Expected Update to read new, got %q (found: %v)", current, found)
+		}
+		return []byte("newer"), nil
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if value, err := store.Get("k"); err != nil || string(value) != "newer" {
+		t.Errorf("Expected newer, got %q (err: %v)", value, err)
+	}
+}
+
+func TestLSMStore_MaxValueSize(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Put("max", make([]byte, MaxValueSize)); err != nil {
+		t.Fatalf("Put of a MaxValueSize value failed: %v", err)
+	}
+
+	tooLarge := make([]byte, MaxValueSize+1)
+	if err := store.Put("big", tooLarge); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge from Put, got %v", err)
+	}
+	if err := store.WriteBatch([]BatchOp{{Op: OpPut, Key: "big", Value: tooLarge}}); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("Expected ErrValueTooLarge from WriteBatch, got %v", err)
+	}
+	if _, err := store.Get("big"); err != ErrKeyNotFound {
+		t.Errorf("Rejected value should not be stored, got %v", err)
+	}
+}
+
+func TestLSMStore_OpenReadOnly(t *testing.T) {
+	dir := t.TempDir()
+
+	// Closing flushes these to an SSTable
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := store.Put(fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.Delete("key3"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// This one is only in the WAL of a store that is still open
+	store, err = NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen LSM store: %v", err)
+	}
+	defer store.Close()
+	if err := store.Put("unflushed", []byte("wal")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	listing := func() map[string]int64 {
+		files, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatalf("ReadDir failed: %v", err)
+		}
+		sizes := make(map[string]int64)
+		for _, file := range files {
+			info, err := file.Info()
+			if err != nil {
+				t.Fatalf("Stat failed: %v", err)
+			}
+			sizes[file.Name()] = info.Size()
+		}
+		return sizes
+	}
+	before := listing()
+
+	ro, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+
+	if value, err := ro.Get("key5"); err != nil || string(value) != "value5" {
+		t.Errorf("Expected value5 from SSTable, got %q (err=%v)", value, err)
+	}
+	if value, err := ro.Get("unflushed"); err != nil || string(value) != "wal" {
+		t.Errorf("Expected WAL write to be visible, got %q (err=%v)", value, err)
+	}
+	if _, err := ro.Get("key3"); err != ErrKeyNotFound {
+		t.Errorf("Expected deleted key to be missing, got %v", err)
+	}
+	entries, err := ro.Scan("", "")
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(entries) != 10 {
+		t.Errorf("Expected 10 live keys, got %d", len(entries))
+	}
+
+	if err := ro.Put("key1", []byte("new")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Put: expected ErrReadOnly, got %v", err)
+	}
+	if err := ro.Delete("key1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Delete: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := ro.PutIfAbsent("new", []byte("v")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("PutIfAbsent: expected ErrReadOnly, got %v", err)
+	}
+	if err := ro.WriteBatch([]BatchOp{{Op: OpPut, Key: "k", Value: []byte("v")}}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteBatch: expected ErrReadOnly, got %v", err)
+	}
+	if err := ro.CompactionManager().ForceCompact(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("ForceCompact: expected ErrReadOnly, got %v", err)
+	}
+	if value, _ := ro.Get("key1"); string(value) != "value1" {
+		t.Errorf("Rejected write changed key1 to %q", value)
+	}
+
+	if err := ro.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	after := listing()
+	if len(after) != len(before) {
+		t.Errorf("Files changed: before %v, after %v", before, after)
+	}
+	for name, size := range before {
+		if after[name] != size {
+			t.Errorf("%s changed: %d -> %d bytes", name, size, after[name])
+		}
+	}
+
+	// A missing directory is an error, not created
+	missing := filepath.Join(dir, "missing")
+	if _, err := OpenReadOnly(missing); err == nil {
+		t.Error("Expected error opening a missing directory")
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("OpenReadOnly created %s", missing)
+	}
+}
+
+func TestMemTable_SizeAccounting(t *testing.T) {
+	mt := NewMemTable()
+	model := make(map[string][]byte)
+
+	check := func(stage string) {
+		t.Helper()
+
+		var expected int64
+		for k, v := range model {
+			expected += int64(len(k)+len(v)) + entryOverhead
+		}
+
+		var recomputed int64
+		for _, entry := range mt.Iterator() {
+			recomputed += entrySize(entry.Key, entry.Value)
+		}
+
+		if mt.Size() != expected || recomputed != expected {
+			t.Fatalf("%s: Size()=%d, recomputed=%d, expected %d", stage, mt.Size(), recomputed, expected)
+		}
+		if mt.Len() != int64(len(model)) {
+			t.Fatalf("%s: Len()=%d, expected %d", stage, mt.Len(), len(model))
+		}
+	}
+
+	// Inserts with varying value sizes
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key_%03d", i)
+		value := make([]byte, i*7%50)
+		mt.Put([]byte(key), value, time.Now().UnixNano())
+		model[key] = value
+	}
+	check("after inserts")
+
+	// Updates that both grow and shrink values
+	for i := 0; i < 100; i += 2 {
+		key := fmt.Sprintf("key_%03d", i)
+		value := make([]byte, (i*13)%80)
+		mt.Put([]byte(key), value, time.Now().UnixNano())
+		model[key] = value
+	}
+	check("after updates")
+
+	// Deletes of existing and missing keys
+	for i := 0; i < 150; i += 3 {
+		key := fmt.Sprintf("key_%03d", i)
+		mt.Delete([]byte(key), time.Now().UnixNano())
+		model[key] = nil
+	}
+	check("after deletes")
+
+	// Re-insert over tombstones
+	for i := 0; i < 150; i += 6 {
+		key := fmt.Sprintf("key_%03d", i)
+		value := []byte("revived")
+		mt.Put([]byte(key), value, time.Now().UnixNano())
+		model[key] = value
+	}
+	check("after re-inserts")
+
+	mt.Clear()
+	model = make(map[string][]byte)
+	check("after clear")
+}
+
+func TestMemTable_IteratorConcurrentWrites(t *testing.T) {
+	memTable := NewMemTable()
+	for i := 0; i < 1000; i += 2 {
+		memTable.Put([]byte(fmt.Sprintf("key%04d", i)), []byte("value"), int64(i))
+	}
+
+	// Fill in the odd keys, overwrite and delete even ones while iterating
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			n := i % 1000
+			key := []byte(fmt.Sprintf("key%04d", n))
+			switch {
+			case n%2 == 1:
+				memTable.Put(key, []byte("new"), int64(i))
+			case n%3 == 0:
+				memTable.Delete(key, int64(i))
+			default:
+				memTable.Put(key, []byte("overwritten"), int64(i))
+			}
+		}
+	}()
+
+	for round := 0; round < 20; round++ {
+		it := memTable.NewIterator()
+		var prev []byte
+		seen := 0
+		for it.Next() {
+			if prev != nil && bytes.Compare(prev, it.Key()) >= 0 {
+				t.Fatalf("Keys out of order: %q after %q", it.Key(), prev)
+			}
+			if it.Value() == nil {
+				t.Fatalf("Key %q has no value", it.Key())
+			}
+			prev = it.Key()
+			seen++
+		}
+		// Keys present before the iterator started are always seen
+		if seen < 500 {
+			t.Fatalf("Expected at least the 500 original keys, saw %d", seen)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	// Seek starts at the first key at or after the target; deletes show up
+	// as tombstones
+	memTable.Delete([]byte("key0600"), time.Now().UnixNano())
+	it := memTable.NewIterator()
+	it.Seek([]byte("key0599x"))
+	if !it.Next() || string(it.Key()) != "key0600" || !it.Deleted() {
+		t.Errorf("Expected the deleted key0600 after Seek, got %q (deleted=%v)", it.Key(), it.Deleted())
+	}
+}
+
+func TestMemTable_SkipList(t *testing.T) {
+	mem := NewMemTable()
+
+	// Insert in random order
+	testData := map[string]string{
+		"zebra":  "stripes",
+		"apple":  "red",
+		"mango":  "yellow",
+		"banana": "yellow",
+	}
+
+	for k, v := range testData {
+		mem.Put([]byte(k), []byte(v), time.Now().UnixNano())
+	}
+
+	// Get all entries - should be sorted
+	entries := mem.Iterator()
+
+	if len(entries) != len(testData) {
+		t.Errorf("Expected %d entries, got %d", len(testData), len(entries))
+	}
+
+	// Verify sorted order
+	prevKey := ""
+	for _, entry := range entries {
+		key := string(entry.Key)
+		if prevKey != "" && key < prevKey {
+			t.Errorf("Entries not in sorted order: %s came after %s", key, prevKey)
+		}
+		prevKey = key
+	}
+
+	t.Logf("Sorted order: ")
+	for _, entry := range entries {
+		t.Logf("  %s: %s", entry.Key, entry.Value)
+	}
+}
+
+func BenchmarkLSMStore_Put(b *testing.B) {
+	tmpDir := b.TempDir()
+	store, _ := NewLSMStore(tmpDir)
+	defer store.Close()
+
+	value := []byte("benchmark_value_with_reasonable_length")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		store.Put(key, value)
+	}
+}
+
+func BenchmarkLSMStore_PutWithStatsPolling(b *testing.B) {
+	tmpDir := b.TempDir()
+	store, _ := NewLSMStore(tmpDir)
+	defer store.Close()
+
+	value := []byte("benchmark_value_with_reasonable_length")
+
+	// Poll Stats as fast as possible while Puts run
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				store.Stats()
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		store.Put(key, value)
+	}
+	b.StopTimer()
+
+	close(stop)
+	<-done
+}
+
+func BenchmarkLSMStore_Get(b *testing.B) {
+	tmpDir := b.TempDir()
+	store, _ := NewLSMStore(tmpDir)
+	defer store.Close()
+
+	// Prepopulate
+	value := []byte("benchmark_value")
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key_%d", i)
+		store.Put(key, value)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := fmt.Sprintf("key_%d", i%10000)
+		store.Get(key)
+	}
+}
+
+func TestSSTable_WithBloomFilter(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create an SSTable with bloom filter
+	writer, err := NewSSTableWriter(tmpDir, 999)
+	if err != nil {
+		t.Fatalf("Failed to create SSTable writer: %v", err)
+	}
+
+	// Write some test data
+	testData := map[string]string{
+		"apple":      "red",
+		"banana":     "yellow",
+		"cherry":     "red",
+		"date":       "brown",
+		"elderberry": "purple",
+	}
+
+	// Write in sorted order
+	keys := []string{"apple", "banana", "cherry", "date", "elderberry"}
+	for _, k := range keys {
+		if err := writer.Write([]byte(k), []byte(testData[k]), time.Now().UnixNano()); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if err := writer.Finalize(); err != nil {
+		t.Fatalf("Finalize failed: %v", err)
+	}
+
+	// Open the SSTable
+	sst, err := OpenSSTable(writer.filePath)
+	if err != nil {
+		t.Fatalf("Failed to open SSTable: %v", err)
+	}
+
+	// Check bloom filter exists
+	if !sst.HasBloomFilter() {
+		t.Error("SSTable should have a bloom filter")
+	}
+
+	// Test that existing keys are found
+	for k, expectedValue := range testData {
+		value, found, err := sst.Get([]byte(k))
+		if err != nil {
+			t.Fatalf("Get failed for key %s: %v", k, err)
+		}
+		if !found {
+			t.Errorf("Key %s not found (should exist)", k)
+		}
+		if string(value) != expectedValue {
+			t.Errorf("Key %s: expected %s, got %s", k, expectedValue, value)
+		}
+	}
+
+	// Test that non-existent keys return false (bloom filter should help)
+	nonExistent := []string{"fig", "grape", "kiwi", "lemon", "mango"}
+	for _, k := range nonExistent {
+		value, found, err := sst.Get([]byte(k))
+		if err != nil {
+			t.Fatalf("Get failed for key %s: %v", k, err)
+		}
+		if found {
+			t.Errorf("Key %s found but shouldn't exist (got value: %s)", k, value)
+		}
+	}
+
+	// Print bloom filter stats
 	stats := sst.BloomFilterStats()
 	t.Logf("Bloom Filter Stats: %+v", stats)
 }
+
+// Test: a key outside every SSTable's key range is answered without
+// searching any index
+func TestLSMStore_KeyRangeSkipsSSTables(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Two tables, [b1, b3] and [d1, d3]
+	for _, prefix := range []string{"b", "d"} {
+		for i := 1; i <= 3; i++ {
+			key := fmt.Sprintf("%s%d", prefix, i)
+			if err := store.Put(key, []byte("value-"+key)); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := store.flushIfOlderThan(0); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if err := store.flushQueued(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	store.mu.RLock()
+	sstables := append([]*SSTable(nil), store.sstables...)
+	store.mu.RUnlock()
+	if len(sstables) != 2 {
+		t.Fatalf("Expected 2 SSTables, got %d", len(sstables))
+	}
+	for _, sst := range sstables {
+		if !sst.hasKeyRange {
+			t.Fatalf("Expected %s to record its key range", sst.filePath)
+		}
+	}
+
+	for _, key := range []string{"a", "c", "z"} {
+		if _, err := store.Get(key); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("Get(%s): expected ErrKeyNotFound, got %v", key, err)
+		}
+		if exists, err := store.Exists(key); err != nil || exists {
+			t.Errorf("Exists(%s) = %v, %v", key, exists, err)
+		}
+		for _, sst := range sstables {
+			if value, found, err := sst.Get([]byte(key)); err != nil || found {
+				t.Errorf("SSTable Get(%s) = %q, %v, %v", key, value, found, err)
+			}
+		}
+	}
+
+	for _, sst := range sstables {
+		if n := sst.indexSearches.Load(); n != 0 {
+			t.Errorf("Expected no index searches in %s, got %d", sst.filePath, n)
+		}
+	}
+	// Get and Exists each skip both tables for each of the three keys
+	if skips := store.Stats()["key_range_skips"].(int64); skips != 12 {
+		t.Errorf("Expected 12 key range skips, got %d", skips)
+	}
+
+	// Keys in range are still found
+	if value, err := store.Get("d2"); err != nil || string(value) != "value-d2" {
+		t.Errorf("Get(d2) = %q, %v", value, err)
+	}
+}
+
+// Test: a MemTable whose flush fails stays readable and queued, leaves no
+// partial SSTable behind, and is flushed once writes succeed again
+func TestLSMStore_FailedFlushKeepsMemTable(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := store.Put(fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	store.sstableOutput = func(w io.Writer) io.Writer {
+		return &failingWriter{w: w, limit: 20, err: syscall.EROFS}
+	}
+	if err := store.flushIfOlderThan(0); err != nil {
+		t.Fatalf("Failed to queue flush: %v", err)
+	}
+	err = store.flushQueued()
+	if !errors.Is(err, ErrWriteFailed) || !errors.Is(err, syscall.EROFS) {
+		t.Fatalf("Expected ErrWriteFailed wrapping EROFS, got %v", err)
+	}
+
+	if files, _ := filepath.Glob(filepath.Join(dir, "sstable_*.db")); len(files) != 0 {
+		t.Errorf("Expected the partial SSTable removed, found %v", files)
+	}
+	if stats := store.Stats(); stats["immutable_memtables"] != 1 || stats["num_sstables"] != 0 {
+		t.Errorf("Expected the MemTable still queued, got %v", stats)
+	}
+	if value, err := store.Get("key3"); err != nil || string(value) != "value3" {
+		t.Errorf("Get(key3) after failed flush = %q, %v", value, err)
+	}
+
+	// The filesystem recovers
+	store.sstableOutput = nil
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Retried flush failed: %v", err)
+	}
+	if stats := store.Stats(); stats["immutable_memtables"] != 0 || stats["num_sstables"] != 1 {
+		t.Errorf("Expected the MemTable flushed, got %v", stats)
+	}
+	store.Close()
+
+	store, err = NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("key%d", i)
+		if value, err := store.Get(key); err != nil || string(value) != fmt.Sprintf("value%d", i) {
+			t.Errorf("Get(%s) after reopen = %q, %v", key, value, err)
+		}
+	}
+}
+
+func TestLSMStore_Truncate(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	// Data in an SSTable, a queued MemTable and the live MemTable
+	for i := 0; i < 30; i++ {
+		if err := store.Put(fmt.Sprintf("key%02d", i), []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if i == 9 || i == 19 {
+			if err := store.flushIfOlderThan(0); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+		}
+		if i == 9 {
+			if err := store.flushQueued(); err != nil {
+				t.Fatalf("Flush failed: %v", err)
+			}
+		}
+	}
+
+	if err := store.Truncate(); err != nil {
+		t.Fatalf("Truncate failed: %v", err)
+	}
+
+	if entries, err := store.Scan("", ""); err != nil || len(entries) != 0 {
+		t.Errorf("Expected an empty store, got %d entries (%v)", len(entries), err)
+	}
+	if _, err := store.Get("key05"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound for key05, got %v", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "sstable_*.db")); len(files) != 0 {
+		t.Errorf("Expected no SSTable files, found %v", files)
+	}
+	if segments, _ := store.wal.Segments(); len(segments) != 0 {
+		t.Errorf("Expected no WAL segments, found %v", segments)
+	}
+
+	// Still usable, with table numbering restarted
+	if err := store.Put("fresh", []byte("value")); err != nil {
+		t.Fatalf("Put after truncate failed: %v", err)
+	}
+	if err := store.flushIfOlderThan(0); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sstable_000000.db")); err != nil {
+		t.Errorf("Expected the next flush to write sstable_000000.db: %v", err)
+	}
+	store.Close()
+
+	// Nothing from before the truncate comes back on restart
+	store, err = NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	entries, err := store.Scan("", "")
+	if err != nil || len(entries) != 1 || string(entries[0].Key) != "fresh" {
+		t.Errorf("Expected only fresh after reopen, got %v (%v)", entries, err)
+	}
+}
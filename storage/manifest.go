@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// manifestFile lists the store's SSTables, in the SSTable directory
+const manifestFile = "MANIFEST"
+
+// sstableManifest is the canonical list of a store's SSTables. Table names
+// only say when a table was created, not where it sits: a compacted table
+// gets a new ID but holds the oldest data. The manifest records the order
+// the store reads them in, and is replaced atomically whenever a flush or
+// compaction changes the set, so after a crash the store opens exactly the
+// tables of the last completed change.
+type sstableManifest struct {
+	Version int      `json:"version"`
+	Tables  []string `json:"tables"` // File names, newest first
+}
+
+// sstableFileName is the file name of table tableID. IDs are zero-padded so
+// names also sort in ID order in a directory listing.
+func sstableFileName(tableID int) string {
+	return fmt.Sprintf("sstable_%06d.db", tableID)
+}
+
+// parseSSTableID returns the ID in an SSTable file name, padded or not
+func parseSSTableID(name string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, "sstable_")
+	if !ok {
+		return 0, false
+	}
+	digits, ok = strings.CutSuffix(digits, ".db")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.Atoi(digits)
+	return id, err == nil && id >= 0
+}
+
+// readManifest returns the table names in dir's manifest; found is false
+// for a directory written before manifests existed
+func readManifest(dir string) (names []string, found bool, err error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var manifest sstableManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, false, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Version != 1 {
+		return nil, false, fmt.Errorf("unsupported manifest version %d", manifest.Version)
+	}
+	for _, name := range manifest.Tables {
+		if _, ok := parseSSTableID(name); !ok || filepath.Base(name) != name {
+			return nil, false, fmt.Errorf("manifest lists unexpected table %q", name)
+		}
+	}
+	return manifest.Tables, true, nil
+}
+
+// writeManifest replaces dir's manifest with one listing paths' tables: it
+// is written to a temporary file and synced, renamed over the old one, and
+// the directory is synced so the rename survives a crash
+func writeManifest(dir string, paths []string) error {
+	manifest := sstableManifest{Version: 1, Tables: make([]string, len(paths))}
+	for i, path := range paths {
+		manifest.Tables[i] = filepath.Base(path)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, manifestFile)
+	tmpPath := path + ".tmp"
+
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync manifest: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace manifest: %w", err)
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to sync SSTable directory: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync SSTable directory: %w", err)
+	}
+	return nil
+}
+
+// listSSTables returns the paths of dir's SSTables, newest first, as its
+// manifest lists them. Orphans are table files the manifest doesn't list:
+// ones a crash left behind after a flush or compaction wrote them but
+// before the manifest was replaced, or compacted tables not yet deleted.
+// A directory without a manifest has every table file, ordered by ID.
+func listSSTables(dir string) (paths, orphans []string, err error) {
+	files, err := filepath.Glob(filepath.Join(dir, "sstable_*.db"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names, found, err := readManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		sort.Slice(files, func(i, j int) bool {
+			a, _ := parseSSTableID(filepath.Base(files[i]))
+			b, _ := parseSSTableID(filepath.Base(files[j]))
+			return a > b
+		})
+		return files, nil, nil
+	}
+
+	listed := make(map[string]bool, len(names))
+	for _, name := range names {
+		listed[name] = true
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	for _, file := range files {
+		if !listed[filepath.Base(file)] {
+			orphans = append(orphans, file)
+		}
+	}
+	return paths, orphans, nil
+}
+
+// publishSSTables replaces the store's table list with update's result,
+// recording it in the manifest first: if the manifest can't be written,
+// the list is left as it was and the error returned. Flushes and
+// compactions publish through here one at a time (manifestMu), so each
+// manifest is built from the list the previous one recorded.
+func (s *LSMStore) publishSSTables(update func(current []*SSTable) []*SSTable) error {
+	s.manifestMu.Lock()
+	defer s.manifestMu.Unlock()
+
+	s.mu.RLock()
+	current := make([]*SSTable, len(s.sstables))
+	copy(current, s.sstables)
+	s.mu.RUnlock()
+
+	next := update(current)
+	paths := make([]string, len(next))
+	for i, sst := range next {
+		paths[i] = sst.filePath
+	}
+	if err := writeManifest(s.sstableDir, paths); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.sstables = next
+	s.mu.Unlock()
+	return nil
+}
+
+// removeOrphanSSTables deletes the table files the manifest doesn't list
+// (see listSSTables); their data is in the WAL or in the tables it lists
+func removeOrphanSSTables(dir string) {
+	_, orphans, err := listSSTables(dir)
+	if err != nil {
+		log.Printf("⚠️  Failed to look for orphaned SSTables: %v", err)
+		return
+	}
+	for _, path := range orphans {
+		if err := os.Remove(path); err != nil {
+			log.Printf("⚠️  Failed to delete orphaned SSTable %s: %v", path, err)
+			continue
+		}
+		log.Printf("🧹 Deleted orphaned SSTable %s", filepath.Base(path))
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeVersions writes key once per table, v00 in the oldest, and returns
+// the tables' paths newest first
+func writeVersions(t *testing.T, dir, key string, tables int) []string {
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	for i := 0; i < tables; i++ {
+		if err := store.Put(key, []byte(fmt.Sprintf("v%02d", i))); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		if err := store.Flush(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+	store.Close()
+
+	paths, orphans, err := listSSTables(dir)
+	if err != nil || len(paths) != tables || len(orphans) != 0 {
+		t.Fatalf("Expected %d tables in the manifest, got %v, orphans %v (err: %v)", tables, paths, orphans, err)
+	}
+	return paths
+}
+
+func TestManifest_OrdersTables(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeVersions(t, dir, "key", 12)
+	if filepath.Base(paths[0]) != "sstable_000011.db" {
+		t.Fatalf("Expected zero-padded names, newest first, got %v", paths)
+	}
+
+	// Swap the newest and oldest files' names: their IDs now say the
+	// opposite of their order, as a compacted table's does
+	newest, oldest := paths[0], paths[len(paths)-1]
+	if err := os.Rename(newest, newest+".swap"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(oldest, newest); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(newest+".swap", oldest); err != nil {
+		t.Fatal(err)
+	}
+	paths[0], paths[len(paths)-1] = oldest, newest
+	if err := writeManifest(dir, paths); err != nil {
+		t.Fatal(err)
+	}
+
+	// A table the manifest doesn't list, as a crash mid-flush leaves
+	stray := filepath.Join(dir, sstableFileName(99))
+	data, err := os.ReadFile(newest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stray, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	if value, err := store.Get("key"); err != nil || string(value) != "v11" {
+		t.Errorf("Expected the manifest's newest table to win with v11, got %q (err: %v)", value, err)
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Errorf("Expected the orphaned table to be deleted, got %v", err)
+	}
+
+	// IDs are not reused, even the orphan's
+	if err := store.Put("key", []byte("v12")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	paths, _, err = listSSTables(dir)
+	if err != nil || filepath.Base(paths[0]) != sstableFileName(100) {
+		t.Errorf("Expected the flush to add %s at the front, got %v (err: %v)", sstableFileName(100), paths, err)
+	}
+}
+
+func TestManifest_LegacyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	paths := writeVersions(t, dir, "key", 12)
+
+	// A directory from before manifests: unpadded names, where sstable_9
+	// sorts after sstable_11 as a string
+	for _, path := range paths {
+		id, _ := parseSSTableID(filepath.Base(path))
+		if err := os.Rename(path, filepath.Join(dir, fmt.Sprintf("sstable_%d.db", id))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(filepath.Join(dir, manifestFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+
+	if value, err := store.Get("key"); err != nil || string(value) != "v11" {
+		t.Errorf("Expected tables ordered by ID with v11 newest, got %q (err: %v)", value, err)
+	}
+
+	// The next flush writes a manifest keeping the legacy tables' order
+	if err := store.Put("other", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	names, found, err := readManifest(dir)
+	if err != nil || !found || len(names) != 13 || names[0] != "sstable_000012.db" || names[1] != "sstable_11.db" || names[12] != "sstable_0.db" {
+		t.Errorf("Expected a manifest of the new table then the legacy ones by ID, got %v (err: %v)", names, err)
+	}
+}
@@ -2,48 +2,104 @@ package storage
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/rand"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	maxLevel    = 16  // Maximum level for skip list
 	probability = 0.5 // Probability for level promotion
+
+	// entryOverhead is the fixed per-entry cost counted in Size
+	entryOverhead = 8
 )
 
-// MemTable is an in-memory sorted structure using Skip List
+// legacyTombstoneMarker is the value SSTables before format version 6
+// stored for a deleted key, followed by the delete's 8-byte big-endian
+// UnixNano timestamp (or, in the oldest tables, nothing). Deletes are now
+// marked out of band, by Op in memory and ValueTombstone on disk, so any
+// value can be stored; the marker is only recognised in those old tables.
+var legacyTombstoneMarker = []byte("__TOMBSTONE__")
+
+// newLegacyTombstone returns the value an SSTable before version 6 stores
+// for a delete at timestamp
+func newLegacyTombstone(timestamp int64) []byte {
+	value := make([]byte, len(legacyTombstoneMarker)+8)
+	copy(value, legacyTombstoneMarker)
+	binary.BigEndian.PutUint64(value[len(legacyTombstoneMarker):], uint64(timestamp))
+	return value
+}
+
+// isLegacyTombstoneLen reports whether a value of this length may be a
+// legacy tombstone
+func isLegacyTombstoneLen(n int) bool {
+	return n == len(legacyTombstoneMarker) || n == len(legacyTombstoneMarker)+8
+}
+
+// isLegacyTombstone reports whether value marks a deleted key in an SSTable
+// before version 6
+func isLegacyTombstone(value []byte) bool {
+	return isLegacyTombstoneLen(len(value)) && bytes.HasPrefix(value, legacyTombstoneMarker)
+}
+
+// MemTable is an in-memory sorted structure using Skip List.
+// Size is kept exactly equal to the sum of entrySize over all entries,
+// tombstones included (they are flushed to SSTables like any other value).
 type MemTable struct {
-	head      *skipNode
-	maxLevel  int
-	size      int64 // Size in bytes
-	mu        sync.RWMutex
-	tombstone []byte // Special marker for deletions
+	head     *skipNode
+	maxLevel int
+	size     atomic.Int64 // Size in bytes (read without the lock by Stats)
+	count    atomic.Int64 // Number of entries, including tombstones
+	firstPut atomic.Int64 // UnixNano of the first write, 0 while empty
+	mu       sync.RWMutex
 }
 
 type skipNode struct {
-	key     []byte
-	value   []byte
-	forward []*skipNode
+	key       []byte
+	value     []byte // nil for a delete
+	timestamp int64  // When the value was written (the WAL entry timestamp)
+	deleted   bool   // The key was deleted at timestamp
+	forward   []*skipNode
+}
+
+// entry returns the node as an Entry, with Op OpDelete for a delete
+func (n *skipNode) entry() Entry {
+	if n.deleted {
+		return Entry{Timestamp: n.timestamp, Op: OpDelete, Key: n.key}
+	}
+	return Entry{Timestamp: n.timestamp, Op: OpPut, Key: n.key, Value: n.value}
 }
 
 // NewMemTable creates a new MemTable
 func NewMemTable() *MemTable {
 	return &MemTable{
-		head:      &skipNode{forward: make([]*skipNode, maxLevel)},
-		maxLevel:  1,
-		tombstone: []byte("__TOMBSTONE__"),
+		head:     &skipNode{forward: make([]*skipNode, maxLevel)},
+		maxLevel: 1,
 	}
 }
 
-// Put inserts or updates a key-value pair
-func (m *MemTable) Put(key, value []byte) {
+// Put inserts or updates a key-value pair written at timestamp (the WAL
+// entry timestamp). A write older than the key's current value is dropped,
+// as a merge would drop it, and Put reports false.
+func (m *MemTable) Put(key, value []byte, timestamp int64) bool {
+	return m.set(key, value, timestamp, false)
+}
+
+// Delete marks a key as deleted at timestamp (the WAL entry timestamp),
+// with a tombstone that hides older values in the SSTables. Like Put, it
+// reports false if the key already holds something newer.
+func (m *MemTable) Delete(key []byte, timestamp int64) bool {
+	return m.set(key, nil, timestamp, true)
+}
+
+// set writes a value, or a tombstone if deleted, for Put and Delete
+func (m *MemTable) set(key, value []byte, timestamp int64, deleted bool) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Calculate size impact
-	keySize := int64(len(key))
-	valueSize := int64(len(value))
-
 	// Find the position and update path
 	update := make([]*skipNode, maxLevel)
 	current := m.head
@@ -58,11 +114,16 @@ func (m *MemTable) Put(key, value []byte) {
 	// Check if key already exists
 	current = current.forward[0]
 	if current != nil && bytes.Equal(current.key, key) {
-		// Update existing value
-		oldValueSize := int64(len(current.value))
-		m.size = m.size - oldValueSize + valueSize
+		if timestamp < current.timestamp {
+			return false
+		}
+
+		// Update existing value: the key is already counted, only the value changes
+		m.size.Add(entrySize(key, value) - entrySize(current.key, current.value))
 		current.value = value
-		return
+		current.timestamp = timestamp
+		current.deleted = deleted
+		return true
 	}
 
 	// Insert new node
@@ -75,9 +136,11 @@ func (m *MemTable) Put(key, value []byte) {
 	}
 
 	newNode := &skipNode{
-		key:     key,
-		value:   value,
-		forward: make([]*skipNode, level),
+		key:       key,
+		value:     value,
+		timestamp: timestamp,
+		deleted:   deleted,
+		forward:   make([]*skipNode, level),
 	}
 
 	for i := 0; i < level; i++ {
@@ -85,11 +148,28 @@ func (m *MemTable) Put(key, value []byte) {
 		update[i].forward[i] = newNode
 	}
 
-	m.size += keySize + valueSize + 8 // 8 bytes overhead per entry
+	m.size.Add(entrySize(key, value))
+	m.count.Add(1)
+	m.firstPut.CompareAndSwap(0, time.Now().UnixNano())
+	return true
 }
 
-// Get retrieves a value by key
+// entrySize returns the bytes an entry contributes to Size
+func entrySize(key, value []byte) int64 {
+	return int64(len(key)+len(value)) + entryOverhead
+}
+
+// Get retrieves a value by key; a deleted key is not found
 func (m *MemTable) Get(key []byte) ([]byte, bool) {
+	entry, found := m.lookupEntry(key)
+	if !found || entry.Op == OpDelete {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+// lookupEntry returns the stored entry for key, which may be a delete
+func (m *MemTable) lookupEntry(key []byte) (Entry, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -102,29 +182,34 @@ func (m *MemTable) Get(key []byte) ([]byte, bool) {
 
 	current = current.forward[0]
 	if current != nil && bytes.Equal(current.key, key) {
-		// Check for tombstone (deleted key)
-		if bytes.Equal(current.value, m.tombstone) {
-			return nil, false
-		}
-		return current.value, true
+		return current.entry(), true
 	}
 
-	return nil, false
-}
-
-// Delete marks a key as deleted using tombstone
-func (m *MemTable) Delete(key []byte) {
-	m.Put(key, m.tombstone)
+	return Entry{}, false
 }
 
 // Size returns the approximate size in bytes
 func (m *MemTable) Size() int64 {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.size
+	return m.size.Load()
 }
 
-// Iterator returns all key-value pairs in sorted order
+// Len returns the number of entries, including tombstones
+func (m *MemTable) Len() int64 {
+	return m.count.Load()
+}
+
+// Age returns how long ago the first entry was written, or 0 if empty
+func (m *MemTable) Age() time.Duration {
+	firstPut := m.firstPut.Load()
+	if firstPut == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, firstPut))
+}
+
+// Iterator returns all entries in sorted order, deletes included (with Op
+// OpDelete). It copies the whole MemTable under the read lock; NewIterator
+// walks it without either.
 func (m *MemTable) Iterator() []Entry {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -133,16 +218,141 @@ func (m *MemTable) Iterator() []Entry {
 	current := m.head.forward[0]
 
 	for current != nil {
-		entries = append(entries, Entry{
-			Key:   current.key,
-			Value: current.value,
-		})
+		entries = append(entries, current.entry())
 		current = current.forward[0]
 	}
 
 	return entries
 }
 
+// Range returns entries with start <= key < end in sorted order, including
+// tombstones. An empty start or end leaves that side of the range open.
+func (m *MemTable) Range(start, end []byte) []Entry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Skip to the first key >= start
+	current := m.head
+	for i := m.maxLevel - 1; i >= 0; i-- {
+		for current.forward[i] != nil && bytes.Compare(current.forward[i].key, start) < 0 {
+			current = current.forward[i]
+		}
+	}
+	current = current.forward[0]
+
+	var entries []Entry
+	for current != nil {
+		if len(end) > 0 && bytes.Compare(current.key, end) >= 0 {
+			break
+		}
+		entries = append(entries, current.entry())
+		current = current.forward[0]
+	}
+
+	return entries
+}
+
+// memTableIterator walks a MemTable in key order. It takes the MemTable's
+// read lock for each step rather than holding it, so writers are never
+// blocked; keys written behind the iterator are not seen, keys ahead of it
+// may be.
+type memTableIterator struct {
+	memTable *MemTable
+	prev     *skipNode // Last node returned (the head before the first)
+}
+
+// newIterator returns an iterator positioned before the first entry
+func (m *MemTable) newIterator() *memTableIterator {
+	return &memTableIterator{memTable: m, prev: m.head}
+}
+
+// seek positions the iterator before the first entry with key >= key
+func (it *memTableIterator) seek(key []byte) {
+	m := it.memTable
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	current := m.head
+	for i := m.maxLevel - 1; i >= 0; i-- {
+		for current.forward[i] != nil && bytes.Compare(current.forward[i].key, key) < 0 {
+			current = current.forward[i]
+		}
+	}
+	it.prev = current
+}
+
+// Next returns the next entry; the boolean is false at the end
+func (it *memTableIterator) Next() (Entry, bool, error) {
+	it.memTable.mu.RLock()
+	defer it.memTable.mu.RUnlock()
+
+	node := it.prev.forward[0]
+	if node == nil {
+		return Entry{}, false, nil
+	}
+	it.prev = node
+	return node.entry(), true, nil
+}
+
+// Close is a no-op; the MemTable holds no resources
+func (it *memTableIterator) Close() error {
+	return nil
+}
+
+// MemTableIterator is a cursor over a MemTable in key order, tombstones
+// included. It holds the MemTable's read lock only for each step, so
+// writers carry on while it runs and nothing is copied up front; like
+// memTableIterator, it sees keys written ahead of it but not behind it.
+// Nodes are never unlinked from the skip list, so a concurrent write can't
+// pull the cursor off the list.
+//
+//	it := memTable.NewIterator()
+//	for it.Next() {
+//		use(it.Key(), it.Value())
+//	}
+type MemTableIterator struct {
+	it    *memTableIterator
+	entry Entry
+}
+
+// NewIterator returns a cursor positioned before the MemTable's first entry
+func (m *MemTable) NewIterator() *MemTableIterator {
+	return &MemTableIterator{it: m.newIterator()}
+}
+
+// Seek positions the cursor before the first entry with key >= key
+func (it *MemTableIterator) Seek(key []byte) {
+	it.it.seek(key)
+	it.entry = Entry{}
+}
+
+// Next advances to the next entry and reports whether there is one
+func (it *MemTableIterator) Next() bool {
+	entry, ok, _ := it.it.Next()
+	it.entry = entry
+	return ok
+}
+
+// Key returns the current entry's key
+func (it *MemTableIterator) Key() []byte {
+	return it.entry.Key
+}
+
+// Value returns the current entry's value, nil for a delete (see Deleted)
+func (it *MemTableIterator) Value() []byte {
+	return it.entry.Value
+}
+
+// Timestamp returns when the current entry was written
+func (it *MemTableIterator) Timestamp() int64 {
+	return it.entry.Timestamp
+}
+
+// Deleted reports whether the current entry is a delete
+func (it *MemTableIterator) Deleted() bool {
+	return it.entry.Op == OpDelete
+}
+
 // randomLevel generates a random level for new node
 func (m *MemTable) randomLevel() int {
 	level := 1
@@ -159,5 +369,6 @@ func (m *MemTable) Clear() {
 
 	m.head = &skipNode{forward: make([]*skipNode, maxLevel)}
 	m.maxLevel = 1
-	m.size = 0
+	m.size.Store(0)
+	m.count.Store(0)
 }
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"bytes"
+	"container/heap"
+	"context"
+	"path/filepath"
+
+	"kvstore/tracing"
+)
+
+// MergeOptions selects what a MergeIterator returns
+type MergeOptions struct {
+	// Start and End bound the keys to Start <= key < End; an empty bound
+	// leaves that side open
+	Start []byte
+	End   []byte
+
+	// IncludeTombstones also returns deleted keys, as entries with Op
+	// OpDelete and the time of the delete in Timestamp
+	IncludeTombstones bool
+
+	// KeysOnly leaves Value nil, so live values are never read from SSTables
+	KeysOnly bool
+}
+
+// mergeSource is one layer of the store (a MemTable or an SSTable) read in
+// key order
+type mergeSource interface {
+	Next() (Entry, bool, error)
+	seek(key []byte)
+	Close() error
+}
+
+// mergeCursor is a source positioned at its current entry
+type mergeCursor struct {
+	src   mergeSource
+	entry Entry
+	age   int // Which source this came from (lower = newer)
+}
+
+// mergeHeap orders cursors by key, and the versions of a key newest first:
+// by timestamp, then by age on a tie (entries from SSTables written before
+// format version 3 have no timestamp and are older than any that do)
+type mergeHeap []*mergeCursor
+
+func (h mergeHeap) Len() int { return len(h) }
+func (h mergeHeap) Less(i, j int) bool {
+	if cmp := bytes.Compare(h[i].entry.Key, h[j].entry.Key); cmp != 0 {
+		return cmp < 0
+	}
+	if h[i].entry.Timestamp != h[j].entry.Timestamp {
+		return h[i].entry.Timestamp > h[j].entry.Timestamp
+	}
+	return h[i].age < h[j].age
+}
+func (h mergeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeCursor)) }
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// MergeIterator merges the MemTables and SSTables of a store into a single
+// stream in key order. When a key has versions in several layers the newest
+// one wins, so every key comes out once, and a deleted key is skipped unless
+// IncludeTombstones is set.
+//
+// The iterator reads the MemTables and SSTables that existed when it was
+// created, holding one entry per layer in memory. It is not a snapshot:
+// writes made while iterating may or may not be returned. A MergeIterator
+// is not safe for concurrent use; call Close when done.
+type MergeIterator struct {
+	sources []mergeSource // Newest first
+	heap    mergeHeap
+	opts    MergeOptions
+	err     error
+
+	ctx context.Context // Parent of the layer read spans (see seekSource)
+}
+
+// NewMergeIterator returns an iterator over the store's keys in
+// [opts.Start, opts.End). A range holding a single key (End is Start plus a
+// NUL byte) is a point lookup: SSTables whose key range or bloom filter
+// rules the key out are skipped. Every other layer is still read, since a
+// write replayed with an old timestamp can sit in a newer layer than the
+// version that beats it.
+func (s *LSMStore) NewMergeIterator(opts MergeOptions) *MergeIterator {
+	return s.newMergeIterator(context.Background(), opts)
+}
+
+// newMergeIterator is NewMergeIterator with the layer reads of its seeks
+// traced under ctx, and checksums validated as ctx says (see
+// WithVerifyChecksums)
+func (s *LSMStore) newMergeIterator(ctx context.Context, opts MergeOptions) *MergeIterator {
+	s.mu.RLock()
+	it := s.mergeIteratorLocked(ctx, opts)
+	s.mu.RUnlock()
+
+	it.Seek(opts.Start)
+	return it
+}
+
+// mergeIteratorLocked builds a merge iterator over the current layers
+// without positioning it (must be called with the lock held, shared or
+// exclusive); Seek reads the layers
+func (s *LSMStore) mergeIteratorLocked(ctx context.Context, opts MergeOptions) *MergeIterator {
+	point := isPointRange(opts.Start, opts.End)
+	verify := s.verifyChecksumsFor(ctx)
+
+	// Sources from newest to oldest
+	sources := []mergeSource{s.memTable.newIterator()}
+	for i := len(s.immutables) - 1; i >= 0; i-- {
+		sources = append(sources, s.immutables[i].table.newIterator())
+	}
+	sstables := s.sstables
+
+	// The store-level filter can rule a point lookup out of every table
+	if point && !s.storeBloomMayContain(opts.Start) {
+		sstables = nil
+	}
+
+	for _, sst := range sstables {
+		if point && !sst.inKeyRange(opts.Start) {
+			s.keyRangeSkips.Add(1)
+			continue
+		}
+
+		// The bloom filter can rule a point lookup out of the table
+		if point && !sst.mayContain(opts.Start) {
+			continue
+		}
+		source := sst.newRangeIterator(opts.End, opts.KeysOnly)
+		source.skipChecksums = !verify
+		sources = append(sources, source)
+	}
+
+	return &MergeIterator{sources: sources, opts: opts, ctx: ctx}
+}
+
+// isPointRange reports whether [start, end) holds exactly the key start
+func isPointRange(start, end []byte) bool {
+	return len(end) == len(start)+1 && end[len(start)] == 0 && bytes.HasPrefix(end, start)
+}
+
+// Seek moves the iterator to the first key >= key, or to Start if key is
+// before it. Seeking backwards is allowed.
+func (it *MergeIterator) Seek(key []byte) {
+	if it.err != nil {
+		return
+	}
+	if bytes.Compare(key, it.opts.Start) < 0 {
+		key = it.opts.Start
+	}
+
+	it.heap = it.heap[:0]
+	for age, src := range it.sources {
+		entry, ok, err := it.seekSource(src, key)
+		if err != nil {
+			it.err = err
+			return
+		}
+		if !ok {
+			continue
+		}
+		it.heap = append(it.heap, &mergeCursor{src: src, entry: entry, age: age})
+	}
+	heap.Init(&it.heap)
+}
+
+// seekSource positions one source at key and reads its entry there. With
+// tracing on this is a span per layer, so a slow lookup shows whether the
+// MemTables or which SSTable it waited on.
+func (it *MergeIterator) seekSource(src mergeSource, key []byte) (Entry, bool, error) {
+	name := "storage.MemTable"
+	sst, isSSTable := src.(*sstableIterator)
+	if isSSTable {
+		name = "storage.SSTable"
+	}
+	_, span := tracing.Start(it.ctx, name)
+	if span != nil && isSSTable {
+		span.SetAttribute("sstable", filepath.Base(sst.sstable.filePath))
+	}
+
+	src.seek(key)
+	entry, ok, err := src.Next()
+
+	span.SetAttribute("found", ok && bytes.Equal(entry.Key, key))
+	span.SetError(err)
+	span.End()
+	return entry, ok, err
+}
+
+// Next returns the next entry in key order, with the time of the write in
+// Timestamp (0 for data from SSTables older than format version 3). The
+// boolean is false at the end of the range or after an error, which Err
+// reports.
+func (it *MergeIterator) Next() (Entry, bool) {
+	for it.err == nil && len(it.heap) > 0 {
+		// The top of the heap is the newest version of the smallest key
+		newest := it.heap[0].entry
+		if len(it.opts.End) > 0 && bytes.Compare(newest.Key, it.opts.End) >= 0 {
+			return Entry{}, false
+		}
+
+		// Consume every version of this key
+		for len(it.heap) > 0 && bytes.Equal(it.heap[0].entry.Key, newest.Key) {
+			c := it.heap[0]
+			entry, ok, err := c.src.Next()
+			if err != nil {
+				it.err = err
+				return Entry{}, false
+			}
+			if ok {
+				c.entry = entry
+				heap.Fix(&it.heap, 0)
+			} else {
+				heap.Pop(&it.heap)
+			}
+		}
+
+		if newest.Op == OpDelete {
+			if !it.opts.IncludeTombstones {
+				continue
+			}
+			return Entry{Timestamp: newest.Timestamp, Op: OpDelete, Key: newest.Key}, true
+		}
+
+		if it.opts.KeysOnly {
+			newest.Value = nil
+		}
+		return Entry{Timestamp: newest.Timestamp, Op: OpPut, Key: newest.Key, Value: newest.Value, Flags: newest.Flags}, true
+	}
+
+	return Entry{}, false
+}
+
+// Err returns the error that stopped the iterator, if any
+func (it *MergeIterator) Err() error {
+	return it.err
+}
+
+// Close releases the SSTable files held by the iterator
+func (it *MergeIterator) Close() error {
+	var firstErr error
+	for _, src := range it.sources {
+		if err := src.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	it.sources = nil
+	it.heap = nil
+	return firstErr
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"testing"
+)
+
+// collectMerge drains a merge iterator into "key=value" / "key deleted" strings
+func collectMerge(t *testing.T, it *MergeIterator) []string {
+	t.Helper()
+
+	var got []string
+	for {
+		entry, ok := it.Next()
+		if !ok {
+			break
+		}
+		if entry.Op == OpDelete {
+			got = append(got, string(entry.Key)+" deleted")
+			continue
+		}
+		got = append(got, string(entry.Key)+"="+string(entry.Value))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iterator failed: %v", err)
+	}
+	return got
+}
+
+func expectMerge(t *testing.T, name string, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("%s: expected %v, got %v", name, want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s: entry %d: expected %q, got %q", name, i, want[i], got[i])
+		}
+	}
+}
+
+func TestMergeIterator_NewestWins(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// Oldest SSTable
+	store.Put("a", []byte("a1"))
+	store.Put("b", []byte("b1"))
+	store.Put("c", []byte("c1"))
+	store.Put("e", []byte("e1"))
+	flushMemTableForTest(t, store)
+
+	// Newer SSTable: overwrites a, deletes c and e
+	store.Put("a", []byte("a2"))
+	store.Delete("c")
+	store.Delete("e")
+	store.Put("d", []byte("d1"))
+	flushMemTableForTest(t, store)
+
+	// MemTable: overwrites a again, brings e back, deletes d
+	store.Put("a", []byte("a3"))
+	store.Put("e", []byte("e2"))
+	store.Delete("d")
+
+	it := store.NewMergeIterator(MergeOptions{})
+	expectMerge(t, "live", collectMerge(t, it), []string{"a=a3", "b=b1", "e=e2"})
+	it.Close()
+
+	it = store.NewMergeIterator(MergeOptions{IncludeTombstones: true})
+	expectMerge(t, "with tombstones", collectMerge(t, it), []string{"a=a3", "b=b1", "c deleted", "d deleted", "e=e2"})
+	it.Close()
+
+	it = store.NewMergeIterator(MergeOptions{KeysOnly: true})
+	expectMerge(t, "keys only", collectMerge(t, it), []string{"a=", "b=", "e="})
+	it.Close()
+}
+
+func TestMergeIterator_RangeAndSeek(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"a", "c", "e", "g"} {
+		store.Put(key, []byte("old"))
+	}
+	flushMemTableForTest(t, store)
+	for _, key := range []string{"b", "c", "f"} {
+		store.Put(key, []byte("new"))
+	}
+
+	it := store.NewMergeIterator(MergeOptions{Start: []byte("b"), End: []byte("f")})
+	defer it.Close()
+	expectMerge(t, "range", collectMerge(t, it), []string{"b=new", "c=new", "e=old"})
+
+	// Seeking back restarts from there; before Start clamps to Start
+	it.Seek([]byte("d"))
+	expectMerge(t, "seek d", collectMerge(t, it), []string{"e=old"})
+	it.Seek([]byte("a"))
+	expectMerge(t, "seek before start", collectMerge(t, it), []string{"b=new", "c=new", "e=old"})
+
+	// A point range returns the newest version of the one key
+	point := store.NewMergeIterator(MergeOptions{Start: []byte("c"), End: []byte("c\x00")})
+	defer point.Close()
+	expectMerge(t, "point", collectMerge(t, point), []string{"c=new"})
+}
@@ -0,0 +1,47 @@
+package storage
+
+import "time"
+
+// Metrics receives the store's instrumentation, so it can be exported to
+// Prometheus, StatsD, OpenTelemetry or anything else without the store
+// depending on one of them. Pass an implementation in StoreOptions.Metrics;
+// the default discards everything.
+//
+// Labels are name, value pairs, e.g. IncCounter(MetricGets, "result",
+// "found"). The methods are called on the read and write paths, often
+// under the store lock, so they must be safe for concurrent use and must
+// not block.
+type Metrics interface {
+	IncCounter(name string, labels ...string)
+	ObserveLatency(name string, d time.Duration)
+	SetGauge(name string, value float64)
+}
+
+// The metrics the store reports
+const (
+	MetricPuts       = "storage_puts_total"    // Puts that succeeded, conditional and batched ones included
+	MetricDeletes    = "storage_deletes_total" // Deletes that succeeded, batched ones included
+	MetricGets       = "storage_gets_total"    // Get calls, labeled result=found, not_found or error
+	MetricPutLatency = "storage_put_latency"   // One sample per Put, conditional write or WriteBatch
+	MetricGetLatency = "storage_get_latency"
+
+	MetricMemTableBytes = "storage_memtable_bytes" // Size of the active MemTable
+	MetricSSTables      = "storage_sstables"       // SSTables open
+
+	MetricFlushes      = "storage_flushes_total" // MemTables written to SSTables
+	MetricFlushLatency = "storage_flush_latency"
+
+	MetricCompactions       = "storage_compactions_total" // Compactions, labeled result=ok or error
+	MetricCompactionLatency = "storage_compaction_latency"
+
+	MetricWALWrites      = "storage_wal_writes_total" // WAL records appended; a write batch is one
+	MetricWALBytes       = "storage_wal_bytes"        // Size of the active WAL file
+	MetricWALSyncLatency = "storage_wal_sync_latency"
+)
+
+// noopMetrics is the default Metrics, which discards everything
+type noopMetrics struct{}
+
+func (noopMetrics) IncCounter(string, ...string)         {}
+func (noopMetrics) ObserveLatency(string, time.Duration) {}
+func (noopMetrics) SetGauge(string, float64)             {}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMetrics records every metric it is sent
+type fakeMetrics struct {
+	mu        sync.Mutex
+	counters  map[string]int // name and labels joined by commas -> count
+	latencies map[string]int // name -> observations
+	gauges    map[string]float64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		counters:  make(map[string]int),
+		latencies: make(map[string]int),
+		gauges:    make(map[string]float64),
+	}
+}
+
+func (m *fakeMetrics) IncCounter(name string, labels ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[strings.Join(append([]string{name}, labels...), ",")]++
+}
+
+func (m *fakeMetrics) ObserveLatency(name string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies[name]++
+}
+
+func (m *fakeMetrics) SetGauge(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges[name] = value
+}
+
+func (m *fakeMetrics) counter(name string, labels ...string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[strings.Join(append([]string{name}, labels...), ",")]
+}
+
+func (m *fakeMetrics) latency(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.latencies[name]
+}
+
+func (m *fakeMetrics) gauge(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.gauges[name]
+}
+
+func TestLSMStore_Metrics(t *testing.T) {
+	metrics := newFakeMetrics()
+	store, err := NewLSMStoreWithOptions(t.TempDir(), StoreOptions{Metrics: metrics})
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := store.Put(key, []byte("value")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.Delete("c"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	store.Get("a")
+	store.Get("c")
+	store.Get("missing")
+
+	if n := metrics.counter(MetricPuts); n != 3 {
+		t.Errorf("Expected 3 puts, got %d", n)
+	}
+	if n := metrics.counter(MetricDeletes); n != 1 {
+		t.Errorf("Expected 1 delete, got %d", n)
+	}
+	if n := metrics.counter(MetricGets, "result", "found"); n != 1 {
+		t.Errorf("Expected 1 found get, got %d", n)
+	}
+	if n := metrics.counter(MetricGets, "result", "not_found"); n != 2 {
+		t.Errorf("Expected 2 not-found gets, got %d", n)
+	}
+	if n := metrics.counter(MetricWALWrites); n != 4 {
+		t.Errorf("Expected 4 WAL writes, got %d", n)
+	}
+	if metrics.latency(MetricPutLatency) != 3 || metrics.latency(MetricGetLatency) != 3 {
+		t.Errorf("Expected 3 put and 3 get latencies, got %d and %d", metrics.latency(MetricPutLatency), metrics.latency(MetricGetLatency))
+	}
+	if metrics.gauge(MetricMemTableBytes) == 0 {
+		t.Error("Expected the MemTable size gauge set")
+	}
+
+	// Two flushes, then a compaction merging their tables
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.Put("d", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if n := metrics.counter(MetricFlushes); n != 2 {
+		t.Errorf("Expected 2 flushes, got %d", n)
+	}
+	if got := metrics.gauge(MetricSSTables); got != 2 {
+		t.Errorf("Expected the SSTable gauge at 2, got %v", got)
+	}
+
+	if err := store.CompactionManager().ForceCompact(); err != nil {
+		t.Fatalf("ForceCompact failed: %v", err)
+	}
+	if n := metrics.counter(MetricCompactions, "result", "ok"); n != 1 {
+		t.Errorf("Expected 1 compaction, got %d", n)
+	}
+	if metrics.latency(MetricCompactionLatency) != 1 {
+		t.Errorf("Expected 1 compaction latency, got %d", metrics.latency(MetricCompactionLatency))
+	}
+	if got := metrics.gauge(MetricSSTables); got != 1 {
+		t.Errorf("Expected the SSTable gauge at 1 after compaction, got %v", got)
+	}
+}
+
+func TestLSMStore_MetricsCountEveryWrite(t *testing.T) {
+	metrics := newFakeMetrics()
+	store, err := NewLSMStoreWithOptions(t.TempDir(), StoreOptions{Metrics: metrics})
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// A batch of two puts and a delete is one write
+	err = store.WriteBatch([]BatchOp{
+		{Op: OpPut, Key: "a", Value: []byte("1")},
+		{Op: OpPut, Key: "b", Value: []byte("2")},
+		{Op: OpDelete, Key: "c"},
+	})
+	if err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	// Conditional writes count when they write, not when their check fails
+	if written, err := store.PutIfAbsent("d", []byte("4")); err != nil || !written {
+		t.Fatalf("PutIfAbsent = %v, %v", written, err)
+	}
+	if written, err := store.PutIfAbsent("d", []byte("5")); err != nil || written {
+		t.Fatalf("PutIfAbsent on an existing key = %v, %v", written, err)
+	}
+	if _, err := store.Update("a", func([]byte, bool) ([]byte, error) { return []byte("11"), nil }); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if existed, err := store.DeleteWithResult("b"); err != nil || !existed {
+		t.Fatalf("DeleteWithResult = %v, %v", existed, err)
+	}
+
+	if n := metrics.counter(MetricPuts); n != 4 {
+		t.Errorf("Expected 4 puts, got %d", n)
+	}
+	if n := metrics.counter(MetricDeletes); n != 2 {
+		t.Errorf("Expected 2 deletes, got %d", n)
+	}
+	if n := metrics.latency(MetricPutLatency); n != 3 {
+		t.Errorf("Expected 3 put latencies, got %d", n)
+	}
+	if metrics.gauge(MetricMemTableBytes) == 0 {
+		t.Error("Expected the MemTable size gauge set")
+	}
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// namespaceSeparator ends the namespace prefix of a namespaced key
+const namespaceSeparator = "\x00"
+
+// ErrInvalidNamespace is returned for a namespace containing the separator
+var ErrInvalidNamespace = errors.New("namespace must not contain a NUL byte")
+
+// ErrInvalidKey is returned for a default-namespace key containing the
+// separator, which would alias a namespaced key
+var ErrInvalidKey = errors.New("key in the default namespace must not contain a NUL byte")
+
+// NamespacedKey returns the key a namespaced key is stored under:
+// "<ns>\x00<key>", or key itself in the default namespace ("").
+//
+// Namespaces (column families) are only a key prefix, so the bloom filters,
+// compaction and everything else treat namespaced keys like any other key.
+// The default namespace is the unprefixed keyspace without the separator:
+// its keys may not contain a NUL byte, and its scans skip keys that do, so
+// it is isolated from the named namespaces as they are from each other.
+func NamespacedKey(ns, key string) (string, error) {
+	if ns == "" {
+		if strings.Contains(key, namespaceSeparator) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+		return key, nil
+	}
+	if strings.Contains(ns, namespaceSeparator) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNamespace, ns)
+	}
+	return ns + namespaceSeparator + key, nil
+}
+
+// PutNS stores a key-value pair in a namespace
+func (s *LSMStore) PutNS(ns, key string, value []byte) error {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return err
+	}
+	return s.Put(nsKey, value)
+}
+
+// PutIfAbsentNS is PutIfAbsent within a namespace
+func (s *LSMStore) PutIfAbsentNS(ns, key string, value []byte) (bool, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return false, err
+	}
+	return s.PutIfAbsent(nsKey, value)
+}
+
+// PutIfVersionNS is PutIfVersion within a namespace
+func (s *LSMStore) PutIfVersionNS(ns, key string, value []byte, expectedVersion int64) (int64, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return 0, err
+	}
+	return s.PutIfVersion(nsKey, value, expectedVersion)
+}
+
+// GetNS retrieves a value by key from a namespace
+func (s *LSMStore) GetNS(ns, key string) ([]byte, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(nsKey)
+}
+
+// GetNSContext is GetNS traced under ctx (see GetWithTimestampContext)
+func (s *LSMStore) GetNSContext(ctx context.Context, ns, key string) ([]byte, error) {
+	value, _, err := s.GetWithTimestampNSContext(ctx, ns, key)
+	return value, err
+}
+
+// GetWithTimestampNSContext is GetWithTimestampContext within a namespace
+func (s *LSMStore) GetWithTimestampNSContext(ctx context.Context, ns, key string) ([]byte, int64, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.GetWithTimestampContext(ctx, nsKey)
+}
+
+// GetRangeNS is GetRange within a namespace
+func (s *LSMStore) GetRangeNS(ns, key string, offset, length int) ([]byte, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRange(nsKey, offset, length)
+}
+
+// ExistsNS reports whether a key has a live value in a namespace
+func (s *LSMStore) ExistsNS(ns, key string) (bool, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return false, err
+	}
+	return s.Exists(nsKey)
+}
+
+// DeleteNS removes a key-value pair from a namespace
+func (s *LSMStore) DeleteNS(ns, key string) error {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return err
+	}
+	return s.Delete(nsKey)
+}
+
+// DeleteWithResultNS is DeleteWithResult within a namespace
+func (s *LSMStore) DeleteWithResultNS(ns, key string) (bool, error) {
+	nsKey, err := NamespacedKey(ns, key)
+	if err != nil {
+		return false, err
+	}
+	return s.DeleteWithResult(nsKey)
+}
+
+// ScanNS returns every live key-value pair in a namespace, with the
+// namespace prefix removed from the keys. Unlike Scan, the default
+// namespace's scan leaves out the keys of the named namespaces.
+func (s *LSMStore) ScanNS(ns string) ([]Entry, error) {
+	return s.scanNS(ns, "", "", false, 0)
+}
+
+// ScanRangeNS is Scan within a namespace: it returns the pairs with
+// start <= key < end, with the namespace prefix removed from the keys
+func (s *LSMStore) ScanRangeNS(ns, start, end string) ([]Entry, error) {
+	return s.scanNS(ns, start, end, false, 0)
+}
+
+// ScanKeysNS is ScanKeys within a namespace
+func (s *LSMStore) ScanKeysNS(ns, start, end string) ([]string, error) {
+	entries, err := s.scanNS(ns, start, end, true, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, len(entries))
+	for i, entry := range entries {
+		keys[i] = string(entry.Key)
+	}
+	return keys, nil
+}
+
+// ScanLimitNS is ScanRangeNS returning at most limit entries (0 = no
+// limit). With keysOnly, values are not read and Value is nil, as in
+// ScanKeysNS.
+func (s *LSMStore) ScanLimitNS(ns, start, end string, keysOnly bool, limit int) ([]Entry, error) {
+	return s.scanNS(ns, start, end, keysOnly, limit)
+}
+
+// scanNS scans [start, end) inside a namespace. Open bounds stop at the
+// edges of the namespace rather than of the whole keyspace.
+func (s *LSMStore) scanNS(ns, start, end string, keysOnly bool, limit int) ([]Entry, error) {
+	if ns == "" {
+		return s.scan(start, end, keysOnly, limit, isNamespacedKey)
+	}
+
+	nsStart, err := NamespacedKey(ns, start)
+	if err != nil {
+		return nil, err
+	}
+	// "<ns>\x01" sorts after every "<ns>\x00..." key
+	nsEnd := ns + "\x01"
+	if end != "" {
+		nsEnd = ns + namespaceSeparator + end
+	}
+
+	entries, err := s.scan(nsStart, nsEnd, keysOnly, limit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixLen := len(ns) + len(namespaceSeparator)
+	for i := range entries {
+		entries[i].Key = entries[i].Key[prefixLen:]
+	}
+	return entries, nil
+}
+
+// isNamespacedKey reports whether a stored key belongs to a named namespace
+func isNamespacedKey(key []byte) bool {
+	return bytes.Contains(key, []byte(namespaceSeparator))
+}
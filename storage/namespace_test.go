@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestLSMStore_NamespaceIsolation(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// The same key in three namespaces, one of which is a prefix of another
+	for _, ns := range []string{"", "users", "users2"} {
+		for _, key := range []string{"a", "b"} {
+			if err := store.PutNS(ns, key, []byte(ns+"/"+key)); err != nil {
+				t.Fatalf("PutNS(%q, %q) failed: %v", ns, key, err)
+			}
+		}
+	}
+	if err := store.WriteBatch([]BatchOp{{Op: OpPut, Namespace: "users", Key: "c", Value: []byte("users/c")}}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	if err := store.DeleteNS("users2", "a"); err != nil {
+		t.Fatalf("DeleteNS failed: %v", err)
+	}
+
+	verify := func(stage string) {
+		for _, tc := range []struct{ ns, key, want string }{
+			{"", "a", "/a"},
+			{"users", "a", "users/a"},
+			{"users", "c", "users/c"},
+			{"users2", "b", "users2/b"},
+		} {
+			value, err := store.GetNS(tc.ns, tc.key)
+			if err != nil || string(value) != tc.want {
+				t.Errorf("%s: GetNS(%q, %q) = %q, %v; want %q", stage, tc.ns, tc.key, value, err, tc.want)
+			}
+		}
+
+		// Deleting from one namespace leaves the others alone
+		if _, err := store.GetNS("users2", "a"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("%s: expected users2/a deleted, got %v", stage, err)
+		}
+		if _, err := store.GetNS("users2", "c"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("%s: batch write leaked into users2: %v", stage, err)
+		}
+
+		entries, err := store.ScanNS("users")
+		if err != nil {
+			t.Fatalf("%s: ScanNS failed: %v", stage, err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, fmt.Sprintf("%s=%s", entry.Key, entry.Value))
+		}
+		if want := "[a=users/a b=users/b c=users/c]"; fmt.Sprint(got) != want {
+			t.Errorf("%s: ScanNS(users) = %v, want %s", stage, got, want)
+		}
+
+		keys, err := store.ScanKeysNS("users", "b", "")
+		if err != nil || fmt.Sprint(keys) != "[b c]" {
+			t.Errorf("%s: ScanKeysNS(users, b, \"\") = %q, %v", stage, keys, err)
+		}
+	}
+
+	verify("in MemTable")
+
+	// Namespaced keys are plain keys to the SSTables and compaction
+	flushMemTableForTest(t, store)
+	verify("in SSTable")
+	if err := store.compactionMgr.ForceCompact(); err != nil {
+		t.Fatalf("Compaction failed: %v", err)
+	}
+	verify("after compaction")
+
+	if err := store.PutNS("bad\x00ns", "k", nil); !errors.Is(err, ErrInvalidNamespace) {
+		t.Errorf("Expected ErrInvalidNamespace, got %v", err)
+	}
+}
+
+func TestLSMStore_DefaultNamespaceIsolation(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	for _, ns := range []string{"", "users", "zzz"} {
+		for _, key := range []string{"a", "b"} {
+			if err := store.PutNS(ns, key, []byte(ns+"/"+key)); err != nil {
+				t.Fatalf("PutNS(%q, %q) failed: %v", ns, key, err)
+			}
+		}
+	}
+	// Sorts after the users keys
+	if err := store.Put("zz", []byte("/zz")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// A default-namespace key that would alias users/a is refused
+	if err := store.PutNS("", "users\x00a", []byte("alias")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if err := store.WriteBatch([]BatchOp{{Op: OpPut, Key: "users\x00a", Value: []byte("alias")}}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Expected ErrInvalidKey from WriteBatch, got %v", err)
+	}
+	if value, err := store.GetNS("users", "a"); err != nil || string(value) != "users/a" {
+		t.Errorf("GetNS(users, a) = %q, %v", value, err)
+	}
+
+	verify := func(stage string) {
+		t.Helper()
+		entries, err := store.ScanNS("")
+		if err != nil {
+			t.Fatalf("%s: ScanNS failed: %v", stage, err)
+		}
+		var got []string
+		for _, entry := range entries {
+			got = append(got, fmt.Sprintf("%s=%s", entry.Key, entry.Value))
+		}
+		if want := "[a=/a b=/b zz=/zz]"; fmt.Sprint(got) != want {
+			t.Errorf("%s: ScanNS(\"\") = %q, want %s", stage, got, want)
+		}
+
+		keys, err := store.ScanKeysNS("", "", "")
+		if err != nil || fmt.Sprint(keys) != "[a b zz]" {
+			t.Errorf("%s: ScanKeysNS(\"\") = %q, %v", stage, keys, err)
+		}
+
+		// The limit counts default-namespace keys only
+		entries, err = store.ScanLimitNS("", "c", "", true, 1)
+		if err != nil || len(entries) != 1 || string(entries[0].Key) != "zz" {
+			t.Errorf("%s: ScanLimitNS(\"\", c, 1) = %+v, %v", stage, entries, err)
+		}
+	}
+
+	verify("in MemTable")
+	flushMemTableForTest(t, store)
+	verify("in SSTable")
+}
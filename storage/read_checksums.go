@@ -0,0 +1,39 @@
+package storage
+
+import "context"
+
+// verifyChecksumsKey is the context key of a per-read checksum override
+type verifyChecksumsKey struct{}
+
+// SetVerifyChecksumsOnRead turns validation of SSTable record checksums
+// during reads on or off (on by default). Off saves a CRC over every record
+// read, for workloads that would rather go faster than notice a damaged
+// record: with it off, a flipped bit in a value is returned as if it were
+// the data. Compaction and Verify always validate, so damage is still caught
+// before it is copied into a new table. WithVerifyChecksums overrides it for
+// one read.
+func (s *LSMStore) SetVerifyChecksumsOnRead(verify bool) {
+	s.skipChecksums.Store(!verify)
+}
+
+// WithVerifyChecksums makes reads done with the returned context validate
+// record checksums, or skip them, whatever SetVerifyChecksumsOnRead says
+func WithVerifyChecksums(ctx context.Context, verify bool) context.Context {
+	return context.WithValue(ctx, verifyChecksumsKey{}, verify)
+}
+
+// verifyChecksumsFor reports whether a read done with ctx validates checksums
+func (s *LSMStore) verifyChecksumsFor(ctx context.Context) bool {
+	if ctx != nil {
+		if verify, ok := ctx.Value(verifyChecksumsKey{}).(bool); ok {
+			return verify
+		}
+	}
+	return !s.skipChecksums.Load()
+}
+
+// verifyOnRead reports whether reads of the table validate checksums,
+// following the owning store's setting
+func (s *SSTable) verifyOnRead() bool {
+	return s.skipChecksums == nil || !s.skipChecksums.Load()
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLSMStore_VerifyChecksumsOnRead(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("key%02d", i)
+		if err := store.Put(key, []byte("value-"+key)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	if err := store.flushIfOlderThan(0); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if err := store.flushQueued(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	// Flip a byte inside one value
+	path := store.sstables[0].filePath
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := bytes.Index(data, []byte("value-key10"))
+	if pos < 0 {
+		t.Fatal("Value not found in the table")
+	}
+	data[pos+3] ^= 0xFF
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var corrupt *ErrCorruptSSTable
+	if _, err := store.Get("key10"); !errors.As(err, &corrupt) {
+		t.Fatalf("Expected the damage to be caught by default, got %v", err)
+	}
+
+	// Documented trade-off: with checks off, the damaged value comes back
+	store.SetVerifyChecksumsOnRead(false)
+	value, err := store.Get("key10")
+	if err != nil {
+		t.Fatalf("Expected no error with checksums off, got %v", err)
+	}
+	if string(value) == "value-key10" || len(value) != len("value-key10") {
+		t.Errorf("Expected the damaged value back, got %q", value)
+	}
+	if _, err := store.Get("key11"); err != nil {
+		t.Errorf("Intact key failed: %v", err)
+	}
+
+	// A read can ask for the check either way
+	ctx := context.Background()
+	if _, _, err := store.GetWithTimestampContext(WithVerifyChecksums(ctx, true), "key10"); !errors.As(err, &corrupt) {
+		t.Errorf("Expected a per-read override to catch the damage, got %v", err)
+	}
+	store.SetVerifyChecksumsOnRead(true)
+	if _, _, err := store.GetWithTimestampContext(WithVerifyChecksums(ctx, false), "key10"); err != nil {
+		t.Errorf("Expected a per-read override to skip the check, got %v", err)
+	}
+
+	// Compaction validates regardless, so the damage isn't copied forward
+	store.SetVerifyChecksumsOnRead(false)
+	if err := store.CompactionManager().ForceCompact(); !errors.As(err, &corrupt) {
+		t.Errorf("Expected compaction to catch the damage, got %v", err)
+	}
+}
+
+// BenchmarkLSMStore_GetChecksums reads 4KB values from SSTables with record
+// checksums validated and skipped
+func BenchmarkLSMStore_GetChecksums(b *testing.B) {
+	for _, verify := range []bool{true, false} {
+		b.Run(fmt.Sprintf("verify=%v", verify), func(b *testing.B) {
+			store, err := NewLSMStore(b.TempDir())
+			if err != nil {
+				b.Fatalf("Failed to create store: %v", err)
+			}
+			defer store.Close()
+			store.SetVerifyChecksumsOnRead(verify)
+
+			value := bytes.Repeat([]byte("x"), 4096)
+			for i := 0; i < 1000; i++ {
+				if err := store.Put(fmt.Sprintf("key%04d", i), value); err != nil {
+					b.Fatalf("Put failed: %v", err)
+				}
+			}
+			if err := store.flushIfOlderThan(0); err != nil {
+				b.Fatalf("Flush failed: %v", err)
+			}
+			if err := store.flushQueued(); err != nil {
+				b.Fatalf("Flush failed: %v", err)
+			}
+
+			b.SetBytes(int64(len(value)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.Get(fmt.Sprintf("key%04d", i%1000)); err != nil {
+					b.Fatalf("Get failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -2,30 +2,89 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 // SSTable represents a Sorted String Table (immutable on-disk file)
 // Format:
-// [Data Block: sorted key-value pairs]
+// [Data Block: sorted records [key_len(4)][key][value_len(4)][value_header(2)][value][timestamp(8)][crc32(4)]]
 // [Index Block: key -> offset mapping]
 // [Bloom Filter Block: serialized bloom filter]
-// [Footer: index offset + bloom offset + magic number]
+// [Key Range Block: [min_len(4)][min_key][max_len(4)][max_key]]
+// [Footer: index offset + bloom offset + tombstone stats + format version + magic number]
+//
+// Version 0 files (written before record checksums) have no crc32 in their
+// records and a footer without the version byte, ending in
+// sstableMagicNumber. They are still readable, without checksum validation.
+// Version 1 footers lack the tombstone stats; they read as having none.
+// Records before version 3 have no timestamp; they read as written at 0,
+// older than anything written since. Before version 4 there is no key range
+// block, so lookups can't skip the table by key range. Records before
+// version 5 have no value header (see ValueFlags); their values are plain.
+// Before version 6 a delete is stored as a marker value (see
+// legacyTombstoneMarker) rather than flagged ValueTombstone, so a value
+// that looks like the marker reads as a delete in those tables.
 
 const (
-	sstableMagicNumber = 0xDEADBEEF
-	indexEntrySize     = 256 // Max key size in index
+	sstableMagicNumber   = 0xDEADBEEF // Version 0 footer
+	sstableVersionMagic  = 0x5354424C // "STBL": footer carries a version byte
+	sstableFormatVersion = 6          // Current format: per-record CRC32, timestamp and value header, flagged tombstones, tombstone stats, key range
+	indexEntrySize       = 256        // Max key size in index
+
+	legacyFooterSize = 28 // [index_offset(8)][bloom_offset(8)][bloom_len(4)][num_entries(4)][magic(4)]
+	footerSizeV1     = 29 // As above with [version(1)] before the magic
+	footerSize       = 41 // As V1 with [num_tombstones(4)][newest_tombstone(8)] before the version
+	recordCRCSize    = 4
+	timestampSize    = 8 // Per-record timestamp, from version 3
 )
 
+// ErrCorruptSSTable is returned when an SSTable record fails its checksum
+// or cannot be parsed. The data is not served.
+type ErrCorruptSSTable struct {
+	Path   string
+	Offset int64 // Offset of the damaged record in the data block
+	Reason string
+}
+
+func (e *ErrCorruptSSTable) Error() string {
+	return fmt.Sprintf("corrupt SSTable %s at offset %d: %s", e.Path, e.Offset, e.Reason)
+}
+
 type SSTable struct {
 	filePath    string
+	version     uint8          // On-disk format version
+	bloomFilter *BloomFilter   // NEW: Bloom filter for fast negative lookups
+	bloomCounts *bloomCounters // Owning store's probe counters (nil outside a store)
+
+	skipChecksums *atomic.Bool // Owning store's read setting (nil: always validate)
+
+	// The index is read on first use by tables opened with OpenSSTableMeta;
+	// only access it after loadIndex
 	index       []IndexEntry
-	bloomFilter *BloomFilter // NEW: Bloom filter for fast negative lookups
+	indexOffset int64
+	numEntries  int
+	indexOnce   sync.Once
+	indexErr    error
+
+	numTombstones   int   // Deleted keys among the entries
+	newestTombstone int64 // Timestamp of the most recent delete (0 if none)
+
+	// First and last keys in the table, from version 4. Lookups for keys
+	// outside them return not-found without reading the bloom filter or index.
+	hasKeyRange bool
+	minKey      []byte
+	maxKey      []byte
+
+	indexSearches atomic.Int64 // Binary searches of the index (see searchIndex)
 }
 
 type IndexEntry struct {
@@ -42,6 +101,10 @@ type SSTableWriter struct {
 	dataOffset  int64
 	bloomFilter *BloomFilter // NEW: Build bloom filter as we write
 	numKeys     int
+	version     uint8 // Format written: sstableFormatVersion, or older in tests of reading it
+
+	numTombstones   int
+	newestTombstone int64
 }
 
 // NewSSTableWriter creates a new SSTable writer
@@ -50,7 +113,11 @@ func NewSSTableWriter(dataDir string, tableID int) (*SSTableWriter, error) {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	filePath := filepath.Join(dataDir, fmt.Sprintf("sstable_%d.db", tableID))
+	return newSSTableWriterAt(filepath.Join(dataDir, sstableFileName(tableID)))
+}
+
+// newSSTableWriterAt creates a writer for an SSTable at filePath
+func newSSTableWriterAt(filePath string) (*SSTableWriter, error) {
 	file, err := os.Create(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SSTable file: %w", err)
@@ -63,11 +130,27 @@ func NewSSTableWriter(dataDir string, tableID int) (*SSTableWriter, error) {
 		index:      make([]IndexEntry, 0),
 		dataOffset: 0,
 		numKeys:    0,
+		version:    sstableFormatVersion,
 	}, nil
 }
 
-// Write writes a sorted entry to the SSTable
-func (w *SSTableWriter) Write(key, value []byte) error {
+// Write writes a sorted entry to the SSTable. timestamp is when the entry
+// was written (the WAL entry timestamp); compaction uses it to pick the
+// newest version of a key.
+func (w *SSTableWriter) Write(key, value []byte, timestamp int64) error {
+	return w.writeRecord(key, value, timestamp, 0)
+}
+
+// WriteDelete writes a tombstone for key, deleted at timestamp, in sorted
+// order with Write
+func (w *SSTableWriter) WriteDelete(key []byte, timestamp int64) error {
+	return w.writeRecord(key, nil, timestamp, ValueTombstone)
+}
+
+// writeRecord is Write for a value encoded as flags describe; with
+// ValueTombstone set it is WriteDelete. Compaction and rebuilds pass on the
+// flags read with each record.
+func (w *SSTableWriter) writeRecord(key, value []byte, timestamp int64, flags ValueFlags) error {
 	// Lazy initialize bloom filter on first write
 	if w.bloomFilter == nil {
 		// Estimate: we'll probably write similar number of keys as we have now
@@ -80,6 +163,19 @@ func (w *SSTableWriter) Write(key, value []byte) error {
 	w.bloomFilter.Add(key)
 	w.numKeys++
 
+	if flags&ValueTombstone != 0 {
+		w.numTombstones++
+		if timestamp > w.newestTombstone {
+			w.newestTombstone = timestamp
+		}
+
+		// Tables before version 6 store the delete as a marker value
+		value = nil
+		if w.version < 6 {
+			value, flags = newLegacyTombstone(timestamp), flags&^ValueTombstone
+		}
+	}
+
 	// Record index entry (key -> current offset)
 	w.index = append(w.index, IndexEntry{
 		Key:    append([]byte(nil), key...), // Copy key
@@ -87,8 +183,9 @@ func (w *SSTableWriter) Write(key, value []byte) error {
 	})
 
 	// Write key length (4 bytes)
-	keyLen := uint32(len(key))
-	if err := binary.Write(w.writer, binary.LittleEndian, keyLen); err != nil {
+	var keyLen [4]byte
+	binary.LittleEndian.PutUint32(keyLen[:], uint32(len(key)))
+	if _, err := w.writer.Write(keyLen[:]); err != nil {
 		return err
 	}
 	w.dataOffset += 4
@@ -100,21 +197,59 @@ func (w *SSTableWriter) Write(key, value []byte) error {
 	w.dataOffset += int64(len(key))
 
 	// Write value length (4 bytes)
-	valueLen := uint32(len(value))
-	if err := binary.Write(w.writer, binary.LittleEndian, valueLen); err != nil {
+	var valueLen [4]byte
+	binary.LittleEndian.PutUint32(valueLen[:], uint32(len(value)))
+	if _, err := w.writer.Write(valueLen[:]); err != nil {
 		return err
 	}
 	w.dataOffset += 4
 
+	// Write value header, from version 5
+	var header []byte
+	if w.version >= 5 {
+		encoded := encodeValueHeader(flags)
+		header = encoded[:]
+		if _, err := w.writer.Write(header); err != nil {
+			return err
+		}
+		w.dataOffset += valueHeaderSize
+	}
+
 	// Write value
 	if _, err := w.writer.Write(value); err != nil {
 		return err
 	}
 	w.dataOffset += int64(len(value))
 
+	// Write timestamp (8 bytes)
+	var ts [timestampSize]byte
+	binary.LittleEndian.PutUint64(ts[:], uint64(timestamp))
+	if _, err := w.writer.Write(ts[:]); err != nil {
+		return err
+	}
+	w.dataOffset += timestampSize
+
+	// Write checksum over everything above (4 bytes)
+	crc := recordChecksum(keyLen[:], key, valueLen[:], header, value, ts[:])
+	if err := binary.Write(w.writer, binary.LittleEndian, crc); err != nil {
+		return err
+	}
+	w.dataOffset += recordCRCSize
+
 	return nil
 }
 
+// recordChecksum returns the CRC32 of a record's fields in order: the
+// length-prefixed key and value (with the value header from version 5),
+// then the timestamp from version 3
+func recordChecksum(fields ...[]byte) uint32 {
+	var crc uint32
+	for _, field := range fields {
+		crc = crc32.Update(crc, crc32.IEEETable, field)
+	}
+	return crc
+}
+
 // Finalize writes the index, bloom filter, and footer, then closes the file
 func (w *SSTableWriter) Finalize() error {
 	// Write index block
@@ -160,8 +295,25 @@ func (w *SSTableWriter) Finalize() error {
 
 	bloomLen := uint32(len(bloomData))
 
-	// Write footer: [index_offset(8)][bloom_offset(8)][bloom_len(4)][num_entries(4)][magic(4)]
-	// Total footer size: 28 bytes
+	// Write key range block: the first and last keys, so lookups outside
+	// them can skip the table (empty for a table with no entries)
+	var minKey, maxKey []byte
+	if len(w.index) > 0 {
+		minKey = w.index[0].Key
+		maxKey = w.index[len(w.index)-1].Key
+	}
+	for _, key := range [][]byte{minKey, maxKey} {
+		if err := binary.Write(w.writer, binary.LittleEndian, uint32(len(key))); err != nil {
+			return err
+		}
+		if _, err := w.writer.Write(key); err != nil {
+			return err
+		}
+	}
+
+	// Write footer: [index_offset(8)][bloom_offset(8)][bloom_len(4)][num_entries(4)]
+	// [num_tombstones(4)][newest_tombstone(8)][version(1)][magic(4)]
+	// Total footer size: 41 bytes
 	if err := binary.Write(w.writer, binary.LittleEndian, indexOffset); err != nil {
 		return err
 	}
@@ -179,7 +331,19 @@ func (w *SSTableWriter) Finalize() error {
 		return err
 	}
 
-	if err := binary.Write(w.writer, binary.LittleEndian, uint32(sstableMagicNumber)); err != nil {
+	if err := binary.Write(w.writer, binary.LittleEndian, uint32(w.numTombstones)); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w.writer, binary.LittleEndian, w.newestTombstone); err != nil {
+		return err
+	}
+
+	if err := w.writer.WriteByte(w.version); err != nil {
+		return err
+	}
+
+	if err := binary.Write(w.writer, binary.LittleEndian, uint32(sstableVersionMagic)); err != nil {
 		return err
 	}
 
@@ -195,8 +359,29 @@ func (w *SSTableWriter) Finalize() error {
 	return w.file.Close()
 }
 
+// abort closes and deletes a partially written SSTable
+func (w *SSTableWriter) abort() {
+	w.file.Close()
+	os.Remove(w.filePath)
+}
+
 // OpenSSTable opens an existing SSTable for reading
 func OpenSSTable(filePath string) (*SSTable, error) {
+	sst, err := OpenSSTableMeta(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sst.loadIndex(); err != nil {
+		return nil, err
+	}
+	return sst, nil
+}
+
+// OpenSSTableMeta opens an existing SSTable reading only its footer and
+// bloom filter. The index is read on the first lookup that gets past the
+// bloom filter, so opening many tables, or only inspecting their metadata
+// (NumEntries, NumTombstones, the bloom filter), stays cheap.
+func OpenSSTableMeta(filePath string) (*SSTable, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open SSTable: %w", err)
@@ -210,12 +395,46 @@ func OpenSSTable(filePath string) (*SSTable, error) {
 	}
 	fileSize := fileInfo.Size()
 
-	// Footer is last 28 bytes: [index_offset(8)][bloom_offset(8)][bloom_len(4)][num_entries(4)][magic(4)]
-	if fileSize < 28 {
+	// The magic number (last 4 bytes) tells which footer layout follows
+	if fileSize < legacyFooterSize {
 		return nil, fmt.Errorf("invalid SSTable file: too small")
 	}
 
-	if _, err := file.Seek(fileSize-28, 0); err != nil {
+	var magicBuf [4]byte
+	if _, err := file.ReadAt(magicBuf[:], fileSize-4); err != nil {
+		return nil, err
+	}
+
+	var version uint8
+	footerLen := int64(legacyFooterSize)
+	switch binary.LittleEndian.Uint32(magicBuf[:]) {
+	case sstableMagicNumber:
+		version = 0
+	case sstableVersionMagic:
+		if fileSize < footerSizeV1 {
+			return nil, fmt.Errorf("invalid SSTable file: too small")
+		}
+		var versionBuf [1]byte
+		if _, err := file.ReadAt(versionBuf[:], fileSize-5); err != nil {
+			return nil, err
+		}
+		version = versionBuf[0]
+		footerLen = footerSizeV1
+		if version >= 2 {
+			if fileSize < footerSize {
+				return nil, fmt.Errorf("invalid SSTable file: too small")
+			}
+			footerLen = footerSize
+		}
+	default:
+		return nil, fmt.Errorf("invalid SSTable magic number")
+	}
+
+	if version > sstableFormatVersion {
+		return nil, fmt.Errorf("unsupported SSTable format version %d (newest supported is %d)", version, sstableFormatVersion)
+	}
+
+	if _, err := file.Seek(fileSize-footerLen, 0); err != nil {
 		return nil, err
 	}
 
@@ -223,7 +442,6 @@ func OpenSSTable(filePath string) (*SSTable, error) {
 	var bloomOffset int64
 	var bloomLen uint32
 	var numEntries uint32
-	var magic uint32
 
 	if err := binary.Read(file, binary.LittleEndian, &indexOffset); err != nil {
 		return nil, err
@@ -237,34 +455,132 @@ func OpenSSTable(filePath string) (*SSTable, error) {
 	if err := binary.Read(file, binary.LittleEndian, &numEntries); err != nil {
 		return nil, err
 	}
-	if err := binary.Read(file, binary.LittleEndian, &magic); err != nil {
-		return nil, err
+
+	var numTombstones uint32
+	var newestTombstone int64
+	if version >= 2 {
+		if err := binary.Read(file, binary.LittleEndian, &numTombstones); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(file, binary.LittleEndian, &newestTombstone); err != nil {
+			return nil, err
+		}
 	}
 
-	if magic != sstableMagicNumber {
-		return nil, fmt.Errorf("invalid SSTable magic number")
+	// Read bloom filter
+	var bloomFilter *BloomFilter
+	if bloomLen > 0 {
+		if _, err := file.Seek(bloomOffset, 0); err != nil {
+			return nil, err
+		}
+
+		bloomData := make([]byte, bloomLen)
+		if _, err := io.ReadFull(file, bloomData); err != nil {
+			return nil, err
+		}
+
+		bloomFilter = DeserializeBloomFilter(bloomData)
+	}
+
+	sst := &SSTable{
+		filePath:    filePath,
+		version:     version,
+		bloomFilter: bloomFilter,
+		indexOffset: indexOffset,
+		numEntries:  int(numEntries),
+
+		numTombstones:   int(numTombstones),
+		newestTombstone: newestTombstone,
+	}
+
+	// The key range block sits between the bloom filter and the footer
+	if version >= 4 {
+		rangeOffset := bloomOffset + int64(bloomLen)
+		rangeLen := fileSize - footerLen - rangeOffset
+		if rangeLen < 8 {
+			return nil, fmt.Errorf("invalid SSTable file: truncated key range")
+		}
+		sst.minKey, sst.maxKey, err = readKeyRange(io.NewSectionReader(file, rangeOffset, rangeLen))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSTable file: %w", err)
+		}
+		sst.hasKeyRange = true
+	}
+
+	return sst, nil
+}
+
+// readKeyRange reads the key range block: [min_len(4)][min_key][max_len(4)][max_key]
+func readKeyRange(r io.Reader) (minKey, maxKey []byte, err error) {
+	keys := make([][]byte, 2)
+	for i := range keys {
+		var keyLen uint32
+		if err := binary.Read(r, binary.LittleEndian, &keyLen); err != nil {
+			return nil, nil, fmt.Errorf("failed to read key range: %w", err)
+		}
+		keys[i] = make([]byte, keyLen)
+		if _, err := io.ReadFull(r, keys[i]); err != nil {
+			return nil, nil, fmt.Errorf("failed to read key range: %w", err)
+		}
+	}
+	return keys[0], keys[1], nil
+}
+
+// inKeyRange reports whether key falls within the table's first and last
+// keys. Tables older than version 4 don't record them, so every key may be
+// in range.
+func (s *SSTable) inKeyRange(key []byte) bool {
+	if !s.hasKeyRange {
+		return true
+	}
+	return bytes.Compare(key, s.minKey) >= 0 && bytes.Compare(key, s.maxKey) <= 0
+}
+
+// searchIndex returns the position of the first index entry >= key
+func (s *SSTable) searchIndex(index []IndexEntry, key []byte) int {
+	s.indexSearches.Add(1)
+	return sort.Search(len(index), func(i int) bool {
+		return bytes.Compare(index[i].Key, key) >= 0
+	})
+}
+
+// loadIndex reads the index block the first time it is called and returns
+// the index. A failed read is remembered and returned on every call.
+func (s *SSTable) loadIndex() ([]IndexEntry, error) {
+	s.indexOnce.Do(func() {
+		s.index, s.indexErr = s.readIndex()
+	})
+	return s.index, s.indexErr
+}
+
+// readIndex reads the index block: numEntries [key_len(4)][key][offset(8)]
+func (s *SSTable) readIndex() ([]IndexEntry, error) {
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SSTable: %w", err)
 	}
+	defer file.Close()
 
-	// Read index
-	if _, err := file.Seek(indexOffset, 0); err != nil {
+	if _, err := file.Seek(s.indexOffset, 0); err != nil {
 		return nil, err
 	}
+	reader := bufio.NewReader(file)
 
-	index := make([]IndexEntry, numEntries)
-	for i := uint32(0); i < numEntries; i++ {
+	index := make([]IndexEntry, s.numEntries)
+	for i := range index {
 		var keyLen uint32
-		if err := binary.Read(file, binary.LittleEndian, &keyLen); err != nil {
-			return nil, err
+		if err := binary.Read(reader, binary.LittleEndian, &keyLen); err != nil {
+			return nil, fmt.Errorf("failed to read SSTable index: %w", err)
 		}
 
 		key := make([]byte, keyLen)
-		if _, err := io.ReadFull(file, key); err != nil {
-			return nil, err
+		if _, err := io.ReadFull(reader, key); err != nil {
+			return nil, fmt.Errorf("failed to read SSTable index: %w", err)
 		}
 
 		var offset int64
-		if err := binary.Read(file, binary.LittleEndian, &offset); err != nil {
-			return nil, err
+		if err := binary.Read(reader, binary.LittleEndian, &offset); err != nil {
+			return nil, fmt.Errorf("failed to read SSTable index: %w", err)
 		}
 
 		index[i] = IndexEntry{
@@ -273,82 +589,436 @@ func OpenSSTable(filePath string) (*SSTable, error) {
 		}
 	}
 
-	// Read bloom filter
-	var bloomFilter *BloomFilter
-	if bloomLen > 0 {
-		if _, err := file.Seek(bloomOffset, 0); err != nil {
-			return nil, err
+	return index, nil
+}
+
+// readRecord reads one data record starting at offset from r and, for
+// checksummed formats, validates it unless verify is false. The entry has
+// Key, Value, Timestamp and Flags set, with the value header stripped from
+// Value. Damaged records return *ErrCorruptSSTable; without verify, damage
+// to anything but the lengths goes unnoticed.
+func (s *SSTable) readRecord(r io.Reader, offset int64, verify bool) (Entry, error) {
+	corrupt := func(reason string) error {
+		return &ErrCorruptSSTable{Path: s.filePath, Offset: offset, Reason: reason}
+	}
+
+	var keyLen [4]byte
+	if _, err := io.ReadFull(r, keyLen[:]); err != nil {
+		return Entry{}, err
+	}
+	key := make([]byte, binary.LittleEndian.Uint32(keyLen[:]))
+	if _, err := io.ReadFull(r, key); err != nil {
+		return Entry{}, corrupt(fmt.Sprintf("truncated key: %v", err))
+	}
+
+	var valueLen [4]byte
+	if _, err := io.ReadFull(r, valueLen[:]); err != nil {
+		return Entry{}, corrupt(fmt.Sprintf("truncated value length: %v", err))
+	}
+	header := make([]byte, s.valueHeaderLen())
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Entry{}, corrupt(fmt.Sprintf("truncated value header: %v", err))
+	}
+	value := make([]byte, binary.LittleEndian.Uint32(valueLen[:]))
+	if _, err := io.ReadFull(r, value); err != nil {
+		return Entry{}, corrupt(fmt.Sprintf("truncated value: %v", err))
+	}
+
+	if s.version == 0 {
+		return s.markDelete(Entry{Key: key, Value: value}), nil
+	}
+
+	fields := [][]byte{keyLen[:], key, valueLen[:], header, value}
+	var timestamp int64
+	if s.version >= 3 {
+		var ts [timestampSize]byte
+		if _, err := io.ReadFull(r, ts[:]); err != nil {
+			return Entry{}, corrupt(fmt.Sprintf("truncated timestamp: %v", err))
 		}
+		fields = append(fields, ts[:])
+		timestamp = int64(binary.LittleEndian.Uint64(ts[:]))
+	}
 
-		bloomData := make([]byte, bloomLen)
-		if _, err := io.ReadFull(file, bloomData); err != nil {
-			return nil, err
+	var stored uint32
+	if err := binary.Read(r, binary.LittleEndian, &stored); err != nil {
+		return Entry{}, corrupt(fmt.Sprintf("truncated checksum: %v", err))
+	}
+	if verify {
+		if computed := recordChecksum(fields...); computed != stored {
+			return Entry{}, corrupt(fmt.Sprintf("checksum mismatch (stored %08x, computed %08x)", stored, computed))
 		}
+	}
 
-		bloomFilter = DeserializeBloomFilter(bloomData)
+	var flags ValueFlags
+	if len(header) > 0 {
+		var err error
+		if flags, err = decodeValueHeader(header); err != nil {
+			return Entry{}, corrupt(err.Error())
+		}
 	}
 
-	return &SSTable{
-		filePath:    filePath,
-		index:       index,
-		bloomFilter: bloomFilter,
-	}, nil
+	return s.markDelete(Entry{Key: key, Value: value, Timestamp: timestamp, Flags: flags}), nil
+}
+
+// markDelete sets a record's Op: OpDelete, with no value and ValueTombstone
+// set, if it is flagged as a delete or, before version 6, holds the legacy
+// marker value; OpPut otherwise
+func (s *SSTable) markDelete(entry Entry) Entry {
+	entry.Op = OpPut
+	if entry.Flags&ValueTombstone != 0 || (s.version < 6 && isLegacyTombstone(entry.Value)) {
+		entry.Op = OpDelete
+		entry.Value = nil
+		entry.Flags |= ValueTombstone
+	}
+	return entry
+}
+
+// peekDelete reports whether the record with its value length at
+// valueLenOffset is a delete, reading only its value header. Before version
+// 6 there is no flag to read: known is false when the value is the length
+// of a legacy tombstone, and the whole record has to be read to tell.
+func (s *SSTable) peekDelete(file *os.File, recordOffset, valueLenOffset int64, valueLen uint32) (deleted, known bool, err error) {
+	if s.version < 6 {
+		return false, !isLegacyTombstoneLen(int(valueLen)), nil
+	}
+
+	var header [valueHeaderSize]byte
+	if _, err := file.ReadAt(header[:], valueLenOffset+4); err != nil {
+		return false, false, &ErrCorruptSSTable{Path: s.filePath, Offset: recordOffset, Reason: fmt.Sprintf("truncated value header: %v", err)}
+	}
+	flags, err := decodeValueHeader(header[:])
+	if err != nil {
+		return false, false, &ErrCorruptSSTable{Path: s.filePath, Offset: recordOffset, Reason: err.Error()}
+	}
+	return flags&ValueTombstone != 0, true, nil
 }
 
-// Get retrieves a value by key from the SSTable
+// valueHeaderLen is the size of the value header in each record: none
+// before version 5
+func (s *SSTable) valueHeaderLen() int64 {
+	if s.version >= 5 {
+		return valueHeaderSize
+	}
+	return 0
+}
+
+// Get retrieves a value by key from the SSTable. A key deleted in this table
+// is not found; Contains tells it apart from an absent one.
 func (s *SSTable) Get(key []byte) ([]byte, bool, error) {
-	// NEW: Check bloom filter first - if it says "definitely not present", skip disk read
-	if s.bloomFilter != nil && !s.bloomFilter.MayContain(key) {
+	// Keys outside the table's range can't be in it
+	if !s.inKeyRange(key) {
+		return nil, false, nil
+	}
+
+	// NEW: Check bloom filter next - if it says "definitely not present", skip disk read
+	if !s.mayContain(key) {
 		return nil, false, nil // Definitely not in this SSTable
 	}
 
 	// Bloom filter says "might be present" or we don't have a bloom filter
-	// Proceed with binary search in index
-	idx := sort.Search(len(s.index), func(i int) bool {
-		return string(s.index[i].Key) >= string(key)
-	})
+	record, found, err := s.lookupEntry(key)
+	if err != nil || !found || record.Op == OpDelete {
+		return nil, false, err
+	}
+	return record.Value, true, nil
+}
 
-	if idx >= len(s.index) || string(s.index[idx].Key) != string(key) {
-		return nil, false, nil // Key not found (bloom filter false positive)
+// lookupEntry reads key's record through the index, without consulting the
+// bloom filter; a delete is returned with Op OpDelete
+func (s *SSTable) lookupEntry(key []byte) (Entry, bool, error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	idx := s.searchIndex(index, key)
+
+	if idx >= len(index) || !bytes.Equal(index[idx].Key, key) {
+		return Entry{}, false, nil // Key not found (bloom filter false positive)
 	}
 
 	// Read from data block
 	file, err := os.Open(s.filePath)
 	if err != nil {
-		return nil, false, err
+		return Entry{}, false, err
 	}
 	defer file.Close()
 
-	if _, err := file.Seek(s.index[idx].Offset, 0); err != nil {
-		return nil, false, err
+	offset := index[idx].Offset
+	if _, err := file.Seek(offset, 0); err != nil {
+		return Entry{}, false, err
 	}
 
-	reader := bufio.NewReader(file)
+	// Read the whole record so its checksum can be validated
+	record, err := s.readRecord(bufio.NewReader(file), offset, s.verifyOnRead())
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if !bytes.Equal(record.Key, key) {
+		return Entry{}, false, &ErrCorruptSSTable{Path: s.filePath, Offset: offset, Reason: "record key does not match index"}
+	}
 
-	// Read key length
-	var keyLen uint32
-	if err := binary.Read(reader, binary.LittleEndian, &keyLen); err != nil {
-		return nil, false, err
+	return record, true, nil
+}
+
+// Contains reports whether the SSTable has an entry for key and whether
+// that entry is a tombstone, reading as little as possible: the bloom filter
+// rules most absent keys out without I/O, an index hit means the key is
+// present, and only the value header is read to tell a live value from a
+// tombstone. Before version 6 the value length is read instead, and a
+// record whose value length matches a tombstone's is read (and checksummed)
+// whole.
+func (s *SSTable) Contains(key []byte) (found bool, tombstone bool, err error) {
+	if !s.inKeyRange(key) {
+		return false, false, nil
+	}
+	if !s.mayContain(key) {
+		return false, false, nil
 	}
 
-	// Skip key (we already know it matches)
-	if _, err := reader.Discard(int(keyLen)); err != nil {
-		return nil, false, err
+	index, err := s.loadIndex()
+	if err != nil {
+		return false, false, err
+	}
+	idx := s.searchIndex(index, key)
+	if idx >= len(index) || !bytes.Equal(index[idx].Key, key) {
+		return false, false, nil
 	}
 
-	// Read value length
-	var valueLen uint32
-	if err := binary.Read(reader, binary.LittleEndian, &valueLen); err != nil {
-		return nil, false, err
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return false, false, err
 	}
+	defer file.Close()
 
-	// Read value
-	value := make([]byte, valueLen)
-	if _, err := io.ReadFull(reader, value); err != nil {
-		return nil, false, err
+	// The record starts [key_len(4)][key][value_len(4)]
+	offset := index[idx].Offset
+	var valueLen [4]byte
+	if _, err := file.ReadAt(valueLen[:], offset+4+int64(len(key))); err != nil {
+		return false, false, &ErrCorruptSSTable{Path: s.filePath, Offset: offset, Reason: fmt.Sprintf("truncated value length: %v", err)}
+	}
+	deleted, known, err := s.peekDelete(file, offset, offset+4+int64(len(key)), binary.LittleEndian.Uint32(valueLen[:]))
+	if err != nil {
+		return false, false, err
+	}
+	if known {
+		return true, deleted, nil
+	}
+
+	record, _, err := s.lookupEntry(key)
+	if err != nil {
+		return false, false, err
+	}
+	return true, record.Op == OpDelete, nil
+}
+
+// Range returns entries with start <= key < end in sorted order, including
+// tombstones. An empty start or end leaves that side of the range open.
+// With keysOnly, value bytes are never read: only the value length is
+// checked so tombstones can still be recognised, and Value is nil for
+// live keys.
+func (s *SSTable) Range(start, end []byte, keysOnly bool) ([]Entry, error) {
+	index, err := s.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the first index entry >= start
+	first := s.searchIndex(index, start)
+
+	if first >= len(index) {
+		return nil, nil
+	}
+
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []Entry
+
+	for i := first; i < len(index); i++ {
+		if len(end) > 0 && bytes.Compare(index[i].Key, end) >= 0 {
+			break
+		}
+
+		entry, err := s.readEntryAt(file, i, keysOnly)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// readEntryAt reads the entry at index position i with positioned reads
+// (the index must be loaded). With keysOnly, only values the size of a
+// tombstone are read; Value is nil for live keys.
+func (s *SSTable) readEntryAt(file *os.File, i int, keysOnly bool) (Entry, error) {
+	indexEntry := s.index[i]
+
+	// Skip [key_len][key] and read the value length
+	valueLenOffset := indexEntry.Offset + 4 + int64(len(indexEntry.Key))
+	var lenBuf [4]byte
+	if _, err := file.ReadAt(lenBuf[:], valueLenOffset); err != nil {
+		return Entry{}, err
+	}
+	valueLen := binary.LittleEndian.Uint32(lenBuf[:])
+
+	// Only tombstones have to be read in keys-only mode; the timestamp after
+	// the value is still needed to order versions
+	var deleted, known bool
+	if keysOnly {
+		var err error
+		if deleted, known, err = s.peekDelete(file, indexEntry.Offset, valueLenOffset, valueLen); err != nil {
+			return Entry{}, err
+		}
+	}
+	if keysOnly && known && !deleted {
+		entry := Entry{Key: indexEntry.Key, Op: OpPut}
+		if s.version >= 3 {
+			var ts [timestampSize]byte
+			if _, err := file.ReadAt(ts[:], valueLenOffset+4+s.valueHeaderLen()+int64(valueLen)); err != nil {
+				return Entry{}, &ErrCorruptSSTable{Path: s.filePath, Offset: indexEntry.Offset, Reason: fmt.Sprintf("truncated timestamp: %v", err)}
+			}
+			entry.Timestamp = int64(binary.LittleEndian.Uint64(ts[:]))
+		}
+		return entry, nil
+	}
+
+	// Read the whole record so its checksum can be validated
+	recordLen := valueLenOffset + 4 + s.valueHeaderLen() + int64(valueLen) - indexEntry.Offset
+	if s.version >= 3 {
+		recordLen += timestampSize
+	}
+	if s.version > 0 {
+		recordLen += recordCRCSize
+	}
+	entry, err := s.readRecord(io.NewSectionReader(file, indexEntry.Offset, recordLen), indexEntry.Offset, s.verifyOnRead())
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Key = indexEntry.Key
+
+	if keysOnly {
+		entry.Value = nil
+	}
+
+	return entry, nil
+}
+
+// sstableIterator reads an SSTable's entries sequentially in key order,
+// holding only the current entry in memory
+type sstableIterator struct {
+	sstable    *SSTable
+	file       *os.File // Opened on the first read for range iterators
+	reader     *bufio.Reader
+	positioned bool   // reader is at the record of entry next
+	next       int    // Index of the next entry
+	end        []byte // Stop before this key; empty = no bound
+	keysOnly   bool   // Read values only for tombstones (see Range)
+
+	skipChecksums bool // Don't validate record checksums (see SetVerifyChecksumsOnRead)
+}
+
+// newIterator opens an iterator positioned before the first entry
+func (s *SSTable) newIterator() (*sstableIterator, error) {
+	if _, err := s.loadIndex(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sstableIterator{
+		sstable:    s,
+		file:       file,
+		reader:     bufio.NewReader(file),
+		positioned: true,
+	}, nil
+}
+
+// newRangeIterator returns an iterator that stops before end (empty = no
+// bound), positioned before the first entry. The file is not opened until
+// an entry is read, so an iterator whose range the table doesn't cover
+// costs no I/O.
+func (s *SSTable) newRangeIterator(end []byte, keysOnly bool) *sstableIterator {
+	return &sstableIterator{sstable: s, end: end, keysOnly: keysOnly}
+}
+
+// seek positions the iterator before the first entry with key >= key. If
+// the index can't be loaded, Next reports the error.
+func (it *sstableIterator) seek(key []byte) {
+	index, err := it.sstable.loadIndex()
+	if err != nil {
+		return
+	}
+	it.next = it.sstable.searchIndex(index, key)
+	it.positioned = false
+}
+
+// Next returns the next entry; the boolean is false once all entries are read
+func (it *sstableIterator) Next() (Entry, bool, error) {
+	index, err := it.sstable.loadIndex()
+	if err != nil {
+		return Entry{}, false, err
+	}
+	if it.next == len(index) {
+		return Entry{}, false, nil
+	}
+	if len(it.end) > 0 && bytes.Compare(index[it.next].Key, it.end) >= 0 {
+		return Entry{}, false, nil
+	}
+
+	if it.file == nil {
+		file, err := os.Open(it.sstable.filePath)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		it.file = file
+	}
+
+	if it.keysOnly {
+		entry, err := it.sstable.readEntryAt(it.file, it.next, true)
+		if err != nil {
+			return Entry{}, false, err
+		}
+		it.next++
+		return entry, true, nil
+	}
+
+	if !it.positioned {
+		if _, err := it.file.Seek(index[it.next].Offset, io.SeekStart); err != nil {
+			return Entry{}, false, err
+		}
+		if it.reader == nil {
+			it.reader = bufio.NewReader(it.file)
+		} else {
+			it.reader.Reset(it.file)
+		}
+		it.positioned = true
+	}
+
+	entry, err := it.sstable.readRecord(it.reader, index[it.next].Offset, !it.skipChecksums)
+	if err != nil {
+		return Entry{}, false, err
 	}
 
-	return value, true, nil
+	it.next++
+	return entry, true, nil
+}
+
+// Close closes the underlying file
+func (it *sstableIterator) Close() error {
+	if it.file == nil {
+		return nil
+	}
+	err := it.file.Close()
+	it.file = nil
+	return err
 }
 
 // FilePath returns the file path
@@ -356,6 +1026,17 @@ func (s *SSTable) FilePath() string {
 	return s.filePath
 }
 
+// NumEntries returns the number of entries, tombstones included
+func (s *SSTable) NumEntries() int {
+	return s.numEntries
+}
+
+// NumTombstones returns the number of tombstones (0 for tables written
+// before format version 2)
+func (s *SSTable) NumTombstones() int {
+	return s.numTombstones
+}
+
 // HasBloomFilter returns true if this SSTable has a bloom filter
 func (s *SSTable) HasBloomFilter() bool {
 	return s.bloomFilter != nil
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// RebuildSSTable recovers an SSTable whose index, bloom filter or footer is
+// damaged or missing (e.g. a crash while it was being written) but whose data
+// block is intact. The records are self-describing and checksummed, so they
+// are read back sequentially from the start of the file; the scan stops at
+// the first bytes that are not a valid record following the previous one in
+// key order, which is normally where the old index began. The records found
+// are written to a new table with a fresh index, bloom filter and footer,
+// which then replaces the file.
+//
+// Only the current format can be rebuilt. Records after a damaged one in the
+// data block are lost; the number recovered is logged. The store must not
+// have the table open.
+func RebuildSSTable(filePath string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open SSTable: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filePath + ".rebuild"
+	w, err := newSSTableWriterAt(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	// readRecord only needs the path and version to validate records
+	sst := &SSTable{filePath: filePath, version: sstableFormatVersion}
+
+	var offset int64
+	var lastKey []byte
+	records := 0
+	for {
+		entry, size, err := sst.scanRecordAt(file, offset, info.Size())
+		if err != nil {
+			break
+		}
+		if records > 0 && bytes.Compare(entry.Key, lastKey) <= 0 {
+			break // Out of order: past the end of the data block
+		}
+
+		if err := w.writeRecord(entry.Key, entry.Value, entry.Timestamp, entry.Flags); err != nil {
+			w.abort()
+			return fmt.Errorf("failed to write rebuilt SSTable: %w", err)
+		}
+		lastKey = entry.Key
+		offset += size
+		records++
+	}
+
+	if records == 0 {
+		w.abort()
+		return fmt.Errorf("no intact records at the start of SSTable %s", filePath)
+	}
+
+	if err := w.Finalize(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to finalize rebuilt SSTable: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace SSTable: %w", err)
+	}
+
+	log.Printf("🔧 Rebuilt SSTable %s: %d records recovered, %d trailing bytes discarded",
+		filePath, records, info.Size()-offset)
+	return nil
+}
+
+// scanRecordAt reads and validates the record at offset in a file of the
+// given size, returning it and its size on disk. Lengths are checked against
+// the file size before anything is allocated, since the bytes may be garbage.
+func (s *SSTable) scanRecordAt(file *os.File, offset, fileSize int64) (Entry, int64, error) {
+	var lenBuf [4]byte
+	if _, err := file.ReadAt(lenBuf[:], offset); err != nil {
+		return Entry{}, 0, err
+	}
+	keyLen := int64(binary.LittleEndian.Uint32(lenBuf[:]))
+
+	if _, err := file.ReadAt(lenBuf[:], offset+4+keyLen); err != nil {
+		return Entry{}, 0, err
+	}
+	valueLen := int64(binary.LittleEndian.Uint32(lenBuf[:]))
+
+	size := 4 + keyLen + 4 + s.valueHeaderLen() + valueLen + timestampSize + recordCRCSize
+	if offset+size > fileSize {
+		return Entry{}, 0, errors.New("record runs past the end of the file")
+	}
+
+	entry, err := s.readRecord(io.NewSectionReader(file, offset, size), offset, true)
+	if err != nil {
+		return Entry{}, 0, err
+	}
+	return entry, size, nil
+}
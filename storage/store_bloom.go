@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// storeBloomFPR is the store-level filter's target false positive rate
+	storeBloomFPR = 0.01
+
+	// storeBloomMinKeys is the smallest capacity the filter is built for,
+	// so a young store doesn't rebuild it on every flush
+	storeBloomMinKeys = 64 * 1024
+)
+
+// storeBloom is a bloom filter over the keys of every SSTable in a store
+// (see StoreOptions.StoreBloom). A lookup it rules out skips the SSTables
+// entirely, instead of probing each table's own filter in turn.
+//
+// Keys are only ever added: a flush adds its keys before the new table
+// becomes visible, and compaction writes no key its inputs didn't have, so
+// the filter never wrongly rules a key out. Keys compacted away (or
+// truncated) stay in it until it fills up and is rebuilt from the tables'
+// indexes. The filter is copied on write, so readers load it without
+// locking.
+type storeBloom struct {
+	filter atomic.Pointer[BloomFilter] // Never modified once published
+	skips  atomic.Int64                // Lookups answered without touching an SSTable
+
+	mu       sync.Mutex // Serializes writers
+	keys     int        // Keys added since the filter was built, with repeats
+	capacity int        // Keys the filter was sized for
+}
+
+// mayContain reports whether key may be in some SSTable
+func (b *storeBloom) mayContain(key []byte) bool {
+	return b.filter.Load().MayContain(key)
+}
+
+// storeBloomMayContain reports whether key may be in some SSTable: true
+// unless the store-level filter is on and rules it out
+func (s *LSMStore) storeBloomMayContain(key []byte) bool {
+	b := s.storeBloom
+	if b == nil || b.mayContain(key) {
+		return true
+	}
+	b.skips.Add(1)
+	return false
+}
+
+// enableStoreBloom builds the store-level filter from the SSTables'
+// indexes. It reads every index, so it is done once at open.
+func (s *LSMStore) enableStoreBloom() error {
+	b := &storeBloom{}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := s.rebuildStoreBloomLocked(b, nil); err != nil {
+		return err
+	}
+	s.storeBloom = b
+	return nil
+}
+
+// addToStoreBloom adds the keys of a table about to be published, rebuilding
+// the filter at twice the size if they would overfill it
+func (s *LSMStore) addToStoreBloom(keys [][]byte) error {
+	b := s.storeBloom
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.keys+len(keys) > b.capacity {
+		return s.rebuildStoreBloomLocked(b, keys)
+	}
+
+	old := b.filter.Load()
+	filter := &BloomFilter{
+		bits:      bytes.Clone(old.bits),
+		size:      old.size,
+		numHashes: old.numHashes,
+	}
+	for _, key := range keys {
+		filter.Add(key)
+	}
+	b.keys += len(keys)
+	b.filter.Store(filter)
+	return nil
+}
+
+// rebuildStoreBloomLocked replaces the filter with one holding the keys of
+// every published SSTable plus extra (must be called with b.mu held)
+func (s *LSMStore) rebuildStoreBloomLocked(b *storeBloom, extra [][]byte) error {
+	s.mu.RLock()
+	sstables := make([]*SSTable, len(s.sstables))
+	copy(sstables, s.sstables)
+	s.mu.RUnlock()
+
+	keys := len(extra)
+	for _, sst := range sstables {
+		keys += sst.numEntries
+	}
+	capacity := max(2*keys, storeBloomMinKeys)
+
+	filter := NewBloomFilter(capacity, storeBloomFPR)
+	for _, sst := range sstables {
+		index, err := sst.loadIndex()
+		if err != nil {
+			return fmt.Errorf("failed to build store bloom filter: %w", err)
+		}
+		for _, entry := range index {
+			filter.Add(entry.Key)
+		}
+	}
+	for _, key := range extra {
+		filter.Add(key)
+	}
+
+	b.keys, b.capacity = keys, capacity
+	b.filter.Store(filter)
+	log.Printf("🌸 Store bloom filter: %d keys from %d SSTables, sized for %d (%d KB)",
+		keys, len(sstables), capacity, len(filter.bits)/1024)
+	return nil
+}
+
+// storeBloomSkips counts the lookups the store-level filter answered alone
+func (s *LSMStore) storeBloomSkips() int64 {
+	if s.storeBloom == nil {
+		return 0
+	}
+	return s.storeBloom.skips.Load()
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+// flushTables writes numTables SSTables of perTable keys each, interleaved
+// so every table's key range covers the whole keyspace
+func flushTables(tb testing.TB, store *LSMStore, numTables, perTable int) {
+	tb.Helper()
+	for table := 0; table < numTables; table++ {
+		for i := 0; i < perTable; i++ {
+			key := fmt.Sprintf("key%06d", i*numTables+table)
+			if err := store.Put(key, []byte("value-"+key)); err != nil {
+				tb.Fatalf("Put failed: %v", err)
+			}
+		}
+		if err := store.flushIfOlderThan(0); err != nil {
+			tb.Fatalf("Flush failed: %v", err)
+		}
+		if err := store.flushQueued(); err != nil {
+			tb.Fatalf("Flush failed: %v", err)
+		}
+	}
+}
+
+func TestLSMStore_StoreBloom(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStoreWithOptions(dir, StoreOptions{StoreBloom: true})
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	const numTables, perTable = 6, 50
+	flushTables(t, store, 3, perTable)
+
+	// The next flush overfills the filter, which is rebuilt from the tables
+	store.storeBloom.mu.Lock()
+	store.storeBloom.capacity = store.storeBloom.keys
+	store.storeBloom.mu.Unlock()
+	flushTables(t, store, numTables, perTable)
+	if err := store.Delete("key000007"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	check := func(store *LSMStore) {
+		t.Helper()
+		// Never a false negative
+		for i := 0; i < numTables*perTable; i++ {
+			key := fmt.Sprintf("key%06d", i)
+			value, err := store.Get(key)
+			if key == "key000007" {
+				if err != ErrKeyNotFound {
+					t.Errorf("Deleted %s: expected ErrKeyNotFound, got %v", key, err)
+				}
+				continue
+			}
+			if err != nil || string(value) != "value-"+key {
+				t.Fatalf("Get(%s) = %q, %v", key, value, err)
+			}
+			if exists, err := store.Exists(key); err != nil || !exists {
+				t.Fatalf("Exists(%s) = %v, %v", key, exists, err)
+			}
+		}
+
+		// Absent keys rarely reach the SSTables
+		skipsBefore, probesBefore := store.storeBloomSkips(), store.bloom.hits.Load()+store.bloom.misses.Load()
+		for i := 0; i < 100; i++ {
+			if _, err := store.Get(fmt.Sprintf("key%06dx", i)); err != ErrKeyNotFound {
+				t.Fatalf("Expected ErrKeyNotFound for an absent key, got %v", err)
+			}
+		}
+		skips := store.storeBloomSkips() - skipsBefore
+		probes := store.bloom.hits.Load() + store.bloom.misses.Load() - probesBefore
+		if skips < 95 {
+			t.Errorf("Expected the store bloom filter to answer nearly all 100 misses, got %d", skips)
+		}
+		if probes > (100-skips)*int64(numTables) {
+			t.Errorf("Expected SSTable filters probed only for the %d misses let through, got %d probes", 100-skips, probes)
+		}
+	}
+	check(store)
+
+	if err := store.CompactionManager().ForceCompact(); err != nil {
+		t.Fatalf("ForceCompact failed: %v", err)
+	}
+	check(store)
+
+	// Rebuilt from the indexes on reopen
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	store, err = NewLSMStoreWithOptions(dir, StoreOptions{StoreBloom: true})
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer store.Close()
+	check(store)
+}
+
+// BenchmarkLSMStore_GetMiss reads absent keys from a store of 40 SSTables
+// whose key ranges all overlap, with each table's filter probed in turn
+// and with the store-level filter consulted first
+func BenchmarkLSMStore_GetMiss(b *testing.B) {
+	for _, storeBloom := range []bool{false, true} {
+		b.Run(fmt.Sprintf("store-bloom=%v", storeBloom), func(b *testing.B) {
+			store, err := NewLSMStoreWithOptions(b.TempDir(), StoreOptions{StoreBloom: storeBloom})
+			if err != nil {
+				b.Fatalf("Failed to create store: %v", err)
+			}
+			defer store.Close()
+			store.CompactionManager().Stop()
+			flushTables(b, store, 40, 250)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.Get(fmt.Sprintf("key%06dx", i%10000)); err != ErrKeyNotFound {
+					b.Fatalf("Expected ErrKeyNotFound, got %v", err)
+				}
+			}
+		})
+	}
+}
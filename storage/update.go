@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UpdateFunc computes a key's new value from its current one; found is
+// false if the key has no live value. Returning an error leaves the key as
+// it was.
+type UpdateFunc func(current []byte, found bool) ([]byte, error)
+
+// Update atomically replaces key's value with update's result and returns
+// the value written. Like PutIfAbsent, the read and the write happen under
+// the store lock, so concurrent updates of a key are applied one after the
+// other and none is lost; update must not call back into the store.
+func (s *LSMStore) Update(key string, update UpdateFunc) ([]byte, error) {
+	if s.readOnly {
+		return nil, ErrReadOnly
+	}
+	if err := s.checkBusy(); err != nil {
+		return nil, err
+	}
+	s.hotKeys.record(key)
+	start := time.Now()
+
+	keyBytes := []byte(key)
+
+	s.rotateMu.RLock()
+	s.mu.Lock()
+
+	current, found, err := s.getLocked(keyBytes)
+	if err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return nil, err
+	}
+
+	value, err := update(current.Value, found)
+	if err == nil && len(value) > MaxValueSize {
+		err = fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(value), MaxValueSize)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return nil, err
+	}
+
+	// Written after the value or tombstone it replaced, even if that was
+	// replayed with a timestamp ahead of this node's clock
+	written, err := s.putAndUnlock(key, value, max(time.Now().UnixNano(), current.Timestamp+1), start)
+	if !written {
+		return nil, err
+	}
+	return value, err
+}
+
+// putAndUnlock is the write half of the conditional writes (Update,
+// PutIfAbsent, PutIfVersion), called once the key's check passed with
+// rotateMu read-locked and mu locked. It logs and applies the put, releases
+// both locks, records it in the metrics like Put, with its latency since
+// start, and flushes the MemTable if it is full; written is false if nothing
+// was written, and true with an error if only the flush failed.
+func (s *LSMStore) putAndUnlock(key string, value []byte, timestamp int64, start time.Time) (written bool, err error) {
+	entry := Entry{
+		Timestamp: timestamp,
+		Op:        OpPut,
+		Key:       []byte(key),
+		Value:     value,
+	}
+	if err := s.wal.Write(entry); err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return false, fmt.Errorf("failed to write to WAL: %w", err)
+	}
+
+	s.memTable.Put(entry.Key, value, entry.Timestamp)
+	memSize := s.memTable.Size()
+	s.watch.publish(WatchEvent{Op: OpPut, Key: key, Value: value, Timestamp: entry.Timestamp})
+	s.mu.Unlock()
+	s.rotateMu.RUnlock()
+
+	s.metrics.IncCounter(MetricPuts)
+	s.metrics.ObserveLatency(MetricPutLatency, time.Since(start))
+	s.metrics.SetGauge(MetricMemTableBytes, float64(memSize))
+
+	// Check if MemTable is full
+	if memSize >= s.memTableThreshold {
+		if err := s.maybeFlush(); err != nil {
+			return true, fmt.Errorf("failed to flush MemTable: %w", err)
+		}
+	}
+	return true, nil
+}
+
+// ErrVersionMismatch is returned by PutIfVersion when the key's current
+// version is not the one the caller expected; nothing was written
+var ErrVersionMismatch = errors.New("version mismatch")
+
+// PutIfVersion stores a key-value pair only if the key's current version
+// is expectedVersion, for optimistic concurrency: read a value and its
+// version with GetWithTimestamp, then write back conditionally, and retry
+// from the read on ErrVersionMismatch. A key's version is the timestamp it
+// was written at, the same version replicas report (see
+// replication.GenerateVersion); it returns the version of the new value.
+//
+// Like Update, the check and the write happen under the store lock. A
+// missing key, and a key read from an SSTable written before records carried
+// timestamps, have no version and never match; expectedVersion must be
+// positive.
+func (s *LSMStore) PutIfVersion(key string, value []byte, expectedVersion int64) (int64, error) {
+	if s.readOnly {
+		return 0, ErrReadOnly
+	}
+	if expectedVersion <= 0 {
+		return 0, fmt.Errorf("expected version must be positive, got %d", expectedVersion)
+	}
+	if len(value) > MaxValueSize {
+		return 0, fmt.Errorf("%w: %d bytes (max %d)", ErrValueTooLarge, len(value), MaxValueSize)
+	}
+	if err := s.checkBusy(); err != nil {
+		return 0, err
+	}
+	s.hotKeys.record(key)
+	start := time.Now()
+
+	keyBytes := []byte(key)
+
+	s.rotateMu.RLock()
+	s.mu.Lock()
+
+	version, err := s.versionLocked(keyBytes)
+	if err == nil && version != expectedVersion {
+		err = fmt.Errorf("%w: key %q is at version %d, expected %d", ErrVersionMismatch, key, version, expectedVersion)
+	}
+	if err != nil {
+		s.mu.Unlock()
+		s.rotateMu.RUnlock()
+		return 0, err
+	}
+
+	// The new version must differ from the one just matched, or a stale
+	// writer holding it would match again
+	newVersion := max(time.Now().UnixNano(), version+1)
+	written, err := s.putAndUnlock(key, value, newVersion, start)
+	if !written {
+		return 0, err
+	}
+	return newVersion, err
+}
+
+// versionLocked returns the version of key's live value, or 0 if it has
+// none (must be called with the lock held)
+func (s *LSMStore) versionLocked(key []byte) (int64, error) {
+	entry, found, err := s.getLocked(key)
+	if err != nil || !found {
+		return 0, err
+	}
+	return entry.Timestamp, nil
+}
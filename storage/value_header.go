@@ -0,0 +1,57 @@
+package storage
+
+import "fmt"
+
+// ValueFlags describe how a value is encoded on disk. From SSTable format
+// version 5 every record carries a value header,
+// [header_version(1)][flags(1)], between its value length and its value, so
+// features that change how values are stored (compression, TTLs) can mark
+// the values they write and existing data needs no migration. Tables from
+// before version 5 have no header; their values read as plain.
+type ValueFlags uint8
+
+const (
+	// ValueCompressed marks a value whose bytes are compressed. Reserved:
+	// nothing decompresses values yet, so such records are refused.
+	ValueCompressed ValueFlags = 1 << 0
+
+	// ValueHasTTL marks a value that carries an expiry time. Reserved:
+	// nothing applies TTLs yet, so such records are refused.
+	ValueHasTTL ValueFlags = 1 << 1
+
+	// ValueTombstone marks a delete, from SSTable format version 6; the
+	// value is empty. Older tables store a marker value instead (see
+	// legacyTombstoneMarker).
+	ValueTombstone ValueFlags = 1 << 2
+
+	// knownValueFlags are the flags this build decodes on every read path;
+	// a value with any other bit set would be served wrong, so it is
+	// rejected as being from a newer format
+	knownValueFlags = ValueTombstone
+)
+
+const (
+	valueHeaderVersion = 1 // Current value header layout
+	valueHeaderSize    = 2 // [header_version(1)][flags(1)]
+)
+
+// encodeValueHeader returns the header for a value with the given flags
+func encodeValueHeader(flags ValueFlags) [valueHeaderSize]byte {
+	return [valueHeaderSize]byte{valueHeaderVersion, byte(flags)}
+}
+
+// decodeValueHeader parses a value header, rejecting header versions and
+// flags this build doesn't know rather than misreading the value
+func decodeValueHeader(header []byte) (ValueFlags, error) {
+	if len(header) != valueHeaderSize {
+		return 0, fmt.Errorf("value header is %d bytes, expected %d", len(header), valueHeaderSize)
+	}
+	if header[0] != valueHeaderVersion {
+		return 0, fmt.Errorf("unsupported value header version %d", header[0])
+	}
+	flags := ValueFlags(header[1])
+	if unknown := flags &^ knownValueFlags; unknown != 0 {
+		return 0, fmt.Errorf("unsupported value flags %#02x", uint8(unknown))
+	}
+	return flags, nil
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLSMStore_VerifyFlagsCorruptTable(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLSMStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	// Three tables; the middle one has a tombstone
+	for table := 0; table < 3; table++ {
+		for i := 0; i < 20; i++ {
+			key := fmt.Sprintf("t%d-key%02d", table, i)
+			if err := store.Put(key, []byte("value-"+key)); err != nil {
+				t.Fatalf("Put failed: %v", err)
+			}
+		}
+		if table == 1 {
+			if err := store.Delete("t1-key05"); err != nil {
+				t.Fatalf("Delete failed: %v", err)
+			}
+		}
+		if err := store.flushIfOlderThan(0); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+		if err := store.flushQueued(); err != nil {
+			t.Fatalf("Flush failed: %v", err)
+		}
+	}
+
+	report, err := store.Verify()
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.OK() || len(report.Tables) != 3 {
+		t.Fatalf("Expected 3 sound tables, got %+v", report)
+	}
+	for _, table := range report.Tables {
+		if table.Entries != 20 || !table.Checksummed {
+			t.Errorf("%s: expected 20 checksummed entries, got %+v", table.Path, table)
+		}
+	}
+
+	// Flip a byte inside one value of the middle table
+	var damaged string
+	for _, table := range report.Tables {
+		data, err := os.ReadFile(table.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos := bytes.Index(data, []byte("value-t1-key10")); pos >= 0 {
+			data[pos+3] ^= 0xFF
+			if err := os.WriteFile(table.Path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			damaged = table.Path
+		}
+	}
+	if damaged == "" {
+		t.Fatal("Value not found in any table")
+	}
+
+	for name, verify := range map[string]func() (*VerifyReport, error){
+		"Verify":           store.Verify,
+		"VerifySSTableDir": func() (*VerifyReport, error) { return VerifySSTableDir(dir) },
+	} {
+		report, err := verify()
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		if report.Corrupt != 1 {
+			t.Errorf("%s: expected 1 corrupt table, got %d", name, report.Corrupt)
+		}
+		for _, table := range report.Tables {
+			if table.Path != damaged {
+				if !table.OK() {
+					t.Errorf("%s: intact table %s flagged: %v", name, table.Path, table.Problems)
+				}
+				continue
+			}
+			if len(table.Problems) != 1 || !strings.Contains(table.Problems[0], "t1-key10") || !strings.Contains(table.Problems[0], "checksum mismatch") {
+				t.Errorf("%s: expected a checksum mismatch for t1-key10, got %v", name, table.Problems)
+			}
+		}
+	}
+}
+
+func TestVerifySSTableDir_BadFooter(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestSSTable(t, dir, "a", "b", "c")
+
+	// Break the magic number, which stops a store from opening the table
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xFF
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := VerifySSTableDir(dir)
+	if err != nil {
+		t.Fatalf("VerifySSTableDir failed: %v", err)
+	}
+	if report.OK() || len(report.Tables) != 1 {
+		t.Fatalf("Expected one corrupt table, got %+v", report)
+	}
+	if problems := report.Tables[0].Problems; len(problems) != 1 || !strings.Contains(problems[0], "magic number") {
+		t.Errorf("Expected a bad magic number, got %v", problems)
+	}
+}
@@ -2,12 +2,17 @@ package storage
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 )
 
 type WAL struct {
@@ -15,13 +20,48 @@ type WAL struct {
 	writer *bufio.Writer
 	mu     sync.Mutex
 	path   string
+	dir    string
+	size   int64 // Bytes of whole records in file; a failed write is truncated back to it
+
+	// readFile is a separate handle used only with ReadAt, so readers never
+	// take mu or move the append handle's offset. readMu guards swapping it
+	// on Rotate.
+	readFile *os.File
+	readMu   sync.RWMutex
+
+	// generation identifies the live file's contents for Tail: Reset and
+	// Rotate move to a new one, so offsets into the old file are refused
+	// rather than read against different data (guarded by readMu)
+	generation int64
+
+	readOnlyMode bool // Opened with OpenWALReadOnly; file and writer are nil
+
+	metrics Metrics // Set by the store before any write
+}
+
+// ErrWALReset is returned by Tail when the offset is into a WAL file that
+// is no longer live, because Reset truncated it or Rotate started a new one
+// (e.g. on MemTable flush). Entries before that are in SSTables or rotated
+// segments; tail again from the zero WALOffset.
+var ErrWALReset = errors.New("WAL was reset past the requested offset")
+
+// WALOffset is a position in the live WAL, as returned by Tail: the file's
+// generation and a byte offset in it. The zero WALOffset is the start of
+// whichever file is live.
+type WALOffset struct {
+	Generation int64
+	Offset     int64
 }
 
+// walEntryHeaderSize is [timestamp(8)][op(1)][key_len(4)] + [value_len(4)]
+const walEntryHeaderSize = 8 + 1 + 4 + 4
+
 type OpType byte
 
 const (
 	OpPut    OpType = 1
 	OpDelete OpType = 2
+	OpBatch  OpType = 3 // WAL-only: Value holds a framed list of Put/Delete records
 )
 
 type Entry struct {
@@ -29,6 +69,7 @@ type Entry struct {
 	Op        OpType
 	Key       []byte
 	Value     []byte
+	Flags     ValueFlags // How Value is encoded, for entries read from SSTables; the WAL doesn't store it
 }
 
 func NewWAL(dirPath string) (*WAL, error) {
@@ -38,22 +79,93 @@ func NewWAL(dirPath string) (*WAL, error) {
 
 	walPath := filepath.Join(dirPath, "wal.log")
 
-	file, err := os.OpenFile(walPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	file, readFile, err := openWALFile(walPath)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open WAL file: %w", err)
+		file.Close()
+		readFile.Close()
+		return nil, fmt.Errorf("failed to stat WAL file: %w", err)
 	}
 
 	return &WAL{
-		file:   file,
-		writer: bufio.NewWriter(file),
-		path:   walPath,
+		file:       file,
+		writer:     bufio.NewWriter(file),
+		path:       walPath,
+		dir:        dirPath,
+		size:       info.Size(),
+		readFile:   readFile,
+		generation: newWALGeneration(),
+		metrics:    noopMetrics{},
 	}, nil
 }
 
+// OpenWALReadOnly opens the WAL in dirPath for reading only. ReadAll, Tail,
+// Segments and ReadSegment work as usual; writes fail with ErrReadOnly.
+// Nothing is created or truncated: a missing wal.log reads as empty.
+func OpenWALReadOnly(dirPath string) (*WAL, error) {
+	walPath := filepath.Join(dirPath, "wal.log")
+
+	w := &WAL{path: walPath, dir: dirPath, readOnlyMode: true, generation: newWALGeneration(), metrics: noopMetrics{}}
+
+	readFile, err := os.Open(walPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open WAL for reading: %w", err)
+	}
+	if err == nil {
+		w.readFile = readFile
+	}
+	return w, nil
+}
+
+// newWALGeneration returns the generation a newly opened WAL starts at. It
+// is taken from the clock so offsets kept from before a restart don't match.
+func newWALGeneration() int64 {
+	return time.Now().UnixNano()
+}
+
+// readOnly reports whether the WAL was opened with OpenWALReadOnly
+func (w *WAL) readOnly() bool {
+	return w.readOnlyMode
+}
+
+// Write appends an entry to the WAL. If the write fails, for example on a
+// full disk, any part of the entry that reached the file is truncated away
+// so the WAL stays readable and later writes can succeed; the error is
+// ErrDiskFull or ErrWriteFailed.
 func (w *WAL) Write(entry Entry) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+
+	if err := w.writeEntry(entry); err != nil {
+		w.rollback()
+		return diskWriteError(err)
+	}
+	w.size += int64(walEntryHeaderSize + len(entry.Key) + len(entry.Value))
+	w.metrics.IncCounter(MetricWALWrites)
+	w.metrics.SetGauge(MetricWALBytes, float64(w.size))
+	return nil
+}
+
+// rollback discards a failed write: whatever is still buffered, and
+// whatever reached the file past the last whole record
+func (w *WAL) rollback() {
+	w.writer.Reset(w.file)
+	if err := w.file.Truncate(w.size); err != nil {
+		log.Printf("⚠️  Failed to truncate WAL after a failed write: %v", err)
+	}
+}
+
+// writeEntry encodes an entry into the buffered writer and flushes it to
+// the file (must be called with mu held)
+func (w *WAL) writeEntry(entry Entry) error {
 	if err := binary.Write(w.writer, binary.LittleEndian, entry.Timestamp); err != nil {
 		return fmt.Errorf("failed to write timestamp: %w", err)
 	}
@@ -88,20 +200,137 @@ func (w *WAL) Write(entry Entry) error {
 	// fsync per-Put is extremely expensive (especially on Windows).
 	// Flushing the buffered writer is sufficient for tests and typical
 	// throughput; we keep Sync on Reset/Close to ensure data is
-	// persisted when rotating or closing the WAL.
+	// persisted when rotating or closing the WAL. Callers that need a
+	// durability barrier use Sync (see LSMStore.Sync).
 
 	return nil
 }
 
+// WriteBatch writes several entries as a single framed WAL record, so that
+// recovery replays either all of them or none of them
+func (w *WAL) WriteBatch(entries []Entry) error {
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+
+	var buf bytes.Buffer
+
+	// Format: [count:4] then per entry [op:1][key_len:4][key][value_len:4][value]
+	binary.Write(&buf, binary.LittleEndian, uint32(len(entries)))
+	for _, entry := range entries {
+		buf.WriteByte(byte(entry.Op))
+		binary.Write(&buf, binary.LittleEndian, uint32(len(entry.Key)))
+		buf.Write(entry.Key)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(entry.Value)))
+		buf.Write(entry.Value)
+	}
+
+	var timestamp int64
+	if len(entries) > 0 {
+		timestamp = entries[0].Timestamp
+	}
+
+	return w.Write(Entry{
+		Timestamp: timestamp,
+		Op:        OpBatch,
+		Value:     buf.Bytes(),
+	})
+}
+
+// decodeBatch expands a batch record into its entries
+func decodeBatch(batch Entry) ([]Entry, error) {
+	reader := bytes.NewReader(batch.Value)
+
+	var count uint32
+	if err := binary.Read(reader, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, count)
+	for i := uint32(0); i < count; i++ {
+		entry := Entry{Timestamp: batch.Timestamp}
+
+		opByte, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		entry.Op = OpType(opByte)
+
+		var keyLen uint32
+		if err := binary.Read(reader, binary.LittleEndian, &keyLen); err != nil {
+			return nil, err
+		}
+		entry.Key = make([]byte, keyLen)
+		if _, err := io.ReadFull(reader, entry.Key); err != nil {
+			return nil, err
+		}
+
+		var valueLen uint32
+		if err := binary.Read(reader, binary.LittleEndian, &valueLen); err != nil {
+			return nil, err
+		}
+		entry.Value = make([]byte, valueLen)
+		if _, err := io.ReadFull(reader, entry.Value); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 func (w *WAL) ReadAll() ([]Entry, error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	entries, _, err := w.Tail(WALOffset{})
+	return entries, err
+}
+
+// Tail returns the entries written at or after from, and the offset to pass
+// to the next call to continue from where this one stopped. Batches are
+// expanded into their entries. It reads through its own file handle without
+// taking the write lock, so it can run concurrently with writers; a record
+// still being written is left for the next call.
+//
+// Offsets are only meaningful until the next Reset or Rotate: after either,
+// an offset returned before it gets ErrWALReset, even once the new file has
+// grown past it.
+func (w *WAL) Tail(from WALOffset) ([]Entry, WALOffset, error) {
+	w.readMu.RLock()
+	defer w.readMu.RUnlock()
+
+	if from != (WALOffset{}) && from.Generation != w.generation {
+		return nil, from, ErrWALReset
+	}
+	if w.readFile == nil {
+		// Read-only WAL with no wal.log: empty
+		if from.Offset > 0 {
+			return nil, from, ErrWALReset
+		}
+		return nil, WALOffset{Generation: w.generation}, nil
+	}
 
-	if _, err := w.file.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("failed to seek to beginning: %w", err)
+	info, err := w.readFile.Stat()
+	if err != nil {
+		return nil, from, fmt.Errorf("failed to stat WAL: %w", err)
+	}
+	if from.Offset > info.Size() {
+		return nil, from, ErrWALReset
 	}
 
-	reader := bufio.NewReader(w.file)
+	// Only read up to the size seen now; later appends are for the next call
+	entries, offset, err := w.readEntries(io.NewSectionReader(w.readFile, from.Offset, info.Size()-from.Offset), from.Offset)
+	if err != nil {
+		return nil, from, err
+	}
+	return entries, WALOffset{Generation: w.generation, Offset: offset}, nil
+}
+
+// readEntries decodes the records in r, which starts at fromOffset in its
+// file, stopping before a torn record at the end. It returns the entries
+// (batches expanded) and the offset just past the last whole record.
+func (w *WAL) readEntries(r io.Reader, fromOffset int64) ([]Entry, int64, error) {
+	reader := bufio.NewReader(r)
+	offset := fromOffset
 	var entries []Entry
 
 	for {
@@ -109,13 +338,27 @@ func (w *WAL) ReadAll() ([]Entry, error) {
 		if err == io.EOF {
 			break
 		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// Torn record at the tail (crash or write in progress): stop before it
+			break
+		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read entry: %w", err)
+			return nil, fromOffset, fmt.Errorf("failed to read entry: %w", err)
+		}
+		offset += int64(walEntryHeaderSize + len(entry.Key) + len(entry.Value))
+
+		if entry.Op == OpBatch {
+			batchEntries, err := decodeBatch(entry)
+			if err != nil {
+				return nil, fromOffset, fmt.Errorf("failed to decode batch: %w", err)
+			}
+			entries = append(entries, batchEntries...)
+			continue
 		}
 		entries = append(entries, entry)
 	}
 
-	return entries, nil
+	return entries, offset, nil
 }
 
 func (w *WAL) readEntry(reader *bufio.Reader) (Entry, error) {
@@ -158,16 +401,49 @@ func (w *WAL) Close() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.readOnly() {
+		if w.readFile != nil {
+			return w.readFile.Close()
+		}
+		return nil
+	}
+
 	if err := w.writer.Flush(); err != nil {
 		return err
 	}
+	w.readFile.Close()
 	return w.file.Close()
 }
 
+// Sync flushes buffered writes and fsyncs the WAL file, so every entry
+// written before the call survives an OS crash or power loss
+func (w *WAL) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+
+	start := time.Now()
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+	w.metrics.ObserveLatency(MetricWALSyncLatency, time.Since(start))
+	return nil
+}
+
 func (w *WAL) Reset() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+
 	if err := w.writer.Flush(); err != nil {
 		return err
 	}
@@ -182,6 +458,12 @@ func (w *WAL) Reset() error {
 
 	w.file = file
 	w.writer = bufio.NewWriter(file)
+	w.size = 0
+
+	w.readMu.Lock()
+	w.generation++
+	w.readMu.Unlock()
+
 	// Ensure new WAL file is synced to disk metadata-wise. Caller
 	// may rely on Reset() to make new file durable.
 	if err := w.file.Sync(); err != nil {
@@ -189,3 +471,148 @@ func (w *WAL) Reset() error {
 	}
 	return nil
 }
+
+// Rotate ends the current WAL file as segment segmentID and starts a new,
+// empty one. The segment is fsynced first, so it holds every entry written
+// before the call until RemoveSegment deletes it; recovery replays segments
+// (see Segments) before the live WAL.
+//
+// If Rotate fails, for example because no file can be created, the WAL
+// keeps writing to the current file and the call can be retried.
+func (w *WAL) Rotate(segmentID int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+
+	// The file is closed before it is renamed, which Windows requires; from
+	// here on a failure reopens the live WAL so writes don't hit a closed file
+	if err := w.file.Close(); err != nil {
+		return w.reopen(fmt.Errorf("failed to close WAL: %w", err))
+	}
+
+	segmentPath := w.segmentPath(segmentID)
+	if err := os.Rename(w.path, segmentPath); err != nil {
+		return w.reopen(fmt.Errorf("failed to rename WAL to %s: %w", segmentPath, err))
+	}
+
+	file, readFile, err := openWALFile(w.path)
+	if err != nil {
+		// Put the entries back in the live WAL. If that fails too they stay
+		// in the segment, which recovery replays, and a new file is started.
+		if renameErr := os.Rename(segmentPath, w.path); renameErr != nil {
+			log.Printf("⚠️  Failed to move WAL segment %s back: %v", segmentPath, renameErr)
+		}
+		return w.reopen(err)
+	}
+
+	w.setFiles(file, readFile, 0, true)
+	return nil
+}
+
+// reopen opens the live WAL file again after a failed Rotate closed it, and
+// returns err along with any error doing so (must be called with mu held)
+func (w *WAL) reopen(err error) error {
+	file, readFile, openErr := openWALFile(w.path)
+	if openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	info, statErr := file.Stat()
+	if statErr != nil {
+		file.Close()
+		readFile.Close()
+		return errors.Join(err, fmt.Errorf("failed to stat WAL file: %w", statErr))
+	}
+
+	// Tail offsets still hold unless the entries stayed in the segment
+	oldInfo, statErr := w.readFile.Stat()
+	w.setFiles(file, readFile, info.Size(), statErr != nil || !os.SameFile(info, oldInfo))
+	return err
+}
+
+// setFiles makes file and readFile the WAL's handles, closing the old read
+// handle; size is file's length, and newFile starts a new generation (must
+// be called with mu held)
+func (w *WAL) setFiles(file, readFile *os.File, size int64, newFile bool) {
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.size = size
+
+	w.readMu.Lock()
+	w.readFile.Close()
+	w.readFile = readFile
+	if newFile {
+		w.generation++
+	}
+	w.readMu.Unlock()
+}
+
+// openWALFile opens the WAL at path for appending, creating it if needed,
+// along with a separate handle for reads
+func openWALFile(path string) (file, readFile *os.File, err error) {
+	file, err = os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open WAL file: %w", err)
+	}
+	readFile, err = os.Open(path)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open WAL for reading: %w", err)
+	}
+	return file, readFile, nil
+}
+
+// Segments returns the IDs of the rotated segments on disk, oldest first
+func (w *WAL) Segments() ([]int, error) {
+	files, err := filepath.Glob(filepath.Join(w.dir, "wal_*.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(files))
+	for _, file := range files {
+		var id int
+		if _, err := fmt.Sscanf(filepath.Base(file), "wal_%d.log", &id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// ReadSegment returns the entries of a rotated segment, like ReadAll
+func (w *WAL) ReadSegment(segmentID int) ([]Entry, error) {
+	file, err := os.Open(w.segmentPath(segmentID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL segment: %w", err)
+	}
+	defer file.Close()
+
+	entries, _, err := w.readEntries(file, 0)
+	return entries, err
+}
+
+// RemoveSegment deletes a rotated segment once its entries are in an SSTable
+func (w *WAL) RemoveSegment(segmentID int) error {
+	if w.readOnly() {
+		return ErrReadOnly
+	}
+	if err := os.Remove(w.segmentPath(segmentID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove WAL segment: %w", err)
+	}
+	return nil
+}
+
+func (w *WAL) segmentPath(segmentID int) string {
+	return filepath.Join(w.dir, fmt.Sprintf("wal_%d.log", segmentID))
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// failingWriter passes the first limit bytes through to w, then fails every
+// write with err, like a filesystem that fills up partway through
+type failingWriter struct {
+	w     io.Writer
+	limit int
+	err   error
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) <= f.limit {
+		f.limit -= len(p)
+		return f.w.Write(p)
+	}
+	n, _ := f.w.Write(p[:f.limit])
+	f.limit = 0
+	return n, f.err
+}
+
+func TestWAL_Tail(t *testing.T) {
+	wal, err := NewWAL(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create WAL: %v", err)
+	}
+	defer wal.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := wal.Write(Entry{Op: OpPut, Key: []byte(fmt.Sprintf("key%d", i)), Value: []byte("v")}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	entries, offset, err := wal.Tail(WALOffset{})
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(entries) != 3 || string(entries[2].Key) != "key2" {
+		t.Fatalf("Expected key0..key2, got %d entries", len(entries))
+	}
+
+	// Nothing new yet
+	if more, next, err := wal.Tail(offset); err != nil || len(more) != 0 || next != offset {
+		t.Fatalf("Expected no new entries at %+v, got %d (next=%+v, err=%v)", offset, len(more), next, err)
+	}
+
+	// Write more, including a batch, and tail from the returned offset
+	if err := wal.Write(Entry{Op: OpDelete, Key: []byte("key0")}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := wal.WriteBatch([]Entry{
+		{Op: OpPut, Key: []byte("key3"), Value: []byte("v")},
+		{Op: OpPut, Key: []byte("key4"), Value: []byte("v")},
+	}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+
+	entries, next, err := wal.Tail(offset)
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 new entries, got %d", len(entries))
+	}
+	if entries[0].Op != OpDelete || string(entries[0].Key) != "key0" || string(entries[2].Key) != "key4" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+	if next.Offset <= offset.Offset {
+		t.Errorf("Expected offset to advance past %+v, got %+v", offset, next)
+	}
+
+	// After a reset the old offset is gone
+	if err := wal.Reset(); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if _, _, err := wal.Tail(next); !errors.Is(err, ErrWALReset) {
+		t.Errorf("Expected ErrWALReset, got %v", err)
+	}
+}
+
+func TestWAL_TailAfterRotate(t *testing.T) {
+	wal, err := NewWAL(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create WAL: %v", err)
+	}
+	defer wal.Close()
+
+	if err := wal.Write(Entry{Op: OpPut, Key: []byte("old"), Value: []byte("v")}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	_, offset, err := wal.Tail(WALOffset{})
+	if err != nil {
+		t.Fatalf("Tail failed: %v", err)
+	}
+
+	// The new file grows past the old offset before the reader comes back;
+	// reading from there would start in the middle of a record
+	if err := wal.Rotate(1); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := wal.Write(Entry{Op: OpPut, Key: []byte(fmt.Sprintf("new%d", i)), Value: []byte("value")}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if _, _, err := wal.Tail(offset); !errors.Is(err, ErrWALReset) {
+		t.Fatalf("Expected ErrWALReset, got %v", err)
+	}
+
+	// Starting over reads the new file from the beginning
+	entries, _, err := wal.Tail(WALOffset{})
+	if err != nil || len(entries) != 3 || string(entries[0].Key) != "new0" {
+		t.Fatalf("Expected new0..new2, got %d entries (err: %v)", len(entries), err)
+	}
+}
+
+func TestWAL_TailConcurrentWithWrites(t *testing.T) {
+	wal, err := NewWAL(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create WAL: %v", err)
+	}
+	defer wal.Close()
+
+	const total = 500
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for i := 0; i < total; i++ {
+			if err := wal.Write(Entry{Op: OpPut, Key: []byte(fmt.Sprintf("key%03d", i)), Value: []byte("value")}); err != nil {
+				t.Errorf("Write failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	// A reader tailing while the writer appends sees every entry exactly once, in order
+	var seen []Entry
+	var offset WALOffset
+	for finished := false; !finished; {
+		select {
+		case <-writerDone:
+			finished = true // One last Tail picks up the final writes
+		default:
+		}
+
+		entries, next, err := wal.Tail(offset)
+		if err != nil {
+			t.Fatalf("Tail failed: %v", err)
+		}
+		seen = append(seen, entries...)
+		offset = next
+	}
+
+	if len(seen) != total {
+		t.Fatalf("Expected %d entries, got %d", total, len(seen))
+	}
+	for i, entry := range seen {
+		if want := fmt.Sprintf("key%03d", i); string(entry.Key) != want {
+			t.Fatalf("Entry %d: expected %s, got %s", i, want, entry.Key)
+		}
+	}
+}
+
+func TestWAL_FailedWriteIsRolledBack(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := NewWAL(dir)
+	if err != nil {
+		t.Fatalf("Failed to create WAL: %v", err)
+	}
+	defer wal.Close()
+
+	if err := wal.Write(Entry{Op: OpPut, Key: []byte("before"), Value: []byte("v")}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// The disk fills up after part of the next record is written
+	wal.writer.Reset(&failingWriter{w: wal.file, limit: 10, err: syscall.ENOSPC})
+	err = wal.Write(Entry{Op: OpPut, Key: []byte("lost"), Value: []byte("value")})
+	if !errors.Is(err, ErrDiskFull) || !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("Expected ErrDiskFull wrapping ENOSPC, got %v", err)
+	}
+
+	// Space is freed: writes go through again
+	if err := wal.Write(Entry{Op: OpPut, Key: []byte("after"), Value: []byte("v")}); err != nil {
+		t.Fatalf("Write after failure failed: %v", err)
+	}
+
+	entries, err := wal.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(entries) != 2 || string(entries[0].Key) != "before" || string(entries[1].Key) != "after" {
+		t.Fatalf("Expected [before after], got %d entries", len(entries))
+	}
+}
+
+func TestWAL_FailedRotateKeepsWriting(t *testing.T) {
+	dir := t.TempDir()
+	wal, err := NewWAL(dir)
+	if err != nil {
+		t.Fatalf("Failed to create WAL: %v", err)
+	}
+	defer wal.Close()
+
+	if err := wal.Write(Entry{Op: OpPut, Key: []byte("before"), Value: []byte("v")}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// A non-empty directory where the segment goes makes the rename fail
+	blocker := filepath.Join(dir, "wal_1.log")
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Rotate(1); err == nil {
+		t.Fatal("Expected Rotate to fail")
+	}
+
+	// The live WAL is still open and holds every entry
+	if err := wal.Write(Entry{Op: OpPut, Key: []byte("after"), Value: []byte("v")}); err != nil {
+		t.Fatalf("Write after failed Rotate failed: %v", err)
+	}
+	entries, err := wal.ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(entries) != 2 || string(entries[0].Key) != "before" || string(entries[1].Key) != "after" {
+		t.Fatalf("Expected [before after], got %d entries", len(entries))
+	}
+
+	// Retried once the cause is gone, Rotate moves both into the segment
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatal(err)
+	}
+	if err := wal.Rotate(1); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	segment, err := wal.ReadSegment(1)
+	if err != nil || len(segment) != 2 {
+		t.Fatalf("Expected 2 entries in the segment, got %d (err: %v)", len(segment), err)
+	}
+	if entries, err := wal.ReadAll(); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty live WAL, got %d entries (err: %v)", len(entries), err)
+	}
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultWatchBuffer is how many events a watcher may fall behind by before
+// events are dropped
+const DefaultWatchBuffer = 1024
+
+// WatchEvent describes one mutation seen by a watcher
+type WatchEvent struct {
+	Op        OpType // OpPut or OpDelete
+	Key       string
+	Value     []byte // nil for OpDelete; shared with the MemTable, do not modify
+	Timestamp int64
+
+	// Lagged is set on a marker event sent after the watcher's buffer filled
+	// up and events were dropped; the other fields are empty
+	Lagged bool
+}
+
+// Watcher receives the mutations of keys with a given prefix made after it
+// was registered with LSMStore.Watch
+type Watcher struct {
+	prefix string
+	events chan WatchEvent
+	lagged bool // Events were dropped; send a Lagged marker before the next one
+	store  *LSMStore
+	closed bool
+}
+
+// watchHub fans out mutations to the registered watchers
+type watchHub struct {
+	watchers map[*Watcher]struct{}
+	mu       sync.Mutex
+}
+
+// Watch registers a watcher for every Put and Delete (including those inside
+// a WriteBatch) of keys starting with prefix; an empty prefix matches every
+// key. Events arrive in the order the writes were applied to the MemTable.
+//
+// A watcher that does not keep up never slows down writes: once buffer events
+// are queued, further events are dropped and a single Lagged event is queued
+// as soon as there is room again. A buffer of 0 or less uses
+// DefaultWatchBuffer. Call Close when done.
+func (s *LSMStore) Watch(prefix string, buffer int) *Watcher {
+	if buffer <= 0 {
+		buffer = DefaultWatchBuffer
+	}
+
+	w := &Watcher{
+		prefix: prefix,
+		events: make(chan WatchEvent, buffer),
+		store:  s,
+	}
+
+	s.watch.mu.Lock()
+	defer s.watch.mu.Unlock()
+
+	if s.watch.watchers == nil {
+		s.watch.watchers = make(map[*Watcher]struct{})
+	}
+	s.watch.watchers[w] = struct{}{}
+	return w
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// watcher or the store is closed.
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Close unregisters the watcher and closes its events channel
+func (w *Watcher) Close() {
+	w.store.watch.mu.Lock()
+	defer w.store.watch.mu.Unlock()
+
+	w.closeLocked()
+}
+
+// closeLocked closes the watcher (must be called with the hub lock held)
+func (w *Watcher) closeLocked() {
+	if w.closed {
+		return
+	}
+	w.closed = true
+	delete(w.store.watch.watchers, w)
+	close(w.events)
+}
+
+// sendLocked queues an event without blocking (must be called with the hub
+// lock held)
+func (w *Watcher) sendLocked(event WatchEvent) {
+	if w.lagged {
+		select {
+		case w.events <- WatchEvent{Lagged: true}:
+			w.lagged = false
+		default:
+			return // Still full
+		}
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		w.lagged = true
+	}
+}
+
+// publish sends events to the watchers whose prefix matches. Writers call it
+// while holding the store lock, so every watcher sees writes in MemTable order.
+func (h *watchHub) publish(events ...WatchEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for w := range h.watchers {
+		for _, event := range events {
+			if strings.HasPrefix(event.Key, w.prefix) {
+				w.sendLocked(event)
+			}
+		}
+	}
+}
+
+// closeAll closes every watcher (on store Close)
+func (h *watchHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for w := range h.watchers {
+		w.closeLocked()
+	}
+}
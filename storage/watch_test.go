@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLSMStore_Watch(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	// Writes before subscribing are not replayed
+	if err := store.Put("user:0", []byte("old")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	w := store.Watch("user:", 0)
+	defer w.Close()
+
+	if err := store.Put("user:1", []byte("alice")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Put("order:1", []byte("ignored")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.WriteBatch([]BatchOp{
+		{Op: OpPut, Key: "user:2", Value: []byte("bob")},
+		{Op: OpDelete, Key: "order:1"},
+	}); err != nil {
+		t.Fatalf("WriteBatch failed: %v", err)
+	}
+	if err := store.Delete("user:1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	expected := []WatchEvent{
+		{Op: OpPut, Key: "user:1", Value: []byte("alice")},
+		{Op: OpPut, Key: "user:2", Value: []byte("bob")},
+		{Op: OpDelete, Key: "user:1"},
+	}
+	for i, want := range expected {
+		got := <-w.Events()
+		if got.Op != want.Op || got.Key != want.Key || string(got.Value) != string(want.Value) || got.Timestamp == 0 {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	select {
+	case event := <-w.Events():
+		t.Errorf("Unexpected event %+v", event)
+	default:
+	}
+
+	w.Close()
+	if _, ok := <-w.Events(); ok {
+		t.Error("Expected events channel to be closed")
+	}
+}
+
+func TestLSMStore_WatchLagged(t *testing.T) {
+	store, err := NewLSMStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create LSM store: %v", err)
+	}
+	defer store.Close()
+
+	w := store.Watch("", 2)
+	defer w.Close()
+
+	// A watcher that isn't reading never blocks writes
+	for i := 0; i < 5; i++ {
+		if err := store.Put(fmt.Sprintf("key%d", i), []byte("v")); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	// The buffered events survive; the rest were dropped
+	for i := 0; i < 2; i++ {
+		if event := <-w.Events(); event.Lagged || event.Key != fmt.Sprintf("key%d", i) {
+			t.Fatalf("Event %d: got %+v", i, event)
+		}
+	}
+
+	// The next write is preceded by a lagged marker
+	if err := store.Put("key5", []byte("v")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if event := <-w.Events(); !event.Lagged {
+		t.Fatalf("Expected lagged event, got %+v", event)
+	}
+	if event := <-w.Events(); event.Lagged || event.Key != "key5" {
+		t.Fatalf("Expected key5 after lagged event, got %+v", event)
+	}
+}
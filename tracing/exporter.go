@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ParseExporter builds the exporter selected by a -trace-exporter flag
+// value: "" or "none" (tracing off, nil exporter) or "log" (JSON spans to
+// stderr)
+func ParseExporter(s string) (Exporter, error) {
+	switch strings.ToLower(s) {
+	case "", "none":
+		return nil, nil
+	case "log":
+		return NewLogExporter(os.Stderr), nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q (expected none or log)", s)
+	}
+}
+
+// LogExporter writes each span as one JSON object per line, for a log
+// collector to assemble into traces
+type LogExporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewLogExporter creates an exporter writing to w
+func NewLogExporter(w io.Writer) *LogExporter {
+	return &LogExporter{enc: json.NewEncoder(w)}
+}
+
+// spanRecord is the JSON form of a span
+type spanRecord struct {
+	TraceID    string            `json:"trace_id"`
+	SpanID     string            `json:"span_id"`
+	ParentID   string            `json:"parent_id,omitempty"`
+	Name       string            `json:"name"`
+	Start      string            `json:"start"`
+	DurationUs int64             `json:"duration_us"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+func (e *LogExporter) ExportSpan(span *Span) {
+	record := spanRecord{
+		TraceID:    span.TraceID.String(),
+		SpanID:     span.SpanID.String(),
+		Name:       span.Name,
+		Start:      span.StartTime.UTC().Format("2006-01-02T15:04:05.000000Z"),
+		DurationUs: span.Duration().Microseconds(),
+		Attributes: span.Attributes(),
+		Error:      span.Err(),
+	}
+	if span.ParentID.IsValid() {
+		record.ParentID = span.ParentID.String()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(record)
+}
@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// traceparentHeader carries the caller's span (W3C Trace Context)
+const traceparentHeader = "traceparent"
+
+// formatTraceparent encodes a span as a version 00, sampled traceparent
+func formatTraceparent(span *Span) string {
+	return fmt.Sprintf("00-%s-%s-01", span.TraceID, span.SpanID)
+}
+
+// parseTraceparent decodes a traceparent header value
+func parseTraceparent(value string) (TraceID, SpanID, error) {
+	var traceID TraceID
+	var spanID SpanID
+
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return traceID, spanID, fmt.Errorf("malformed traceparent %q", value)
+	}
+	if n, err := hex.Decode(traceID[:], []byte(parts[1])); err != nil || n != len(traceID) || len(parts[1]) != 32 {
+		return traceID, spanID, fmt.Errorf("malformed trace ID in traceparent %q", value)
+	}
+	if n, err := hex.Decode(spanID[:], []byte(parts[2])); err != nil || n != len(spanID) || len(parts[2]) != 16 {
+		return traceID, spanID, fmt.Errorf("malformed span ID in traceparent %q", value)
+	}
+	if !traceID.IsValid() || !spanID.IsValid() {
+		return traceID, spanID, fmt.Errorf("zero ID in traceparent %q", value)
+	}
+	return traceID, spanID, nil
+}
+
+// injectOutgoing adds span to the outgoing gRPC metadata of ctx
+func injectOutgoing(ctx context.Context, span *Span) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, traceparentHeader, formatTraceparent(span))
+}
+
+// extractIncoming returns ctx with the caller's span from the incoming gRPC
+// metadata as the parent, if the caller sent a valid one
+func extractIncoming(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	values := md.Get(traceparentHeader)
+	if len(values) == 0 {
+		return ctx
+	}
+	traceID, spanID, err := parseTraceparent(values[0])
+	if err != nil {
+		return ctx // Start a new trace rather than fail the call
+	}
+	return withRemoteParent(ctx, traceID, spanID)
+}
+
+// UnaryClientInterceptor traces each outgoing unary call as a span and sends
+// it to the server as the parent of the server's spans
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !Enabled() {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, span := Start(ctx, method)
+		span.SetAttribute("rpc.target", cc.Target())
+		err := invoker(injectOutgoing(ctx, span), method, req, reply, cc, opts...)
+		span.SetError(err)
+		span.End()
+		return err
+	}
+}
+
+// StreamClientInterceptor traces each outgoing stream as a span, which ends
+// when the stream does
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !Enabled() {
+			return streamer(ctx, desc, cc, method, opts...)
+		}
+
+		ctx, span := Start(ctx, method)
+		span.SetAttribute("rpc.target", cc.Target())
+		stream, err := streamer(injectOutgoing(ctx, span), desc, cc, method, opts...)
+		if err != nil {
+			span.SetError(err)
+			span.End()
+			return nil, err
+		}
+		return &tracedClientStream{ClientStream: stream, span: span}, nil
+	}
+}
+
+// tracedClientStream ends its span at the end of the stream
+type tracedClientStream struct {
+	grpc.ClientStream
+	span *Span
+}
+
+func (s *tracedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			s.span.SetError(err)
+		}
+		s.span.End()
+	}
+	return err
+}
+
+// UnaryServerInterceptor traces each incoming unary call as a span, a child
+// of the caller's span when it sent one
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !Enabled() {
+			return handler(ctx, req)
+		}
+
+		ctx, span := Start(extractIncoming(ctx), info.FullMethod)
+		resp, err := handler(ctx, req)
+		span.SetError(err)
+		span.End()
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor traces each incoming stream as a span, a child of
+// the caller's span when it sent one
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !Enabled() {
+			return handler(srv, ss)
+		}
+
+		ctx, span := Start(extractIncoming(ss.Context()), info.FullMethod)
+		err := handler(srv, &tracedServerStream{ServerStream: ss, ctx: ctx})
+		span.SetError(err)
+		span.End()
+		return err
+	}
+}
+
+// tracedServerStream gives the handler a context carrying the server span
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// DialOptions installs the client interceptors on a connection
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(StreamClientInterceptor()),
+	}
+}
+
+// ServerOptions installs the server interceptors on a server
+func ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(StreamServerInterceptor()),
+	}
+}
@@ -0,0 +1,206 @@
+// Package tracing records spans along a request's path through the cluster:
+// the client call, the coordinator, each replica RPC and the storage reads
+// behind it, so a slow request shows which replica or which SSTable read
+// took the time.
+//
+// Span context crosses gRPC in the W3C Trace Context "traceparent" header,
+// the format OpenTelemetry propagates by default, so traces join up with
+// OpenTelemetry-instrumented services on either side. Finished spans go to
+// the Exporter set with SetExporter. Tracing is off until one is set: Start
+// then returns a nil *Span, whose methods do nothing, and the gRPC
+// interceptors pass calls straight through.
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID identifies every span of one request
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is set (the all-zero ID is invalid)
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// SpanID identifies one span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsValid reports whether the ID is set (the all-zero ID is invalid)
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// Span is one timed operation. The exported fields are read by exporters
+// once End has been called.
+type Span struct {
+	TraceID   TraceID
+	SpanID    SpanID
+	ParentID  SpanID // Zero for the root span of a trace
+	Name      string
+	StartTime time.Time
+	EndTime   time.Time
+
+	mu         sync.Mutex
+	attributes map[string]string
+	err        string
+	exporter   Exporter // nil for a remote parent, which is never exported
+	ended      bool
+}
+
+// Exporter receives every span when it ends. ExportSpan is called on the
+// goroutine that ended the span, so it should not block for long.
+type Exporter interface {
+	ExportSpan(span *Span)
+}
+
+// exporterHolder lets the exporter be swapped atomically, including for nil
+type exporterHolder struct {
+	exporter Exporter
+}
+
+var current atomic.Pointer[exporterHolder]
+
+// SetExporter turns tracing on with spans sent to exporter, or off with nil
+func SetExporter(exporter Exporter) {
+	if exporter == nil {
+		current.Store(nil)
+		return
+	}
+	current.Store(&exporterHolder{exporter: exporter})
+}
+
+// Enabled reports whether an exporter is set
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+type spanKey struct{}
+
+// Start begins a span named name, as a child of the span in ctx if there is
+// one, and returns a context carrying it. The caller must call End. With
+// tracing off it returns ctx and a nil span.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	holder := current.Load()
+	if holder == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		SpanID:    newSpanID(),
+		Name:      name,
+		StartTime: time.Now(),
+		exporter:  holder.exporter,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+	} else {
+		span.TraceID = newTraceID()
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span carried by ctx, or nil
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// withRemoteParent returns a context whose spans are children of a span in
+// another process
+func withRemoteParent(ctx context.Context, traceID TraceID, spanID SpanID) context.Context {
+	return context.WithValue(ctx, spanKey{}, &Span{TraceID: traceID, SpanID: spanID})
+}
+
+// SetAttribute annotates the span with a key-value pair
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attributes == nil {
+		s.attributes = make(map[string]string)
+	}
+	s.attributes[key] = fmt.Sprint(value)
+}
+
+// SetError marks the span as failed; a nil error leaves it unchanged
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err.Error()
+}
+
+// End records the end time and exports the span. Calls after the first
+// do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	if s.exporter != nil {
+		s.exporter.ExportSpan(s)
+	}
+}
+
+// Duration is how long the span took, once ended
+func (s *Span) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Attributes returns a copy of the span's attributes
+func (s *Span) Attributes() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attributes := make(map[string]string, len(s.attributes))
+	for key, value := range s.attributes {
+		attributes[key] = value
+	}
+	return attributes
+}
+
+// Err returns the error recorded with SetError, or ""
+func (s *Span) Err() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		for i := range id {
+			id[i] = byte(rand.Uint32())
+		}
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		for i := range id {
+			id[i] = byte(rand.Uint32())
+		}
+	}
+	return id
+}
@@ -0,0 +1,165 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// recordingExporter keeps every span it is given
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recordingExporter) ExportSpan(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func TestStart_DisabledIsNoop(t *testing.T) {
+	SetExporter(nil)
+
+	ctx := context.Background()
+	spanCtx, span := Start(ctx, "op")
+	if span != nil || spanCtx != ctx {
+		t.Fatal("Expected no span and the same context with tracing off")
+	}
+
+	// A nil span is safe to use
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("boom"))
+	span.End()
+}
+
+func TestStart_ChildSpans(t *testing.T) {
+	recorder := &recordingExporter{}
+	SetExporter(recorder)
+	defer SetExporter(nil)
+
+	ctx, parent := Start(context.Background(), "parent")
+	_, child := Start(ctx, "child")
+	child.SetError(errors.New("boom"))
+	child.End()
+	child.End() // Exported once
+	parent.End()
+
+	if len(recorder.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(recorder.spans))
+	}
+	if parent.ParentID.IsValid() {
+		t.Error("Expected the first span to be a root")
+	}
+	if child.TraceID != parent.TraceID || child.ParentID != parent.SpanID {
+		t.Errorf("Child %s/%s not under parent %s/%s", child.TraceID, child.ParentID, parent.TraceID, parent.SpanID)
+	}
+	if child.Err() != "boom" {
+		t.Errorf("Expected the child's error recorded, got %q", child.Err())
+	}
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	span := &Span{TraceID: newTraceID(), SpanID: newSpanID()}
+
+	traceID, spanID, err := parseTraceparent(formatTraceparent(span))
+	if err != nil {
+		t.Fatalf("parseTraceparent failed: %v", err)
+	}
+	if traceID != span.TraceID || spanID != span.SpanID {
+		t.Errorf("Round trip changed the IDs: %s-%s", traceID, spanID)
+	}
+
+	for _, bad := range []string{
+		"",
+		"00-abc-def-01",
+		"00-00000000000000000000000000000000-0000000000000001-01",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-zzad6b7169203331-01",
+	} {
+		if _, _, err := parseTraceparent(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+// Test: the server span of an RPC joins the client's trace as its child
+func TestInterceptors_PropagateAcrossGRPC(t *testing.T) {
+	recorder := &recordingExporter{}
+	SetExporter(recorder)
+	defer SetExporter(nil)
+
+	lis := bufconn.Listen(1 << 20)
+	var serverMD metadata.MD
+	srv := grpc.NewServer(append(ServerOptions(), grpc.ChainUnaryInterceptor(
+		func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			serverMD, _ = metadata.FromIncomingContext(ctx)
+			return handler(ctx, req)
+		}))...)
+	grpc_health_v1.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", append(DialOptions(),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)...)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, root := Start(context.Background(), "request")
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	root.End()
+
+	const method = "/grpc.health.v1.Health/Check"
+	recorder.mu.Lock()
+	var client, server *Span
+	for _, span := range recorder.spans {
+		if span.Name != method {
+			continue
+		}
+		if span.ParentID == root.SpanID {
+			client = span
+		} else {
+			server = span
+		}
+	}
+	recorder.mu.Unlock()
+
+	if client == nil || server == nil {
+		t.Fatalf("Expected a client and a server span for %s", method)
+	}
+	if client.TraceID != root.TraceID || server.TraceID != root.TraceID {
+		t.Error("RPC spans are not in the caller's trace")
+	}
+	if server.ParentID != client.SpanID {
+		t.Errorf("Expected the server span under the client span %s, got parent %s", client.SpanID, server.ParentID)
+	}
+	if got := serverMD.Get(traceparentHeader); len(got) != 1 || got[0] != formatTraceparent(client) {
+		t.Errorf("Expected traceparent %s, got %v", formatTraceparent(client), got)
+	}
+
+	// With tracing off nothing is sent or recorded
+	SetExporter(nil)
+	if _, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if got := serverMD.Get(traceparentHeader); len(got) != 0 {
+		t.Errorf("Expected no traceparent with tracing off, got %v", got)
+	}
+}